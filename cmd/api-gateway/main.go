@@ -1,16 +1,29 @@
 package main
 
 import (
+	"context"
 	"log"
 
 	"marketplace/internal/api-gateway/app"
 	"marketplace/internal/api-gateway/config"
+	"marketplace/pkg/logging"
+	"marketplace/pkg/tracing"
 )
 
 func main() {
 	// Initialize Application
 
+	if err := logging.Init("api-gateway"); err != nil {
+		log.Fatalf("failed to initialise logging: %v", err)
+	}
+
 	cfg := config.Read()
+
+	shutdownTracing, err := tracing.Init(context.Background(), "api-gateway")
+	if err != nil {
+		log.Fatalf("failed to initialise tracing: %v", err)
+	}
+
 	application := app.New(cfg)
 
 	// @title Marketplace Backend API
@@ -27,19 +40,12 @@ func main() {
 	// @host localhost:8080
 	// @BasePath /
 
-	application.RegisterService("user-service", []string{"http://localhost:8081", "http://localhost:8081"}, "/users")
-	application.RegisterService("seller-service", []string{"http://localhost:8083"}, "/sellers")
-	// application.RegisterService("auth-service", []string{"http://localhost:8084"}, "/auth")
-	application.RegisterService("test-service", []string{"http://localhost:8082"}, "/test")
-	application.RegisterService("chat-service", []string{"http://localhost:8085"}, "/chat")
-	application.RegisterService("product-service", []string{"http://localhost:8084"}, "/products")
-	application.RegisterService("basket-service", []string{"http://localhost:8085"}, "/baskets")
-	application.RegisterService("order-service", []string{"http://localhost:8086"}, "/orders")
-	application.RegisterService("payment-service", []string{"http://localhost:8087"}, "/payments")
+	// Servisler, prefix'ler, korumalı route'lar ve limitler config/gateway.yaml'dan okunur
+	// (USER_ROUTING_FILE ile değiştirilebilir).
 
 	log.Printf("🚀 Gateway started on %s", config.GatewayPort)
 	log.Printf("ℹ️  Usage:")
-	log.Printf("  - /users/profile -> user-service (Auth required)")
+	log.Printf("  - /users/user/profile -> user-service (Auth required)")
 	log.Printf("  - /test/hello    -> test-service (Strict Rate Limit)")
 	log.Printf("  - /simulate/login -> Create test session")
 
@@ -48,7 +54,9 @@ func main() {
 	// 	log.Fatalf("Error starting server: %v", err)
 	// }
 
-	if err := application.Start(); err != nil {
+	err = application.Start()
+	shutdownTracing.Flush()
+	if err != nil {
 		log.Fatalf("server stopped with error: %v", err)
 	}
 }
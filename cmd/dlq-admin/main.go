@@ -0,0 +1,365 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"marketplace/pkg/messaging"
+)
+
+const usage = `dlq-admin: DLQ mesajlarını incele, tekrar bas ve temizle.
+
+Kullanım:
+  dlq-admin list    [filtreler]
+  dlq-admin replay  [filtreler] [-dry-run]
+  dlq-admin import  [-dir critical_messages] [-dry-run]
+  dlq-admin purge   [filtreler] [-dry-run] [-backup dosya] [-force] -yes
+  dlq-admin restore -file dosya
+
+  dlq-admin critical list     [filtreler] [-status stored,replayed,resolved]
+  dlq-admin critical replay   [filtreler] [-status ...] [-dry-run]
+  dlq-admin critical resolve  [filtreler] [-status ...] [-dry-run]
+
+Filtreler:
+  -id       Virgülle ayrılmış mesaj ID'leri
+  -type     Virgülle ayrılmış mesaj tipleri (Örn: ORDER_CREATED,PAYMENT_FAILED)
+  -service  Virgülle ayrılmış servisler; kaynak veya hedef servisle eşleşir (Örn: ORDER_SERVICE)
+  -since    Bu zamandan sonra DLQ'ya düşenler (RFC3339 veya süre, Örn: 24h)
+  -until    Bu zamandan önce DLQ'ya düşenler (RFC3339 veya süre)
+  -limit    En fazla kaç mesaj seçileceği (0 = sınırsız)
+
+Purge:
+  -backup   Tutulan mesajların topic silinmeden önce yazıldığı dosya
+            (varsayılan: dlq-backup-<zaman>.jsonl). Geri yazma yarıda kalırsa
+            "dlq-admin restore -file <dosya>" ile mesajlar DLQ'ya tekrar basılır.
+  -force    DLQ'yu okuyan consumer group'lar (servislerin DLQ recovery'si) çalışırken de
+            purge et. Verilmezse purge, okuyan grupları listeleyip durur.
+
+Kritik mesaj kasası:
+  -store    Dizin veya postgres:// bağlantı adresi (varsayılan: $CRITICAL_MESSAGES_DIR veya
+            ~/.marketplace/critical_messages). Servisin kullandığı kasa verilmelidir.
+  -status   Virgülle ayrılmış durumlar; replay ve resolve için varsayılan: stored
+
+Ortak:
+  -brokers  Kafka broker listesi (varsayılan: $KAFKA_BROKERS veya localhost:9092)
+  -dlq      DLQ topic'i (varsayılan: dlq-events)
+  -topic    OriginalTopic header'ı yoksa kullanılacak ana topic (varsayılan: main-events)
+`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	cmd, args := os.Args[1], os.Args[2:]
+	if cmd == "critical" && len(args) > 0 {
+		cmd, args = "critical "+args[0], args[1:]
+	}
+	fs := flag.NewFlagSet(cmd, flag.ExitOnError)
+	fs.Usage = func() { fmt.Fprint(os.Stderr, usage) }
+
+	brokers := fs.String("brokers", envOr("KAFKA_BROKERS", "localhost:9092"), "")
+	dlqTopic := fs.String("dlq", messaging.DefaultDLQTopic, "")
+	mainTopic := fs.String("topic", messaging.DefaultTopic, "")
+	ids := fs.String("id", "", "")
+	types := fs.String("type", "", "")
+	services := fs.String("service", "", "")
+	since := fs.String("since", "", "")
+	until := fs.String("until", "", "")
+	limit := fs.Int("limit", 0, "")
+	dir := fs.String("dir", "critical_messages", "")
+	dryRun := fs.Bool("dry-run", false, "")
+	yes := fs.Bool("yes", false, "")
+	force := fs.Bool("force", false, "")
+	backup := fs.String("backup", "dlq-backup-"+time.Now().Format("20060102-150405")+".jsonl", "")
+	file := fs.String("file", "", "")
+	store := fs.String("store", "", "")
+	statuses := fs.String("status", "", "")
+	_ = fs.Parse(args)
+
+	cfg := messaging.NewDefaultConfig(strings.Split(*brokers, ","))
+	cfg.Topic = *mainTopic
+	cfg.DLQTopic = *dlqTopic
+
+	admin, err := messaging.NewDLQAdmin(cfg)
+	if err != nil {
+		log.Fatalf("dlq-admin: %v", err)
+	}
+
+	filter, err := buildFilter(*ids, *types, *services, *since, *until, *limit)
+	if err != nil {
+		log.Fatalf("dlq-admin: %v", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	switch cmd {
+	case "list":
+		err = runList(ctx, admin, filter)
+	case "replay":
+		err = runReplay(ctx, admin, filter, *dryRun)
+	case "import":
+		err = runImport(ctx, admin, *dir, *dryRun)
+	case "purge":
+		err = runPurge(ctx, admin, filter, *dryRun, *yes, *force, *backup)
+	case "restore":
+		err = runRestore(ctx, admin, *file)
+	case "critical list", "critical replay", "critical resolve":
+		var q messaging.CriticalQuery
+		if q, err = criticalQuery(filter, *statuses); err == nil {
+			err = runCritical(ctx, admin, strings.TrimPrefix(cmd, "critical "), *store, q, *dryRun)
+		}
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n%s", cmd, usage)
+		os.Exit(2)
+	}
+	if err != nil {
+		log.Fatalf("dlq-admin %s: %v", cmd, err)
+	}
+}
+
+func runList(ctx context.Context, admin *messaging.DLQAdmin, filter messaging.DLQFilter) error {
+	messages, err := admin.List(ctx, filter)
+	if err != nil {
+		return err
+	}
+	printMessages(messages)
+	return nil
+}
+
+func runReplay(ctx context.Context, admin *messaging.DLQAdmin, filter messaging.DLQFilter, dryRun bool) error {
+	if dryRun {
+		messages, err := admin.List(ctx, filter)
+		if err != nil {
+			return err
+		}
+		printMessages(messages)
+		fmt.Printf("\n%d messages would be replayed\n", len(messages))
+		return nil
+	}
+
+	replayed, err := admin.Replay(ctx, filter)
+	fmt.Printf("%d messages replayed\n", len(replayed))
+	return err
+}
+
+func runImport(ctx context.Context, admin *messaging.DLQAdmin, dir string, dryRun bool) error {
+	files, err := messaging.LoadCriticalFiles(dir)
+	if err != nil {
+		return err
+	}
+
+	if dryRun {
+		for _, f := range files {
+			fmt.Printf("%s\t%s\t%s\n", f.Path, f.Message.Id, f.Message.Type)
+		}
+		fmt.Printf("\n%d files would be imported\n", len(files))
+		return nil
+	}
+
+	imported, err := admin.ImportCriticalFiles(ctx, files)
+	fmt.Printf("%d files imported\n", len(imported))
+	return err
+}
+
+func runPurge(ctx context.Context, admin *messaging.DLQAdmin, filter messaging.DLQFilter, dryRun, yes, force bool, backup string) error {
+	if dryRun {
+		messages, err := admin.List(ctx, filter)
+		if err != nil {
+			return err
+		}
+		printMessages(messages)
+		fmt.Printf("\n%d messages would be purged\n", len(messages))
+		return nil
+	}
+
+	// Purge topic'i yeniden oluşturduğu için açık onay istiyoruz.
+	if !yes {
+		return fmt.Errorf("purge recreates the DLQ topic; re-run with -yes to confirm (or -dry-run to preview)")
+	}
+
+	purged, err := admin.Purge(ctx, filter, backup, force)
+	if errors.Is(err, messaging.ErrDLQConsumersActive) {
+		return fmt.Errorf("%w; stop the services reading the DLQ first, or re-run with -force", err)
+	}
+	fmt.Printf("%d messages purged\n", purged)
+	return err
+}
+
+func runRestore(ctx context.Context, admin *messaging.DLQAdmin, file string) error {
+	if file == "" {
+		return fmt.Errorf("-file is required")
+	}
+	restored, err := admin.RestoreBackup(ctx, file)
+	fmt.Printf("%d messages restored\n", restored)
+	return err
+}
+
+// runCritical, kritik mesaj kasasındaki kayıtları listeler, tekrar basar veya çözüldü olarak işaretler.
+// Kayıtlar servisin saklayacağı şekilde (gerekirse şifreli) basılır; anahtar gerekmez.
+func runCritical(ctx context.Context, admin *messaging.DLQAdmin, action, storeAddr string, q messaging.CriticalQuery, dryRun bool) error {
+	store, err := openCriticalStore(storeAddr)
+	if err != nil {
+		return err
+	}
+
+	// Replay ve resolve varsayılan olarak sadece bekleyen kayıtlara uygulanır.
+	if action != "list" && len(q.Statuses) == 0 {
+		q.Statuses = []messaging.CriticalStatus{messaging.CriticalStored}
+	}
+
+	if action == "list" || dryRun {
+		entries, err := store.Query(ctx, q)
+		if err != nil {
+			return err
+		}
+		printCritical(entries)
+		if dryRun {
+			fmt.Printf("\n%d entries would be %s\n", len(entries), map[string]string{"replay": "replayed", "resolve": "resolved"}[action])
+		}
+		return nil
+	}
+
+	switch action {
+	case "replay":
+		replayed, err := admin.ReplayCritical(ctx, store, q)
+		fmt.Printf("%d entries replayed\n", len(replayed))
+		return err
+	default:
+		entries, err := store.Query(ctx, q)
+		if err != nil {
+			return err
+		}
+		for i, e := range entries {
+			if err := store.SetStatus(ctx, e.Service, e.MessageID, messaging.CriticalResolved); err != nil {
+				fmt.Printf("%d entries resolved\n", i)
+				return err
+			}
+		}
+		fmt.Printf("%d entries resolved\n", len(entries))
+		return nil
+	}
+}
+
+// openCriticalStore, postgres:// ile başlayan adres için Postgres, diğerleri için dosya kasası açar.
+func openCriticalStore(addr string) (messaging.CriticalStore, error) {
+	if strings.HasPrefix(addr, "postgres://") || strings.HasPrefix(addr, "postgresql://") {
+		db, err := sql.Open("postgres", addr)
+		if err != nil {
+			return nil, err
+		}
+		return messaging.NewPostgresCriticalStore(db)
+	}
+
+	if addr == "" {
+		dir, err := messaging.DefaultCriticalDir()
+		if err != nil {
+			return nil, err
+		}
+		addr = dir
+	}
+	return messaging.NewFileCriticalStore(addr)
+}
+
+func criticalQuery(filter messaging.DLQFilter, statuses string) (messaging.CriticalQuery, error) {
+	q := messaging.CriticalQuery{
+		IDs:      filter.IDs,
+		Types:    filter.Types,
+		Services: filter.Services,
+		Since:    filter.Since,
+		Until:    filter.Until,
+		Limit:    filter.Limit,
+	}
+	for _, s := range strings.Split(statuses, ",") {
+		switch status := messaging.CriticalStatus(strings.TrimSpace(s)); status {
+		case "":
+		case messaging.CriticalStored, messaging.CriticalReplayed, messaging.CriticalResolved:
+			q.Statuses = append(q.Statuses, status)
+		default:
+			return q, fmt.Errorf("-status: unknown status %q", s)
+		}
+	}
+	return q, nil
+}
+
+func printCritical(entries []messaging.CriticalEntry) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "SERVICE\tID\tTYPE\tSTATUS\tATTEMPTS\tCREATED AT\tUPDATED AT\tLAST ERROR")
+	for _, e := range entries {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%d\t%s\t%s\t%s\n",
+			e.Service, e.MessageID, e.Type, e.Status, e.Attempts,
+			e.CreatedAt.Format(time.RFC3339), e.UpdatedAt.Format(time.RFC3339), e.LastError)
+	}
+	w.Flush()
+}
+
+func printMessages(messages []messaging.DLQMessage) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "PARTITION/OFFSET\tID\tTYPE\tFROM\tTO\tRETRIES\tFAILED AT\tORIGINAL TOPIC\tERROR")
+	for _, m := range messages {
+		to := make([]string, 0, len(m.Message.ToServices))
+		for _, svc := range m.Message.ToServices {
+			to = append(to, svc.String())
+		}
+		fmt.Fprintf(w, "%d/%d\t%s\t%s\t%s\t%s\t%d\t%s\t%s\t%s\n",
+			m.Partition, m.Offset, m.Message.Id, m.Message.Type, m.Message.FromService,
+			strings.Join(to, ","), m.Message.RetryCount, m.FailedAt.Format(time.RFC3339),
+			m.OriginalTopic, m.ErrorReason)
+	}
+	w.Flush()
+}
+
+func buildFilter(ids, types, services, since, until string, limit int) (messaging.DLQFilter, error) {
+	filter := messaging.DLQFilter{Limit: limit}
+
+	for _, id := range strings.Split(ids, ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			filter.IDs = append(filter.IDs, id)
+		}
+	}
+
+	var err error
+	if filter.Types, err = messaging.ParseMessageTypes(types); err != nil {
+		return filter, err
+	}
+	if filter.Services, err = messaging.ParseServiceTypes(services); err != nil {
+		return filter, err
+	}
+	if filter.Since, err = parseTime(since); err != nil {
+		return filter, fmt.Errorf("-since: %w", err)
+	}
+	if filter.Until, err = parseTime(until); err != nil {
+		return filter, fmt.Errorf("-until: %w", err)
+	}
+	return filter, nil
+}
+
+// parseTime, RFC3339 zamanını veya "24h" gibi şu andan geriye bir süreyi kabul eder.
+func parseTime(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if d, err := time.ParseDuration(s); err == nil {
+		return time.Now().Add(-d), nil
+	}
+	return time.Parse(time.RFC3339, s)
+}
+
+func envOr(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+
+	"marketplace/pkg/messaging"
+	pb "marketplace/pkg/proto/events"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// decoded, çözümlenmiş bir kayıttır. Message nil ise Err nedenini taşır.
+type decoded struct {
+	Record  record
+	Message *pb.Message
+	Err     error
+}
+
+func decode(r record) decoded {
+	msg := &pb.Message{}
+	if err := proto.Unmarshal(r.Value, msg); err != nil {
+		return decoded{Record: r, Err: err}
+	}
+	return decoded{Record: r, Message: msg}
+}
+
+type filter struct {
+	types       []pb.MessageType
+	from        []pb.ServiceType
+	to          []pb.ServiceType
+	orderID     string
+	userID      string
+	correlation string
+}
+
+func buildFilter(types, from, to, orderID, userID, correlation string) (filter, error) {
+	f := filter{orderID: orderID, userID: userID, correlation: correlation}
+
+	var err error
+	if f.types, err = messaging.ParseMessageTypes(types); err != nil {
+		return f, fmt.Errorf("-type: %w", err)
+	}
+	if f.from, err = messaging.ParseServiceTypes(from); err != nil {
+		return f, fmt.Errorf("-from: %w", err)
+	}
+	if f.to, err = messaging.ParseServiceTypes(to); err != nil {
+		return f, fmt.Errorf("-to: %w", err)
+	}
+	return f, nil
+}
+
+func (f filter) empty() bool {
+	return len(f.types) == 0 && len(f.from) == 0 && len(f.to) == 0 &&
+		f.orderID == "" && f.userID == "" && f.correlation == ""
+}
+
+// match, kaydın tüm filtrelere uyup uymadığını söyler. Çözümlenemeyen kayıtlar sadece
+// filtre yokken gösterilir; aksi halde hangi filtreye uyduklarını bilemeyiz.
+func (f filter) match(d decoded) bool {
+	if d.Message == nil {
+		return f.empty()
+	}
+	msg := d.Message
+
+	if len(f.types) > 0 && !slices.Contains(f.types, msg.Type) {
+		return false
+	}
+	if len(f.from) > 0 && !slices.Contains(f.from, msg.FromService) {
+		return false
+	}
+	if len(f.to) > 0 && !slices.ContainsFunc(msg.ToServices, func(s pb.ServiceType) bool {
+		return slices.Contains(f.to, s)
+	}) {
+		return false
+	}
+
+	fields := payloadStrings(msg)
+	if f.orderID != "" && fields["order_id"] != f.orderID {
+		return false
+	}
+	if f.userID != "" && fields["user_id"] != f.userID {
+		return false
+	}
+	if f.correlation != "" && !correlates(msg, d.Record, fields, f.correlation) {
+		return false
+	}
+	return true
+}
+
+// correlates, bir ID'nin mesajla ilişkili olup olmadığına bakar. Aynı mesaj ana topic, retry
+// ve DLQ'da aynı ID ile dolaşır; ilgili diğer olaylar (Örn: ORDER_CREATED -> PAYMENT_SUCCESSFUL)
+// ise payload'daki sipariş/kullanıcı ID'si ile bağlanır. Header'lar da aranır (Örn: istek ID'si).
+func correlates(msg *pb.Message, r record, fields map[string]string, id string) bool {
+	if msg.Id == id {
+		return true
+	}
+	for _, v := range fields {
+		if v == id {
+			return true
+		}
+	}
+	for _, v := range msg.Headers {
+		if v == id {
+			return true
+		}
+	}
+	for _, v := range r.Headers {
+		if v == id {
+			return true
+		}
+	}
+	return false
+}
+
+// payloadStrings, payload'ın üst seviyedeki string alanlarını (order_id, user_id ...) döner.
+func payloadStrings(msg *pb.Message) map[string]string {
+	m := msg.ProtoReflect()
+	oneof := m.Descriptor().Oneofs().ByName("payload")
+	field := m.WhichOneof(oneof)
+	if field == nil {
+		return nil
+	}
+
+	payload := m.Get(field).Message()
+	fields := make(map[string]string)
+	payload.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		if fd.Kind() == protoreflect.StringKind && !fd.IsList() && !fd.IsMap() {
+			fields[string(fd.Name())] = v.String()
+		}
+		return true
+	})
+	return fields
+}
+
+// printer, çözümlenmiş kayıtları satır başına bir JSON nesnesi olarak yazar.
+type printer struct {
+	enc     *json.Encoder
+	marshal protojson.MarshalOptions
+}
+
+func newPrinter(w io.Writer, pretty bool) *printer {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	if pretty {
+		enc.SetIndent("", "  ")
+	}
+	return &printer{
+		enc:     enc,
+		marshal: protojson.MarshalOptions{UseProtoNames: true},
+	}
+}
+
+type line struct {
+	Topic     string            `json:"topic"`
+	Partition int               `json:"partition"`
+	Offset    int64             `json:"offset"`
+	Time      string            `json:"time"`
+	Key       string            `json:"key,omitempty"`
+	Headers   map[string]string `json:"headers,omitempty"`
+	Message   json.RawMessage   `json:"message,omitempty"`
+	Error     string            `json:"error,omitempty"`
+}
+
+func (p *printer) Print(d decoded) error {
+	l := line{
+		Topic:     d.Record.Topic,
+		Partition: d.Record.Partition,
+		Offset:    d.Record.Offset,
+		Time:      d.Record.Time.Format("2006-01-02T15:04:05.000Z07:00"),
+		Key:       d.Record.Key,
+		Headers:   d.Record.Headers,
+	}
+	if d.Message != nil {
+		raw, err := p.marshal.Marshal(d.Message)
+		if err != nil {
+			return err
+		}
+		l.Message = raw
+	} else {
+		l.Error = "decode pb.Message: " + d.Err.Error()
+	}
+	return p.enc.Encode(l)
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	pb "marketplace/pkg/proto/events"
+)
+
+func TestBuildFilter(t *testing.T) {
+	f, err := buildFilter("order_created, PAYMENT_FAILED", "ORDER_SERVICE", "notification_service,product_service", "order-1", "user-1", "req-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := filter{
+		types:       []pb.MessageType{pb.MessageType_ORDER_CREATED, pb.MessageType_PAYMENT_FAILED},
+		from:        []pb.ServiceType{pb.ServiceType_ORDER_SERVICE},
+		to:          []pb.ServiceType{pb.ServiceType_NOTIFICATION_SERVICE, pb.ServiceType_PRODUCT_SERVICE},
+		orderID:     "order-1",
+		userID:      "user-1",
+		correlation: "req-1",
+	}
+	if !reflect.DeepEqual(f, want) {
+		t.Fatalf("buildFilter = %+v, want %+v", f, want)
+	}
+
+	if f, err := buildFilter("", "", "", "", "", ""); err != nil || !f.empty() {
+		t.Fatalf("buildFilter with no flags = %+v, %v; want an empty filter", f, err)
+	}
+}
+
+func TestBuildFilterRejectsUnknownNames(t *testing.T) {
+	tests := []struct {
+		name            string
+		types, from, to string
+		wantErrWithFlag string
+	}{
+		{name: "message type", types: "ORDER_CREATED,ORDER_SHIPPED", wantErrWithFlag: "-type:"},
+		{name: "source service", from: "BILLING_SERVICE", wantErrWithFlag: "-from:"},
+		{name: "target service", to: "ORDER_SERVICE,SHIPPING_SERVICE", wantErrWithFlag: "-to:"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := buildFilter(tt.types, tt.from, tt.to, "", "", "")
+			if err == nil || !strings.HasPrefix(err.Error(), tt.wantErrWithFlag) {
+				t.Fatalf("buildFilter error = %v, want one starting with %q", err, tt.wantErrWithFlag)
+			}
+		})
+	}
+}
+
+func TestCorrelates(t *testing.T) {
+	msg := &pb.Message{
+		Id:      "msg-1",
+		Type:    pb.MessageType_ORDER_CREATED,
+		Headers: map[string]string{"x-request-id": "req-1"},
+		Payload: &pb.Message_OrderCreatedData{OrderCreatedData: &pb.OrderCreatedData{OrderId: "order-1", UserId: "user-1"}},
+	}
+	r := record{Topic: "main-events", Headers: map[string]string{"traceparent": "trace-1"}}
+	fields := payloadStrings(msg)
+
+	tests := []struct {
+		id   string
+		want bool
+	}{
+		{"msg-1", true},   // mesaj ID'si: aynı mesajın retry/DLQ kopyaları
+		{"order-1", true}, // payload ID'si: aynı siparişin diğer olayları
+		{"user-1", true},
+		{"req-1", true},   // mesaj header'ı
+		{"trace-1", true}, // Kafka header'ı
+		{"order-2", false},
+		{"main-events", false}, // kayıt alanları ID değildir
+	}
+
+	for _, tt := range tests {
+		if got := correlates(msg, r, fields, tt.id); got != tt.want {
+			t.Errorf("correlates(%q) = %v, want %v", tt.id, got, tt.want)
+		}
+	}
+}
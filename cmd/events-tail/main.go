@@ -0,0 +1,199 @@
+// events-tail, Kafka'daki olayları (ana topic, retry kademeleri, DLQ) okunabilir JSON olarak döker.
+// Consumer group'a katılmaz; servislerin offset'lerine dokunmadan istenen offset veya
+// zamandan okur. Aynı akışı Kafka olmadan incelemek için mesajlar bir dosyaya kaydedilip
+// daha sonra -file ile tekrar okunabilir.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"marketplace/pkg/messaging"
+)
+
+const usage = `events-tail: Kafka olaylarını pb.Message -> JSON olarak göster.
+
+Kullanım:
+  events-tail [kaynak] [filtreler] [çıktı]
+
+Kaynak:
+  -brokers  Kafka broker listesi (varsayılan: $KAFKA_BROKERS veya localhost:9092)
+  -topics   Virgülle ayrılmış topic'ler; '*' ile biten isim önek olarak eşleşir
+            (varsayılan: main-events,retry-events*,dlq-events)
+  -offset   first, last veya sayı; negatif sayı her partition'ın sonundan geriye sayar (varsayılan: first)
+  -since    Bu zamandan itibaren oku (RFC3339 veya süre, Örn: 15m); -offset'i ezer
+  -follow   Mevcut sona gelince durma, yeni mesajları beklemeye devam et
+  -file     Kafka yerine kaydedilmiş dosyadan oku (bkz. -record)
+
+Filtreler:
+  -type         Virgülle ayrılmış mesaj tipleri (Örn: ORDER_CREATED,PAYMENT_FAILED)
+  -from         Virgülle ayrılmış kaynak servisler
+  -to           Virgülle ayrılmış hedef servisler
+  -order        Payload'daki order_id
+  -user         Payload'daki user_id
+  -correlation  Mesaj ID'si, header değeri veya payload'daki herhangi bir ID; eşleşen mesajlar
+                tüm topic'lerde zaman sırasıyla gösterilir (Örn: bir siparişin tüm olayları)
+
+Çıktı:
+  -record   Okunan ham kayıtları (filtrelemeden önce) bu dosyaya JSON satırları olarak yaz
+  -pretty   JSON'u girintili yaz
+`
+
+func main() {
+	fs := flag.NewFlagSet("events-tail", flag.ExitOnError)
+	fs.Usage = func() { fmt.Fprint(os.Stderr, usage) }
+
+	brokers := fs.String("brokers", envOr("KAFKA_BROKERS", "localhost:9092"), "")
+	topics := fs.String("topics", messaging.DefaultTopic+","+messaging.DefaultRetryTopic+"*,"+messaging.DefaultDLQTopic, "")
+	offset := fs.String("offset", "first", "")
+	since := fs.String("since", "", "")
+	follow := fs.Bool("follow", false, "")
+	file := fs.String("file", "", "")
+	types := fs.String("type", "", "")
+	from := fs.String("from", "", "")
+	to := fs.String("to", "", "")
+	orderID := fs.String("order", "", "")
+	userID := fs.String("user", "", "")
+	correlation := fs.String("correlation", "", "")
+	record := fs.String("record", "", "")
+	pretty := fs.Bool("pretty", false, "")
+	_ = fs.Parse(os.Args[1:])
+
+	filter, err := buildFilter(*types, *from, *to, *orderID, *userID, *correlation)
+	if err != nil {
+		log.Fatalf("events-tail: %v", err)
+	}
+
+	start, err := parseStart(*offset, *since)
+	if err != nil {
+		log.Fatalf("events-tail: %v", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	var src source
+	if *file != "" {
+		src = &fileSource{path: *file, topics: splitList(*topics), start: start}
+	} else {
+		src = &kafkaSource{
+			brokers: splitList(*brokers),
+			topics:  splitList(*topics),
+			start:   start,
+			follow:  *follow,
+		}
+	}
+
+	if err := run(ctx, src, filter, *record, *follow, newPrinter(os.Stdout, *pretty)); err != nil {
+		log.Fatalf("events-tail: %v", err)
+	}
+}
+
+// run, kaynaktaki kayıtları okur, filtreler ve yazar.
+// -follow yoksa önce her şey toplanır ve zamana göre sıralanır; böylece bir mesajın
+// ana topic -> retry -> DLQ yolculuğu, topic'ler arasında dağınık değil sırayla görünür.
+func run(ctx context.Context, src source, filter filter, recordPath string, follow bool, out *printer) (err error) {
+	var rec *recorder
+	if recordPath != "" {
+		if rec, err = newRecorder(recordPath); err != nil {
+			return err
+		}
+		defer func() {
+			if closeErr := rec.Close(); err == nil {
+				err = closeErr
+			}
+		}()
+	}
+
+	var collected []decoded
+	emit := func(r record) error {
+		if rec != nil {
+			if err := rec.Write(r); err != nil {
+				return err
+			}
+		}
+		d := decode(r)
+		if !filter.match(d) {
+			return nil
+		}
+		if follow {
+			return out.Print(d)
+		}
+		collected = append(collected, d)
+		return nil
+	}
+
+	if err := src.Read(ctx, emit); err != nil && ctx.Err() == nil {
+		return err
+	}
+
+	sort.SliceStable(collected, func(i, j int) bool {
+		return collected[i].Record.Time.Before(collected[j].Record.Time)
+	})
+	for _, d := range collected {
+		if err := out.Print(d); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseStart, -offset ve -since bayraklarını okuma başlangıcına çevirir.
+func parseStart(offset, since string) (startPosition, error) {
+	if since != "" {
+		t, err := parseTime(since)
+		if err != nil {
+			return startPosition{}, fmt.Errorf("-since: %w", err)
+		}
+		return startPosition{since: t}, nil
+	}
+
+	switch offset {
+	case "", "first":
+		return startPosition{offset: 0}, nil
+	case "last":
+		return startPosition{fromEnd: true}, nil
+	}
+
+	var n int64
+	if _, err := fmt.Sscan(offset, &n); err != nil {
+		return startPosition{}, fmt.Errorf("-offset: expected first, last or a number, got %q", offset)
+	}
+	if n < 0 {
+		return startPosition{fromEnd: true, offset: -n}, nil
+	}
+	return startPosition{offset: n}, nil
+}
+
+// parseTime, RFC3339 zamanını veya "15m" gibi şu andan geriye bir süreyi kabul eder.
+func parseTime(s string) (time.Time, error) {
+	if d, err := time.ParseDuration(s); err == nil {
+		return time.Now().Add(-d), nil
+	}
+	return time.Parse(time.RFC3339, s)
+}
+
+func splitList(s string) []string {
+	var out []string
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
+
+func envOr(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
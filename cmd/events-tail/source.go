@@ -0,0 +1,289 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// record, Kafka'dan okunan ham kayıttır. -record dosyasına da bu haliyle (JSON satırı) yazılır;
+// Value, protobuf baytlarıdır ve JSON'da base64 olarak durur.
+type record struct {
+	Topic     string            `json:"topic"`
+	Partition int               `json:"partition"`
+	Offset    int64             `json:"offset"`
+	Time      time.Time         `json:"time"`
+	Key       string            `json:"key,omitempty"`
+	Headers   map[string]string `json:"headers,omitempty"`
+	Value     []byte            `json:"value"`
+}
+
+// startPosition, her partition'da okumanın nereden başlayacağını belirtir.
+type startPosition struct {
+	offset  int64     // fromEnd ise sondan geriye kaç mesaj
+	fromEnd bool      // offset'i partition sonuna göre yorumla
+	since   time.Time // sıfır değilse offset yerine bu zaman kullanılır
+}
+
+// source, kayıtları sırayla emit'e veren okuyucudur. emit hata dönerse okuma durur.
+type source interface {
+	Read(ctx context.Context, emit func(record) error) error
+}
+
+// kafkaSource, topic'lerin tüm partition'larını consumer group olmadan, doğrudan okur.
+type kafkaSource struct {
+	brokers []string
+	topics  []string
+	start   startPosition
+	follow  bool
+}
+
+func (s *kafkaSource) Read(ctx context.Context, emit func(record) error) error {
+	partitions, err := s.partitions(ctx)
+	if err != nil {
+		return err
+	}
+	if len(partitions) == 0 {
+		return fmt.Errorf("no topic matches %v", s.topics)
+	}
+
+	// Partition'lar paralel okunur; emit tek goroutine'den çağrılsın diye kilitlenir.
+	var (
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		firstErr error
+	)
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	for _, p := range partitions {
+		wg.Add(1)
+		go func(p kafka.Partition) {
+			defer wg.Done()
+			err := s.readPartition(ctx, p, func(r record) error {
+				mu.Lock()
+				defer mu.Unlock()
+				return emit(r)
+			})
+			if err != nil && ctx.Err() == nil {
+				mu.Lock()
+				if firstErr == nil {
+					firstErr = err
+				}
+				mu.Unlock()
+				cancel()
+			}
+		}(p)
+	}
+	wg.Wait()
+	return firstErr
+}
+
+// partitions, -topics listesindeki isim ve öneklerle eşleşen tüm partition'ları döner.
+func (s *kafkaSource) partitions(ctx context.Context) ([]kafka.Partition, error) {
+	conn, err := kafka.DialContext(ctx, "tcp", s.brokers[0])
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	all, err := conn.ReadPartitions()
+	if err != nil {
+		return nil, fmt.Errorf("read partitions: %w", err)
+	}
+
+	var matched []kafka.Partition
+	for _, p := range all {
+		if matchTopic(s.topics, p.Topic) {
+			matched = append(matched, p)
+		}
+	}
+	sort.Slice(matched, func(i, j int) bool {
+		if matched[i].Topic != matched[j].Topic {
+			return matched[i].Topic < matched[j].Topic
+		}
+		return matched[i].ID < matched[j].ID
+	})
+	return matched, nil
+}
+
+func (s *kafkaSource) readPartition(ctx context.Context, p kafka.Partition, emit func(record) error) error {
+	conn, err := kafka.DialLeader(ctx, "tcp", s.brokers[0], p.Topic, p.ID)
+	if err != nil {
+		return fmt.Errorf("dial %s/%d: %w", p.Topic, p.ID, err)
+	}
+	first, last, err := conn.ReadOffsets()
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("read offsets %s/%d: %w", p.Topic, p.ID, err)
+	}
+
+	from := first
+	switch {
+	case !s.start.since.IsZero():
+		if from, err = conn.ReadOffset(s.start.since); err != nil {
+			conn.Close()
+			return fmt.Errorf("offset at %s for %s/%d: %w", s.start.since.Format(time.RFC3339), p.Topic, p.ID, err)
+		}
+	case s.start.fromEnd:
+		from = max(last-s.start.offset, first)
+	default:
+		from = max(s.start.offset, first)
+	}
+	conn.Close()
+
+	// -follow yoksa okuma, başlangıçtaki son offset'te biter.
+	if !s.follow && from >= last {
+		return nil
+	}
+
+	reader := kafka.NewReader(kafka.ReaderConfig{
+		Brokers:   s.brokers,
+		Topic:     p.Topic,
+		Partition: p.ID,
+		MaxWait:   time.Second,
+	})
+	defer reader.Close()
+
+	if err := reader.SetOffset(from); err != nil {
+		return err
+	}
+
+	for {
+		m, err := reader.ReadMessage(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("read %s/%d: %w", p.Topic, p.ID, err)
+		}
+		if err := emit(fromKafka(m)); err != nil {
+			return err
+		}
+		if !s.follow && m.Offset >= last-1 {
+			return nil
+		}
+	}
+}
+
+func fromKafka(m kafka.Message) record {
+	r := record{
+		Topic:     m.Topic,
+		Partition: m.Partition,
+		Offset:    m.Offset,
+		Time:      m.Time,
+		Key:       string(m.Key),
+		Value:     m.Value,
+	}
+	if len(m.Headers) > 0 {
+		r.Headers = make(map[string]string, len(m.Headers))
+		for _, h := range m.Headers {
+			r.Headers[h.Key] = string(h.Value)
+		}
+	}
+	return r
+}
+
+// fileSource, -record ile kaydedilmiş JSON satırlarını okur. Kafka gerektirmez; aynı
+// filtreler ve çıktı ile akışı çevrimdışı incelemek veya tekrar üretmek için kullanılır.
+type fileSource struct {
+	path   string
+	topics []string
+	start  startPosition
+}
+
+func (s *fileSource) Read(ctx context.Context, emit func(record) error) error {
+	f, err := os.Open(s.path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+
+	for line := 1; scanner.Scan(); line++ {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if len(strings.TrimSpace(scanner.Text())) == 0 {
+			continue
+		}
+
+		var r record
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			return fmt.Errorf("%s:%d: %w", s.path, line, err)
+		}
+		if !s.includes(r) {
+			continue
+		}
+		if err := emit(r); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+// includes, topic ve başlangıç filtrelerini dosyadaki kayda uygular.
+// Dosyada partition sonu bilinmediği için "-offset last" ve negatif offset'ler her şeyi içerir.
+func (s *fileSource) includes(r record) bool {
+	if len(s.topics) > 0 && !matchTopic(s.topics, r.Topic) {
+		return false
+	}
+	switch {
+	case !s.start.since.IsZero():
+		return !r.Time.Before(s.start.since)
+	case s.start.fromEnd:
+		return true
+	}
+	return r.Offset >= s.start.offset
+}
+
+func matchTopic(patterns []string, topic string) bool {
+	for _, p := range patterns {
+		if prefix, ok := strings.CutSuffix(p, "*"); ok {
+			if strings.HasPrefix(topic, prefix) {
+				return true
+			}
+		} else if p == topic {
+			return true
+		}
+	}
+	return false
+}
+
+// recorder, okunan ham kayıtları -file ile tekrar okunabilecek biçimde dosyaya yazar.
+type recorder struct {
+	f   *os.File
+	w   *bufio.Writer
+	enc *json.Encoder
+}
+
+func newRecorder(path string) (*recorder, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	w := bufio.NewWriter(f)
+	return &recorder{f: f, w: w, enc: json.NewEncoder(w)}, nil
+}
+
+func (r *recorder) Write(rec record) error {
+	return r.enc.Encode(rec)
+}
+
+func (r *recorder) Close() error {
+	if err := r.w.Flush(); err != nil {
+		r.f.Close()
+		return err
+	}
+	return r.f.Close()
+}
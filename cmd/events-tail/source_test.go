@@ -0,0 +1,180 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testdata/recording.jsonl, -record ile yazılmış bir kayıttır: order-1'in ana topic, retry ve
+// cevap topic'indeki olayları, order-2'nin DLQ kaydı ve çözümlenemeyen bir değer.
+const fixture = "testdata/recording.jsonl"
+
+var (
+	defaultTopics = []string{"main-events", "retry-events*", "dlq-events"}
+	fixtureStart  = time.Date(2026, 10, 1, 10, 0, 0, 0, time.UTC)
+)
+
+func readAll(t *testing.T, src source) []string {
+	t.Helper()
+	var got []string
+	err := src.Read(context.Background(), func(r record) error {
+		got = append(got, fmt.Sprintf("%s/%d/%d", r.Topic, r.Partition, r.Offset))
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return got
+}
+
+func TestFileSourceReadsRecording(t *testing.T) {
+	tests := []struct {
+		name   string
+		topics []string
+		start  startPosition
+		want   []string
+	}{
+		{
+			name: "every topic",
+			want: []string{"main-events/0/10", "main-events/0/11", "retry-events-5s/0/3", "dlq-events/0/0", "main-events/1/4", "reply-events-order-1/0/0"},
+		},
+		{
+			name:   "topic prefixes",
+			topics: defaultTopics,
+			want:   []string{"main-events/0/10", "main-events/0/11", "retry-events-5s/0/3", "dlq-events/0/0", "main-events/1/4"},
+		},
+		{
+			name:   "from an offset",
+			topics: defaultTopics,
+			start:  startPosition{offset: 4},
+			want:   []string{"main-events/0/10", "main-events/0/11", "main-events/1/4"},
+		},
+		{
+			name:   "from the end includes everything",
+			topics: []string{"main-events"},
+			start:  startPosition{offset: -1, fromEnd: true},
+			want:   []string{"main-events/0/10", "main-events/0/11", "main-events/1/4"},
+		},
+		{
+			name:   "since overrides the offset",
+			topics: defaultTopics,
+			start:  startPosition{offset: 100, since: fixtureStart.Add(2 * time.Second)},
+			want:   []string{"main-events/0/11", "dlq-events/0/0", "main-events/1/4"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := readAll(t, &fileSource{path: fixture, topics: tt.topics, start: tt.start})
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("read %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFileSourceReportsMalformedLine(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "broken.jsonl")
+	data, err := os.ReadFile(fixture)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, append(append(data, '\n'), "{not json\n"...), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	err = (&fileSource{path: path}).Read(context.Background(), func(record) error { return nil })
+	// Boş satır da sayılır: kayıtlar 6 satır, ardından boş satır ve bozuk satır.
+	if err == nil || !strings.Contains(err.Error(), path+":8:") {
+		t.Fatalf("Read error = %v, want it to point at %s:8", err, path)
+	}
+}
+
+// Kaydı -file ile tekrar okumak, canlı okumayla aynı çıktıyı vermeli: filtre uygulanır ve
+// mesajın ana topic -> retry yolculuğu topic'ler arasında zaman sırasıyla görünür.
+func TestRunReplaysRecording(t *testing.T) {
+	tests := []struct {
+		name                             string
+		types, from, to, order, user, id string
+		want                             []string
+	}{
+		{
+			name: "no filter shows undecodable records",
+			want: []string{"main-events/0/10", "retry-events-5s/0/3", "dlq-events/0/0", "main-events/0/11", "main-events/1/4"},
+		},
+		{
+			name: "correlation follows an order across topics",
+			id:   "order-1",
+			want: []string{"main-events/0/10", "retry-events-5s/0/3", "main-events/0/11"},
+		},
+		{
+			name: "correlation by request id header",
+			id:   "req-2",
+			want: []string{"dlq-events/0/0"},
+		},
+		{
+			name:  "type and target service",
+			types: "ORDER_CREATED",
+			to:    "PRODUCT_SERVICE",
+			want:  []string{"main-events/0/10"},
+		},
+		{
+			name: "source service and user",
+			from: "PAYMENT_SERVICE",
+			user: "user-1",
+			want: []string{"main-events/0/11"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := buildFilter(tt.types, tt.from, tt.to, tt.order, tt.user, tt.id)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var out bytes.Buffer
+			src := &fileSource{path: fixture, topics: defaultTopics}
+			if err := run(context.Background(), src, f, "", false, newPrinter(&out, false)); err != nil {
+				t.Fatal(err)
+			}
+
+			var got []string
+			dec := json.NewDecoder(&out)
+			for dec.More() {
+				var l line
+				if err := dec.Decode(&l); err != nil {
+					t.Fatal(err)
+				}
+				if (l.Message == nil) == (l.Error == "") {
+					t.Fatalf("line %+v must have either a message or an error", l)
+				}
+				got = append(got, fmt.Sprintf("%s/%d/%d", l.Topic, l.Partition, l.Offset))
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("printed %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// -record ile yeniden kaydedilen akış, kaynağıyla aynı kayıtları içermeli.
+func TestRecordingRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "again.jsonl")
+	var out bytes.Buffer
+	if err := run(context.Background(), &fileSource{path: fixture}, filter{}, path, false, newPrinter(&out, false)); err != nil {
+		t.Fatal(err)
+	}
+
+	want := readAll(t, &fileSource{path: fixture})
+	if got := readAll(t, &fileSource{path: path}); !reflect.DeepEqual(got, want) {
+		t.Fatalf("re-recorded %v, want %v", got, want)
+	}
+}
@@ -1,15 +1,27 @@
 package main
 
 import (
+    "context"
     "log"
     "marketplace/internal/notification-service/app" 
     "marketplace/internal/notification-service/config"
+    "marketplace/pkg/logging"
+    "marketplace/pkg/tracing"
 )
 
 func main() {
+    if err := logging.Init("notification-service"); err != nil {
+        log.Fatalf("failed to initialise logging: %v", err)
+    }
+
     // 1. Konfigürasyonu yükle
     appConfig := config.Read()
 
+    shutdownTracing, err := tracing.Init(context.Background(), "notification-service")
+    if err != nil {
+        log.Fatalf("failed to initialise tracing: %v", err)
+    }
+
     // 2. Uygulamayı ayağa kaldır (NewApp kullanımı daha yaygındır)
     application, err := app.NewApp(appConfig) 
     if err != nil {
@@ -17,7 +29,9 @@ func main() {
     }
 
     // 3. Uygulamayı başlat
-    if err := application.Start(); err != nil {
+    err = application.Start()
+    shutdownTracing.Flush()
+    if err != nil {
         log.Fatalf("server stopped with error: %v", err)
     }
 }
\ No newline at end of file
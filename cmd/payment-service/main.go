@@ -1,21 +1,35 @@
 package main
 
 import (
+	"context"
 	"log"
 
 	application "marketplace/internal/payment-service/app"
 	"marketplace/internal/payment-service/config"
+	"marketplace/pkg/logging"
+	"marketplace/pkg/tracing"
 )
 
 func main() {
+	if err := logging.Init("payment-service"); err != nil {
+		log.Fatalf("failed to initialise logging: %v", err)
+	}
+
 	appConfig := config.Read()
 
+	shutdownTracing, err := tracing.Init(context.Background(), "payment-service")
+	if err != nil {
+		log.Fatalf("failed to initialise tracing: %v", err)
+	}
+
 	app, err := application.NewApp(appConfig)
 	if err != nil {
 		log.Fatalf("failed to initialise app: %v", err)
 	}
 
-	if err := app.Start(); err != nil {
+	err = app.Start()
+	shutdownTracing.Flush()
+	if err != nil {
 		log.Fatalf("server stopped with error: %v", err)
 	}
 }
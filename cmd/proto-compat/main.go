@@ -0,0 +1,65 @@
+// proto-compat, derlenmiş proto tanımlarını kayıtlı şema kilidiyle (pkg/proto/schema.lock.json)
+// karşılaştırır ve geriye uyumsuz bir değişiklik varsa sıfırdan farklı kodla çıkar.
+//
+// Neden? Kafka'daki retry/DLQ mesajları ve kritik mesaj dosyaları eski şemayla yazılmıştır.
+// Bir alanın silinmesi, numarasının veya tipinin değişmesi bu mesajları sessizce bozar;
+// upcaster'lar da ancak eski alan numaraları korunursa çalışabilir.
+//
+// Kullanım:
+//
+//	go run ./cmd/proto-compat            # kontrol et (CI / pre-commit)
+//	go run ./cmd/proto-compat -update    # uyumlu değişikliklerden sonra kilidi güncelle
+//	go run ./cmd/proto-compat -update -force  # bilinçli kırıcı değişikliği kilide yaz
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+
+	"marketplace/pkg/proto/compat"
+)
+
+func main() {
+	lockPath := flag.String("lock", "pkg/proto/schema.lock.json", "şema kilit dosyası")
+	update := flag.Bool("update", false, "kilidi güncel şemayla yeniden yaz")
+	force := flag.Bool("force", false, "-update ile birlikte: kırıcı değişikliklere rağmen yaz")
+	flag.Parse()
+
+	current := compat.Current()
+
+	locked, err := compat.ReadLock(*lockPath)
+	if errors.Is(err, fs.ErrNotExist) {
+		if !*update {
+			log.Fatalf("proto-compat: %s not found; create it with -update", *lockPath)
+		}
+		locked = &compat.Schema{}
+	} else if err != nil {
+		log.Fatalf("proto-compat: %v", err)
+	}
+
+	violations := compat.Compare(locked, current)
+	for _, v := range violations {
+		fmt.Fprintln(os.Stderr, "✗", v)
+	}
+
+	if *update {
+		if len(violations) > 0 && !*force {
+			log.Fatalf("proto-compat: %d breaking changes; fix them or re-run with -force", len(violations))
+		}
+		if err := compat.WriteLock(*lockPath, current); err != nil {
+			log.Fatalf("proto-compat: %v", err)
+		}
+		fmt.Printf("✓ %s updated\n", *lockPath)
+		return
+	}
+
+	if len(violations) > 0 {
+		fmt.Fprintf(os.Stderr, "\n%d breaking proto changes; restore the fields or see pkg/messaging/upcasters.go\n", len(violations))
+		os.Exit(1)
+	}
+	fmt.Println("✓ proto schema is backward compatible")
+}
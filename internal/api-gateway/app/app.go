@@ -21,6 +21,7 @@ type App struct {
 	// RateLimiter  *limiter.RateLimiter
 	// Metrics      *metrics.Metrics
 	cacheManager *cache.CacheManager
+	gateway      *config.GatewayStore
 }
 
 func New(cfg config.Config) *App {
@@ -40,7 +41,14 @@ func New(cfg config.Config) *App {
 
 	// Uygulama kapanırken cache'i kapat
 
-	server := server.New(cfg, cacheManager)
+	// Servisler, route politikaları ve limitler dosyadan gelir; geçersizse gateway başlamaz.
+	gateway, err := config.NewGatewayStore(cfg.Routing.File)
+	if err != nil {
+		log.Fatalf("❌ Gateway config yüklenemedi: %v", err)
+	}
+	log.Printf("✅ Gateway config yüklendi: %s", gateway.Path())
+
+	server := server.New(cfg, cacheManager, gateway)
 	return &App{
 		//Fiber:        f,
 		// Registry:     registry,
@@ -48,13 +56,19 @@ func New(cfg config.Config) *App {
 		// Metrics:      metrics,
 		server:       server,
 		cacheManager: cacheManager,
+		gateway:      gateway,
 	}
 }
 func (a *App) Start() error {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	// Start Kafka consumer
+	// Dosya değişikliklerini izle; Start dönünce ctx iptal edilir ve izleme durur.
+	go func() {
+		if err := a.gateway.Watch(ctx); err != nil {
+			log.Printf("⚠️ Gateway config hot reload disabled: %v", err)
+		}
+	}()
 
 	go graceful.WaitForShutdown(a.server.FiberApp(), 5*time.Second, ctx)
 
@@ -63,10 +77,7 @@ func (a *App) Start() error {
 		return fmt.Errorf("server exited with error: %w", err)
 	}
 
+	a.server.Registry.Close()
 	log.Println("server stopped, closing repository")
 	return a.cacheManager.Close()
 }
-
-func (a *App) RegisterService(name string, baseURLs []string, prefix string) error {
-	return a.server.Registry.Register(name, baseURLs, prefix)
-}
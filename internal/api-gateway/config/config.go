@@ -42,39 +42,24 @@ type RedisCacheConfig struct {
 	CacheTTL time.Duration `mapstructure:"cache_ttl"`
 }
 
+// RoutingConfig, servis, route ve limit tanımlarının okunduğu dosyayı gösterir (bkz. GatewaySpec).
+type RoutingConfig struct {
+	File string `mapstructure:"file"`
+}
+
+// RateLimitConfig, limitlerin nerede tutulduğunu belirler.
+// "redis" (varsayılan): limitler redisCache'teki Redis'te tutulur ve tüm gateway replikaları
+// aynı kotayı paylaşır; Redis'e ulaşılamazsa bellek içi limitlere düşülür.
+// "memory": her replika kendi limitini uygular (tek replika veya yerel geliştirme).
+type RateLimitConfig struct {
+	Backend string `mapstructure:"backend"`
+}
+
 type Config struct {
 	RedisCache RedisCacheConfig `mapstructure:"redisCache"`
 	Server     ServerConfig     `mapstructure:"server"`
-}
-
-// GetDefaultRouteConfigs returns the default rate limit configurations
-func GetDefaultRouteConfigs() map[string]RouteConfig {
-	return map[string]RouteConfig{
-		"/users": {
-			GlobalLimit: 50.0 / 60, GlobalBurst: 50,
-			UserLimit: 20.0 / 60, UserBurst: 20,
-		},
-		// "/users/profile": {
-		// 	GlobalLimit: 10.0 / 60, GlobalBurst: 10,
-		// 	UserLimit: 2.0 / 60, UserBurst: 2,
-		// },
-		"/sellers": {
-			GlobalLimit: 50.0 / 60, GlobalBurst: 50,
-			UserLimit: 20.0 / 60, UserBurst: 20,
-		},
-		"/test/hello": {
-			GlobalLimit: 2.0 / 60, GlobalBurst: 2,
-			UserLimit: 1.0 / 60, UserBurst: 1,
-		},
-		"/chat": {
-			GlobalLimit: 50.0 / 60, GlobalBurst: 10,
-			UserLimit: 5.0 / 60, UserBurst: 3,
-		},
-		"default": {
-			GlobalLimit: 1000.0 / 60, GlobalBurst: 1000,
-			UserLimit: 1000.0 / 60, UserBurst: 1000,
-		},
-	}
+	Routing    RoutingConfig    `mapstructure:"routing"`
+	RateLimit  RateLimitConfig  `mapstructure:"rateLimit"`
 }
 
 type RoutePolicy struct {
@@ -90,98 +75,6 @@ const (
 	PermissionManageOwnStore        int64 = 1 << 10
 )
 
-// GetProtectedRoutes returns the set of routes that require authentication
-func GetProtectedRoutes() map[string]RoutePolicy {
-	return map[string]RoutePolicy{
-		"/users/profile": {
-			Permissions: PermissionNone,
-		},
-		"/users/add-user-role/:user_id": {
-			Permissions: PermissionManageRoles | PermissionAdministrator,
-		},
-		"/users/create-role": {
-			Permissions: PermissionManageRoles | PermissionAdministrator,
-		},
-		"/users/change-password": {
-			Permissions: PermissionNone,
-		},
-		"/users/upload-avatar": {
-			Permissions: PermissionNone,
-		},
-		"/sellers/store/me": {
-			Permissions: PermissionNone,
-		},
-		"/sellers/store/onboard": {
-			Permissions: PermissionNone,
-		},
-		"/sellers/admin/approve/:seller_id": {
-			Permissions: PermissionApproveOrRejectSeller | PermissionAdministrator,
-		},
-		"/sellers/admin/reject/:seller_id": {
-			Permissions: PermissionApproveOrRejectSeller | PermissionAdministrator,
-		},
-		"/sellers/store/upload-logo/:seller_id": {
-			Permissions: PermissionManageOwnStore,
-		},
-		"/sellers/store/upload-banner/:seller_id": {
-			Permissions: PermissionManageOwnStore,
-		},
-		"/products/create": {
-			Permissions: PermissionManageOwnStore,
-		},
-		"/products/upload/:product_id": {
-			Permissions: PermissionManageOwnStore,
-		},
-		"/products/category": {
-			Permissions: PermissionAdministrator,
-		},
-		"/products/recommended": {
-			Permissions: PermissionNone,
-		},
-		"/products/product/:product_id": {
-			Permissions: PermissionViewProduct,
-		},
-		"/products/toggle-favorite/:product_id": {
-			Permissions: PermissionNone,
-		},
-		"/products/favorites": {
-			Permissions: PermissionNone,
-		},
-		"/products/update/:product_id": {
-			Permissions: PermissionManageOwnStore,
-		},
-		"/products/delete/:product_id": {
-			Permissions: PermissionManageOwnStore | PermissionAdministrator,
-		},
-		"/baskets/add-item": {
-			Permissions: PermissionNone,
-		},
-		"/baskets/remove-item/:product_id": {
-			Permissions: PermissionNone,
-		},
-		"/baskets/decrement-item/:product_id": {
-			Permissions: PermissionNone,
-		},
-		"/baskets/increment-item/:product_id": {
-			Permissions: PermissionNone,
-		},
-		"/baskets/clear-basket": {
-			Permissions: PermissionNone,
-		},
-		"/baskets/basket": {
-			Permissions: PermissionNone,
-		},
-		"/baskets/count": {
-			Permissions: PermissionNone,
-		},
-		"/orders/order": {
-			Permissions: PermissionNone,
-		},
-		"/orders/user": {
-			Permissions: PermissionNone,
-		},
-	}
-}
 func Read() Config {
 	v := viper.New()
 
@@ -203,6 +96,9 @@ func Read() Config {
 	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
 	v.AutomaticEnv()
 
+	v.SetDefault("routing.file", filepath.Join(configDir, "gateway.yaml"))
+	v.SetDefault("rateLimit.backend", "redis")
+
 	var cfg Config
 	if err := v.Unmarshal(&cfg); err != nil {
 		panic("Config unmarshal error: " + err.Error())
@@ -0,0 +1,555 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/url"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-viper/mapstructure/v2"
+	"github.com/spf13/viper"
+)
+
+// GatewaySpec, gateway yönlendirme dosyasının (gateway.yaml veya .json) kök yapısıdır.
+// Neden dosya? Servis adresleri, prefix'ler, korumalı route'lar ve rate limit'ler daha önce
+// main.go'da ve Go map'lerinde duruyordu; backend router'ları değiştiğinde bu listeler
+// kendiliğinden güncellenmediği için gateway yanlış path'leri koruyordu. Dosya tek kaynak olur,
+// başlangıçta doğrulanır ve değiştiğinde gateway yeniden başlatılmadan uygulanır.
+type GatewaySpec struct {
+	// DefaultRateLimit, hiçbir servis veya route limitine uymayan isteklere uygulanır.
+	DefaultRateLimit *RateLimitSpec `mapstructure:"default_rate_limit"`
+	Services         []ServiceSpec  `mapstructure:"services"`
+}
+
+// ServiceSpec, bir backend servisinin tanımıdır. Prefix ile başlayan istekler, prefix
+// çıkarılıp (ve varsa rewrite kuralları uygulanıp) instance'lardan birine iletilir.
+type ServiceSpec struct {
+	Name      string         `mapstructure:"name"`
+	Prefix    string         `mapstructure:"prefix"`
+	Instances []InstanceSpec `mapstructure:"instances"`
+	// LoadBalancer, instance seçme stratejisidir (varsayılan round_robin).
+	LoadBalancer string `mapstructure:"load_balancer"`
+	// Timeout, backend'e yapılan isteğin okuma/yazma süresidir (varsayılan DefaultTimeout).
+	Timeout   time.Duration  `mapstructure:"timeout"`
+	RateLimit *RateLimitSpec `mapstructure:"rate_limit"`
+	Rewrites  []RewriteSpec  `mapstructure:"rewrites"`
+	Routes    []RouteSpec    `mapstructure:"routes"`
+	// Retries, idempotent isteklerin (GET, HEAD, OPTIONS, PUT, DELETE) başka bir instance'ta
+	// en fazla kaç kez tekrar deneneceğidir (varsayılan DefaultRetries). 0 tekrar denemeyi kapatır.
+	Retries        *int               `mapstructure:"retries"`
+	CircuitBreaker CircuitBreakerSpec `mapstructure:"circuit_breaker"`
+	// Discovery tanımlıysa instance listesi dış bir kaynaktan izlenir; Instances sadece
+	// kaynaktan ilk liste gelene kadar kullanılır.
+	Discovery DiscoverySpec `mapstructure:"discovery"`
+}
+
+// Discovery kaynakları.
+const (
+	// DiscoveryFile, instance listesini ayrı bir dosyadan okur ve dosya değiştikçe günceller.
+	DiscoveryFile = "file"
+	// DiscoveryDNS, DNS SRV kaydını periyodik olarak sorgular (Örn: Kubernetes headless service, Consul DNS).
+	DiscoveryDNS = "dns"
+	// DiscoveryRedis, backend'lerin kendilerini Redis'e kaydettiği self-registration kayıtlarını okur.
+	DiscoveryRedis = "redis"
+)
+
+// Discovery varsayılanları.
+const (
+	DefaultDNSInterval   = 30 * time.Second
+	DefaultRedisInterval = 5 * time.Second
+)
+
+var discoveryProviders = []string{DiscoveryFile, DiscoveryDNS, DiscoveryRedis}
+
+// DiscoverySpec, servisin instance'larının nereden bulunacağıdır. Provider boşsa instance'lar
+// gateway dosyasındaki Instances listesidir.
+type DiscoverySpec struct {
+	Provider string `mapstructure:"provider"`
+	// File, file provider'ın okuduğu dosyadır (instances: [...]); göreli yol gateway dosyasına göredir.
+	File string `mapstructure:"file"`
+	// SRV, dns provider'ın sorguladığı kayıttır (Örn: _http._tcp.user-service.marketplace.svc.cluster.local).
+	SRV string `mapstructure:"srv"`
+	// Scheme, SRV kayıtlarından kurulan URL'lerin şemasıdır (varsayılan http).
+	Scheme string `mapstructure:"scheme"`
+	// Interval, dns ve redis provider'larının sorgu aralığıdır.
+	Interval time.Duration `mapstructure:"interval"`
+}
+
+// InstanceSpec, servisin tek bir upstream adresidir. Dosyada düz URL ("http://host:port")
+// veya {url, weight} olarak yazılabilir.
+type InstanceSpec struct {
+	URL string `mapstructure:"url"`
+	// Weight, weighted ve consistent_hash stratejilerinde instance'ın payıdır (varsayılan 1).
+	Weight int `mapstructure:"weight"`
+}
+
+// Load balancing stratejileri.
+const (
+	// LBRoundRobin, sağlıklı instance'ları sırayla kullanır.
+	LBRoundRobin = "round_robin"
+	// LBLeastRequests, o an en az işlenmekte olan isteği olan instance'ı seçer; yavaş bir
+	// instance'ın önünde kuyruk birikmesini önler.
+	LBLeastRequests = "least_requests"
+	// LBWeighted, istekleri instance ağırlıklarıyla orantılı dağıtır (Örn: farklı boyutta makineler).
+	LBWeighted = "weighted"
+	// LBConsistentHash, aynı kullanıcının isteklerini aynı instance'a gönderir; instance'taki
+	// yerel cache'in isabet oranı artar. Bir instance çıkarıldığında sadece onun kullanıcıları taşınır.
+	LBConsistentHash = "consistent_hash"
+)
+
+// loadBalancers, geçerli strateji adlarıdır.
+var loadBalancers = []string{LBRoundRobin, LBLeastRequests, LBWeighted, LBConsistentHash}
+
+// instanceSpecHook, instances listesindeki düz URL'leri InstanceSpec'e çevirir.
+func instanceSpecHook(from, to reflect.Type, data any) (any, error) {
+	if from.Kind() == reflect.String && to == reflect.TypeOf(InstanceSpec{}) {
+		return InstanceSpec{URL: data.(string)}, nil
+	}
+	return data, nil
+}
+
+// CircuitBreakerSpec, servisin her instance'ına ayrı ayrı uygulanan devre kesici ayarlarıdır.
+// Boş alanlar varsayılanlarla doldurulur.
+type CircuitBreakerSpec struct {
+	// FailureThreshold, devreyi açan ardışık hata sayısıdır (varsayılan 5).
+	FailureThreshold int `mapstructure:"failure_threshold"`
+	// OpenTimeout, açık devrenin deneme isteklerine izin vermeden önce beklediği süredir (varsayılan 30s).
+	OpenTimeout time.Duration `mapstructure:"open_timeout"`
+	// HalfOpenRequests, half-open durumda aynı anda geçebilecek deneme isteği sayısıdır (varsayılan 1).
+	HalfOpenRequests int `mapstructure:"half_open_requests"`
+	// SlowCall, bundan uzun süren başarılı istekleri de hata sayar (0 = kapalı).
+	SlowCall time.Duration `mapstructure:"slow_call"`
+}
+
+// RouteSpec, servis altındaki tek bir route'un politikasıdır. Path, istemcinin gördüğü tam
+// yoldur (prefix dahil) ve ":param" segmentleri içerebilir.
+// Listelenen route'lar varsayılan olarak oturum ister; Public route'lar istemez.
+type RouteSpec struct {
+	Path string `mapstructure:"path"`
+	// Permissions, kullanıcının sahip olması gereken izinlerdir; biri yeterlidir.
+	// Boşsa oturum açmış her kullanıcı erişebilir (bkz. PermissionNames).
+	Permissions []string       `mapstructure:"permissions"`
+	Public      bool           `mapstructure:"public"`
+	RateLimit   *RateLimitSpec `mapstructure:"rate_limit"`
+	Timeout     time.Duration  `mapstructure:"timeout"`
+}
+
+// RateLimitSpec, global (route başına toplam) ve kullanıcı başına limitlerdir.
+// Tanımlanmayan limit uygulanmaz.
+type RateLimitSpec struct {
+	Global LimitSpec `mapstructure:"global"`
+	User   LimitSpec `mapstructure:"user"`
+}
+
+// LimitSpec, Per süresi içinde izin verilen istek sayısıdır (Örn: 50 istek / 1m).
+// Burst boşsa Requests kadardır.
+type LimitSpec struct {
+	Requests int           `mapstructure:"requests"`
+	Per      time.Duration `mapstructure:"per"`
+	Burst    int           `mapstructure:"burst"`
+}
+
+// RewriteSpec, backend'e giden path'i (prefix çıkarıldıktan sonra) düzenli ifade ile değiştirir.
+// To, $1 gibi grup referansları içerebilir. Kurallar sırayla uygulanır.
+type RewriteSpec struct {
+	From string `mapstructure:"from"`
+	To   string `mapstructure:"to"`
+
+	pattern *regexp.Regexp
+}
+
+// Apply, kuralı path'e uygular.
+func (r RewriteSpec) Apply(path string) string {
+	return r.pattern.ReplaceAllString(path, r.To)
+}
+
+// PermissionNames, dosyada kullanılabilen izin adlarıdır.
+var PermissionNames = map[string]int64{
+	"view_product":             PermissionViewProduct,
+	"manage_own_store":         PermissionManageOwnStore,
+	"approve_or_reject_seller": PermissionApproveOrRejectSeller,
+	"manage_roles":             PermissionManageRoles,
+	"administrator":            PermissionAdministrator,
+}
+
+// Devre kesici ve tekrar deneme varsayılanları.
+const (
+	DefaultRetries                 = 1
+	DefaultBreakerFailureThreshold = 5
+	DefaultBreakerOpenTimeout      = 30 * time.Second
+	DefaultBreakerHalfOpenRequests = 1
+)
+
+// defaultRouteConfig, dosyada default_rate_limit yoksa kullanılır (dakikada 1000 istek).
+var defaultRouteConfig = RouteConfig{
+	GlobalLimit: 1000.0 / 60, GlobalBurst: 1000,
+	UserLimit: 1000.0 / 60, UserBurst: 1000,
+}
+
+// Gateway, doğrulanmış ve middleware'lerin doğrudan kullanabileceği hale getirilmiş
+// yapılandırmadır. Yeniden yüklemede yeni bir Gateway oluşturulur; var olan değiştirilmez.
+type Gateway struct {
+	Services []ServiceSpec
+	// Policies, korumalı route'ların izinleridir (AuthMiddleware).
+	Policies map[string]RoutePolicy
+	// RateLimits, path prefix'ine göre limitlerdir; "default" anahtarı yedek limittir (RateLimitMiddleware).
+	RateLimits map[string]RouteConfig
+}
+
+// LoadGateway, dosyayı okur ve doğrular. Bilinmeyen alanlar (Örn: yazım hatası) hata sayılır.
+func LoadGateway(path string) (*Gateway, error) {
+	v := viper.New()
+	v.SetConfigFile(path)
+	if err := v.ReadInConfig(); err != nil {
+		return nil, fmt.Errorf("read %s: %w", filepath.Base(path), err)
+	}
+
+	var spec GatewaySpec
+	if err := v.UnmarshalExact(&spec, decodeHooks); err != nil {
+		return nil, fmt.Errorf("decode %s: %w", filepath.Base(path), err)
+	}
+	g, err := spec.Compile()
+	if err != nil {
+		return nil, err
+	}
+	for i := range g.Services {
+		d := &g.Services[i].Discovery
+		if d.File != "" && !filepath.IsAbs(d.File) {
+			d.File = filepath.Join(filepath.Dir(path), d.File)
+		}
+	}
+	return g, nil
+}
+
+// decodeHooks, viper'ın varsayılan dönüşümlerine düz URL -> InstanceSpec dönüşümünü ekler.
+var decodeHooks = viper.DecodeHook(mapstructure.ComposeDecodeHookFunc(
+	mapstructure.StringToTimeDurationHookFunc(),
+	mapstructure.StringToSliceHookFunc(","),
+	instanceSpecHook,
+))
+
+// LoadInstances, file discovery dosyasını (instances: [...]) okur ve doğrular.
+func LoadInstances(path string) ([]InstanceSpec, error) {
+	v := viper.New()
+	v.SetConfigFile(path)
+	if err := v.ReadInConfig(); err != nil {
+		return nil, fmt.Errorf("read %s: %w", filepath.Base(path), err)
+	}
+
+	var file struct {
+		Instances []InstanceSpec `mapstructure:"instances"`
+	}
+	if err := v.UnmarshalExact(&file, decodeHooks); err != nil {
+		return nil, fmt.Errorf("decode %s: %w", filepath.Base(path), err)
+	}
+	if errs := normalizeInstances(file.Instances); len(errs) > 0 {
+		return nil, fmt.Errorf("%s: %w", filepath.Base(path), errors.Join(errs...))
+	}
+	return file.Instances, nil
+}
+
+// normalizeInstances, URL'lerin sonundaki /'ı siler, ağırlık varsayılanını doldurur ve
+// geçersiz veya tekrarlanan adresleri hata olarak döner.
+func normalizeInstances(instances []InstanceSpec) []error {
+	var errs []error
+	seen := make(map[string]bool)
+	for j := range instances {
+		instance := &instances[j]
+		instance.URL = strings.TrimSuffix(instance.URL, "/")
+		if u, err := url.Parse(instance.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			errs = append(errs, fmt.Errorf("instance %q must be an http(s) URL", instance.URL))
+		}
+		if seen[instance.URL] {
+			errs = append(errs, fmt.Errorf("duplicate instance %q", instance.URL))
+		}
+		seen[instance.URL] = true
+		if instance.Weight < 0 {
+			errs = append(errs, fmt.Errorf("instance %q: weight must not be negative", instance.URL))
+		}
+		if instance.Weight == 0 {
+			instance.Weight = 1
+		}
+	}
+	return errs
+}
+
+// Compile, tanımı doğrular, varsayılanları doldurur ve Gateway'i oluşturur.
+// Tüm hatalar birlikte döner; operatör dosyayı tek seferde düzeltebilir.
+func (spec GatewaySpec) Compile() (*Gateway, error) {
+	var errs []error
+	fail := func(format string, args ...any) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+
+	if len(spec.Services) == 0 {
+		fail("no services defined")
+	}
+
+	g := &Gateway{
+		Policies:   make(map[string]RoutePolicy),
+		RateLimits: map[string]RouteConfig{"default": defaultRouteConfig},
+	}
+	if spec.DefaultRateLimit != nil {
+		rc, err := spec.DefaultRateLimit.routeConfig()
+		if err != nil {
+			fail("default_rate_limit: %v", err)
+		}
+		g.RateLimits["default"] = rc
+	}
+
+	names := make(map[string]bool)
+	prefixes := make(map[string]string)
+	routes := make(map[string]string)
+
+	for i, svc := range spec.Services {
+		where := fmt.Sprintf("services[%d]", i)
+		if svc.Name != "" {
+			where = fmt.Sprintf("service %q", svc.Name)
+		}
+
+		switch {
+		case svc.Name == "":
+			fail("%s: name is required", where)
+		case names[svc.Name]:
+			fail("%s: duplicate service name", where)
+		}
+		names[svc.Name] = true
+
+		switch {
+		case !strings.HasPrefix(svc.Prefix, "/") || svc.Prefix == "/":
+			fail("%s: prefix must start with / and not be the root (got %q)", where, svc.Prefix)
+		case strings.HasSuffix(svc.Prefix, "/"):
+			fail("%s: prefix must not end with / (got %q)", where, svc.Prefix)
+		case prefixes[svc.Prefix] != "":
+			fail("%s: prefix %s is already used by %q", where, svc.Prefix, prefixes[svc.Prefix])
+		default:
+			prefixes[svc.Prefix] = svc.Name
+		}
+
+		d := &svc.Discovery
+		switch d.Provider {
+		case "":
+			if len(svc.Instances) == 0 {
+				fail("%s: at least one instance is required", where)
+			}
+		case DiscoveryFile:
+			if d.File == "" {
+				fail("%s: discovery.file is required for the file provider", where)
+			}
+		case DiscoveryDNS:
+			if d.SRV == "" {
+				fail("%s: discovery.srv is required for the dns provider", where)
+			}
+			if d.Scheme == "" {
+				d.Scheme = "http"
+			} else if d.Scheme != "http" && d.Scheme != "https" {
+				fail("%s: discovery.scheme must be http or https", where)
+			}
+			if d.Interval == 0 {
+				d.Interval = DefaultDNSInterval
+			}
+		case DiscoveryRedis:
+			if d.Interval == 0 {
+				d.Interval = DefaultRedisInterval
+			}
+		default:
+			fail("%s: unknown discovery provider %q (valid: %s)", where, d.Provider, strings.Join(discoveryProviders, ", "))
+		}
+		if d.Interval < 0 {
+			fail("%s: discovery.interval must not be negative", where)
+		}
+		for _, err := range normalizeInstances(svc.Instances) {
+			fail("%s: %v", where, err)
+		}
+
+		if svc.LoadBalancer == "" {
+			svc.LoadBalancer = LBRoundRobin
+		} else if !slices.Contains(loadBalancers, svc.LoadBalancer) {
+			fail("%s: unknown load_balancer %q (valid: %s)", where, svc.LoadBalancer, strings.Join(loadBalancers, ", "))
+		}
+
+		if svc.Timeout < 0 {
+			fail("%s: timeout must not be negative", where)
+		}
+		if svc.Timeout == 0 {
+			svc.Timeout = DefaultTimeout
+		}
+
+		if svc.Retries == nil {
+			retries := DefaultRetries
+			svc.Retries = &retries
+		} else if *svc.Retries < 0 {
+			fail("%s: retries must not be negative", where)
+		}
+
+		cb := &svc.CircuitBreaker
+		if cb.FailureThreshold < 0 || cb.OpenTimeout < 0 || cb.HalfOpenRequests < 0 || cb.SlowCall < 0 {
+			fail("%s: circuit_breaker values must not be negative", where)
+		}
+		if cb.FailureThreshold == 0 {
+			cb.FailureThreshold = DefaultBreakerFailureThreshold
+		}
+		if cb.OpenTimeout == 0 {
+			cb.OpenTimeout = DefaultBreakerOpenTimeout
+		}
+		if cb.HalfOpenRequests == 0 {
+			cb.HalfOpenRequests = DefaultBreakerHalfOpenRequests
+		}
+
+		if svc.RateLimit != nil {
+			rc, err := svc.RateLimit.routeConfig()
+			if err != nil {
+				fail("%s: rate_limit: %v", where, err)
+			}
+			g.RateLimits[svc.Prefix] = rc
+		}
+
+		for j := range svc.Rewrites {
+			rw := &svc.Rewrites[j]
+			pattern, err := regexp.Compile(rw.From)
+			if err != nil || rw.From == "" {
+				fail("%s: rewrites[%d]: invalid pattern %q", where, j, rw.From)
+				continue
+			}
+			rw.pattern = pattern
+		}
+
+		for j, route := range svc.Routes {
+			rwhere := fmt.Sprintf("%s: route %q", where, route.Path)
+			if route.Path == "" {
+				rwhere = fmt.Sprintf("%s: routes[%d]", where, j)
+			}
+
+			if !strings.HasPrefix(route.Path, svc.Prefix+"/") {
+				fail("%s: path must start with the service prefix %s/", rwhere, svc.Prefix)
+			}
+			if owner, ok := routes[route.Path]; ok {
+				fail("%s: already defined by %q", rwhere, owner)
+			}
+			routes[route.Path] = svc.Name
+
+			if route.Timeout < 0 {
+				fail("%s: timeout must not be negative", rwhere)
+			}
+
+			var perms int64
+			for _, name := range route.Permissions {
+				bit, ok := PermissionNames[name]
+				if !ok {
+					fail("%s: unknown permission %q (known: %s)", rwhere, name, strings.Join(permissionNameList(), ", "))
+				}
+				perms |= bit
+			}
+			switch {
+			case route.Public && len(route.Permissions) > 0:
+				fail("%s: public routes cannot require permissions", rwhere)
+			case !route.Public:
+				g.Policies[route.Path] = RoutePolicy{Permissions: perms}
+			}
+
+			if route.RateLimit != nil {
+				// Rate limit anahtarları path prefix'idir; ":param" içeren bir path hiçbir isteğin
+				// prefix'i olamaz ve limit sessizce uygulanmazdı.
+				if strings.Contains(route.Path, "/:") {
+					fail("%s: rate_limit is not supported on parameterized routes; set it on the service", rwhere)
+					continue
+				}
+				rc, err := route.RateLimit.routeConfig()
+				if err != nil {
+					fail("%s: rate_limit: %v", rwhere, err)
+				}
+				g.RateLimits[route.Path] = rc
+			}
+		}
+
+		spec.Services[i] = svc
+	}
+
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("invalid gateway config: %w", errors.Join(errs...))
+	}
+	g.Services = spec.Services
+	return g, nil
+}
+
+// routeConfig, limit tanımını rate limiter'ın kullandığı saniye başına orana çevirir.
+// Global limit tanımlı değilse sınırsızdır; kullanıcı limiti tanımlı değilse uygulanmaz.
+func (r *RateLimitSpec) routeConfig() (RouteConfig, error) {
+	global, globalBurst, err := r.Global.perSecond()
+	if err != nil {
+		return RouteConfig{}, fmt.Errorf("global: %w", err)
+	}
+	if r.Global.Requests == 0 {
+		global = math.Inf(1)
+	}
+	user, userBurst, err := r.User.perSecond()
+	if err != nil {
+		return RouteConfig{}, fmt.Errorf("user: %w", err)
+	}
+	return RouteConfig{GlobalLimit: global, GlobalBurst: globalBurst, UserLimit: user, UserBurst: userBurst}, nil
+}
+
+func (l LimitSpec) perSecond() (float64, int, error) {
+	if l.Requests < 0 || l.Burst < 0 || l.Per < 0 {
+		return 0, 0, errors.New("requests, per and burst must not be negative")
+	}
+	if l.Requests == 0 {
+		return 0, 0, nil
+	}
+	per := l.Per
+	if per == 0 {
+		per = time.Minute
+	}
+	burst := l.Burst
+	if burst == 0 {
+		burst = l.Requests
+	}
+	return float64(l.Requests) / per.Seconds(), burst, nil
+}
+
+// TimeoutFor, path'e uyan route'un süresini, yoksa servisin süresini döner.
+func (s ServiceSpec) TimeoutFor(path string) time.Duration {
+	for _, route := range s.Routes {
+		if route.Timeout > 0 && MatchPath(route.Path, path) {
+			return route.Timeout
+		}
+	}
+	return s.Timeout
+}
+
+// MatchPath, path'in ":param" segmentleri içerebilen şablona uyup uymadığını söyler.
+func MatchPath(pattern, path string) bool {
+	if pattern == path {
+		return true
+	}
+	patternParts := strings.Split(pattern, "/")
+	pathParts := strings.Split(path, "/")
+	if len(patternParts) != len(pathParts) {
+		return false
+	}
+	for i, part := range patternParts {
+		if strings.HasPrefix(part, ":") || part == "" {
+			continue
+		}
+		if part != pathParts[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func permissionNameList() []string {
+	names := make([]string, 0, len(PermissionNames))
+	for name := range PermissionNames {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,236 @@
+package config
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// Repodaki gateway.yaml her zaman geçerli olmalı; aksi halde gateway başlamaz.
+func TestShippedGatewayConfigIsValid(t *testing.T) {
+	g, err := LoadGateway("gateway.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(g.Services) == 0 {
+		t.Fatal("gateway.yaml defines no services")
+	}
+}
+
+func TestLoadGateway(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "gateway.yaml")
+	writeFile(t, path, `
+default_rate_limit:
+  global: { requests: 600, per: 1m }
+services:
+  - name: user-service
+    prefix: /users
+    instances:
+      - http://10.0.0.1:8081/
+      - { url: http://10.0.0.2:8081, weight: 3 }
+    rate_limit:
+      user: { requests: 20, per: 1m, burst: 5 }
+    rewrites:
+      - { from: "^/v1/(.*)$", to: "/$1" }
+    routes:
+      - path: /users/profile
+      - path: /users/roles/assign/:user_id
+        permissions: [manage_roles, administrator]
+        timeout: 5s
+      - path: /users/login
+        public: true
+        rate_limit:
+          global: { requests: 10, per: 1s }
+  - name: product-service
+    prefix: /products
+    load_balancer: least_requests
+    retries: 0
+    discovery: { provider: file, file: instances/products.yaml }
+  - name: order-service
+    prefix: /orders
+    discovery: { provider: dns, srv: _http._tcp.orders.local }
+`)
+
+	g, err := LoadGateway(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	users, products, orders := g.Services[0], g.Services[1], g.Services[2]
+	if want := []InstanceSpec{{URL: "http://10.0.0.1:8081", Weight: 1}, {URL: "http://10.0.0.2:8081", Weight: 3}}; !slices.Equal(users.Instances, want) {
+		t.Errorf("instances = %v, want %v", users.Instances, want)
+	}
+	if users.LoadBalancer != LBRoundRobin || users.Timeout != DefaultTimeout || *users.Retries != DefaultRetries {
+		t.Errorf("defaults not applied: lb %q, timeout %v, retries %d", users.LoadBalancer, users.Timeout, *users.Retries)
+	}
+	if want := (CircuitBreakerSpec{FailureThreshold: DefaultBreakerFailureThreshold, OpenTimeout: DefaultBreakerOpenTimeout, HalfOpenRequests: DefaultBreakerHalfOpenRequests}); users.CircuitBreaker != want {
+		t.Errorf("circuit_breaker = %+v, want %+v", users.CircuitBreaker, want)
+	}
+	if got := users.Rewrites[0].Apply("/v1/profile"); got != "/profile" {
+		t.Errorf("rewrite = %q, want /profile", got)
+	}
+	if got := users.TimeoutFor("/users/roles/assign/42"); got != 5*time.Second {
+		t.Errorf("route timeout = %v, want 5s", got)
+	}
+	if *products.Retries != 0 || products.LoadBalancer != LBLeastRequests {
+		t.Errorf("products: retries %d, lb %q", *products.Retries, products.LoadBalancer)
+	}
+	if want := filepath.Join(dir, "instances/products.yaml"); products.Discovery.File != want {
+		t.Errorf("discovery file = %q, want it relative to the gateway file (%q)", products.Discovery.File, want)
+	}
+	if orders.Discovery.Scheme != "http" || orders.Discovery.Interval != DefaultDNSInterval {
+		t.Errorf("dns defaults not applied: %+v", orders.Discovery)
+	}
+
+	wantPolicies := map[string]RoutePolicy{
+		"/users/profile":               {Permissions: PermissionNone},
+		"/users/roles/assign/:user_id": {Permissions: PermissionManageRoles | PermissionAdministrator},
+	}
+	if len(g.Policies) != len(wantPolicies) {
+		t.Errorf("policies = %v, want %v (public routes are not protected)", g.Policies, wantPolicies)
+	}
+	for path, want := range wantPolicies {
+		if g.Policies[path] != want {
+			t.Errorf("policy %s = %+v, want %+v", path, g.Policies[path], want)
+		}
+	}
+
+	wantLimits := map[string]RouteConfig{
+		"default":      {GlobalLimit: 10, GlobalBurst: 600},
+		"/users":       {GlobalLimit: math.Inf(1), UserLimit: 20.0 / 60, UserBurst: 5},
+		"/users/login": {GlobalLimit: 10, GlobalBurst: 10},
+	}
+	if len(g.RateLimits) != len(wantLimits) {
+		t.Errorf("rate limits = %v, want %v", g.RateLimits, wantLimits)
+	}
+	for prefix, want := range wantLimits {
+		if g.RateLimits[prefix] != want {
+			t.Errorf("rate limit %s = %+v, want %+v", prefix, g.RateLimits[prefix], want)
+		}
+	}
+}
+
+func TestLoadGatewayRejectsUnknownFields(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "gateway.yaml")
+	writeFile(t, path, `
+services:
+  - name: user-service
+    prefix: /users
+    instance: [http://localhost:8081]
+`)
+	if _, err := LoadGateway(path); err == nil || !strings.Contains(err.Error(), "instance") {
+		t.Fatalf("LoadGateway error = %v, want the misspelled field reported", err)
+	}
+}
+
+func TestCompileReportsEveryError(t *testing.T) {
+	negative := -1
+	spec := GatewaySpec{Services: []ServiceSpec{
+		{Name: "a", Prefix: "/a", Instances: []InstanceSpec{{URL: "http://a"}}},
+		{Name: "a", Prefix: "/a/", Instances: []InstanceSpec{{URL: "ftp://b"}, {URL: "ftp://b"}}},
+		{Name: "c", Prefix: "/a", LoadBalancer: "random", Retries: &negative},
+		{Prefix: "", Discovery: DiscoverySpec{Provider: "consul"}, Timeout: -time.Second},
+		{Name: "d", Prefix: "/d", Discovery: DiscoverySpec{Provider: DiscoveryDNS, Scheme: "tcp"}},
+		{Name: "e", Prefix: "/e", Discovery: DiscoverySpec{Provider: DiscoveryFile}},
+		{Name: "f", Prefix: "/f", Instances: []InstanceSpec{{URL: "http://f", Weight: -2}},
+			CircuitBreaker: CircuitBreakerSpec{OpenTimeout: -time.Second},
+			Rewrites:       []RewriteSpec{{From: "("}},
+			Routes: []RouteSpec{
+				{Path: "/other/x"},
+				{Path: "/f/x", Permissions: []string{"superuser"}},
+				{Path: "/f/x"},
+				{Path: "/f/open", Public: true, Permissions: []string{"administrator"}},
+				{Path: "/f/item/:id", RateLimit: &RateLimitSpec{Global: LimitSpec{Requests: 1}}},
+				{Path: "/f/limited", RateLimit: &RateLimitSpec{User: LimitSpec{Requests: -1}}},
+			}},
+	}}
+
+	_, err := spec.Compile()
+	if err == nil {
+		t.Fatal("Compile accepted an invalid spec")
+	}
+	for _, want := range []string{
+		`service "a": duplicate service name`,
+		`service "a": prefix must not end with /`,
+		`service "a": instance "ftp://b" must be an http(s) URL`,
+		`service "a": duplicate instance "ftp://b"`,
+		`service "c": prefix /a is already used by "a"`,
+		`service "c": at least one instance is required`,
+		`service "c": unknown load_balancer "random"`,
+		`service "c": retries must not be negative`,
+		`services[3]: name is required`,
+		`services[3]: prefix must start with /`,
+		`services[3]: unknown discovery provider "consul"`,
+		`services[3]: timeout must not be negative`,
+		`service "d": discovery.srv is required`,
+		`service "d": discovery.scheme must be http or https`,
+		`service "e": discovery.file is required`,
+		`service "f": instance "http://f": weight must not be negative`,
+		`service "f": circuit_breaker values must not be negative`,
+		`service "f": rewrites[0]: invalid pattern "("`,
+		`service "f": route "/other/x": path must start with the service prefix /f/`,
+		`service "f": route "/f/x": unknown permission "superuser"`,
+		`service "f": route "/f/x": already defined by "f"`,
+		`service "f": route "/f/open": public routes cannot require permissions`,
+		`service "f": route "/f/item/:id": rate_limit is not supported on parameterized routes`,
+		`service "f": route "/f/limited": rate_limit: user: requests, per and burst must not be negative`,
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error does not mention %q", want)
+		}
+	}
+
+	if _, err := (GatewaySpec{}).Compile(); err == nil || !strings.Contains(err.Error(), "no services defined") {
+		t.Errorf("empty spec error = %v", err)
+	}
+}
+
+func TestLoadInstances(t *testing.T) {
+	dir := t.TempDir()
+
+	valid := filepath.Join(dir, "valid.yaml")
+	writeFile(t, valid, `
+instances:
+  - http://10.0.0.1:8081/
+  - { url: https://10.0.0.2:8443, weight: 2 }
+`)
+	got, err := LoadInstances(valid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []InstanceSpec{{URL: "http://10.0.0.1:8081", Weight: 1}, {URL: "https://10.0.0.2:8443", Weight: 2}}; !slices.Equal(got, want) {
+		t.Fatalf("LoadInstances = %v, want %v", got, want)
+	}
+
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{"not a URL", "instances: [10.0.0.1:8081]", "must be an http(s) URL"},
+		{"duplicate", "instances: [http://a:1, http://a:1/]", "duplicate instance"},
+		{"unknown field", "instances: [{ url: http://a:1, wieght: 2 }]", "wieght"},
+		{"malformed", "instances: [", "read"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, strings.ReplaceAll(tt.name, " ", "_")+".yaml")
+			writeFile(t, path, tt.content)
+			if _, err := LoadInstances(path); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("LoadInstances error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,140 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// reloadDebounce, dosyaya art arda gelen yazma olaylarının tek bir yüklemeye indirildiği süredir.
+// Editörler dosyayı önce boşaltıp sonra yazar; yarım dosyayı okuyup hata loglamamak için beklenir.
+const reloadDebounce = 200 * time.Millisecond
+
+// GatewayStore, geçerli Gateway yapılandırmasını tutar ve dosya değiştiğinde yeniden yükler.
+// Neden atomic pointer? Her istek Current() ile o anki yapılandırmayı alır; yükleme yeni bir
+// Gateway oluşturup işaretçiyi değiştirir. İşlenmekte olan istekler eski yapılandırmayla biter,
+// hiçbir istek kilit beklemez veya yarım güncellenmiş bir tabloyu görmez.
+// Geçersiz bir dosya reddedilir ve eski yapılandırma kullanılmaya devam eder.
+type GatewayStore struct {
+	path    string
+	current atomic.Pointer[Gateway]
+
+	mu          sync.Mutex
+	subscribers []func(*Gateway)
+}
+
+// NewGatewayStore, dosyayı yükler. Başlangıçta geçersiz dosya hata döner; gateway başlamaz.
+func NewGatewayStore(path string) (*GatewayStore, error) {
+	g, err := LoadGateway(path)
+	if err != nil {
+		return nil, err
+	}
+	s := &GatewayStore{path: path}
+	s.current.Store(g)
+	return s, nil
+}
+
+// Current, geçerli yapılandırmadır.
+func (s *GatewayStore) Current() *Gateway {
+	return s.current.Load()
+}
+
+// Path, izlenen dosyanın yoludur.
+func (s *GatewayStore) Path() string {
+	return s.path
+}
+
+// Subscribe, fn'i şimdiki yapılandırmayla hemen, sonra her başarılı yüklemede çağırır.
+// Servis kaydı gibi kendi durumunu tutan bileşenler bununla güncellenir.
+func (s *GatewayStore) Subscribe(fn func(*Gateway)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.subscribers = append(s.subscribers, fn)
+	fn(s.Current())
+}
+
+// Reload, dosyayı tekrar okur; geçerliyse yeni yapılandırmaya geçer.
+func (s *GatewayStore) Reload() error {
+	g, err := LoadGateway(s.path)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.current.Store(g)
+	for _, fn := range s.subscribers {
+		fn(g)
+	}
+	return nil
+}
+
+// Watch, dosya değiştikçe Reload çağırır; ctx iptal edilene kadar bloklar.
+func (s *GatewayStore) Watch(ctx context.Context) error {
+	log.Printf("👀 Watching gateway config: %s", s.path)
+	return WatchFile(ctx, s.path, func() {
+		if err := s.Reload(); err != nil {
+			log.Printf("❌ Gateway config reload rejected, keeping previous config: %v", err)
+			return
+		}
+		log.Printf("🔄 Gateway config reloaded: %s", s.path)
+	})
+}
+
+// WatchFile, dosyanın bulunduğu dizini izler ve dosya değiştikçe onChange'i çağırır; ctx iptal
+// edilene kadar bloklar. Dizin izlenir çünkü editörler ve Kubernetes ConfigMap'leri dosyayı
+// yerinde yazmak yerine yenisiyle değiştirir (rename/symlink).
+func WatchFile(ctx context.Context, path string, onChange func()) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("create watcher: %w", err)
+	}
+	defer watcher.Close()
+
+	dir := filepath.Dir(path)
+	if err := watcher.Add(dir); err != nil {
+		return fmt.Errorf("watch %s: %w", dir, err)
+	}
+
+	target := filepath.Clean(path)
+	realPath, _ := filepath.EvalSymlinks(path)
+
+	timer := time.NewTimer(reloadDebounce)
+	timer.Stop()
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			current, _ := filepath.EvalSymlinks(path)
+			changed := filepath.Clean(event.Name) == target && (event.Has(fsnotify.Write) || event.Has(fsnotify.Create))
+			if changed || (current != "" && current != realPath) {
+				realPath = current
+				timer.Reset(reloadDebounce)
+			}
+
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			log.Printf("⚠️ File watcher error [%s]: %v", path, err)
+
+		case <-timer.C:
+			onChange()
+		}
+	}
+}
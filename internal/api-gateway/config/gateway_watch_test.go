@@ -0,0 +1,134 @@
+package config
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+const validGateway = `
+services:
+  - name: user-service
+    prefix: /users
+    instances: [http://localhost:8081]
+`
+
+const changedGateway = `
+services:
+  - name: user-service
+    prefix: /users
+    instances: [http://localhost:9091]
+`
+
+// invalidGateway, YAML olarak geçerli ama doğrulamadan geçmeyen bir dosyadır.
+const invalidGateway = `
+services:
+  - name: user-service
+    prefix: users
+    instances: [http://localhost:9091]
+`
+
+func removeFile(t *testing.T, path string) {
+	t.Helper()
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func firstInstance(g *Gateway) string {
+	return g.Services[0].Instances[0].URL
+}
+
+func TestGatewayStoreReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "gateway.yaml")
+	writeFile(t, path, validGateway)
+
+	store, err := NewGatewayStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var notified []string
+	store.Subscribe(func(g *Gateway) { notified = append(notified, firstInstance(g)) })
+
+	steps := []struct {
+		name     string
+		content  string
+		wantErr  bool
+		wantURL  string
+		notified int
+	}{
+		{name: "invalid reload keeps the last good config", content: invalidGateway, wantErr: true, wantURL: "http://localhost:8081", notified: 1},
+		{name: "malformed reload keeps the last good config", content: "services: [", wantErr: true, wantURL: "http://localhost:8081", notified: 1},
+		{name: "valid reload replaces the config", content: changedGateway, wantURL: "http://localhost:9091", notified: 2},
+		{name: "removed file keeps the last good config", wantErr: true, wantURL: "http://localhost:9091", notified: 2},
+	}
+
+	for _, step := range steps {
+		before := store.Current()
+		if step.content != "" {
+			writeFile(t, path, step.content)
+		} else {
+			removeFile(t, path)
+		}
+
+		err := store.Reload()
+		if (err != nil) != step.wantErr {
+			t.Fatalf("%s: Reload error = %v, wantErr %v", step.name, err, step.wantErr)
+		}
+		if got := firstInstance(store.Current()); got != step.wantURL {
+			t.Fatalf("%s: current instance = %s, want %s", step.name, got, step.wantURL)
+		}
+		if step.wantErr && store.Current() != before {
+			t.Fatalf("%s: rejected reload replaced the config", step.name)
+		}
+		if len(notified) != step.notified {
+			t.Fatalf("%s: subscribers notified %d times, want %d", step.name, len(notified), step.notified)
+		}
+	}
+}
+
+func TestNewGatewayStoreRejectsInvalidFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "gateway.yaml")
+	writeFile(t, path, invalidGateway)
+	if _, err := NewGatewayStore(path); err == nil {
+		t.Fatal("NewGatewayStore accepted an invalid file")
+	}
+}
+
+func TestGatewayStoreWatchAppliesValidChanges(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "gateway.yaml")
+	writeFile(t, path, validGateway)
+	store, err := NewGatewayStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- store.Watch(ctx) }()
+	defer func() {
+		cancel()
+		if err := <-done; err != nil {
+			t.Error(err)
+		}
+	}()
+	// Watcher'ın dizini izlemeye başlaması için kısa bir süre tanınır.
+	time.Sleep(50 * time.Millisecond)
+
+	writeFile(t, path, invalidGateway)
+	time.Sleep(3 * reloadDebounce)
+	if got := firstInstance(store.Current()); got != "http://localhost:8081" {
+		t.Fatalf("invalid change was applied: %s", got)
+	}
+
+	writeFile(t, path, changedGateway)
+	deadline := time.Now().Add(5 * time.Second)
+	for firstInstance(store.Current()) != "http://localhost:9091" {
+		if time.Now().After(deadline) {
+			t.Fatal("valid change was not applied")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
@@ -9,6 +9,7 @@ import (
 
 	// Kendi oluşturduğunuz proto paketini import edin
 	pb "marketplace/pkg/proto/auth"
+	"marketplace/pkg/tracing"
 
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
@@ -25,7 +26,7 @@ func InitAuthClient(grpcAddress string) error {
 	var err error
 
 	// Güvenliksiz bağlantı (Genellikle internal mikroservisler için kabul edilebilir)
-	conn, err = grpc.Dial(grpcAddress, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	conn, err = grpc.Dial(grpcAddress, grpc.WithTransportCredentials(insecure.NewCredentials()), tracing.DialOption())
 	if err != nil {
 		return err
 	}
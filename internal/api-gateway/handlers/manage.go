@@ -35,14 +35,9 @@ func (h *ManageHandler) HealthCheck(c *fiber.Ctx) error {
 	serviceHealth := make(map[string]interface{})
 
 	for _, svc := range services {
-		healthy := h.Registry.IsHealthy(svc)
-		// We need to access FailCount and LastCheck but they are not easily accessible thread-safely
-		// if we strictly follow encapsulation.
-		// For now, let's just return basic info or access directly if we are ok with race (for reading stats it is usually ok).
-		// Or better, let's stick to what we can access.
 		serviceHealth[svc.Name] = map[string]interface{}{
-			"healthy":   healthy,
-			"base_urls": svc.BaseURLs,
+			"healthy":   h.Registry.IsHealthy(svc),
+			"instances": svc.Status(),
 		}
 	}
 
@@ -67,7 +62,9 @@ func (h *ManageHandler) ListServices(c *fiber.Ctx) error {
 			"name":        svc.Name,
 			"base_urls":   svc.BaseURLs,
 			"path_prefix": svc.PathPrefix,
+			"lb":          svc.LoadBalancer,
 			"healthy":     h.Registry.IsHealthy(svc),
+			"instances":   svc.Status(),
 		})
 	}
 
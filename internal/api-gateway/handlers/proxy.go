@@ -2,17 +2,23 @@ package handlers
 
 import (
 	"context"
+	"fmt"
 	"log"
 	"strings"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/proxy"
 	"github.com/valyala/fasthttp"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 
 	"marketplace/internal/api-gateway/cache"
 	"marketplace/internal/api-gateway/config"
 	"marketplace/internal/api-gateway/metrics"
 	"marketplace/internal/api-gateway/service"
+	"marketplace/pkg/tracing"
 )
 
 type ProxyHandler struct {
@@ -36,46 +42,90 @@ func (h *ProxyHandler) Handle(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Service not found"})
 	}
 
-	// Circuit Breaker Check
-	if !h.Registry.IsHealthy(svc) {
-		h.Metrics.IncrementFailed()
-		log.Printf("❌ Circuit Open: %s", svc.Name)
-		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
-			"error":   "Service unavailable (Circuit Open)",
-			"service": svc.Name,
-		})
-	}
-
-	targetBaseURL, ok := svc.GetNextBaseURL()
-	if !ok {
-		h.Metrics.IncrementFailed()
-		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
-			"error":   "No healthy service instance found",
-			"service": svc.Name,
-		})
-	}
-
 	h.Metrics.IncrementService(svc.Name)
 
-	targetPath := strings.TrimPrefix(path, svc.PathPrefix)
-	targetURL := targetBaseURL + targetPath
+	targetPath := svc.TargetPath(path)
 	if len(c.Request().URI().QueryString()) > 0 {
-		targetURL += "?" + string(c.Request().URI().QueryString())
+		targetPath += "?" + string(c.Request().URI().QueryString())
 	}
 
 	// Setup Request Headers
 	c.Request().Header.Set(config.InternalGatewayHeader, config.InternalGatewaySecret)
 	c.Request().Header.Set("X-Forwarded-For", c.IP())
 
-	// Fiber Proxy Middleware `Do`
-	// Note: proxy.Do uses fasthttp.Client for the client argument
-	if err := proxy.Do(c, targetURL, &fasthttp.Client{
-		ReadTimeout:  svc.Timeout,
-		WriteTimeout: svc.Timeout,
-	}); err != nil {
+	// Tracing: Backend çağrısı için client span'i açılır ve traceparent isteğe yazılır;
+	// backend'in server span'i bu span'in altına düşer.
+	ctx, span := tracing.Tracer().Start(c.UserContext(), "proxy "+svc.Name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("peer.service", svc.Name),
+			attribute.String("url.path", targetPath),
+		),
+	)
+	defer span.End()
+	tracing.InjectHTTP(ctx, &c.Request().Header)
+
+	// Her deneme farklı bir instance'a gider; sonuç o instance'ın breaker'ına yazılır.
+	// İdempotent olmayan metotlarda Attempts 1'dir, hata olduğu gibi istemciye döner.
+	timeout := svc.TimeoutFor(path)
+	client := &fasthttp.Client{
+		ReadTimeout:  timeout,
+		WriteTimeout: timeout,
+	}
+	attempts := svc.Attempts(c.Method())
+	var (
+		tried   []*service.Instance
+		lastErr error
+	)
+	userID, _ := c.Locals("userID").(string)
+	for len(tried) < attempts {
+		inst, token, ok := svc.NextInstance(userID, tried)
+		if !ok {
+			break
+		}
+		tried = append(tried, inst)
+
+		start := time.Now()
+		err := proxy.Do(c, inst.URL+targetPath, client)
+		if err == nil && isUpstreamFailure(c.Response().StatusCode()) {
+			err = fmt.Errorf("upstream returned %d", c.Response().StatusCode())
+		}
+		inst.Done(token, err, time.Since(start))
+		span.AddEvent("attempt", trace.WithAttributes(
+			attribute.String("server.address", inst.URL),
+			attribute.Bool("error", err != nil),
+		))
+		lastErr = err
+		if err == nil {
+			break
+		}
+		log.Printf("⚠️ Proxy attempt %d/%d failed [%s -> %s]: %v", len(tried), attempts, svc.Name, inst.URL, err)
+	}
+	span.SetAttributes(attribute.Int("gateway.attempts", len(tried)))
+
+	if len(tried) == 0 {
 		h.Metrics.IncrementFailed()
-		log.Printf("❌ Proxy error [%s]: %v", svc.Name, err)
-		return c.Status(fiber.StatusBadGateway).JSON(fiber.Map{"error": "Backend service error"})
+		span.SetStatus(codes.Error, "circuit open")
+		log.Printf("❌ Circuit Open: %s", svc.Name)
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+			"error":   "Service unavailable (Circuit Open)",
+			"service": svc.Name,
+		})
+	}
+	if lastErr != nil {
+		h.Metrics.IncrementFailed()
+		span.RecordError(lastErr)
+		span.SetStatus(codes.Error, lastErr.Error())
+		log.Printf("❌ Proxy error [%s]: %v", svc.Name, lastErr)
+		// Backend cevap verdiyse (503 vb.) o cevap iletilir; hiç cevap yoksa 502 dönülür.
+		if c.Response().StatusCode() < fiber.StatusInternalServerError {
+			return c.Status(fiber.StatusBadGateway).JSON(fiber.Map{"error": "Backend service error"})
+		}
+		return nil
+	}
+	span.SetAttributes(attribute.Int("http.response.status_code", c.Response().StatusCode()))
+	if c.Response().StatusCode() >= fiber.StatusInternalServerError {
+		span.SetStatus(codes.Error, fmt.Sprintf("HTTP %d", c.Response().StatusCode()))
 	}
 	if strings.HasSuffix(path, "/signout") && c.Response().StatusCode() == fiber.StatusOK {
 		authValue := c.Cookies(config.SessionCookieName)
@@ -113,3 +163,15 @@ func (h *ProxyHandler) Handle(c *fiber.Ctx) error {
 	h.Metrics.IncrementSuccess()
 	return nil
 }
+
+// isUpstreamFailure, cevabın instance'ın kendisinden kaynaklanan bir hata olup olmadığını söyler.
+// 500 iş mantığı hatası olabilir (Örn: geçersiz sipariş) ve başka instance'ta da aynı sonucu
+// verir; 502/503/504 ise instance'ın veya önündeki altyapının sorunudur.
+func isUpstreamFailure(status int) bool {
+	switch status {
+	case fiber.StatusBadGateway, fiber.StatusServiceUnavailable, fiber.StatusGatewayTimeout:
+		return true
+	default:
+		return false
+	}
+}
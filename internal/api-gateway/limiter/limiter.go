@@ -1,13 +1,41 @@
 package limiter
 
 import (
+	"context"
 	"log"
+	"math"
 	"sync"
 	"time"
 
 	"golang.org/x/time/rate"
 )
 
+// Limit, bir anahtara saniyede izin verilen istek sayısı (Rate) ve art arda kabul edilebilecek
+// en fazla istektir (Burst).
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// Result, tek bir isteğin limit kararıdır ve X-RateLimit-* başlıklarını besler.
+type Result struct {
+	Allowed bool
+	// Limit, art arda kabul edilebilecek en fazla istektir (Burst).
+	Limit int
+	// Remaining, şu an art arda gönderilebilecek istek sayısıdır.
+	Remaining int
+	// RetryAfter, reddedilen isteğin ne kadar sonra kabul edileceğidir.
+	RetryAfter time.Duration
+	// ResetAfter, kotanın tamamen dolmasına kalan süredir.
+	ResetAfter time.Duration
+}
+
+// Limiter, anahtar başına limit uygular. Redis'e bağlı bir Limiter tüm gateway replikaları
+// arasında ortaktır; bellek içi Limiter sadece kendi replikasını görür.
+type Limiter interface {
+	Allow(ctx context.Context, key string, limit Limit) (Result, error)
+}
+
 type LimiterEntry struct {
 	limiter    *rate.Limiter
 	lastAccess time.Time
@@ -29,6 +57,13 @@ func (rl *RateLimiter) GetLimiter(key string, r rate.Limit, b int) *rate.Limiter
 	if entry, ok := rl.limiters.Load(key); ok {
 		limiterEntry := entry.(*LimiterEntry)
 		limiterEntry.lastAccess = now
+		// Gateway config yeniden yüklendiyse limit değişmiş olabilir; biriken token'lar korunur.
+		if limiterEntry.limiter.Limit() != r {
+			limiterEntry.limiter.SetLimitAt(now, r)
+		}
+		if limiterEntry.limiter.Burst() != b {
+			limiterEntry.limiter.SetBurstAt(now, b)
+		}
 		return limiterEntry.limiter
 	}
 	newLimiter := rate.NewLimiter(r, b)
@@ -43,6 +78,28 @@ func (rl *RateLimiter) GetLimiter(key string, r rate.Limit, b int) *rate.Limiter
 	return newLimiter
 }
 
+// Allow, RateLimiter'ı Limiter olarak kullanır (bellek içi token bucket).
+func (rl *RateLimiter) Allow(_ context.Context, key string, limit Limit) (Result, error) {
+	l := rl.GetLimiter(key, rate.Limit(limit.Rate), limit.Burst)
+	now := time.Now()
+
+	res := Result{Limit: limit.Burst}
+	r := l.ReserveN(now, 1)
+	if delay := r.DelayFrom(now); r.OK() && delay == 0 {
+		res.Allowed = true
+	} else {
+		r.CancelAt(now)
+		res.RetryAfter = delay
+	}
+
+	tokens := l.TokensAt(now)
+	res.Remaining = max(int(math.Floor(tokens)), 0)
+	if limit.Rate > 0 {
+		res.ResetAfter = time.Duration((float64(limit.Burst) - tokens) / limit.Rate * float64(time.Second))
+	}
+	return res, nil
+}
+
 // func (rl *RateLimiter) StartCleanup(interval, maxAge time.Duration) {
 // 	ticker := time.NewTicker(interval)
 // 	go func() {
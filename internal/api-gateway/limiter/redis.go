@@ -0,0 +1,105 @@
+package limiter
+
+import (
+	"context"
+	"log"
+	"math"
+	"sync/atomic"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// redisTimeout, Redis'e yapılan limit sorgusunun süresidir. Aşılırsa istek bellek içi limiter'a
+// düşer; Redis'in yavaşlaması gateway'in tüm isteklerini yavaşlatmaz.
+const redisTimeout = 100 * time.Millisecond
+
+// redisRetryInterval, Redis'e ulaşılamadıktan sonra tekrar denenmeden önce beklenen süredir.
+// Bu sürede istekler doğrudan bellek içi limiter'a gider; her istek redisTimeout beklemez.
+const redisRetryInterval = 5 * time.Second
+
+// keyPrefix, limit anahtarlarının Redis önekidir.
+const keyPrefix = "ratelimit:"
+
+// gcraScript, GCRA (Generic Cell Rate Algorithm) kararını tek bir atomik adımda verir.
+// Anahtar başına sadece "theoretical arrival time" (TAT) saklanır: her kabul edilen istek TAT'ı
+// bir emission interval ileri taşır; TAT şimdiden burst*interval'den fazla ilerideyse istek
+// reddedilir. Saat olarak Redis'in TIME'ı kullanılır; replikaların saat farkı sonucu etkilemez.
+//
+// KEYS[1] anahtar, ARGV[1] emission interval (µs), ARGV[2] burst.
+// Dönüş: {allowed, remaining, retry_after_us, reset_after_us}
+var gcraScript = redis.NewScript(`
+if redis.replicate_commands then redis.replicate_commands() end
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000000 + tonumber(t[2])
+local interval = tonumber(ARGV[1])
+local tolerance = interval * tonumber(ARGV[2])
+
+local tat = tonumber(redis.call('GET', KEYS[1]) or now)
+if tat < now then tat = now end
+
+local new_tat = tat + interval
+local allow_at = new_tat - tolerance
+if now < allow_at then
+	return {0, 0, allow_at - now, tat - now}
+end
+
+-- Lua sayıları varsayılan olarak %.14g ile yazılır ve µs hassasiyeti kaybolur.
+redis.call('SET', KEYS[1], string.format('%.0f', new_tat), 'PX', math.ceil((new_tat - now) / 1000))
+return {1, math.floor((now - allow_at) / interval), 0, new_tat - now}
+`)
+
+// RedisLimiter, limitleri Redis'te GCRA ile uygular; tüm gateway replikaları aynı kotayı paylaşır.
+// Neden GCRA? Sliding window log'dan farklı olarak anahtar başına tek bir sayı saklar ve
+// token bucket ile aynı davranır (Burst kadar anlık istek, sonra Rate hızında).
+// Redis'e ulaşılamazsa karar fallback limiter'a (bellek içi) bırakılır; istekler reddedilmez
+// ama limit sadece replika başına uygulanır.
+type RedisLimiter struct {
+	client   redis.Scripter
+	fallback Limiter
+	degraded atomic.Bool
+	retryAt  atomic.Int64 // unix nano; degraded iken Redis bu zamandan önce denenmez
+}
+
+func NewRedisLimiter(client redis.Scripter, fallback Limiter) *RedisLimiter {
+	return &RedisLimiter{client: client, fallback: fallback}
+}
+
+func (l *RedisLimiter) Allow(ctx context.Context, key string, limit Limit) (Result, error) {
+	if l.degraded.Load() && time.Now().UnixNano() < l.retryAt.Load() {
+		return l.fallback.Allow(ctx, key, limit)
+	}
+
+	res, err := l.allow(ctx, key, limit)
+	if err == nil {
+		if l.degraded.CompareAndSwap(true, false) {
+			log.Printf("🟢 Rate limiter: Redis available again")
+		}
+		return res, nil
+	}
+
+	l.retryAt.Store(time.Now().Add(redisRetryInterval).UnixNano())
+	if l.degraded.CompareAndSwap(false, true) {
+		log.Printf("⚠️ Rate limiter: Redis unavailable, falling back to in-memory limits: %v", err)
+	}
+	return l.fallback.Allow(ctx, key, limit)
+}
+
+func (l *RedisLimiter) allow(ctx context.Context, key string, limit Limit) (Result, error) {
+	ctx, cancel := context.WithTimeout(ctx, redisTimeout)
+	defer cancel()
+
+	interval := int64(math.Round(float64(time.Second/time.Microsecond) / limit.Rate))
+	values, err := gcraScript.Run(ctx, l.client, []string{keyPrefix + key}, max(interval, 1), limit.Burst).Int64Slice()
+	if err != nil {
+		return Result{}, err
+	}
+
+	return Result{
+		Allowed:    values[0] == 1,
+		Limit:      limit.Burst,
+		Remaining:  int(values[1]),
+		RetryAfter: time.Duration(values[2]) * time.Microsecond,
+		ResetAfter: time.Duration(values[3]) * time.Microsecond,
+	}, nil
+}
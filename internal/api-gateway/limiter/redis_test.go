@@ -0,0 +1,132 @@
+package limiter
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestRedisLimiterGCRA(t *testing.T) {
+	// 10 istek/sn: emission interval 100ms, burst 3 -> tolerans 300ms.
+	limit := Limit{Rate: 10, Burst: 3}
+
+	type step struct {
+		advance time.Duration // istekten önce ilerletilen süre
+		want    Result
+	}
+
+	tests := []struct {
+		name  string
+		steps []step
+	}{
+		{
+			name: "burst then reject",
+			steps: []step{
+				{want: Result{Allowed: true, Limit: 3, Remaining: 2, ResetAfter: 100 * time.Millisecond}},
+				{want: Result{Allowed: true, Limit: 3, Remaining: 1, ResetAfter: 200 * time.Millisecond}},
+				{want: Result{Allowed: true, Limit: 3, Remaining: 0, ResetAfter: 300 * time.Millisecond}},
+				{want: Result{Allowed: false, Limit: 3, RetryAfter: 100 * time.Millisecond, ResetAfter: 300 * time.Millisecond}},
+			},
+		},
+		{
+			name: "one emission interval frees one request",
+			steps: []step{
+				{want: Result{Allowed: true, Limit: 3, Remaining: 2, ResetAfter: 100 * time.Millisecond}},
+				{want: Result{Allowed: true, Limit: 3, Remaining: 1, ResetAfter: 200 * time.Millisecond}},
+				{want: Result{Allowed: true, Limit: 3, Remaining: 0, ResetAfter: 300 * time.Millisecond}},
+				{advance: 100 * time.Millisecond, want: Result{Allowed: true, Limit: 3, Remaining: 0, ResetAfter: 300 * time.Millisecond}},
+				{want: Result{Allowed: false, Limit: 3, RetryAfter: 100 * time.Millisecond, ResetAfter: 300 * time.Millisecond}},
+			},
+		},
+		{
+			name: "rejected requests do not consume quota",
+			steps: []step{
+				{want: Result{Allowed: true, Limit: 3, Remaining: 2, ResetAfter: 100 * time.Millisecond}},
+				{want: Result{Allowed: true, Limit: 3, Remaining: 1, ResetAfter: 200 * time.Millisecond}},
+				{want: Result{Allowed: true, Limit: 3, Remaining: 0, ResetAfter: 300 * time.Millisecond}},
+				{want: Result{Allowed: false, Limit: 3, RetryAfter: 100 * time.Millisecond, ResetAfter: 300 * time.Millisecond}},
+				{advance: 40 * time.Millisecond, want: Result{Allowed: false, Limit: 3, RetryAfter: 60 * time.Millisecond, ResetAfter: 260 * time.Millisecond}},
+				{advance: 60 * time.Millisecond, want: Result{Allowed: true, Limit: 3, Remaining: 0, ResetAfter: 300 * time.Millisecond}},
+			},
+		},
+		{
+			name: "idle key refills to full burst",
+			steps: []step{
+				{want: Result{Allowed: true, Limit: 3, Remaining: 2, ResetAfter: 100 * time.Millisecond}},
+				{want: Result{Allowed: true, Limit: 3, Remaining: 1, ResetAfter: 200 * time.Millisecond}},
+				{advance: time.Second, want: Result{Allowed: true, Limit: 3, Remaining: 2, ResetAfter: 100 * time.Millisecond}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mr := miniredis.RunT(t)
+			now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+			mr.SetTime(now)
+
+			client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+			defer client.Close()
+			l := NewRedisLimiter(client, NewRateLimiter())
+
+			for i, s := range tt.steps {
+				if s.advance > 0 {
+					now = now.Add(s.advance)
+					mr.SetTime(now)
+					mr.FastForward(s.advance)
+				}
+				got, err := l.Allow(context.Background(), "user:1", limit)
+				if err != nil {
+					t.Fatalf("step %d: Allow error = %v", i, err)
+				}
+				if got != s.want {
+					t.Fatalf("step %d: Allow = %+v, want %+v", i, got, s.want)
+				}
+			}
+		})
+	}
+}
+
+func TestRedisLimiterKeysAreIndependent(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer client.Close()
+	l := NewRedisLimiter(client, NewRateLimiter())
+	limit := Limit{Rate: 1, Burst: 1}
+
+	for _, key := range []string{"user:1", "user:2"} {
+		res, err := l.Allow(context.Background(), key, limit)
+		if err != nil || !res.Allowed {
+			t.Fatalf("first request for %s = (%+v, %v), want allowed", key, res, err)
+		}
+	}
+	if res, _ := l.Allow(context.Background(), "user:1", limit); res.Allowed {
+		t.Fatal("second request for user:1 was allowed past its burst")
+	}
+}
+
+func TestRedisLimiterFallsBackWhenRedisIsDown(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr(), MaxRetries: -1})
+	defer client.Close()
+	l := NewRedisLimiter(client, NewRateLimiter())
+	limit := Limit{Rate: 1, Burst: 2}
+
+	mr.Close()
+
+	for i, want := range []bool{true, true, false} {
+		res, err := l.Allow(context.Background(), "user:1", limit)
+		if err != nil {
+			t.Fatalf("request %d: Allow error = %v, want fallback decision", i, err)
+		}
+		if res.Allowed != want {
+			t.Fatalf("request %d: allowed = %v, want %v", i, res.Allowed, want)
+		}
+	}
+	if !l.degraded.Load() {
+		t.Fatal("limiter did not switch to degraded mode")
+	}
+}
@@ -19,9 +19,12 @@ const (
 	PermissionAdministrator int64 = 1 << 62
 )
 
-// AuthMiddleware checks for session cookie or authorization header
-func AuthMiddleware(policies map[string]config.RoutePolicy, cacheManager *cache.CacheManager) fiber.Handler {
+// AuthMiddleware checks for session cookie or authorization header.
+// Korumalı route'lar her istekte geçerli gateway yapılandırmasından okunur; dosya
+// yeniden yüklendiğinde yeni politikalar bir sonraki istekte uygulanır.
+func AuthMiddleware(gateway *config.GatewayStore, cacheManager *cache.CacheManager) fiber.Handler {
 	return func(c *fiber.Ctx) error {
+		policies := gateway.Current().Policies
 
 		routePath := c.Route().Path
 		requestPath := c.Path()
@@ -100,31 +103,8 @@ func AuthMiddleware(policies map[string]config.RoutePolicy, cacheManager *cache.
 
 // findParametrizedRoute, isteği haritadaki parametreli şablonlarla eşleştirmeye çalışır
 func findParametrizedRoute(requestPath string, policies map[string]config.RoutePolicy) (bool, config.RoutePolicy) {
-	requestParts := strings.Split(requestPath, "/")
-
 	for policyPath, policy := range policies {
-		policyParts := strings.Split(policyPath, "/")
-
-		if len(policyParts) != len(requestParts) {
-			continue
-		}
-
-		isMatch := true
-		for i := 0; i < len(policyParts); i++ {
-			policyPart := policyParts[i]
-			requestPart := requestParts[i]
-
-			if strings.HasPrefix(policyPart, ":") || policyPart == "" {
-				continue
-			}
-
-			if policyPart != requestPart {
-				isMatch = false
-				break
-			}
-		}
-
-		if isMatch {
+		if config.MatchPath(policyPath, requestPath) {
 			return true, policy
 		}
 	}
@@ -1,93 +1,124 @@
 package middleware
 
 import (
-	"fmt"
 	"log"
 	"marketplace/internal/api-gateway/config"
 	"marketplace/internal/api-gateway/limiter"
 	"marketplace/internal/api-gateway/metrics"
+	"math"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
-	"golang.org/x/time/rate"
 )
 
-func RateLimitMiddleware(rl *limiter.RateLimiter, m *metrics.Metrics, configs map[string]config.RouteConfig) fiber.Handler {
+// RateLimitMiddleware, isteğe uyan limitleri uygular: istemci başına (user) ve route başına
+// toplam (global). Anahtarlar istek path'ine değil limitin tanımlandığı route/prefix'e göredir;
+// /products/1 ve /products/2 aynı kotayı kullanır.
+// Cevaba X-RateLimit-Limit/Remaining/Reset (Reset: kotanın dolacağı unix zamanı) yazılır;
+// reddedilen isteklere Retry-After (saniye) eklenir.
+func RateLimitMiddleware(rl limiter.Limiter, m *metrics.Metrics, gateway *config.GatewayStore) fiber.Handler {
 	return func(c *fiber.Ctx) error {
+		configs := gateway.Current().RateLimits
 		path := c.Path()
 		clientID := ExtractClientIdentifier(c)
 		m.IncrementTotal()
 		m.IncrementPath(path)
 
-		routeConfig := getRouteConfig(path, configs)
+		route, routeConfig := getRouteConfig(path, configs)
 
 		// User Limit
 		if routeConfig.UserLimit > 0 {
-			l := rl.GetLimiter("user:"+clientID+":"+path, toLimit(routeConfig.UserLimit), routeConfig.UserBurst)
-			if !l.Allow() {
-				m.IncrementRateLimit("user-path")
-				log.Printf("⛔ Rate limit (User): %s -> %s", clientID, path)
-				c.Set("X-RateLimit-Limit", fmt.Sprintf("%.0f", routeConfig.UserLimit*60))
-				c.Set("X-RateLimit-Remaining", fmt.Sprintf("%.0f", l.Tokens()))
-				return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
-					"error": "Too many requests",
-					"type":  "user-path",
-				})
+			res, err := rl.Allow(c.UserContext(), "user:"+clientID+":"+route, limiter.Limit{Rate: routeConfig.UserLimit, Burst: routeConfig.UserBurst})
+			if err != nil {
+				log.Printf("⚠️ Rate limit check failed (User): %v", err)
+			} else {
+				setRateLimitHeaders(c, res)
+				if !res.Allowed {
+					m.IncrementRateLimit("user-path")
+					log.Printf("⛔ Rate limit (User): %s -> %s", clientID, path)
+					return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
+						"error": "Too many requests",
+						"type":  "user-path",
+					})
+				}
 			}
-			c.Set("X-RateLimit-Limit", fmt.Sprintf("%.0f", routeConfig.UserLimit*60))
-			c.Set("X-RateLimit-Remaining", fmt.Sprintf("%.0f", l.Tokens()))
 		}
 
 		// Global Limit
-		l := rl.GetLimiter("global:"+path, toLimit(routeConfig.GlobalLimit), routeConfig.GlobalBurst)
-		if !l.Allow() {
-			m.IncrementRateLimit("global-path")
-			log.Printf("⛔ Rate limit (Global): %s", path)
-			return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
-				"error": "System busy",
-				"type":  "global-path",
-			})
+		if routeConfig.GlobalLimit > 0 && !math.IsInf(routeConfig.GlobalLimit, 1) {
+			res, err := rl.Allow(c.UserContext(), "global:"+route, limiter.Limit{Rate: routeConfig.GlobalLimit, Burst: routeConfig.GlobalBurst})
+			if err != nil {
+				log.Printf("⚠️ Rate limit check failed (Global): %v", err)
+			} else {
+				// İstemciye kendi kotası gösterilir; user limiti yoksa route'un toplam kotası.
+				if routeConfig.UserLimit <= 0 {
+					setRateLimitHeaders(c, res)
+				}
+				if !res.Allowed {
+					m.IncrementRateLimit("global-path")
+					log.Printf("⛔ Rate limit (Global): %s", path)
+					c.Set(fiber.HeaderRetryAfter, retryAfterSeconds(res.RetryAfter))
+					return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
+						"error": "System busy",
+						"type":  "global-path",
+					})
+				}
+			}
 		}
 
 		return c.Next()
 	}
 }
 
-// Helpers
+func setRateLimitHeaders(c *fiber.Ctx, res limiter.Result) {
+	c.Set("X-RateLimit-Limit", strconv.Itoa(res.Limit))
+	c.Set("X-RateLimit-Remaining", strconv.Itoa(res.Remaining))
+	c.Set("X-RateLimit-Reset", strconv.FormatInt(time.Now().Add(res.ResetAfter).Add(time.Second-1).Unix(), 10))
+	if !res.Allowed {
+		c.Set(fiber.HeaderRetryAfter, retryAfterSeconds(res.RetryAfter))
+	}
+}
 
-func toLimit(f float64) rate.Limit {
-	return rate.Limit(f)
+// retryAfterSeconds, süreyi yukarı yuvarlanmış saniyeye çevirir; istemci erken denemez.
+func retryAfterSeconds(d time.Duration) string {
+	return strconv.FormatInt(max(int64(math.Ceil(d.Seconds())), 1), 10)
 }
 
-func getRouteConfig(path string, configs map[string]config.RouteConfig) config.RouteConfig {
+// Helpers
+
+// getRouteConfig, path'e uyan limitin anahtarını (route/prefix veya "default") ve limitini döner.
+func getRouteConfig(path string, configs map[string]config.RouteConfig) (string, config.RouteConfig) {
 	conf, exists := configs[path]
 	if exists {
-		return conf
+		return path, conf
 	}
 
 	// Default fallback
-	conf = configs["default"]
+	route := "default"
+	conf = configs[route]
 	longestMatchLen := 0
 
-	for route, c := range configs {
-		if route != "default" && strings.HasPrefix(path, route) {
-			if len(route) > longestMatchLen {
+	for key, c := range configs {
+		if key != "default" && strings.HasPrefix(path, key) {
+			if len(key) > longestMatchLen {
+				route = key
 				conf = c
-				longestMatchLen = len(route)
+				longestMatchLen = len(key)
 			}
 		}
 	}
-	return conf
+	return route, conf
 }
 
+// ExtractClientIdentifier, user limitinin istemci anahtarıdır: AuthMiddleware'in doğruladığı
+// kullanıcı kimliği, yoksa IP.
+// Neden doğrulanmamış token/cookie değil? Korumasız route'larda credential doğrulanmaz; istemci
+// her istekte rastgele bir token göndererek her seferinde yeni bir kotayla başlardı.
 func ExtractClientIdentifier(c *fiber.Ctx) string {
-	if cookie := c.Cookies(config.SessionCookieName); cookie != "" {
-		return "session:" + cookie
-	}
-	authHeader := c.Get("Authorization")
-	if strings.HasPrefix(authHeader, "Bearer ") && len(authHeader) > 7 {
-		return "token:" + authHeader[7:15]
+	if userID, ok := c.Locals("userID").(string); ok && userID != "" {
+		return "user:" + userID
 	}
-	ip := c.IP()
-	return "ip:" + ip
+	return "ip:" + c.IP()
 }
@@ -12,6 +12,9 @@ import (
 	"marketplace/internal/api-gateway/metrics"
 	"marketplace/internal/api-gateway/middleware"
 	"marketplace/internal/api-gateway/service"
+	"marketplace/pkg/discovery"
+	"marketplace/pkg/logging"
+	"marketplace/pkg/tracing"
 
 	"net/http"
 	"time"
@@ -22,7 +25,7 @@ import (
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
-	"github.com/gofiber/fiber/v2/middleware/logger"
+	"github.com/redis/go-redis/v9"
 )
 
 type RouteRegistrar interface {
@@ -33,13 +36,24 @@ type Server struct {
 	app         *fiber.App
 	cfg         config.Config
 	Registry    *service.ServiceRegistry
-	RateLimiter *limiter.RateLimiter
+	RateLimiter limiter.Limiter
 	Metrics     *metrics.Metrics
 }
 
-func New(cfg config.Config, cacheManager *cache.CacheManager) *Server {
-	registry := service.NewServiceRegistry()
-	rateLimiter := limiter.NewRateLimiter()
+func New(cfg config.Config, cacheManager *cache.CacheManager, gateway *config.GatewayStore) *Server {
+	// redis discovery'si backend'lerin self-registration kayıtlarını okur (DISCOVERY_REDIS_ADDR).
+	var sd service.Discovery
+	if client, err := discovery.NewClientFromEnv(); err != nil {
+		log.Printf("⚠️ Discovery Redis disabled: %v", err)
+	} else if client != nil {
+		sd.Redis = client
+	}
+	registry := service.NewServiceRegistry(sd)
+	gateway.Subscribe(func(g *config.Gateway) {
+		registry.Apply(g.Services)
+	})
+	memoryLimiter := limiter.NewRateLimiter()
+	rateLimiter := newRateLimiter(cfg, memoryLimiter)
 	metrics := metrics.NewMetrics()
 	// Initialize Fiber App
 	f := fiber.New(fiber.Config{
@@ -50,17 +64,19 @@ func New(cfg config.Config, cacheManager *cache.CacheManager) *Server {
 
 	// Global Middleware
 	f.Use(recover.New())
-	f.Use(logger.New(logger.Config{
-		Format: "[${time}] ${status} - ${method} ${path} - ${latency}\n",
-	}))
+	// İstek kimliği burada üretilir ve backend'e X-Request-ID ile iletilir.
+	f.Use(logging.Middleware())
+	// Trace, auth ve rate limit'ten önce başlar; reddedilen istekler de trace'te görünür.
+	f.Use(tracing.Middleware())
+	f.Use(logging.AccessLog())
 	f.Use(cors.New())
 
 	// Custom Middleware
 	// 1. Logging (Done by fiber/logger above roughly, or we can use custom if we want extraction)
 	// 2. Auth
-	f.Use(middleware.AuthMiddleware(config.GetProtectedRoutes(), cacheManager))
+	f.Use(middleware.AuthMiddleware(gateway, cacheManager))
 	// 3. Rate Limit
-	f.Use(middleware.RateLimitMiddleware(rateLimiter, metrics, config.GetDefaultRouteConfigs()))
+	f.Use(middleware.RateLimitMiddleware(rateLimiter, metrics, gateway))
 
 	f.Use(middleware.WebhookSecurityMiddleware())
 
@@ -82,7 +98,7 @@ func New(cfg config.Config, cacheManager *cache.CacheManager) *Server {
 	// In Fiber `*` works as wildcard.
 	f.All("/*", proxyHandler.Handle)
 	registry.StartHealthChecks(15 * time.Second)
-	rateLimiter.StartCleanup(5*time.Minute, 15*time.Minute)
+	memoryLimiter.StartCleanup(5*time.Minute, 15*time.Minute)
 	return &Server{
 		app:         f,
 		cfg:         cfg,
@@ -92,6 +108,24 @@ func New(cfg config.Config, cacheManager *cache.CacheManager) *Server {
 	}
 }
 
+// newRateLimiter, yapılandırmaya göre Redis veya bellek içi limiter'ı döner. Redis limiter'ı
+// cache ile aynı Redis'i kullanır; bellek içi limiter onun yedeğidir.
+func newRateLimiter(cfg config.Config, memory *limiter.RateLimiter) limiter.Limiter {
+	if cfg.RateLimit.Backend != "redis" {
+		log.Printf("⚠️ Rate limits are per gateway replica (backend: %s)", cfg.RateLimit.Backend)
+		return memory
+	}
+	client := redis.NewClient(&redis.Options{
+		Addr:     cfg.RedisCache.Addr,
+		Password: cfg.RedisCache.Password,
+		DB:       cfg.RedisCache.DB,
+		// Limit sorgusunun süresi isteğin context'iyle sınırlanır (bkz. limiter.RedisLimiter).
+		ContextTimeoutEnabled: true,
+	})
+	log.Printf("✅ Rate limits are shared via Redis: %s", cfg.RedisCache.Addr)
+	return limiter.NewRedisLimiter(client, memory)
+}
+
 func (s *Server) Start() error {
 	// 1. gRPC sunucusunu bir goroutine içinde başlatın
 	// Fiber'in Listen() çağrısı bloklayıcı olduğu için bunu yapmalıyız.
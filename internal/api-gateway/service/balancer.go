@@ -0,0 +1,168 @@
+package service
+
+import (
+	"hash/fnv"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+
+	"marketplace/internal/api-gateway/config"
+)
+
+// ringReplicas, consistent hashing'de ağırlığı 1 olan instance'ın halkadaki sanal düğüm sayısıdır.
+// Az düğümde dağılım dengesiz olur; 100 ile sapma birkaç yüzdeye iner.
+const ringReplicas = 100
+
+// balancer, seçilebilir instance'ları tercih sırasına dizer. Servis ilk sıradakini dener;
+// half-open deneme hakkı dolmuşsa bir sonrakine geçer.
+// Neden sıralama? Devre kesicinin Allow'u half-open'da deneme hakkı tüketir; strateji tüm
+// adaylara Allow sormak yerine sırayı verir, servis sadece seçtiğine sorar.
+type balancer interface {
+	order(candidates []*Instance, key string) []*Instance
+}
+
+func newBalancer(strategy string, instances []*Instance) balancer {
+	switch strategy {
+	case config.LBLeastRequests:
+		return &leastRequests{}
+	case config.LBWeighted:
+		return &weighted{current: make(map[*Instance]int, len(instances))}
+	case config.LBConsistentHash:
+		return newHashRing(instances)
+	default:
+		return &roundRobin{}
+	}
+}
+
+// roundRobin, adayları her istekte bir kaydırır.
+type roundRobin struct {
+	next atomic.Uint64
+}
+
+func (b *roundRobin) order(candidates []*Instance, _ string) []*Instance {
+	n := uint64(len(candidates))
+	start := b.next.Add(1) - 1
+	ordered := make([]*Instance, 0, n)
+	for i := uint64(0); i < n; i++ {
+		ordered = append(ordered, candidates[(start+i)%n])
+	}
+	return ordered
+}
+
+// leastRequests, adayları işlenmekte olan istek sayısına göre sıralar. Eşitlikte round-robin
+// sırası korunur; boştaki instance'lar arasında yük yine dağılır.
+type leastRequests struct {
+	roundRobin
+}
+
+func (b *leastRequests) order(candidates []*Instance, key string) []*Instance {
+	ordered := b.roundRobin.order(candidates, key)
+	outstanding := make(map[*Instance]int64, len(ordered))
+	for _, inst := range ordered {
+		outstanding[inst] = inst.Outstanding()
+	}
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return outstanding[ordered[i]] < outstanding[ordered[j]]
+	})
+	return ordered
+}
+
+// weighted, nginx'in smooth weighted round-robin algoritmasıdır: ağırlığı 3 ve 1 olan iki
+// instance a,a,b,a yerine a,b,a,a gibi araya serpiştirilmiş bir sırayla seçilir.
+type weighted struct {
+	mu      sync.Mutex
+	current map[*Instance]int
+}
+
+func (b *weighted) order(candidates []*Instance, _ string) []*Instance {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var (
+		best  *Instance
+		total int
+	)
+	for _, inst := range candidates {
+		b.current[inst] += inst.Weight
+		total += inst.Weight
+		if best == nil || b.current[inst] > b.current[best] {
+			best = inst
+		}
+	}
+	if best == nil {
+		return nil
+	}
+	b.current[best] -= total
+
+	ordered := make([]*Instance, 0, len(candidates))
+	ordered = append(ordered, best)
+	for _, inst := range candidates {
+		if inst != best {
+			ordered = append(ordered, inst)
+		}
+	}
+	return ordered
+}
+
+// hashRing, instance'ları sanal düğümlerle bir halkaya yerleştirir; anahtar halkada saat
+// yönünde ilk rastladığı aday instance'a gider. Anahtarsız (anonim) istekler round-robin'e düşer.
+type hashRing struct {
+	points   []ringPoint
+	fallback roundRobin
+}
+
+type ringPoint struct {
+	hash     uint64
+	instance *Instance
+}
+
+func newHashRing(instances []*Instance) *hashRing {
+	r := &hashRing{}
+	for _, inst := range instances {
+		for i := 0; i < ringReplicas*inst.Weight; i++ {
+			r.points = append(r.points, ringPoint{hash: hashKey(inst.URL + "#" + strconv.Itoa(i)), instance: inst})
+		}
+	}
+	sort.Slice(r.points, func(i, j int) bool { return r.points[i].hash < r.points[j].hash })
+	return r
+}
+
+func (r *hashRing) order(candidates []*Instance, key string) []*Instance {
+	if key == "" || len(r.points) == 0 {
+		return r.fallback.order(candidates, key)
+	}
+
+	allowed := make(map[*Instance]bool, len(candidates))
+	for _, inst := range candidates {
+		allowed[inst] = true
+	}
+
+	h := hashKey(key)
+	start := sort.Search(len(r.points), func(i int) bool { return r.points[i].hash >= h })
+	ordered := make([]*Instance, 0, len(candidates))
+	for i := 0; i < len(r.points) && len(ordered) < len(candidates); i++ {
+		inst := r.points[(start+i)%len(r.points)].instance
+		if allowed[inst] {
+			ordered = append(ordered, inst)
+			delete(allowed, inst)
+		}
+	}
+	return ordered
+}
+
+// hashKey, FNV-1a'nın sonucunu splitmix64 ile karıştırır. Sadece son karakteri farklı
+// anahtarlarda (Örn: "url#1", "url#2") FNV'nin üst bitleri neredeyse aynı kalır ve sanal
+// düğümler halkada kümelenir. Sabit bir hash olduğu için birden fazla gateway aynı kullanıcıyı
+// aynı instance'a gönderir.
+func hashKey(key string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	x := h.Sum64()
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
@@ -0,0 +1,183 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"marketplace/internal/api-gateway/config"
+)
+
+func testInstances(weights ...int) []*Instance {
+	instances := make([]*Instance, len(weights))
+	for i, w := range weights {
+		instances[i] = &Instance{URL: fmt.Sprintf("http://%c", 'a'+i), Weight: w}
+	}
+	return instances
+}
+
+// firstPicks, balancer'ın n istek boyunca ilk sıraya koyduğu instance'ları "abc..." olarak döner.
+func firstPicks(b balancer, candidates []*Instance, key string, n int) string {
+	var picks strings.Builder
+	for range n {
+		ordered := b.order(candidates, key)
+		picks.WriteString(strings.TrimPrefix(ordered[0].URL, "http://"))
+	}
+	return picks.String()
+}
+
+func TestBalancerOrder(t *testing.T) {
+	tests := []struct {
+		name     string
+		strategy string
+		weights  []int
+		setup    func(instances []*Instance)
+		requests int
+		want     string
+	}{
+		{
+			name:     "round robin rotates",
+			strategy: config.LBRoundRobin,
+			weights:  []int{1, 1, 1},
+			requests: 6,
+			want:     "abcabc",
+		},
+		{
+			name:     "unknown strategy falls back to round robin",
+			strategy: "random",
+			weights:  []int{1, 1},
+			requests: 4,
+			want:     "abab",
+		},
+		{
+			name:     "smooth weighted interleaves",
+			strategy: config.LBWeighted,
+			weights:  []int{5, 1, 1},
+			requests: 7,
+			want:     "aabacaa",
+		},
+		{
+			name:     "weighted 3:1",
+			strategy: config.LBWeighted,
+			weights:  []int{3, 1},
+			requests: 8,
+			want:     "aabaaaba",
+		},
+		{
+			name:     "least requests prefers idle instance",
+			strategy: config.LBLeastRequests,
+			weights:  []int{1, 1, 1},
+			setup: func(instances []*Instance) {
+				instances[0].outstanding.Store(5)
+				instances[1].outstanding.Store(2)
+			},
+			requests: 3,
+			want:     "ccc",
+		},
+		{
+			name:     "least requests round robins on ties",
+			strategy: config.LBLeastRequests,
+			weights:  []int{1, 1, 1},
+			setup: func(instances []*Instance) {
+				instances[2].outstanding.Store(1)
+			},
+			requests: 4,
+			want:     "abaa",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			instances := testInstances(tt.weights...)
+			if tt.setup != nil {
+				tt.setup(instances)
+			}
+			b := newBalancer(tt.strategy, instances)
+			if got := firstPicks(b, instances, "", tt.requests); got != tt.want {
+				t.Fatalf("picks = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBalancerOrderIncludesEveryCandidate(t *testing.T) {
+	strategies := []string{config.LBRoundRobin, config.LBLeastRequests, config.LBWeighted, config.LBConsistentHash}
+	for _, strategy := range strategies {
+		t.Run(strategy, func(t *testing.T) {
+			instances := testInstances(2, 1, 1)
+			b := newBalancer(strategy, instances)
+			for _, key := range []string{"", "user-1", "user-2"} {
+				ordered := b.order(instances, key)
+				seen := make(map[*Instance]bool, len(ordered))
+				for _, inst := range ordered {
+					seen[inst] = true
+				}
+				if len(ordered) != len(instances) || len(seen) != len(instances) {
+					t.Fatalf("order(%q) = %d instances (%d unique), want each of %d once", key, len(ordered), len(seen), len(instances))
+				}
+			}
+		})
+	}
+}
+
+func TestHashRing(t *testing.T) {
+	instances := testInstances(1, 1, 1)
+	ring := newHashRing(instances)
+
+	t.Run("same key is sticky", func(t *testing.T) {
+		for i := range 100 {
+			key := fmt.Sprintf("user-%d", i)
+			first := ring.order(instances, key)[0]
+			for range 3 {
+				if got := ring.order(instances, key)[0]; got != first {
+					t.Fatalf("key %s moved from %s to %s", key, first.URL, got.URL)
+				}
+			}
+		}
+	})
+
+	t.Run("unavailable instance only moves its own keys", func(t *testing.T) {
+		moved, owned := 0, 0
+		remaining := instances[1:]
+		for i := range 1000 {
+			key := fmt.Sprintf("user-%d", i)
+			before := ring.order(instances, key)[0]
+			after := ring.order(remaining, key)[0]
+			if before == instances[0] {
+				owned++
+				continue
+			}
+			if before != after {
+				moved++
+			}
+		}
+		if owned == 0 {
+			t.Fatal("instance a owned no keys")
+		}
+		if moved != 0 {
+			t.Fatalf("%d keys of healthy instances moved", moved)
+		}
+	})
+
+	t.Run("distribution follows weight", func(t *testing.T) {
+		weighted := testInstances(2, 1, 1)
+		ring := newHashRing(weighted)
+		counts := make(map[*Instance]int)
+		const keys = 20000
+		for i := range keys {
+			counts[ring.order(weighted, fmt.Sprintf("user-%d", i))[0]]++
+		}
+		// Ağırlık 2 olan instance anahtarların yaklaşık yarısını almalı.
+		share := float64(counts[weighted[0]]) / keys
+		if share < 0.4 || share > 0.6 {
+			t.Fatalf("weight-2 instance share = %.2f, want ~0.5 (counts: a=%d b=%d c=%d)",
+				share, counts[weighted[0]], counts[weighted[1]], counts[weighted[2]])
+		}
+	})
+
+	t.Run("anonymous requests round robin", func(t *testing.T) {
+		if got := firstPicks(newHashRing(instances), instances, "", 3); got != "abc" {
+			t.Fatalf("picks = %q, want %q", got, "abc")
+		}
+	})
+}
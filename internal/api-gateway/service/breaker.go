@@ -0,0 +1,174 @@
+package service
+
+import (
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"marketplace/internal/api-gateway/config"
+)
+
+// errSlowCall, SlowCall süresini aşan başarılı isteklerin breaker'daki hatasıdır.
+var errSlowCall = errors.New("slow call")
+
+// BreakerState, bir instance'ın devre kesici durumudur.
+type BreakerState string
+
+const (
+	// BreakerClosed: istekler geçer, ardışık hatalar sayılır.
+	BreakerClosed BreakerState = "closed"
+	// BreakerOpen: instance'a istek gönderilmez; OpenTimeout dolunca half-open'a geçer.
+	BreakerOpen BreakerState = "open"
+	// BreakerHalfOpen: sınırlı sayıda deneme isteği geçer; başarılıysa closed, değilse tekrar open.
+	BreakerHalfOpen BreakerState = "half-open"
+)
+
+// Breaker, tek bir upstream instance'ının devre kesicisidir. Gerçek isteklerin sonucu ve
+// süresiyle beslenir: bağlantı hataları, 502/503/504 cevapları ve SlowCall'dan uzun süren
+// istekler hatadır. FailureThreshold kadar ardışık hatada devre açılır.
+// Neden instance başına? Aynı servisin bir instance'ı çöktüğünde diğerleri trafiği almaya
+// devam etmeli; servis ancak tüm instance'ları açıkken kullanılamaz sayılır.
+type Breaker struct {
+	instance string
+	cfg      config.CircuitBreakerSpec
+
+	mu         sync.Mutex
+	state      BreakerState
+	generation uint64 // her durum değişikliğinde artar (bkz. BreakerToken)
+	failures   int
+	probes     int
+	openedAt   time.Time
+	lastError  string
+}
+
+// BreakerToken, Allow'un verdiği izindir; isteğin sonucu Record'a bu izinle bildirilir.
+// Neden? Devre kapalıyken başlamış yavaş bir istek, devre açıldıktan sonra başarıyla dönebilir.
+// Bu sonuç instance'ın toparlandığını göstermez; devreyi yalnızca half-open deneme isteğinin
+// başarısı kapatmalıdır. İzin alındığı durumdan (generation) sonra gelen sonuçlar yok sayılır.
+type BreakerToken struct {
+	generation uint64
+	probe      bool
+}
+
+func newBreaker(instance string, cfg config.CircuitBreakerSpec) *Breaker {
+	return &Breaker{instance: instance, cfg: cfg, state: BreakerClosed}
+}
+
+// Allow, isteğin bu instance'a gönderilip gönderilemeyeceğini söyler. true dönerse
+// çağıran sonucu dönen izinle Record'a bildirmelidir; half-open'da deneme hakkı ancak böyle
+// geri verilir.
+func (b *Breaker) Allow() (BreakerToken, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case BreakerOpen:
+		if time.Since(b.openedAt) < b.cfg.OpenTimeout {
+			return BreakerToken{}, false
+		}
+		b.transition(BreakerHalfOpen)
+		fallthrough
+	case BreakerHalfOpen:
+		if b.probes >= b.cfg.HalfOpenRequests {
+			return BreakerToken{}, false
+		}
+		b.probes++
+		return BreakerToken{generation: b.generation, probe: true}, true
+	default:
+		return BreakerToken{generation: b.generation}, true
+	}
+}
+
+// Record, Allow ile izin alınmış isteğin sonucunu bildirir. err nil değilse istek başarısızdır.
+// İzin alındıktan sonra durum değiştiyse sonuç yok sayılır (bkz. BreakerToken).
+func (b *Breaker) Record(token BreakerToken, err error, latency time.Duration) {
+	if err == nil && b.cfg.SlowCall > 0 && latency > b.cfg.SlowCall {
+		err = errSlowCall
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if token.generation != b.generation {
+		return
+	}
+	if token.probe && b.probes > 0 {
+		b.probes--
+	}
+
+	if err == nil {
+		b.failures = 0
+		if b.state != BreakerClosed {
+			b.transition(BreakerClosed)
+		}
+		return
+	}
+
+	b.failures++
+	b.lastError = err.Error()
+	switch {
+	case b.state == BreakerHalfOpen:
+		b.transition(BreakerOpen)
+	case b.state == BreakerClosed && b.failures >= b.cfg.FailureThreshold:
+		b.transition(BreakerOpen)
+	}
+}
+
+// transition, durumu değiştirir ve loglar. mu tutulurken çağrılır.
+func (b *Breaker) transition(to BreakerState) {
+	from := b.state
+	b.state = to
+	b.generation++
+	switch to {
+	case BreakerOpen:
+		b.openedAt = time.Now()
+		b.probes = 0
+		log.Printf("🔴 Circuit OPEN: %s (failures: %d, last error: %s)", b.instance, b.failures, b.lastError)
+	case BreakerHalfOpen:
+		b.probes = 0
+		log.Printf("🟡 Circuit HALF-OPEN: %s", b.instance)
+	case BreakerClosed:
+		b.failures = 0
+		if from != BreakerClosed {
+			log.Printf("🟢 Circuit CLOSED: %s", b.instance)
+		}
+	}
+}
+
+// Available, instance'ın şu an istek kabul edip etmeyeceğini durumu değiştirmeden söyler.
+func (b *Breaker) Available() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case BreakerOpen:
+		return time.Since(b.openedAt) >= b.cfg.OpenTimeout
+	case BreakerHalfOpen:
+		return b.probes < b.cfg.HalfOpenRequests
+	default:
+		return true
+	}
+}
+
+// BreakerStatus, /health ve /services çıktısındaki instance durumudur.
+type BreakerStatus struct {
+	URL       string       `json:"url"`
+	State     BreakerState `json:"state"`
+	Failures  int          `json:"consecutive_failures"`
+	OpenedAt  *time.Time   `json:"opened_at,omitempty"`
+	LastError string       `json:"last_error,omitempty"`
+}
+
+// Status, breaker'ın anlık görüntüsüdür.
+func (b *Breaker) Status() BreakerStatus {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	status := BreakerStatus{URL: b.instance, State: b.state, Failures: b.failures, LastError: b.lastError}
+	if b.state != BreakerClosed {
+		openedAt := b.openedAt
+		status.OpenedAt = &openedAt
+	}
+	return status
+}
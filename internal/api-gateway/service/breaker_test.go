@@ -0,0 +1,207 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"marketplace/internal/api-gateway/config"
+)
+
+func TestBreakerTransitions(t *testing.T) {
+	cfg := config.CircuitBreakerSpec{
+		FailureThreshold: 3,
+		OpenTimeout:      time.Minute,
+		HalfOpenRequests: 1,
+		SlowCall:         time.Second,
+	}
+	errUpstream := errors.New("502 bad gateway")
+
+	// action, breaker'a uygulanan tek bir adımdır; want, adımdan sonraki durumdur.
+	type action struct {
+		do        string // "fail", "ok", "slow", "allow", "deny", "expire"
+		wantState BreakerState
+	}
+
+	tests := []struct {
+		name    string
+		actions []action
+	}{
+		{
+			name: "opens after consecutive failures",
+			actions: []action{
+				{"fail", BreakerClosed},
+				{"fail", BreakerClosed},
+				{"fail", BreakerOpen},
+				{"deny", BreakerOpen},
+			},
+		},
+		{
+			name: "success resets the failure count",
+			actions: []action{
+				{"fail", BreakerClosed},
+				{"fail", BreakerClosed},
+				{"ok", BreakerClosed},
+				{"fail", BreakerClosed},
+				{"fail", BreakerClosed},
+				{"fail", BreakerOpen},
+			},
+		},
+		{
+			name: "slow successful calls count as failures",
+			actions: []action{
+				{"slow", BreakerClosed},
+				{"slow", BreakerClosed},
+				{"slow", BreakerOpen},
+			},
+		},
+		{
+			name: "half-open probe success closes",
+			actions: []action{
+				{"fail", BreakerClosed},
+				{"fail", BreakerClosed},
+				{"fail", BreakerOpen},
+				{"expire", BreakerOpen},
+				{"allow", BreakerHalfOpen},
+				{"deny", BreakerHalfOpen}, // tek deneme hakkı kullanımda
+				{"ok", BreakerClosed},
+				{"allow", BreakerClosed},
+			},
+		},
+		{
+			name: "half-open probe failure reopens",
+			actions: []action{
+				{"fail", BreakerClosed},
+				{"fail", BreakerClosed},
+				{"fail", BreakerOpen},
+				{"expire", BreakerOpen},
+				{"allow", BreakerHalfOpen},
+				{"fail", BreakerOpen},
+				{"deny", BreakerOpen},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newBreaker("http://instance", cfg)
+			// pending, "allow" adımında alınan ve sonraki sonucun bildirileceği izindir;
+			// yoksa sonuç için yeni bir izin alınır.
+			var pending *BreakerToken
+			token := func(i int) BreakerToken {
+				if pending != nil {
+					defer func() { pending = nil }()
+					return *pending
+				}
+				token, ok := b.Allow()
+				if !ok {
+					t.Fatalf("step %d: Allow() = false before recording a result", i)
+				}
+				return token
+			}
+			for i, a := range tt.actions {
+				switch a.do {
+				case "fail":
+					b.Record(token(i), errUpstream, time.Millisecond)
+				case "ok":
+					b.Record(token(i), nil, time.Millisecond)
+				case "slow":
+					b.Record(token(i), nil, 2*cfg.SlowCall)
+				case "allow", "deny":
+					want := a.do == "allow"
+					if avail := b.Available(); avail != want {
+						t.Fatalf("step %d: Available() = %v, want %v", i, avail, want)
+					}
+					got, ok := b.Allow()
+					if ok != want {
+						t.Fatalf("step %d: Allow() = %v, want %v", i, ok, want)
+					}
+					if ok && pending == nil {
+						pending = &got
+					}
+				case "expire":
+					b.mu.Lock()
+					b.openedAt = time.Now().Add(-cfg.OpenTimeout)
+					b.mu.Unlock()
+				}
+				if got := b.Status().State; got != a.wantState {
+					t.Fatalf("step %d (%s): state = %s, want %s", i, a.do, got, a.wantState)
+				}
+			}
+		})
+	}
+}
+
+func TestBreakerStatus(t *testing.T) {
+	b := newBreaker("http://instance", config.CircuitBreakerSpec{FailureThreshold: 1, OpenTimeout: time.Minute, HalfOpenRequests: 1})
+	if s := b.Status(); s.OpenedAt != nil || s.Failures != 0 {
+		t.Fatalf("closed breaker status = %+v", s)
+	}
+
+	token, _ := b.Allow()
+	b.Record(token, errors.New("connection refused"), time.Millisecond)
+	s := b.Status()
+	if s.State != BreakerOpen || s.OpenedAt == nil || s.LastError != "connection refused" || s.Failures != 1 {
+		t.Fatalf("open breaker status = %+v", s)
+	}
+}
+
+// Devre açıldıktan sonra dönen ve izni daha önceki bir durumda alınmış isteklerin sonucu
+// devreyi kapatmamalı; devreyi yalnızca half-open deneme isteğinin başarısı kapatır.
+func TestBreakerIgnoresResultsFromAnEarlierState(t *testing.T) {
+	cfg := config.CircuitBreakerSpec{FailureThreshold: 2, OpenTimeout: time.Minute, HalfOpenRequests: 2}
+	errUpstream := errors.New("502 bad gateway")
+	b := newBreaker("http://instance", cfg)
+	expire := func() {
+		b.mu.Lock()
+		b.openedAt = time.Now().Add(-cfg.OpenTimeout)
+		b.mu.Unlock()
+	}
+	allow := func() BreakerToken {
+		t.Helper()
+		token, ok := b.Allow()
+		if !ok {
+			t.Fatal("Allow() = false")
+		}
+		return token
+	}
+	wantState := func(step string, want BreakerState) {
+		t.Helper()
+		if got := b.Status().State; got != want {
+			t.Fatalf("%s: state = %s, want %s", step, got, want)
+		}
+	}
+
+	// Devre kapalıyken iki yavaş istek başlar, ardından hatalar devreyi açar.
+	slowSuccess, slowFailure := allow(), allow()
+	b.Record(allow(), errUpstream, time.Millisecond)
+	b.Record(allow(), errUpstream, time.Millisecond)
+	wantState("failures", BreakerOpen)
+
+	b.Record(slowSuccess, nil, time.Millisecond)
+	wantState("success admitted while closed", BreakerOpen)
+
+	expire()
+	probe, other := allow(), allow()
+	wantState("probes", BreakerHalfOpen)
+	if _, ok := b.Allow(); ok {
+		t.Fatal("half-open breaker admitted more than HalfOpenRequests probes")
+	}
+
+	b.Record(slowFailure, errUpstream, time.Millisecond)
+	wantState("failure admitted while closed", BreakerHalfOpen)
+
+	// Başarısız deneme devreyi tekrar açar; aynı turdaki diğer denemenin başarısı onu kapatmaz.
+	b.Record(probe, errUpstream, time.Millisecond)
+	wantState("failed probe", BreakerOpen)
+	b.Record(other, nil, time.Millisecond)
+	wantState("probe of the previous half-open round", BreakerOpen)
+
+	expire()
+	probe = allow()
+	b.Record(probe, nil, time.Millisecond)
+	wantState("successful probe", BreakerClosed)
+	if s := b.Status(); s.Failures != 0 {
+		t.Fatalf("closed breaker keeps %d failures", s.Failures)
+	}
+}
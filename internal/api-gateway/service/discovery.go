@@ -0,0 +1,157 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"marketplace/internal/api-gateway/config"
+	"marketplace/pkg/discovery"
+)
+
+// Provider, bir servisin instance listesini dış bir kaynaktan izler.
+// Watch, liste her değiştiğinde update'i çağırır ve ctx iptal edilene kadar bloklar.
+// Kaynağa geçici olarak ulaşılamazsa hata loglanır ve son bilinen liste korunur;
+// Watch sadece izleme hiç başlatılamıyorsa hata döner.
+type Provider interface {
+	Watch(ctx context.Context, update func([]config.InstanceSpec)) error
+}
+
+// Discovery, servis tanımındaki discovery ayarına göre Provider oluşturur.
+type Discovery struct {
+	// Redis, redis provider'ının kayıtları okuduğu client'tır (bkz. pkg/discovery).
+	Redis redis.Cmdable
+}
+
+func (d Discovery) provider(service string, spec config.DiscoverySpec) (Provider, error) {
+	switch spec.Provider {
+	case config.DiscoveryFile:
+		return &fileProvider{path: spec.File}, nil
+	case config.DiscoveryDNS:
+		return &dnsProvider{srv: spec.SRV, scheme: spec.Scheme, interval: spec.Interval, resolver: net.DefaultResolver}, nil
+	case config.DiscoveryRedis:
+		if d.Redis == nil {
+			return nil, fmt.Errorf("redis discovery requires DISCOVERY_REDIS_ADDR")
+		}
+		return &redisProvider{client: d.Redis, service: service, interval: spec.Interval}, nil
+	default:
+		return nil, fmt.Errorf("unknown discovery provider %q", spec.Provider)
+	}
+}
+
+// fileProvider, instance listesini ayrı bir dosyadan okur ve dosya değiştikçe yeniden okur.
+// Geçersiz dosya reddedilir; son geçerli liste kullanılmaya devam eder.
+type fileProvider struct {
+	path string
+}
+
+func (p *fileProvider) Watch(ctx context.Context, update func([]config.InstanceSpec)) error {
+	load := func() {
+		instances, err := config.LoadInstances(p.path)
+		if err != nil {
+			log.Printf("❌ Discovery file rejected, keeping previous instances: %v", err)
+			return
+		}
+		update(instances)
+	}
+
+	load()
+	return config.WatchFile(ctx, p.path, load)
+}
+
+// srvResolver, dnsProvider'ın kullandığı net.Resolver metodudur.
+type srvResolver interface {
+	LookupSRV(ctx context.Context, service, proto, name string) (string, []*net.SRV, error)
+}
+
+// dnsProvider, SRV kaydını periyodik olarak sorgular. Her kayıt bir instance'tır; SRV
+// ağırlığı instance ağırlığı olarak kullanılır.
+type dnsProvider struct {
+	srv      string
+	scheme   string
+	interval time.Duration
+	resolver srvResolver
+}
+
+func (p *dnsProvider) Watch(ctx context.Context, update func([]config.InstanceSpec)) error {
+	return poll(ctx, p.interval, func(ctx context.Context) ([]config.InstanceSpec, error) {
+		_, records, err := p.resolver.LookupSRV(ctx, "", "", p.srv)
+		if err != nil {
+			return nil, err
+		}
+		instances := make([]config.InstanceSpec, 0, len(records))
+		for _, r := range records {
+			host := strings.TrimSuffix(r.Target, ".")
+			instances = append(instances, config.InstanceSpec{
+				URL:    p.scheme + "://" + net.JoinHostPort(host, strconv.Itoa(int(r.Port))),
+				Weight: max(int(r.Weight), 1),
+			})
+		}
+		return instances, nil
+	}, update)
+}
+
+// redisProvider, backend'lerin self-registration kayıtlarını okur. Heartbeat'i kesilen
+// instance'lar TTL dolunca listeden düşer.
+type redisProvider struct {
+	client   redis.Cmdable
+	service  string
+	interval time.Duration
+}
+
+func (p *redisProvider) Watch(ctx context.Context, update func([]config.InstanceSpec)) error {
+	return poll(ctx, p.interval, func(ctx context.Context) ([]config.InstanceSpec, error) {
+		urls, err := discovery.Instances(ctx, p.client, p.service)
+		if err != nil {
+			return nil, err
+		}
+		instances := make([]config.InstanceSpec, 0, len(urls))
+		for _, url := range urls {
+			instances = append(instances, config.InstanceSpec{URL: url, Weight: 1})
+		}
+		return instances, nil
+	}, update)
+}
+
+// poll, lookup'ı interval'de bir çalıştırır ve liste değiştiğinde update'i çağırır.
+// Sorgu hatasında son liste korunur; DNS veya Redis'in kısa kesintisi tüm instance'ları düşürmez.
+func poll(ctx context.Context, interval time.Duration, lookup func(context.Context) ([]config.InstanceSpec, error), update func([]config.InstanceSpec)) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var last []config.InstanceSpec
+	first := true
+	for {
+		lookupCtx, cancel := context.WithTimeout(ctx, interval)
+		instances, err := lookup(lookupCtx)
+		cancel()
+
+		switch {
+		case err != nil:
+			if ctx.Err() != nil {
+				return nil
+			}
+			log.Printf("⚠️ Discovery lookup failed, keeping previous instances: %v", err)
+		default:
+			slices.SortFunc(instances, func(a, b config.InstanceSpec) int { return strings.Compare(a.URL, b.URL) })
+			if first || !slices.Equal(instances, last) {
+				first = false
+				last = instances
+				update(instances)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
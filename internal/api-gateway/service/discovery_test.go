@@ -0,0 +1,281 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+
+	"marketplace/internal/api-gateway/config"
+	"marketplace/pkg/discovery"
+)
+
+// updates, provider'ın update çağrılarını toplar.
+type updates struct {
+	mu   sync.Mutex
+	seen [][]config.InstanceSpec
+}
+
+func (u *updates) record(instances []config.InstanceSpec) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.seen = append(u.seen, instances)
+}
+
+func (u *updates) get() [][]config.InstanceSpec {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return slices.Clone(u.seen)
+}
+
+// watch, provider'ı test bitene kadar çalıştırır.
+func watch(t *testing.T, p Provider) *updates {
+	t.Helper()
+	u := &updates{}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- p.Watch(ctx, u.record) }()
+	t.Cleanup(func() {
+		cancel()
+		if err := <-done; err != nil {
+			t.Error(err)
+		}
+	})
+	return u
+}
+
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func urls(instances []config.InstanceSpec) []string {
+	out := make([]string, len(instances))
+	for i, inst := range instances {
+		out[i] = inst.URL
+	}
+	return out
+}
+
+func TestFileProvider(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "instances.yaml")
+	write := func(content string) {
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("instances: [http://a:1, { url: http://b:1, weight: 2 }]")
+
+	u := watch(t, &fileProvider{path: path})
+	waitFor(t, "the initial list", func() bool { return len(u.get()) == 1 })
+	if want := []config.InstanceSpec{{URL: "http://a:1", Weight: 1}, {URL: "http://b:1", Weight: 2}}; !slices.Equal(u.get()[0], want) {
+		t.Fatalf("initial list = %v, want %v", u.get()[0], want)
+	}
+	// Watcher'ın dizini izlemeye başlaması için kısa bir süre tanınır.
+	time.Sleep(50 * time.Millisecond)
+
+	write("instances: [a:1]")
+	time.Sleep(time.Second)
+	if len(u.get()) != 1 {
+		t.Fatalf("invalid file produced an update: %v", u.get())
+	}
+
+	write("instances: [http://c:1]")
+	waitFor(t, "the changed list", func() bool { return len(u.get()) == 2 })
+	if got := urls(u.get()[1]); !slices.Equal(got, []string{"http://c:1"}) {
+		t.Fatalf("changed list = %v", got)
+	}
+}
+
+// fakeResolver, her sorguda sıradaki cevabı döner; cevaplar bitince sonuncuyu tekrarlar.
+type fakeResolver struct {
+	mu        sync.Mutex
+	name      string
+	responses []srvResponse
+	calls     int
+}
+
+type srvResponse struct {
+	records []*net.SRV
+	err     error
+}
+
+func (r *fakeResolver) LookupSRV(_ context.Context, service, proto, name string) (string, []*net.SRV, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.name = name
+	resp := r.responses[min(r.calls, len(r.responses)-1)]
+	r.calls++
+	return "", resp.records, resp.err
+}
+
+func (r *fakeResolver) callCount() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.calls
+}
+
+func TestDNSProvider(t *testing.T) {
+	first := []*net.SRV{
+		{Target: "b.user-service.local.", Port: 8081, Weight: 0},
+		{Target: "a.user-service.local.", Port: 8081, Weight: 3},
+	}
+	resolver := &fakeResolver{responses: []srvResponse{
+		{records: first},
+		{records: []*net.SRV{first[1], first[0]}}, // aynı liste, farklı sıra
+		{err: errors.New("i/o timeout")},          // son liste korunur
+		{records: first[:1]},
+	}}
+
+	u := watch(t, &dnsProvider{srv: "_http._tcp.user-service.local", scheme: "https", interval: 10 * time.Millisecond, resolver: resolver})
+	waitFor(t, "every response", func() bool { return resolver.callCount() > len(resolver.responses) })
+
+	want := [][]config.InstanceSpec{
+		{{URL: "https://a.user-service.local:8081", Weight: 3}, {URL: "https://b.user-service.local:8081", Weight: 1}},
+		{{URL: "https://b.user-service.local:8081", Weight: 1}},
+	}
+	got := u.get()
+	if len(got) != len(want) {
+		t.Fatalf("updates = %v, want %v", got, want)
+	}
+	for i := range want {
+		if !slices.Equal(got[i], want[i]) {
+			t.Fatalf("update %d = %v, want %v", i, got[i], want[i])
+		}
+	}
+	resolver.mu.Lock()
+	defer resolver.mu.Unlock()
+	if resolver.name != "_http._tcp.user-service.local" {
+		t.Fatalf("queried %q", resolver.name)
+	}
+}
+
+func newTestRedis(t *testing.T) (*miniredis.Miniredis, *redis.Client) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+	return mr, client
+}
+
+// register, instance'ı ttl süreyle kaydeder (bkz. discovery.Announcer); negatif ttl süresi dolmuş kayıttır.
+func register(t *testing.T, client *redis.Client, service, url string, ttl time.Duration) {
+	t.Helper()
+	expiresAt := time.Now().Add(ttl).UnixMilli()
+	if err := client.ZAdd(context.Background(), discovery.Key(service), redis.Z{Score: float64(expiresAt), Member: url}).Err(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRedisProvider(t *testing.T) {
+	_, client := newTestRedis(t)
+	register(t, client, "user-service", "http://b:1", time.Minute)
+	register(t, client, "user-service", "http://a:1", time.Minute)
+	register(t, client, "user-service", "http://dead:1", -time.Second)
+	register(t, client, "seller-service", "http://seller:1", time.Minute)
+
+	u := watch(t, &redisProvider{client: client, service: "user-service", interval: 10 * time.Millisecond})
+	waitFor(t, "the initial list", func() bool { return len(u.get()) == 1 })
+	if got := urls(u.get()[0]); !slices.Equal(got, []string{"http://a:1", "http://b:1"}) {
+		t.Fatalf("initial list = %v, want the live user-service instances", got)
+	}
+
+	register(t, client, "user-service", "http://c:1", time.Minute)
+	waitFor(t, "the new instance", func() bool { return len(u.get()) == 2 })
+	if got := urls(u.get()[1]); !slices.Equal(got, []string{"http://a:1", "http://b:1", "http://c:1"}) {
+		t.Fatalf("changed list = %v", got)
+	}
+
+	// Süresi dolmuş kayıtlar okurken silinir.
+	if dead, _ := client.ZScore(context.Background(), discovery.Key("user-service"), "http://dead:1").Result(); dead != 0 {
+		t.Fatal("expired registration was not removed")
+	}
+}
+
+func TestRegistryMergesDiscoveredInstances(t *testing.T) {
+	_, client := newTestRedis(t)
+	register(t, client, "user-service", "http://a:1", time.Minute)
+	register(t, client, "user-service", "http://b:1", time.Minute)
+
+	retries := 1
+	spec := config.ServiceSpec{
+		Name:         "user-service",
+		Prefix:       "/users",
+		Instances:    []config.InstanceSpec{{URL: "http://static:1", Weight: 1}},
+		LoadBalancer: config.LBRoundRobin,
+		Timeout:      time.Second,
+		Retries:      &retries,
+		CircuitBreaker: config.CircuitBreakerSpec{
+			FailureThreshold: 5, OpenTimeout: time.Minute, HalfOpenRequests: 1,
+		},
+		Discovery: config.DiscoverySpec{Provider: config.DiscoveryRedis, Interval: 10 * time.Millisecond},
+	}
+
+	sr := NewServiceRegistry(Discovery{Redis: client})
+	defer sr.Close()
+
+	current := func() *Service {
+		svc, ok := sr.GetByPath("/users/profile")
+		if !ok {
+			t.Fatal("service is not registered")
+		}
+		return svc
+	}
+	hasInstances := func(want ...string) func() bool {
+		return func() bool { return slices.Equal(current().BaseURLs, want) }
+	}
+
+	sr.Apply([]config.ServiceSpec{spec})
+	waitFor(t, "the discovered instances", hasInstances("http://a:1", "http://b:1"))
+	a := current().Instances[0]
+
+	// Discovery ayarı değişmeyen bir yeniden yükleme, bulunan instance'ları ve devre
+	// kesicilerini korur; statik listeye geri dönülmez.
+	reloaded := spec
+	reloaded.Timeout = 2 * time.Second
+	sr.Apply([]config.ServiceSpec{reloaded})
+	if got := current(); !slices.Equal(got.BaseURLs, []string{"http://a:1", "http://b:1"}) || got.Timeout != 2*time.Second {
+		t.Fatalf("after reload: instances %v, timeout %v", got.BaseURLs, got.Timeout)
+	}
+	if current().Instances[0] != a {
+		t.Fatal("reload replaced an unchanged instance")
+	}
+
+	register(t, client, "user-service", "http://c:1", time.Minute)
+	waitFor(t, "the new instance", hasInstances("http://a:1", "http://b:1", "http://c:1"))
+	if current().Instances[0] != a {
+		t.Fatal("discovery update replaced an unchanged instance")
+	}
+
+	// Discovery kaldırılınca statik liste kullanılır.
+	static := reloaded
+	static.Discovery = config.DiscoverySpec{}
+	sr.Apply([]config.ServiceSpec{static})
+	if got := current().BaseURLs; !slices.Equal(got, []string{"http://static:1"}) {
+		t.Fatalf("without discovery: instances %v, want the static list", got)
+	}
+	register(t, client, "user-service", "http://d:1", time.Minute)
+	time.Sleep(50 * time.Millisecond)
+	if got := current().BaseURLs; !slices.Equal(got, []string{"http://static:1"}) {
+		t.Fatalf("stopped discovery still updates the service: %v", got)
+	}
+
+	sr.Apply(nil)
+	if _, ok := sr.GetByPath("/users/profile"); ok {
+		t.Fatal("removed service is still registered")
+	}
+}
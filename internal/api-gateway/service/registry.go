@@ -1,9 +1,11 @@
 package service
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"net/http"
+	"slices"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -12,52 +14,217 @@ import (
 	"marketplace/internal/api-gateway/config"
 )
 
-// ServiceHealth tracks the health status of a service
-type ServiceHealth struct {
-	Healthy   bool
-	LastCheck time.Time
-	FailCount int32
-	mu        sync.RWMutex
+// Instance, bir servisin tek bir upstream adresi ve onun devre kesicisidir.
+// Her instance ayrı ayrı devreden çıkar (breaker açılır) ve devreye geri girer; diğer
+// instance'lar bundan etkilenmez.
+type Instance struct {
+	URL     string
+	Weight  int
+	Breaker *Breaker
+
+	outstanding atomic.Int64
+}
+
+// Outstanding, instance'ta o an işlenmekte olan proxy isteği sayısıdır.
+func (i *Instance) Outstanding() int64 {
+	return i.outstanding.Load()
+}
+
+// Done, NextInstance ile seçilen instance'a yapılan isteğin sonucunu, seçimle birlikte
+// dönen breaker izniyle bildirir.
+func (i *Instance) Done(token BreakerToken, err error, latency time.Duration) {
+	i.outstanding.Add(-1)
+	i.Breaker.Record(token, err, latency)
+}
+
+// InstanceStatus, /health ve /services çıktısındaki instance durumudur.
+type InstanceStatus struct {
+	BreakerStatus
+	Weight      int   `json:"weight"`
+	Outstanding int64 `json:"outstanding_requests"`
 }
 
 // Service represents a backend service
 type Service struct {
-	Name       string
-	BaseURLs   []string
-	PathPrefix string
-	Health     *ServiceHealth
-	Timeout    time.Duration
-	nextIndex  uint64
+	Name         string
+	BaseURLs     []string
+	PathPrefix   string
+	Instances    []*Instance
+	LoadBalancer string
+	Timeout      time.Duration
+
+	balancer balancer
+	spec     config.ServiceSpec
 }
 
 // ServiceRegistry manages services
 type ServiceRegistry struct {
 	services sync.Map // map[string]*Service
+
+	discovery Discovery
+	ctx       context.Context
+	cancel    context.CancelFunc
+
+	mu         sync.Mutex
+	specs      map[string]config.ServiceSpec
+	discovered map[string][]config.InstanceSpec
+	watchers   map[string]*watcher
 }
 
-func NewServiceRegistry() *ServiceRegistry {
-	return &ServiceRegistry{}
+// watcher, bir servisin çalışan discovery izlemesidir.
+type watcher struct {
+	spec   config.DiscoverySpec
+	cancel context.CancelFunc
 }
 
-func (sr *ServiceRegistry) Register(name string, baseURLs []string, pathPrefix string) error {
-	if len(baseURLs) == 0 {
-		return fmt.Errorf("at least one BaseURL is required")
+func NewServiceRegistry(discovery Discovery) *ServiceRegistry {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &ServiceRegistry{
+		discovery:  discovery,
+		ctx:        ctx,
+		cancel:     cancel,
+		specs:      make(map[string]config.ServiceSpec),
+		discovered: make(map[string][]config.InstanceSpec),
+		watchers:   make(map[string]*watcher),
+	}
+}
+
+// Close, çalışan discovery izlemelerini durdurur.
+func (sr *ServiceRegistry) Close() {
+	sr.cancel()
+}
+
+// Apply, kaydı yapılandırmadaki servislerle eşitler: yeni servisler eklenir, değişenler
+// yenisiyle değiştirilir, dosyadan çıkarılanlar silinir. Servisler yerinde değiştirilmez;
+// işlenmekte olan istekler aldıkları eski *Service ile tamamlanır.
+// Discovery ayarı olan servislerin izlemesi başlatılır; ayarı değişmeyen izleme sürer ve
+// bulduğu instance'lar yüklemeden sonra da kullanılır.
+func (sr *ServiceRegistry) Apply(specs []config.ServiceSpec) {
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+
+	keep := make(map[string]bool, len(specs))
+	for _, spec := range specs {
+		keep[spec.Name] = true
+		sr.specs[spec.Name] = spec
+		sr.watch(spec)
+		sr.store(spec)
+	}
+
+	for name := range sr.specs {
+		if keep[name] {
+			continue
+		}
+		if w, ok := sr.watchers[name]; ok {
+			w.cancel()
+			delete(sr.watchers, name)
+		}
+		delete(sr.specs, name)
+		delete(sr.discovered, name)
+		sr.services.Delete(name)
+		log.Printf("🗑️ Service removed: %s", name)
+	}
+}
+
+// watch, servisin discovery izlemesini ayarıyla eşitler. sr.mu tutulurken çağrılır.
+func (sr *ServiceRegistry) watch(spec config.ServiceSpec) {
+	if w, ok := sr.watchers[spec.Name]; ok {
+		if w.spec == spec.Discovery {
+			return
+		}
+		w.cancel()
+		delete(sr.watchers, spec.Name)
+		delete(sr.discovered, spec.Name)
+	}
+	if spec.Discovery.Provider == "" {
+		return
+	}
+
+	provider, err := sr.discovery.provider(spec.Name, spec.Discovery)
+	if err != nil {
+		log.Printf("❌ Discovery disabled for %s, using static instances: %v", spec.Name, err)
+		return
+	}
+
+	ctx, cancel := context.WithCancel(sr.ctx)
+	w := &watcher{spec: spec.Discovery, cancel: cancel}
+	sr.watchers[spec.Name] = w
+	go func() {
+		err := provider.Watch(ctx, func(instances []config.InstanceSpec) {
+			sr.mu.Lock()
+			defer sr.mu.Unlock()
+
+			// İzleme bu arada değiştirildiyse eski provider'ın sonucu atılır.
+			if sr.watchers[spec.Name] != w {
+				return
+			}
+			sr.discovered[spec.Name] = instances
+			log.Printf("🔎 Discovered %d instance(s) for %s via %s", len(instances), spec.Name, spec.Discovery.Provider)
+			sr.store(sr.specs[spec.Name])
+		})
+		if err != nil {
+			log.Printf("❌ Discovery stopped for %s: %v", spec.Name, err)
+		}
+	}()
+}
+
+// store, servisi geçerli instance listesiyle (discovery sonucu veya statik liste) oluşturup
+// kaydeder. sr.mu tutulurken çağrılır.
+func (sr *ServiceRegistry) store(spec config.ServiceSpec) {
+	instances := spec.Instances
+	if found, ok := sr.discovered[spec.Name]; ok {
+		instances = found
+	}
+
+	var previous map[string]*Instance
+	if old, ok := sr.services.Load(spec.Name); ok {
+		previous = make(map[string]*Instance)
+		for _, inst := range old.(*Service).Instances {
+			previous[inst.URL] = inst
+		}
 	}
 
 	service := &Service{
-		Name:       name,
-		BaseURLs:   baseURLs,
-		PathPrefix: pathPrefix,
-		Health: &ServiceHealth{
-			Healthy:   true,
-			LastCheck: time.Now(),
-		},
-		Timeout:   config.DefaultTimeout,
-		nextIndex: 0,
+		Name:         spec.Name,
+		PathPrefix:   spec.Prefix,
+		LoadBalancer: spec.LoadBalancer,
+		Timeout:      spec.Timeout,
+		spec:         spec,
 	}
-	sr.services.Store(name, service)
-	log.Printf("✅ Service registered: %s -> %v (prefix: %s)", name, baseURLs, pathPrefix)
-	return nil
+	for _, is := range instances {
+		service.BaseURLs = append(service.BaseURLs, is.URL)
+
+		// Değişmeyen instance korunur; açık devre yüklemeyle kapanmaz, işlenmekte olan
+		// istek sayısı sıfırlanmaz.
+		prev, ok := previous[is.URL]
+		if ok && prev.Weight == is.Weight && prev.Breaker.cfg == spec.CircuitBreaker {
+			service.Instances = append(service.Instances, prev)
+			continue
+		}
+		inst := &Instance{URL: is.URL, Weight: is.Weight, Breaker: newBreaker(is.URL, spec.CircuitBreaker)}
+		if ok && prev.Breaker.cfg == spec.CircuitBreaker {
+			inst.Breaker = prev.Breaker
+		}
+		service.Instances = append(service.Instances, inst)
+	}
+	service.balancer = newBalancer(spec.LoadBalancer, service.Instances)
+
+	sr.services.Store(spec.Name, service)
+	log.Printf("✅ Service registered: %s -> %v (prefix: %s, lb: %s)", spec.Name, service.BaseURLs, spec.Prefix, spec.LoadBalancer)
+}
+
+// TargetPath, istek yolundan prefix'i çıkarır ve rewrite kurallarını uygular.
+func (s *Service) TargetPath(path string) string {
+	target := strings.TrimPrefix(path, s.PathPrefix)
+	for _, rw := range s.spec.Rewrites {
+		target = rw.Apply(target)
+	}
+	return target
+}
+
+// TimeoutFor, istek yoluna uyan route'un süresini, yoksa servisin süresini döner.
+func (s *Service) TimeoutFor(path string) time.Duration {
+	return s.spec.TimeoutFor(path)
 }
 
 func (sr *ServiceRegistry) GetByPath(path string) (*Service, bool) {
@@ -86,24 +253,62 @@ func (sr *ServiceRegistry) List() []*Service {
 	return services
 }
 
-func (s *Service) GetNextBaseURL() (string, bool) {
-	s.Health.mu.RLock()
-	if !s.Health.Healthy {
-		s.Health.mu.RUnlock()
-		return "", false
+// NextInstance, devresi açık olmayan ve exclude'da bulunmayan instance'lar arasından servisin
+// stratejisiyle birini seçer. key, consistent_hash için kullanıcı kimliğidir (boş olabilir).
+// Dönen instance'ın sonucu, dönen izinle Done'a bildirilmelidir.
+func (s *Service) NextInstance(key string, exclude []*Instance) (*Instance, BreakerToken, bool) {
+	candidates := make([]*Instance, 0, len(s.Instances))
+	for _, inst := range s.Instances {
+		if !slices.Contains(exclude, inst) && inst.Breaker.Available() {
+			candidates = append(candidates, inst)
+		}
+	}
+	if len(candidates) == 0 {
+		return nil, BreakerToken{}, false
+	}
+
+	for _, inst := range s.balancer.order(candidates, key) {
+		if token, ok := inst.Breaker.Allow(); ok {
+			inst.outstanding.Add(1)
+			return inst, token, true
+		}
 	}
-	s.Health.mu.RUnlock()
+	return nil, BreakerToken{}, false
+}
 
-	index := atomic.AddUint64(&s.nextIndex, 1) - 1
-	urlIndex := index % uint64(len(s.BaseURLs))
+// Attempts, bu istek için en fazla kaç instance deneneceğidir. Sadece idempotent metotlar
+// tekrar denenir; POST/PATCH'in backend'e ulaşıp ulaşmadığı bilinemediği için iki kez
+// gönderilmesi (Örn: çift sipariş) riske atılmaz.
+func (s *Service) Attempts(method string) int {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return 1 + *s.spec.Retries
+	default:
+		return 1
+	}
+}
 
-	return s.BaseURLs[urlIndex], true
+// Status, instance'ların anlık durumudur.
+func (s *Service) Status() []InstanceStatus {
+	statuses := make([]InstanceStatus, len(s.Instances))
+	for i, inst := range s.Instances {
+		statuses[i] = InstanceStatus{
+			BreakerStatus: inst.Breaker.Status(),
+			Weight:        inst.Weight,
+			Outstanding:   inst.Outstanding(),
+		}
+	}
+	return statuses
 }
 
+// IsHealthy, servisin en az bir instance'ının istek kabul edip etmediğini söyler.
 func (sr *ServiceRegistry) IsHealthy(service *Service) bool {
-	service.Health.mu.RLock()
-	defer service.Health.mu.RUnlock()
-	return service.Health.Healthy
+	for _, inst := range service.Instances {
+		if inst.Breaker.Available() {
+			return true
+		}
+	}
+	return false
 }
 
 func (sr *ServiceRegistry) StartHealthChecks(interval time.Duration) {
@@ -112,7 +317,9 @@ func (sr *ServiceRegistry) StartHealthChecks(interval time.Duration) {
 		for range ticker.C {
 			sr.services.Range(func(key, value interface{}) bool {
 				service := value.(*Service)
-				go sr.checkHealth(service)
+				for _, inst := range service.Instances {
+					go sr.checkHealth(inst)
+				}
 				return true
 			})
 		}
@@ -120,37 +327,23 @@ func (sr *ServiceRegistry) StartHealthChecks(interval time.Duration) {
 	log.Printf("🏥 Health check started (interval: %v)", interval)
 }
 
-func (sr *ServiceRegistry) checkHealth(service *Service) {
-	allHealthy := true
-
-	service.Health.mu.Lock()
-	defer service.Health.mu.Unlock()
-
-	service.Health.LastCheck = time.Now()
-	client := &http.Client{Timeout: 5 * time.Second}
-
-	for _, baseURL := range service.BaseURLs {
-		resp, err := client.Get(baseURL + "/health")
-		if err != nil || resp.StatusCode != http.StatusOK {
-			atomic.AddInt32(&service.Health.FailCount, 1)
-			allHealthy = false
-		} else {
-			if resp != nil {
-				resp.Body.Close()
-			}
-			atomic.StoreInt32(&service.Health.FailCount, 0)
-		}
+// checkHealth, instance'ın /health endpoint'ini yoklar ve sonucu breaker'a bildirir.
+// Trafik olmayan instance'ların devresi de böylece açılır; açık devre, OpenTimeout dolunca
+// ilk yoklamayı half-open deneme isteği olarak kullanır.
+func (sr *ServiceRegistry) checkHealth(inst *Instance) {
+	token, ok := inst.Breaker.Allow()
+	if !ok {
+		return
 	}
 
-	if !allHealthy && atomic.LoadInt32(&service.Health.FailCount) >= 3 {
-		if service.Health.Healthy {
-			log.Printf("🔴 Service DOWN (Circuit Open): %s", service.Name)
-			service.Health.Healthy = false
-		}
-	} else if allHealthy {
-		if !service.Health.Healthy {
-			log.Printf("🟢 Service UP (Circuit Closed): %s", service.Name)
+	client := &http.Client{Timeout: 5 * time.Second}
+	start := time.Now()
+	resp, err := client.Get(inst.URL + "/health")
+	if err == nil {
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			err = fmt.Errorf("health check returned %d", resp.StatusCode)
 		}
-		service.Health.Healthy = true
 	}
+	inst.Breaker.Record(token, err, time.Since(start))
 }
@@ -16,6 +16,7 @@ import (
 	httptransport "marketplace/internal/basket-service/transport/http"
 	"marketplace/internal/basket-service/transport/kafka"
 	messaginghandler "marketplace/internal/basket-service/transport/messaging"
+	"marketplace/pkg/messaging"
 	"time"
 )
 
@@ -27,8 +28,15 @@ type App struct {
 	consumer                 *kafka.Consumer
 }
 
+// NewApp, uygulamayı Kafka broker'ı ile kurar.
 func NewApp(cfg config.Config) (*App, error) {
-	container, err := buildContainer(cfg)
+	return NewAppWithBroker(cfg, messaging.NewKafkaBroker)
+}
+
+// NewAppWithBroker, uygulamayı verilen broker factory ile kurar. Üretici ve tüketiciler
+// aynı factory'den bağlanır; testlerde messaging.MemoryBroker.Connect verilerek Kafka gerekmez.
+func NewAppWithBroker(cfg config.Config, connect messaging.BrokerFactory) (*App, error) {
+	container, err := buildContainer(cfg, connect)
 	if err != nil {
 		return nil, fmt.Errorf("bootstrap failed: %w", err)
 	}
@@ -58,6 +66,12 @@ func (a *App) Start() error {
 		return fmt.Errorf("server exited with error: %w", err)
 	}
 
+	// Consumer'ları durdur; çalışan handler'lar bitip commit'ler yazılana kadar bekle.
+	cancel()
+	if err := a.consumer.Close(); err != nil {
+		log.Printf("kafka consumer close error: %v", err)
+	}
+
 	log.Println("server stopped, closing repositories")
 	// Repoları güvenli kapatma
 	_ = a.BasketRedisRepository.Close()
@@ -71,7 +85,7 @@ type container struct {
 	consumer                 *kafka.Consumer
 }
 
-func buildContainer(cfg config.Config) (*container, error) {
+func buildContainer(cfg config.Config, connect messaging.BrokerFactory) (*container, error) {
 	// 1. Repositories
 	postgresRepo, err := postgres.NewRepository(cfg)
 	if err != nil {
@@ -96,8 +110,11 @@ func buildContainer(cfg config.Config) (*container, error) {
 	router := httptransport.NewRouter(h)
 
 	// 4. Messaging
-	msgHandlers := messaginghandler.SetupMessageHandlers(redisRepo)
-	consumer, err := kafka.NewConsumer(cfg.Messaging, msgHandlers)
+	msgHandlers, err := messaginghandler.SetupMessageHandlers(redisRepo)
+	if err != nil {
+		return nil, fmt.Errorf("init message handlers: %w", err)
+	}
+	consumer, err := kafka.NewConsumerWithBroker(cfg.Messaging, msgHandlers, redisRepo.IdempotencyStore(), connect)
 	if err != nil {
 		return nil, err
 	}
@@ -115,6 +132,7 @@ func buildContainer(cfg config.Config) (*container, error) {
 }
 func getServerConfig(cfg config.Config) server.Config {
 	return server.Config{
+		Name:         "basket-service",
 		Port:         cfg.Server.Port,
 		GrpcPort:     cfg.Server.GrpcPort,
 		IdleTimeout:  5 * time.Second,
@@ -7,6 +7,7 @@ import (
 
 	"marketplace/internal/basket-service/domain"
 	pb "marketplace/pkg/proto/product"
+	"marketplace/pkg/tracing"
 
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
@@ -22,7 +23,7 @@ type productClient struct {
 
 func NewProductClient(grpcAddress string) (domain.ProductClient, error) {
 
-	conn, err := grpc.Dial(grpcAddress, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	conn, err := grpc.Dial(grpcAddress, grpc.WithTransportCredentials(insecure.NewCredentials()), tracing.DialOption())
 	if err != nil {
 		return nil, err
 	}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"marketplace/internal/basket-service/domain"
 	"marketplace/internal/basket-service/repository/postgres"
+	"marketplace/pkg/logging"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/gofiber/fiber/v2"
@@ -24,11 +25,12 @@ func HandleBasic[R Request, Res Response](handler BasicHandler[R, Res]) fiber.Ha
 			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "validation failed", "details": err.Error()})
 		}
 
-		ctx := c.UserContext()
+		ctx := logging.Bind(c)
 		res, err := handler.Handle(ctx, &req)
 
 		if err != nil {
 			status := getStatusCodeFromError(err)
+			logging.RequestError(c, status, err)
 			return c.Status(status).JSON(fiber.Map{"error": err.Error()})
 		}
 		if c.Method() == fiber.MethodPost {
@@ -49,11 +51,13 @@ func HandleWithFiber[R Request, Res Response](handler FiberHandler[R, Res]) fibe
 			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "validation failed", "details": err.Error()})
 		}
 
-		// ctx := c.UserContext()
+		// Controller context'i fiberCtx.UserContext() ile alır; istek kimliği oraya bağlanır.
+		logging.Bind(c)
 		res, err := handler.Handle(c, &req)
 
 		if err != nil {
 			status := getStatusCodeFromError(err)
+			logging.RequestError(c, status, err)
 			return c.Status(status).JSON(fiber.Map{"error": err.Error()})
 		}
 		if c.Method() == fiber.MethodPost {
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"marketplace/internal/basket-service/config"
 	"marketplace/internal/basket-service/domain"
+	"marketplace/pkg/messaging"
 	"time"
 
 	"github.com/google/uuid"
@@ -28,6 +29,11 @@ func NewBasketRedisRepository(cfg config.Config) (*BasketRedisRepository, error)
 	}, nil
 }
 
+// IdempotencyStore, Kafka tüketicisinin işlenmiş mesaj kayıtlarını sepetlerle aynı Redis'te tutar.
+func (r *BasketRedisRepository) IdempotencyStore() messaging.IdempotencyStore {
+	return messaging.NewRedisIdempotencyStore(r.client, 7*24*time.Hour)
+}
+
 func (r *BasketRedisRepository) Close() error {
 	if r.client != nil {
 		return r.client.Close()
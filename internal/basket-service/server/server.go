@@ -3,6 +3,9 @@ package server
 import (
 	"fmt"
 	"log"
+	"marketplace/pkg/discovery"
+	"marketplace/pkg/metrics"
+	"marketplace/pkg/tracing"
 	"net"
 	"time"
 
@@ -21,6 +24,8 @@ type GrpcServerRegistrar interface {
 }
 
 type Config struct {
+	// Name, servisin gateway.yaml'daki adıdır; self-registration bu adla yapılır.
+	Name         string
 	GrpcPort     string
 	Port         string
 	IdleTimeout  time.Duration
@@ -32,6 +37,7 @@ type Server struct {
 	app        *fiber.App
 	cfg        Config
 	grpcServer *grpc.Server
+	announcer  *discovery.Announcer
 }
 
 func New(cfg Config, registrar RouteRegistrar, grpcHandler GrpcServerRegistrar) *Server {
@@ -42,6 +48,7 @@ func New(cfg Config, registrar RouteRegistrar, grpcHandler GrpcServerRegistrar)
 	})
 
 	app.Use(requestid.New())
+	app.Use(tracing.Middleware())
 	app.Use(cors.New(cors.Config{
 		AllowOrigins:     "http://localhost:5173",
 		AllowHeaders:     "Origin, Content-Type, Accept, Authorization",
@@ -53,6 +60,7 @@ func New(cfg Config, registrar RouteRegistrar, grpcHandler GrpcServerRegistrar)
 	app.Get("/health", func(c *fiber.Ctx) error {
 		return c.JSON(fiber.Map{"status": "UP", "service": "basket-service"})
 	})
+	app.Get("/metrics", metrics.Handler(metrics.Default))
 
 	// HTTP Rotalarını Kaydet
 	if registrar != nil {
@@ -60,7 +68,7 @@ func New(cfg Config, registrar RouteRegistrar, grpcHandler GrpcServerRegistrar)
 	}
 
 	// gRPC Sunucusu Kurulumu
-	grpcSrv := grpc.NewServer()
+	grpcSrv := grpc.NewServer(tracing.ServerOption())
 	if grpcHandler != nil {
 		grpcHandler.Register(grpcSrv)
 	}
@@ -73,6 +81,23 @@ func New(cfg Config, registrar RouteRegistrar, grpcHandler GrpcServerRegistrar)
 }
 
 func (s *Server) Start() error {
+	// Self-registration: DISCOVERY_REDIS_ADDR tanımlıysa instance, HTTP sunucusu dinlemeye
+	// başlayınca Redis'e kaydedilir ve kapanırken kaydı silinir (bkz. pkg/discovery).
+	announcer, err := discovery.FromEnv(s.cfg.Name, s.cfg.Port)
+	if err != nil {
+		return fmt.Errorf("discovery: %w", err)
+	}
+	s.announcer = announcer
+	s.app.Hooks().OnListen(func(fiber.ListenData) error {
+		s.announcer.Start()
+		return nil
+	})
+	s.app.Hooks().OnShutdown(func() error {
+		s.announcer.Stop()
+		return nil
+	})
+	defer s.announcer.Stop()
+
 	// gRPC'yi ayrı bir goroutine'de başlat
 	go func() {
 		if err := s.startGrpc(); err != nil {
@@ -3,9 +3,8 @@ package controller
 import (
 	"context"
 
-	"fmt"
-
 	"marketplace/internal/basket-service/transport/messaging/usecase"
+	"marketplace/pkg/messaging"
 	pb "marketplace/pkg/proto/events"
 )
 
@@ -19,12 +18,6 @@ func NewProductPriceUpdatedHandler(usecase usecase.ProductPriceUpdatedUseCase) *
 	}
 }
 
-func (h *ProductPriceUpdatedHandler) Handle(ctx context.Context, msg *pb.Message) error {
-
-	data := msg.GetProductPriceUpdatedData()
-	if data == nil {
-		return fmt.Errorf("payload is nil or not ProductPriceUpdatedData for message ID: %s", msg.Id)
-	}
-
+func (h *ProductPriceUpdatedHandler) Handle(ctx context.Context, data *pb.ProductPriceUpdatedData, meta messaging.Meta) error {
 	return h.usecase.Execute(ctx, data.ProductId, float64(data.Price))
 }
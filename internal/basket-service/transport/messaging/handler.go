@@ -1,16 +1,19 @@
 package messaginghandler
 
 import (
+	"errors"
+
 	"marketplace/internal/basket-service/domain"
 	"marketplace/internal/basket-service/transport/messaging/controller"
 	"marketplace/internal/basket-service/transport/messaging/usecase"
+	"marketplace/pkg/messaging"
 
 	pb "marketplace/pkg/proto/events"
 )
 
 type Handlers struct {
-	ProductPriceUpdated domain.MessageHandler
-	PaymentSuccess      domain.MessageHandler
+	ProductPriceUpdated *controller.ProductPriceUpdatedHandler
+	PaymentSuccess      *controller.PaymentSuccessHandler
 }
 
 func NewHandlers(repository domain.BasketRedisRepository) *Handlers {
@@ -24,11 +27,16 @@ func NewHandlers(repository domain.BasketRedisRepository) *Handlers {
 	}
 }
 
-func SetupMessageHandlers(repository domain.BasketRedisRepository) map[pb.MessageType]domain.MessageHandler {
+func SetupMessageHandlers(repository domain.BasketRedisRepository) (*messaging.Router, error) {
 	h := NewHandlers(repository)
+	router := messaging.NewRouter()
 
-	return map[pb.MessageType]domain.MessageHandler{
-		pb.MessageType_PRODUCT_PRICE_UPDATED: h.ProductPriceUpdated,
-		pb.MessageType_PAYMENT_SUCCESSFUL:    h.PaymentSuccess,
+	err := errors.Join(
+		messaging.Handle(router, pb.MessageType_PRODUCT_PRICE_UPDATED, h.ProductPriceUpdated.Handle),
+		messaging.Handle(router, pb.MessageType_PAYMENT_SUCCESSFUL, h.PaymentSuccess.Handle),
+	)
+	if err != nil {
+		return nil, err
 	}
+	return router, nil
 }
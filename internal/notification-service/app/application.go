@@ -32,9 +32,16 @@ type App struct {
 	repository domain.NotificationRepository
 }
 
+// NewApp, uygulamayı Kafka broker'ı ile kurar.
 func NewApp(cfg config.Config) (*App, error) {
+	return NewAppWithBroker(cfg, messaging.NewKafkaBroker)
+}
+
+// NewAppWithBroker, uygulamayı verilen broker factory ile kurar. Üretici ve tüketiciler
+// aynı factory'den bağlanır; testlerde messaging.MemoryBroker.Connect verilerek Kafka gerekmez.
+func NewAppWithBroker(cfg config.Config, connect messaging.BrokerFactory) (*App, error) {
 	// buildContainer'ı çağırıp tüm bağımlılıkları (repo, kafka, server) hazırlıyoruz
-	container, err := buildContainer(cfg)
+	container, err := buildContainer(cfg, connect)
 	if err != nil {
 		return nil, fmt.Errorf("failed to build container: %w", err)
 	}
@@ -52,6 +59,7 @@ func (a *App) Start() error {
 	defer cancel()
 
 	a.consumer.Start(ctx)
+	go a.repository.IdempotencyStore().RunCleanup(ctx)
 
 	// Log mesajını dinamikleştirin (user-service değil notification-service)
 	log.Printf("Starting Notification Service on %s", a.server.Address())
@@ -62,10 +70,16 @@ func (a *App) Start() error {
 		return fmt.Errorf("server exited with error: %w", err)
 	}
 
+	// Consumer'ları durdur; çalışan handler'lar bitip commit'ler yazılana kadar bekle.
+	cancel()
+	if err := a.consumer.Close(); err != nil {
+		log.Printf("kafka consumer close error: %v", err)
+	}
+
 	return a.repository.Close()
 }
 
-func buildContainer(cfg config.Config) (*container, error) {
+func buildContainer(cfg config.Config, connect messaging.BrokerFactory) (*container, error) {
 	// 1. Veritabanı Başlatma
 	repo, err := postgres.NewRepository(cfg)
 	if err != nil {
@@ -81,11 +95,14 @@ func buildContainer(cfg config.Config) (*container, error) {
 
 	// 3. İş Mantığı ve Handlerlar (Transport/Messaging)
 	// Bu kısım çok şişiyorsa bir 'Dependency Registry' oluşturulabilir
-	handlers := messaginghandler.SetupMessageHandlers(emailProvider, templateMgr, repo)
+	handlers, err := messaginghandler.SetupMessageHandlers(emailProvider, templateMgr, repo)
+	if err != nil {
+		return nil, fmt.Errorf("init message handlers: %w", err)
+	}
 
 	// 4. Messaging (Kafka) Başlatma
 	messagingConfig := getKafkaSettings(cfg.Messaging)
-	kafkaClient, err := messaging.NewKafkaClient(messagingConfig)
+	kafkaClient, err := connect(messagingConfig)
 	if err != nil {
 		return nil, err
 	}
@@ -93,7 +110,7 @@ func buildContainer(cfg config.Config) (*container, error) {
 	// 5. Taşıma Katmanları (HTTP & Kafka Consumer)
 	httpRouter := setupRouter(kafkaClient)
 
-	consumer, err := kafka.NewConsumer(cfg.Messaging, handlers)
+	consumer, err := kafka.NewConsumerWithBroker(cfg.Messaging, handlers, repo.IdempotencyStore(), repo.CriticalStore(), connect)
 	if err != nil {
 		return nil, err
 	}
@@ -114,9 +131,9 @@ func getKafkaSettings(cfg config.MessagingConfig) messaging.KafkaConfig {
 
 	return messaging.KafkaConfig{
 		Brokers:              []string{broker},
-		Topic:                "main-events",
-		RetryTopic:           "main-events-retry",
-		DLQTopic:             "main-events-dlq",
+		Topic:                messaging.DefaultTopic,
+		RetryTopic:           messaging.DefaultRetryTopic,
+		DLQTopic:             messaging.DefaultDLQTopic,
 		ServiceType:          pb.ServiceType_NOTIFICATION_SERVICE,
 		EnableRetry:          true,
 		MaxRetries:           10,
@@ -131,6 +148,7 @@ func setupRouter(msgClient domain.Messaging) server.RouteRegistrar {
 
 func getServerConfig(cfg config.Config) server.Config {
 	return server.Config{
+		Name:         "notification-service",
 		Port:         cfg.Server.Port,
 		IdleTimeout:  5 * time.Second,
 		ReadTimeout:  10 * time.Second,
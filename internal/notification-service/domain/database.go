@@ -2,6 +2,7 @@ package domain
 
 import (
 	"context"
+	"marketplace/pkg/messaging"
 
 	"github.com/google/uuid"
 )
@@ -9,5 +10,8 @@ import (
 type NotificationRepository interface {
 	AddUser(ctx context.Context, userID uuid.UUID, username string, email string) error
 	GetUser(ctx context.Context, userID uuid.UUID) (*User, error)
+	// IdempotencyStore, işlenmiş mesaj kayıtlarıdır; eski kayıtlar RunCleanup ile silinir.
+	IdempotencyStore() *messaging.PostgresIdempotencyStore
+	CriticalStore() messaging.CriticalStore
 	Close() error
 }
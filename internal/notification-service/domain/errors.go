@@ -1,9 +1,22 @@
 // internal/notification-service/domain/errors.go
 package domain
 
-import "errors"
+import (
+	"errors"
+	"time"
+)
 
 var (
 	ErrUnauthorized  = errors.New("unauthorized access")
 	ErrOrderNotFound = errors.New("order not found")
 )
+
+// RateLimitedError, e-posta sağlayıcısının isteği rate limit nedeniyle reddettiğini bildirir.
+// RetryAfter, sağlayıcının istediği bekleme süresidir; bilinmiyorsa sıfırdır.
+type RateLimitedError struct {
+	RetryAfter time.Duration
+	Err        error
+}
+
+func (e *RateLimitedError) Error() string { return "rate limited: " + e.Err.Error() }
+func (e *RateLimitedError) Unwrap() error { return e.Err }
@@ -1,7 +1,10 @@
 package email
 
 import (
+	"errors"
 	"fmt"
+	"strconv"
+	"time"
 
 	"marketplace/internal/notification-service/domain"
 
@@ -31,6 +34,16 @@ func (r *resendProvider) SendEmail(to string, subject string, htmlContent string
 
 	sent, err := r.Client.Emails.Send(params)
 	if err != nil {
+		// Resend limiti aşıldığında Retry-After header'ını saniye olarak döner; süre hatayla
+		// birlikte taşınır ki e-postayı gönderen handler mesajı o kadar sonra tekrar denesin.
+		var rateLimit *resend.RateLimitError
+		if errors.As(err, &rateLimit) {
+			seconds, _ := strconv.Atoi(rateLimit.RetryAfter)
+			return &domain.RateLimitedError{
+				RetryAfter: time.Duration(seconds) * time.Second,
+				Err:        fmt.Errorf("resend error: %w", err),
+			}
+		}
 		return fmt.Errorf("resend error: %w", err)
 	}
 
@@ -6,6 +6,7 @@ import (
 	"errors"
 	"marketplace/internal/notification-service/config"
 	"marketplace/internal/notification-service/domain"
+	"marketplace/pkg/messaging"
 
 	_ "github.com/lib/pq"
 )
@@ -23,7 +24,9 @@ var (
 )
 
 type Repository struct {
-	db *sql.DB
+	db          *sql.DB
+	idempotency *messaging.PostgresIdempotencyStore
+	critical    *messaging.PostgresCriticalStore
 }
 
 func NewRepository(cfg config.Config) (domain.NotificationRepository, error) {
@@ -36,11 +39,32 @@ func NewRepository(cfg config.Config) (domain.NotificationRepository, error) {
 		return nil, err
 	}
 
-	repo := &Repository{db: db}
+	// Kafka tüketicisinin işlenmiş mesaj kayıtları da aynı veritabanında tutulur;
+	// böylece handler'lar kaydı kendi transaction'ları içinde atabilir.
+	idempotency, err := messaging.NewPostgresIdempotencyStore(db)
+	if err != nil {
+		return nil, err
+	}
+
+	// DLQ'ya düşen kritik mesajlar da servisin veritabanında saklanır (bkz. messaging.CriticalStore).
+	critical, err := messaging.NewPostgresCriticalStore(db)
+	if err != nil {
+		return nil, err
+	}
+
+	repo := &Repository{db: db, idempotency: idempotency, critical: critical}
 
 	return repo, nil
 }
 
+func (r *Repository) IdempotencyStore() *messaging.PostgresIdempotencyStore {
+	return r.idempotency
+}
+
+func (r *Repository) CriticalStore() messaging.CriticalStore {
+	return r.critical
+}
+
 func (r *Repository) Close() error {
 	if r.db != nil {
 		return r.db.Close()
@@ -4,6 +4,9 @@ package server
 import (
 	"fmt"
 	"log"
+	"marketplace/pkg/discovery"
+	"marketplace/pkg/metrics"
+	"marketplace/pkg/tracing"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
@@ -16,6 +19,8 @@ type RouteRegistrar interface {
 }
 
 type Config struct {
+	// Name, servisin gateway.yaml'daki adıdır; self-registration bu adla yapılır.
+	Name         string
 	GrpcPort     string
 	Port         string
 	IdleTimeout  time.Duration
@@ -24,8 +29,9 @@ type Config struct {
 }
 
 type Server struct {
-	app *fiber.App
-	cfg Config
+	app       *fiber.App
+	cfg       Config
+	announcer *discovery.Announcer
 }
 
 func New(cfg Config, registrar RouteRegistrar) *Server {
@@ -43,10 +49,12 @@ func New(cfg Config, registrar RouteRegistrar) *Server {
 		AllowCredentials: true,
 	}))
 	app.Use(requestid.New())
+	app.Use(tracing.Middleware())
 
 	app.Get("/health", func(c *fiber.Ctx) error {
 		return c.JSON(fiber.Map{"status": "UP"})
 	})
+	app.Get("/metrics", metrics.Handler(metrics.Default))
 
 	if registrar != nil {
 		registrar.Register(app)
@@ -59,6 +67,23 @@ func New(cfg Config, registrar RouteRegistrar) *Server {
 }
 
 func (s *Server) Start() error {
+	// Self-registration: DISCOVERY_REDIS_ADDR tanımlıysa instance, HTTP sunucusu dinlemeye
+	// başlayınca Redis'e kaydedilir ve kapanırken kaydı silinir (bkz. pkg/discovery).
+	announcer, err := discovery.FromEnv(s.cfg.Name, s.cfg.Port)
+	if err != nil {
+		return fmt.Errorf("discovery: %w", err)
+	}
+	s.announcer = announcer
+	s.app.Hooks().OnListen(func(fiber.ListenData) error {
+		s.announcer.Start()
+		return nil
+	})
+	s.app.Hooks().OnShutdown(func() error {
+		s.announcer.Stop()
+		return nil
+	})
+	defer s.announcer.Stop()
+
 	log.Printf("🌐 HTTP sunucusu %s adresinde dinliyor...", s.cfg.Port)
 	return s.app.Listen(s.Address())
 }
@@ -7,6 +7,7 @@ import (
 	"fmt"
 
 	"marketplace/internal/notification-service/transport/messaging/usecase"
+	"marketplace/pkg/messaging"
 	pb "marketplace/pkg/proto/events"
 
 	"github.com/google/uuid"
@@ -22,17 +23,11 @@ func NewApproveSellerHandler(usecase usecase.ApproveSellerUseCase) *ApproveSelle
 	}
 }
 
-func (h *ApproveSellerHandler) Handle(ctx context.Context, msg *pb.Message) error {
-
-	data := msg.GetSellerApprovedData()
-	if data == nil {
-		return fmt.Errorf("payload is nil or not OrderCreatedData for message ID: %s", msg.Id)
-	}
-
+func (h *ApproveSellerHandler) Handle(ctx context.Context, data *pb.SellerApprovedData, meta messaging.Meta) error {
 	userIDUUID, err := uuid.Parse(data.UserId)
 	if err != nil {
-		return fmt.Errorf("invalid user id format: %w", err)
+		return messaging.Permanent(fmt.Errorf("invalid user id format: %w", err))
 	}
 
-	return h.usecase.Execute(ctx, userIDUUID)
+	return retryLater(h.usecase.Execute(ctx, userIDUUID))
 }
@@ -0,0 +1,28 @@
+// internal/notification-service/transport/messaging/controller/errors.go
+package controller
+
+import (
+	"errors"
+	"time"
+
+	"marketplace/internal/notification-service/domain"
+	"marketplace/pkg/messaging"
+)
+
+// defaultRateLimitDelay, e-posta sağlayıcısı Retry-After göndermediğinde beklenecek süredir.
+const defaultRateLimitDelay = 30 * time.Second
+
+// retryLater, e-posta sağlayıcısının rate limit hatasını messaging.RetryAfter ile sarar.
+// Neden? Rate limit birkaç saniye içinde kalkar; backoff kademeleriyle beklemek ya gereksiz
+// geç kalır ya da limit kalkmadan denemeyi tüketir. Diğer hatalar aynen döner.
+func retryLater(err error) error {
+	var rateLimited *domain.RateLimitedError
+	if !errors.As(err, &rateLimited) {
+		return err
+	}
+	delay := rateLimited.RetryAfter
+	if delay <= 0 {
+		delay = defaultRateLimitDelay
+	}
+	return messaging.RetryAfter(delay, err)
+}
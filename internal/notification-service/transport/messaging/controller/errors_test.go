@@ -0,0 +1,61 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"marketplace/internal/notification-service/domain"
+	"marketplace/pkg/messaging"
+	pb "marketplace/pkg/proto/events"
+
+	"github.com/google/uuid"
+)
+
+type stubOrderCreated struct{ err error }
+
+func (s stubOrderCreated) Execute(context.Context, uuid.UUID, uuid.UUID, float64) error {
+	return s.err
+}
+
+func TestHandlerRetriesRateLimitedEmailAfterProviderDelay(t *testing.T) {
+	errSMTP := errors.New("resend error: 500")
+	rateLimited := func(d time.Duration) error {
+		// Usecase'ler sağlayıcı hatasını %w ile sarar.
+		return fmt.Errorf("failed to send create order email: %w", &domain.RateLimitedError{RetryAfter: d, Err: errSMTP})
+	}
+
+	tests := []struct {
+		name      string
+		err       error
+		wantDelay time.Duration // 0 ise hata RetryAfter ile sarılmamalı
+	}{
+		{name: "success", err: nil},
+		{name: "other errors are returned as is", err: errSMTP},
+		{name: "provider delay is kept", err: rateLimited(12 * time.Second), wantDelay: 12 * time.Second},
+		{name: "missing provider delay falls back to the default", err: rateLimited(0), wantDelay: defaultRateLimitDelay},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewOrderCreatedHandler(stubOrderCreated{err: tt.err})
+			data := &pb.OrderCreatedData{UserId: uuid.NewString(), OrderId: uuid.NewString(), TotalPrice: 10}
+
+			err := h.Handle(context.Background(), data, messaging.Meta{})
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("Handle error = %v, want %v in the chain", err, tt.err)
+			}
+
+			var transient *messaging.TransientError
+			gotDelay := time.Duration(0)
+			if errors.As(err, &transient) {
+				gotDelay = transient.Delay
+			}
+			if gotDelay != tt.wantDelay {
+				t.Fatalf("retry delay = %v, want %v", gotDelay, tt.wantDelay)
+			}
+		})
+	}
+}
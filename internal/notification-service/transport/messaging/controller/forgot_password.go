@@ -5,6 +5,7 @@ import (
 	"context"
 	"fmt"
 	"marketplace/internal/notification-service/transport/messaging/usecase"
+	"marketplace/pkg/messaging"
 	pb "marketplace/pkg/proto/events"
 
 	"github.com/google/uuid"
@@ -20,17 +21,12 @@ func NewForgotPasswordHandler(usecase usecase.ForgotPasseordUseCase) *ForgotPass
 	}
 }
 
-func (h *ForgotPasswordHandler) Handle(ctx context.Context, msg *pb.Message) error {
-	data := msg.GetUserForgotPasswordData()
-	if data == nil {
-		return fmt.Errorf("payload is  nill or not UserForgotPasswordData  for message ID %s", msg.Id)
-	}
-
+func (h *ForgotPasswordHandler) Handle(ctx context.Context, data *pb.UserForgotPasswordData, meta messaging.Meta) error {
 	userIDUUID, err := uuid.Parse(data.UserId)
 	if err != nil {
-		return fmt.Errorf("invalid user ID: %w", err)
+		return messaging.Permanent(fmt.Errorf("invalid user ID: %w", err))
 	}
 
-	return h.usecase.Execute(ctx, userIDUUID, data.Token)
+	return retryLater(h.usecase.Execute(ctx, userIDUUID, data.Token))
 
 }
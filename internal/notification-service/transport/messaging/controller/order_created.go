@@ -7,6 +7,7 @@ import (
 	"fmt"
 
 	"marketplace/internal/notification-service/transport/messaging/usecase"
+	"marketplace/pkg/messaging"
 	pb "marketplace/pkg/proto/events"
 
 	"github.com/google/uuid"
@@ -22,23 +23,17 @@ func NewOrderCreatedHandler(usecase usecase.OrderCreatedUseCase) *OrderCreatedHa
 	}
 }
 
-func (h *OrderCreatedHandler) Handle(ctx context.Context, msg *pb.Message) error {
-
-	data := msg.GetOrderCreatedData()
-	if data == nil {
-		return fmt.Errorf("payload is nil or not OrderCreatedData for message ID: %s", msg.Id)
-	}
-
+func (h *OrderCreatedHandler) Handle(ctx context.Context, data *pb.OrderCreatedData, meta messaging.Meta) error {
 	userIDUUID, err := uuid.Parse(data.UserId)
 	if err != nil {
-		return fmt.Errorf("invalid user id format: %w", err)
+		return messaging.Permanent(fmt.Errorf("invalid user id format: %w", err))
 	}
 
 	orderIDUUID, err := uuid.Parse(data.OrderId)
 	if err != nil {
-		return fmt.Errorf("invalid order id format: %w", err)
+		return messaging.Permanent(fmt.Errorf("invalid order id format: %w", err))
 	}
 	totalPrice := data.TotalPrice
 
-	return h.usecase.Execute(ctx, userIDUUID, orderIDUUID, totalPrice)
+	return retryLater(h.usecase.Execute(ctx, userIDUUID, orderIDUUID, totalPrice))
 }
@@ -7,6 +7,7 @@ import (
 	"fmt"
 
 	"marketplace/internal/notification-service/transport/messaging/usecase"
+	"marketplace/pkg/messaging"
 	eventsProto "marketplace/pkg/proto/events"
 
 	"github.com/google/uuid"
@@ -22,21 +23,15 @@ func NewPaymentSuccessHandler(usecase usecase.PaymentSuccessUseCase) *PaymentSuc
 	}
 }
 
-func (h *PaymentSuccessHandler) Handle(ctx context.Context, msg *eventsProto.Message) error {
-
-	data := msg.GetPaymentSuccessfulData()
-	if data == nil {
-		return fmt.Errorf("payload is nil or not PaymentSuccessfulData for message ID: %s", msg.Id)
-	}
-
+func (h *PaymentSuccessHandler) Handle(ctx context.Context, data *eventsProto.PaymentSuccessfulData, meta messaging.Meta) error {
 	orderIDUUID, err := uuid.Parse(data.OrderId)
 	if err != nil {
-		return fmt.Errorf("invalid order id format: %w", err)
+		return messaging.Permanent(fmt.Errorf("invalid order id format: %w", err))
 	}
 	userIDUUID, err := uuid.Parse(data.UserId)
 	if err != nil {
-		return fmt.Errorf("Invalid user id format:%w", err)
+		return messaging.Permanent(fmt.Errorf("Invalid user id format:%w", err))
 	}
 
-	return h.usecase.Execute(ctx, orderIDUUID, userIDUUID, data.Amount)
+	return retryLater(h.usecase.Execute(ctx, orderIDUUID, userIDUUID, data.Amount))
 }
@@ -7,6 +7,7 @@ import (
 	"fmt"
 
 	"marketplace/internal/notification-service/transport/messaging/usecase"
+	"marketplace/pkg/messaging"
 	pb "marketplace/pkg/proto/events"
 
 	"github.com/google/uuid"
@@ -22,19 +23,13 @@ func NewRejectSellerHandler(usecase usecase.RejectSellerUseCase) *RejectSellerHa
 	}
 }
 
-func (h *RejectSellerHandler) Handle(ctx context.Context, msg *pb.Message) error {
-
-	data := msg.GetSellerRejectedData()
-	if data == nil {
-		return fmt.Errorf("payload is nil or not OrderCreatedData for message ID: %s", msg.Id)
-	}
-
+func (h *RejectSellerHandler) Handle(ctx context.Context, data *pb.SellerRejectedData, meta messaging.Meta) error {
 	userIDUUID, err := uuid.Parse(data.SellerId)
 	if err != nil {
-		return fmt.Errorf("invalid user id format: %w", err)
+		return messaging.Permanent(fmt.Errorf("invalid user id format: %w", err))
 	}
 
 	reason := data.Reason
 
-	return h.usecase.Execute(ctx, userIDUUID, reason)
+	return retryLater(h.usecase.Execute(ctx, userIDUUID, reason))
 }
@@ -7,6 +7,7 @@ import (
 	"fmt"
 
 	"marketplace/internal/notification-service/transport/messaging/usecase"
+	"marketplace/pkg/messaging"
 	pb "marketplace/pkg/proto/events"
 
 	"github.com/google/uuid"
@@ -22,13 +23,7 @@ func NewUserActivationHandler(usecase usecase.UserActivationUseCase) *UserActiva
 	}
 }
 
-func (h *UserActivationHandler) Handle(ctx context.Context, msg *pb.Message) error {
-
-	data := msg.GetUserActivationEmailData()
-	if data == nil {
-		return fmt.Errorf("payload is nil or not UserActivationEmailData for message ID: %s", msg.Id)
-	}
-
+func (h *UserActivationHandler) Handle(ctx context.Context, data *pb.UserActivationEmailData, meta messaging.Meta) error {
 	userEmail := data.Email
 	userName := data.Username
 	userActivationCode := data.ActivationCode
@@ -36,7 +31,7 @@ func (h *UserActivationHandler) Handle(ctx context.Context, msg *pb.Message) err
 
 	activationIDUUID, err := uuid.Parse(data.ActivationId)
 	if err != nil {
-		return fmt.Errorf("invalid activation id format: %w", err)
+		return messaging.Permanent(fmt.Errorf("invalid activation id format: %w", err))
 	}
-	return h.usecase.Execute(ctx, activationIDUUID, userEmail, userName, userActivationCode)
+	return retryLater(h.usecase.Execute(ctx, activationIDUUID, userEmail, userName, userActivationCode))
 }
@@ -1,9 +1,12 @@
 package messaginghandler
 
 import (
+	"errors"
+
 	"marketplace/internal/notification-service/domain"
 	"marketplace/internal/notification-service/transport/messaging/controller"
 	"marketplace/internal/notification-service/transport/messaging/usecase"
+	"marketplace/pkg/messaging"
 	pb "marketplace/pkg/proto/events"
 )
 
@@ -11,15 +14,16 @@ type registry struct {
 	email       domain.EmailProvider
 	templateMgr domain.TemplateManager
 	repo        domain.NotificationRepository
-	handlers    map[pb.MessageType]domain.MessageHandler
+	router      *messaging.Router
+	errs        []error
 }
 
-func SetupMessageHandlers(email domain.EmailProvider, templateMgr domain.TemplateManager, repository domain.NotificationRepository) map[pb.MessageType]domain.MessageHandler {
+func SetupMessageHandlers(email domain.EmailProvider, templateMgr domain.TemplateManager, repository domain.NotificationRepository) (*messaging.Router, error) {
 	r := &registry{
 		email:       email,
 		repo:        repository,
 		templateMgr: templateMgr,
-		handlers:    make(map[pb.MessageType]domain.MessageHandler),
+		router:      messaging.NewRouter(),
 	}
 
 	//Grouped registration processes
@@ -28,44 +32,54 @@ func SetupMessageHandlers(email domain.EmailProvider, templateMgr domain.Templat
 	r.registerPaymentHandlers()
 	r.registerSellerHandlers()
 
-	return r.handlers
+	if err := errors.Join(r.errs...); err != nil {
+		return nil, err
+	}
+	return r.router, nil
+}
+
+// collect, kayıt hatalarını biriktirir; hepsi SetupMessageHandlers sonunda tek seferde döner.
+func (r *registry) collect(err error) {
+	if err != nil {
+		r.errs = append(r.errs, err)
+	}
 }
 
 func (r *registry) registerUserHandlers() {
 	// User Activation
 	activationUC := usecase.NewUserActivationUseCase(r.email, r.templateMgr)
-	r.handlers[pb.MessageType_USER_ACTIVATION_EMAIL] = controller.NewUserActivationHandler(activationUC)
+	r.collect(messaging.Handle(r.router, pb.MessageType_USER_ACTIVATION_EMAIL, controller.NewUserActivationHandler(activationUC).Handle))
 
 	// User Created (Sync)
 	createdUC := usecase.NewUserCreatedUseCase(r.repo)
-	r.handlers[pb.MessageType_USER_CREATED] = controller.NewUserCreatedHandler(createdUC)
+	r.collect(messaging.Handle(r.router, pb.MessageType_USER_CREATED, controller.NewUserCreatedHandler(createdUC).Handle))
 
 	// Forgot Password
 	forgotUC := usecase.NewForgotPasswordUseCase(r.email, r.repo, r.templateMgr)
-	r.handlers[pb.MessageType_USER_FORGOT_PASSWORD] = controller.NewForgotPasswordHandler(forgotUC)
+	r.collect(messaging.Handle(r.router, pb.MessageType_USER_FORGOT_PASSWORD, controller.NewForgotPasswordHandler(forgotUC).Handle))
 }
 
 func (r *registry) registerOrderHandlers() {
 	orderUC := usecase.NewOrderCreatedUseCase(r.email, r.repo, r.templateMgr)
-	r.handlers[pb.MessageType_ORDER_CREATED] = controller.NewOrderCreatedHandler(orderUC)
+	r.collect(messaging.Handle(r.router, pb.MessageType_ORDER_CREATED, controller.NewOrderCreatedHandler(orderUC).Handle))
 }
 
 func (r *registry) registerPaymentHandlers() {
 	// Success
 	successUC := usecase.NewPaymentSuccessUseCase(r.repo, r.email, r.templateMgr)
-	r.handlers[pb.MessageType_PAYMENT_SUCCESSFUL] = controller.NewPaymentSuccessHandler(successUC)
+	r.collect(messaging.Handle(r.router, pb.MessageType_PAYMENT_SUCCESSFUL, controller.NewPaymentSuccessHandler(successUC).Handle))
 
 	// Failed
 	failedUC := usecase.NewPaymentFailedUseCase(r.repo, r.email, r.templateMgr)
-	r.handlers[pb.MessageType_PAYMENT_FAILED] = controller.NewPaymentFailedHandler(failedUC)
+	r.collect(messaging.Handle(r.router, pb.MessageType_PAYMENT_FAILED, controller.NewPaymentFailedHandler(failedUC).Handle))
 }
 
 func (r *registry) registerSellerHandlers() {
 	// Reject
 	rejectUC := usecase.NewRejectSellerUseCase(r.email, r.repo, r.templateMgr)
-	r.handlers[pb.MessageType_SELLER_REJECTED] = controller.NewRejectSellerHandler(rejectUC)
+	r.collect(messaging.Handle(r.router, pb.MessageType_SELLER_REJECTED, controller.NewRejectSellerHandler(rejectUC).Handle))
 
 	// Approve
 	approveUC := usecase.NewApproveSellerUseCase(r.email, r.templateMgr, r.repo)
-	r.handlers[pb.MessageType_SELLER_APPROVED] = controller.NewApproveSellerHandler(approveUC)
+	r.collect(messaging.Handle(r.router, pb.MessageType_SELLER_APPROVED, controller.NewApproveSellerHandler(approveUC).Handle))
 }
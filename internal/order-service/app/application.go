@@ -24,10 +24,18 @@ type App struct {
 	server     *server.Server
 	repository domain.OrderRepository
 	consumer   *kafka.Consumer
+	messaging  domain.Messaging
 }
 
+// NewApp, uygulamayı Kafka broker'ı ile kurar.
 func NewApp(cfg config.Config) (*App, error) {
-	container, err := buildContainer(cfg)
+	return NewAppWithBroker(cfg, messaging.NewKafkaBroker)
+}
+
+// NewAppWithBroker, uygulamayı verilen broker factory ile kurar. Üretici ve tüketiciler
+// aynı factory'den bağlanır; testlerde messaging.MemoryBroker.Connect verilerek Kafka gerekmez.
+func NewAppWithBroker(cfg config.Config, connect messaging.BrokerFactory) (*App, error) {
+	container, err := buildContainer(cfg, connect)
 	if err != nil {
 		return nil, fmt.Errorf("bootstrap failed: %w", err)
 	}
@@ -37,6 +45,7 @@ func NewApp(cfg config.Config) (*App, error) {
 		server:     container.server,
 		repository: container.repository,
 		consumer:   container.consumer,
+		messaging:  container.messaging,
 	}, nil
 }
 
@@ -46,12 +55,21 @@ func (a *App) Start() error {
 
 	go graceful.WaitForShutdown(a.server.FiberApp(), 5*time.Second, ctx)
 	go a.consumer.Start(ctx)
+	// Outbox'taki olayları Kafka'ya aktaran relay; Kafka erişilemezken olaylar DB'de bekler.
+	go a.repository.Outbox().Run(ctx, a.messaging)
+	go a.repository.IdempotencyStore().RunCleanup(ctx)
 	log.Printf("starting user-service on %s", a.server.Address())
 
 	if err := a.server.Start(); err != nil {
 		return fmt.Errorf("server exited with error: %w", err)
 	}
 
+	// Consumer'ları durdur; çalışan handler'lar bitip commit'ler yazılana kadar bekle.
+	cancel()
+	if err := a.consumer.Close(); err != nil {
+		log.Printf("kafka consumer close error: %v", err)
+	}
+
 	log.Println("server stopped, closing repository")
 	return a.repository.Close()
 }
@@ -71,9 +89,9 @@ func createMessagingConfig(cfg config.MessagingConfig) messaging.KafkaConfig {
 	kafkaBrokers := []string{broker}
 	return messaging.KafkaConfig{
 		Brokers:              kafkaBrokers,
-		Topic:                "main-events", // Ana olay topic'i
-		RetryTopic:           "main-events-retry",
-		DLQTopic:             "main-events-dlq",
+		Topic:                messaging.DefaultTopic, // Ana olay topic'i
+		RetryTopic:           messaging.DefaultRetryTopic,
+		DLQTopic:             messaging.DefaultDLQTopic,
 		ServiceType:          eventsProto.ServiceType_ORDER_SERVICE,
 		EnableRetry:          true,
 		MaxRetries:           10,
@@ -81,7 +99,7 @@ func createMessagingConfig(cfg config.MessagingConfig) messaging.KafkaConfig {
 		CriticalMessageTypes: []eventsProto.MessageType{eventsProto.MessageType_PAYMENT_SUCCESSFUL},
 	}
 }
-func buildContainer(cfg config.Config) (*container, error) {
+func buildContainer(cfg config.Config, connect messaging.BrokerFactory) (*container, error) {
 	repo, err := postgres.NewRepository(cfg)
 	if err != nil {
 		return nil, fmt.Errorf("init postgres repository: %w", err)
@@ -106,19 +124,23 @@ func buildContainer(cfg config.Config) (*container, error) {
 	}
 
 	messagingConfig := createMessagingConfig(cfg.Messaging)
-	messaging, err := messaging.NewKafkaClient(messagingConfig)
+	messaging, err := connect(messagingConfig)
 	if err != nil {
 		return nil, fmt.Errorf("init kafka messaging: %w", err)
 	}
-	messsagingHnadlers := messaginghandler.SetupMessageHandlers(repo)
-	httpHandlers := httptransport.NewHandlers(repo, grpcProductClient, grpcBasketClient, grpcPaymentClient, messaging)
+	messsagingHnadlers, err := messaginghandler.SetupMessageHandlers(repo)
+	if err != nil {
+		return nil, fmt.Errorf("init message handlers: %w", err)
+	}
+	httpHandlers := httptransport.NewHandlers(repo, grpcProductClient, grpcBasketClient, grpcPaymentClient)
 	router := httptransport.NewRouter(httpHandlers)
-	kafkaConsumer, err := kafka.NewConsumer(cfg.Messaging, messsagingHnadlers)
+	kafkaConsumer, err := kafka.NewConsumerWithBroker(cfg.Messaging, messsagingHnadlers, repo.IdempotencyStore(), repo.CriticalStore(), connect)
 	if err != nil {
 		return nil, fmt.Errorf("init kafka consumer: %w", err)
 	}
 
 	serverCfg := server.Config{
+		Name:         "order-service",
 		Port:         cfg.Server.Port,
 		IdleTimeout:  5 * time.Second,
 		ReadTimeout:  10 * time.Second,
@@ -132,6 +154,6 @@ func buildContainer(cfg config.Config) (*container, error) {
 		server:     httpServer,
 		repository: repo,
 		consumer:   kafkaConsumer,
-		messaging:  kafkaConsumer.Client(),
+		messaging:  messaging,
 	}, nil
 }
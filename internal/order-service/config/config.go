@@ -27,10 +27,18 @@ type DatabaseConfig struct {
 	DB       string `mapstructure:"db"`
 	Host     string `mapstructure:"host"`
 }
+
+// OutboxConfig, outbox relay modunu seçer: "poll" (varsayılan) veya "cdc".
+// cdc modu Postgres'te wal_level=logical gerektirir; kullanılamazsa relay polling'e düşer.
+type OutboxConfig struct {
+	Relay string `mapstructure:"relay"`
+}
+
 type Config struct {
 	Server    ServerConfig    `mapstructure:"server"`
 	Database  DatabaseConfig  `mapstructure:"database"`
 	Messaging MessagingConfig `mapstructure:"messaging"`
+	Outbox    OutboxConfig    `mapstructure:"outbox"`
 }
 type MessagingConfig struct {
 	Brokers []string `mapstructure:"brokers"`
@@ -65,6 +73,7 @@ func Read() Config {
 	v.SetDefault("database.password", "password")
 	v.SetDefault("database.db", "marketplace")
 	v.SetDefault("messaging.brokers", []string{"localhost:9092"})
+	v.SetDefault("outbox.relay", "poll")
 
 	var cfg Config
 	if err := v.Unmarshal(&cfg); err != nil {
@@ -2,13 +2,21 @@ package domain
 
 import (
 	"context"
+	"marketplace/pkg/messaging"
+	"marketplace/pkg/outbox"
+	pb "marketplace/pkg/proto/events"
 
 	"github.com/google/uuid"
 )
 
 type OrderRepository interface {
-	CreateOrder(ctx context.Context, order *Order) error
+	// CreateOrder, verilen olayları siparişle aynı transaction'da outbox'a yazar.
+	CreateOrder(ctx context.Context, order *Order, events ...*pb.Message) error
 	UpdateOrderStatus(ctx context.Context, orderID uuid.UUID, status OrderStatus) error
 	GetOrdersByUserID(ctx context.Context, userID uuid.UUID) ([]Order, error)
+	// IdempotencyStore, işlenmiş mesaj kayıtlarıdır; eski kayıtlar RunCleanup ile silinir.
+	IdempotencyStore() *messaging.PostgresIdempotencyStore
+	CriticalStore() messaging.CriticalStore
+	Outbox() *outbox.Outbox
 	Close() error
 }
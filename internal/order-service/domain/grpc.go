@@ -21,5 +21,6 @@ type ProductClient interface {
 }
 type PaymentClient interface {
 	CreatePaymentSession(ctx context.Context, orderID, userID, email string, amount float64) (*pPayment.CreatePaymentResponse, error)
+	ExpirePaymentSession(ctx context.Context, sessionID string) error
 	Close() error
 }
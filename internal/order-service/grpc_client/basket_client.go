@@ -7,6 +7,7 @@ import (
 
 	"marketplace/internal/order-service/domain"
 	pb "marketplace/pkg/proto/basket"
+	"marketplace/pkg/tracing"
 
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
@@ -22,7 +23,7 @@ type basketClient struct {
 
 func NewBasketClient(grpcAddress string) (domain.BasketClient, error) {
 
-	conn, err := grpc.Dial(grpcAddress, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	conn, err := grpc.Dial(grpcAddress, grpc.WithTransportCredentials(insecure.NewCredentials()), tracing.DialOption())
 	if err != nil {
 		return nil, err
 	}
@@ -7,6 +7,7 @@ import (
 
 	"marketplace/internal/order-service/domain"
 	pPayment "marketplace/pkg/proto/payment"
+	"marketplace/pkg/tracing"
 
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
@@ -22,7 +23,7 @@ type paymentClient struct {
 
 func NewPaymentClient(grpcAddress string) (domain.PaymentClient, error) {
 
-	paymentConn, err := grpc.Dial(grpcAddress, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	paymentConn, err := grpc.Dial(grpcAddress, grpc.WithTransportCredentials(insecure.NewCredentials()), tracing.DialOption())
 	if err != nil {
 		return nil, err
 	}
@@ -45,6 +46,11 @@ func (c *paymentClient) CreatePaymentSession(ctx context.Context, orderID, userI
 	return c.client.CreatePaymentSession(ctx, req)
 }
 
+func (c *paymentClient) ExpirePaymentSession(ctx context.Context, sessionID string) error {
+	_, err := c.client.ExpirePaymentSession(ctx, &pPayment.ExpirePaymentSessionRequest{SessionId: sessionID})
+	return err
+}
+
 func (c *paymentClient) Close() error {
 	return c.conn.Close()
 }
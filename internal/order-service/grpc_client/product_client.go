@@ -8,6 +8,7 @@ import (
 	"marketplace/internal/order-service/domain"
 	cp "marketplace/pkg/proto/common"
 	pb "marketplace/pkg/proto/product"
+	"marketplace/pkg/tracing"
 
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
@@ -23,7 +24,7 @@ type productClient struct {
 
 func NewProductClient(grpcAddress string) (domain.ProductClient, error) {
 
-	productConn, err := grpc.Dial(grpcAddress, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	productConn, err := grpc.Dial(grpcAddress, grpc.WithTransportCredentials(insecure.NewCredentials()), tracing.DialOption())
 	if err != nil {
 		return nil, err
 	}
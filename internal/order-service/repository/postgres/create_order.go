@@ -4,9 +4,10 @@ import (
 	"context"
 	"fmt"
 	"marketplace/internal/order-service/domain"
+	pb "marketplace/pkg/proto/events"
 )
 
-func (r *Repository) CreateOrder(ctx context.Context, order *domain.Order) error {
+func (r *Repository) CreateOrder(ctx context.Context, order *domain.Order, events ...*pb.Message) error {
 
 	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
@@ -45,6 +46,12 @@ func (r *Repository) CreateOrder(ctx context.Context, order *domain.Order) error
 		}
 	}
 
+	for _, event := range events {
+		if err := r.outbox.Add(ctx, tx, event); err != nil {
+			return err
+		}
+	}
+
 	if err := tx.Commit(); err != nil {
 		return fmt.Errorf("failed to commit transaction: %w", err)
 	}
@@ -3,6 +3,7 @@ package postgres
 import (
 	"context"
 	"fmt"
+	"marketplace/pkg/messaging"
 	"marketplace/internal/order-service/domain"
 
 	"github.com/google/uuid"
@@ -20,10 +21,13 @@ func (r *Repository) UpdateOrderStatus(ctx context.Context, orderID uuid.UUID, s
         SET status = $1, updated_at = CURRENT_TIMESTAMP 
         WHERE id = $2`
 
-	_, err = tx.ExecContext(ctx, updateOrderQuery, status, orderID)
+	res, err := tx.ExecContext(ctx, updateOrderQuery, status, orderID)
 	if err != nil {
 		return fmt.Errorf("failed to update order status: %w", err)
 	}
+	if affected, err := res.RowsAffected(); err == nil && affected == 0 {
+		return domain.ErrOrderNotFound
+	}
 
 
 	const updateItemsQuery = `
@@ -36,5 +40,10 @@ func (r *Repository) UpdateOrderStatus(ctx context.Context, orderID uuid.UUID, s
 		return fmt.Errorf("failed to update order items status: %w", err)
 	}
 
+	// Kafka mesajından geliyorsak "işlendi" kaydı bu transaction ile birlikte commit edilir.
+	if err := messaging.MarkProcessedInTx(ctx, tx); err != nil {
+		return err
+	}
+
 	return tx.Commit()
 }
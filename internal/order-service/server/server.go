@@ -5,6 +5,9 @@ import (
 	"fmt"
 	"log"
 	"marketplace/internal/order-service/domain"
+	"marketplace/pkg/discovery"
+	"marketplace/pkg/metrics"
+	"marketplace/pkg/tracing"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
@@ -17,6 +20,8 @@ type RouteRegistrar interface {
 }
 
 type Config struct {
+	// Name, servisin gateway.yaml'daki adıdır; self-registration bu adla yapılır.
+	Name         string
 	GrpcPort     string
 	Port         string
 	IdleTimeout  time.Duration
@@ -29,6 +34,7 @@ type Server struct {
 	cfg               Config
 	grpcBasketClient  domain.BasketClient
 	grpcProductClient domain.ProductClient
+	announcer         *discovery.Announcer
 }
 
 func New(cfg Config, registrar RouteRegistrar, grpcBasketClient domain.BasketClient, grpcProductClient domain.ProductClient) *Server {
@@ -46,10 +52,12 @@ func New(cfg Config, registrar RouteRegistrar, grpcBasketClient domain.BasketCli
 		AllowCredentials: true,
 	}))
 	app.Use(requestid.New())
+	app.Use(tracing.Middleware())
 
 	app.Get("/health", func(c *fiber.Ctx) error {
 		return c.JSON(fiber.Map{"status": "UP"})
 	})
+	app.Get("/metrics", metrics.Handler(metrics.Default))
 
 	if registrar != nil {
 		registrar.Register(app)
@@ -64,6 +72,23 @@ func New(cfg Config, registrar RouteRegistrar, grpcBasketClient domain.BasketCli
 }
 
 func (s *Server) Start() error {
+	// Self-registration: DISCOVERY_REDIS_ADDR tanımlıysa instance, HTTP sunucusu dinlemeye
+	// başlayınca Redis'e kaydedilir ve kapanırken kaydı silinir (bkz. pkg/discovery).
+	announcer, err := discovery.FromEnv(s.cfg.Name, s.cfg.Port)
+	if err != nil {
+		return fmt.Errorf("discovery: %w", err)
+	}
+	s.announcer = announcer
+	s.app.Hooks().OnListen(func(fiber.ListenData) error {
+		s.announcer.Start()
+		return nil
+	})
+	s.app.Hooks().OnShutdown(func() error {
+		s.announcer.Stop()
+		return nil
+	})
+	defer s.announcer.Stop()
+
 	log.Printf("🌐 HTTP sunucusu %s adresinde dinliyor...", s.cfg.Port)
 	return s.app.Listen(s.Address())
 }
@@ -14,16 +14,14 @@ type Handlers struct {
 	grpcProductClient domain.ProductClient
 	grpcBasketClient  domain.BasketClient
 	grpcPaymentClient domain.PaymentClient
-	messaging         domain.Messaging
 }
 
-func NewHandlers(repo domain.OrderRepository, grpcProductClient domain.ProductClient, grpcBasketClient domain.BasketClient, grpcPaymentClient domain.PaymentClient, messaging domain.Messaging) *Handlers {
+func NewHandlers(repo domain.OrderRepository, grpcProductClient domain.ProductClient, grpcBasketClient domain.BasketClient, grpcPaymentClient domain.PaymentClient) *Handlers {
 	return &Handlers{
 		orderRepository:   repo,
 		grpcProductClient: grpcProductClient,
 		grpcBasketClient:  grpcBasketClient,
 		grpcPaymentClient: grpcPaymentClient,
-		messaging:         messaging,
 	}
 }
 
@@ -37,7 +35,7 @@ func (h *Handlers) Hello(c *fiber.Ctx) error {
 }
 
 func (h *Handlers) CreateOrder() *controller.CreateOrderController {
-	usecase := usecase.NewCreateOrderUseCase(h.orderRepository, h.grpcProductClient, h.grpcBasketClient, h.grpcPaymentClient, h.messaging)
+	usecase := usecase.NewCreateOrderUseCase(h.orderRepository, h.grpcProductClient, h.grpcBasketClient, h.grpcPaymentClient)
 	return controller.NewCreateOrderController(usecase)
 }
 
@@ -3,14 +3,19 @@ package usecase
 import (
 	"context"
 	"fmt"
+	"log"
 	"marketplace/internal/order-service/domain"
 	cp "marketplace/pkg/proto/common"
 	eventsProto "marketplace/pkg/proto/events"
 	pp "marketplace/pkg/proto/product"
+	"time"
 
 	"github.com/google/uuid"
 )
 
+// expireSessionTimeout, sipariş kaydedilemediğinde ödeme oturumunu kapatmak için beklenen süredir.
+const expireSessionTimeout = 5 * time.Second
+
 type CreateOrderUseCase interface {
 	Execute(ctx context.Context, userID uuid.UUID) (string, error)
 }
@@ -20,16 +25,14 @@ type createOrderUseCase struct {
 	grpcProductClient domain.ProductClient
 	grpcBasketClient  domain.BasketClient
 	grpcPaymentClient domain.PaymentClient
-	messaging         domain.Messaging
 }
 
-func NewCreateOrderUseCase(orderRepository domain.OrderRepository, grpcProductClient domain.ProductClient, grpcBasketClient domain.BasketClient, grpcPaymentClient domain.PaymentClient, messaging domain.Messaging) CreateOrderUseCase {
+func NewCreateOrderUseCase(orderRepository domain.OrderRepository, grpcProductClient domain.ProductClient, grpcBasketClient domain.BasketClient, grpcPaymentClient domain.PaymentClient) CreateOrderUseCase {
 	return &createOrderUseCase{
 		orderRepository:   orderRepository,
 		grpcProductClient: grpcProductClient,
 		grpcBasketClient:  grpcBasketClient,
 		grpcPaymentClient: grpcPaymentClient,
-		messaging:         messaging,
 	}
 }
 
@@ -90,17 +93,10 @@ func (u *createOrderUseCase) Execute(ctx context.Context, userID uuid.UUID) (str
 		Items:      orderItems,
 	}
 
-	if err := u.orderRepository.CreateOrder(ctx, newOrder); err != nil {
-
-		return "", fmt.Errorf("failed to save order: %v", err)
-	}
-
-	payment, err := u.grpcPaymentClient.CreatePaymentSession(ctx, orderID.String(), userID.String(), "user@mail.com", totalPrice)
-	if err != nil {
-		return "", err
-	}
-
+	// ORDER_CREATED olayı siparişle aynı transaction'da outbox'a yazılır.
+	// Neden? Sipariş kaydedilip olay kaybolursa stok rezervasyonu hiç onaylanmıyordu.
 	msg := &eventsProto.Message{
+		Id:          uuid.New().String(),
 		Type:        eventsProto.MessageType_ORDER_CREATED,
 		FromService: eventsProto.ServiceType_ORDER_SERVICE,
 		Critical:    true,
@@ -115,7 +111,30 @@ func (u *createOrderUseCase) Execute(ctx context.Context, userID uuid.UUID) (str
 			},
 		},
 	}
-	u.messaging.PublishMessage(ctx, msg)
+
+	// Ödeme oturumu siparişten önce açılır. Neden? Sipariş ve ORDER_CREATED commit edildikten sonra
+	// oturum açılamazsa kullanıcı hata alır ama olay yayınlanır; stok ödemesi hiç yapılamayacak
+	// bir sipariş için ayrılır. Sipariş kaydedilemezse oturum kapatılır (bkz. expirePaymentSession).
+	payment, err := u.grpcPaymentClient.CreatePaymentSession(ctx, orderID.String(), userID.String(), "user@mail.com", totalPrice)
+	if err != nil {
+		return "", err
+	}
+
+	if err := u.orderRepository.CreateOrder(ctx, newOrder, msg); err != nil {
+		u.expirePaymentSession(ctx, payment.SessionId, orderID)
+		return "", fmt.Errorf("failed to save order: %v", err)
+	}
 
 	return payment.PaymentUrl, nil
 }
+
+// expirePaymentSession, kaydedilemeyen siparişin ödeme oturumunu kapatır.
+// Neden? Oturum açık kalırsa kullanıcı linkten, var olmayan bir sipariş için ödeme yapabilir.
+// İstek iptal edilmiş olsa bile denenir; başarısız olursa oturum kendi süresi dolunca kapanır.
+func (u *createOrderUseCase) expirePaymentSession(ctx context.Context, sessionID string, orderID uuid.UUID) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), expireSessionTimeout)
+	defer cancel()
+	if err := u.grpcPaymentClient.ExpirePaymentSession(ctx, sessionID); err != nil {
+		log.Printf("⚠ failed to expire payment session [order=%s, session=%s]: %v", orderID, sessionID, err)
+	}
+}
@@ -0,0 +1,104 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"marketplace/internal/order-service/domain"
+	pb "marketplace/pkg/proto/basket"
+	cp "marketplace/pkg/proto/common"
+	eventsProto "marketplace/pkg/proto/events"
+	pPayment "marketplace/pkg/proto/payment"
+	pp "marketplace/pkg/proto/product"
+
+	"github.com/google/uuid"
+)
+
+type stubBasket struct{ domain.BasketClient }
+
+func (stubBasket) GetBasket(_ context.Context, userID string) (*pb.BasketResponse, error) {
+	return &pb.BasketResponse{
+		UserId: userID,
+		Items:  []*pb.BasketItem{{ProductId: uuid.NewString(), Quantity: 1, Price: 10}},
+	}, nil
+}
+
+type stubProducts struct{ domain.ProductClient }
+
+func (stubProducts) ReserveStock(_ context.Context, _ string, items []*cp.OrderItemData) (*pp.ReserveStockResponse, error) {
+	resp := &pp.ReserveStockResponse{Success: true}
+	for _, item := range items {
+		resp.Products = append(resp.Products, &pp.ProductResponse{Id: item.ProductId, Price: 10, SellerId: uuid.NewString()})
+	}
+	return resp, nil
+}
+
+// recordingPayments, açılan ve kapatılan ödeme oturumlarını kaydeder.
+type recordingPayments struct {
+	domain.PaymentClient
+	expired   []string
+	expireErr error
+}
+
+func (p *recordingPayments) CreatePaymentSession(_ context.Context, orderID, _, _ string, _ float64) (*pPayment.CreatePaymentResponse, error) {
+	return &pPayment.CreatePaymentResponse{PaymentUrl: "https://checkout.test/" + orderID, SessionId: "cs_test_1"}, nil
+}
+
+func (p *recordingPayments) ExpirePaymentSession(ctx context.Context, sessionID string) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	p.expired = append(p.expired, sessionID)
+	return p.expireErr
+}
+
+type stubOrders struct {
+	domain.OrderRepository
+	err error
+}
+
+func (r stubOrders) CreateOrder(context.Context, *domain.Order, ...*eventsProto.Message) error {
+	return r.err
+}
+
+func TestCreateOrderExpiresPaymentSessionWhenOrderIsNotSaved(t *testing.T) {
+	errDB := errors.New("connection reset")
+
+	tests := []struct {
+		name        string
+		saveErr     error
+		expireErr   error
+		wantExpired []string
+	}{
+		{name: "saved order keeps its session"},
+		{name: "unsaved order expires the session", saveErr: errDB, wantExpired: []string{"cs_test_1"}},
+		{name: "expire failure keeps the save error", saveErr: errDB, expireErr: errors.New("stripe down"), wantExpired: []string{"cs_test_1"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payments := &recordingPayments{expireErr: tt.expireErr}
+			uc := NewCreateOrderUseCase(stubOrders{err: tt.saveErr}, stubProducts{}, stubBasket{}, payments)
+
+			// İstek iptal edilmiş olsa bile oturum kapatılmalı.
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if tt.saveErr != nil {
+				cancel()
+			}
+
+			url, err := uc.Execute(ctx, uuid.New())
+			if (err != nil) != (tt.saveErr != nil) {
+				t.Fatalf("Execute error = %v, want save error %v", err, tt.saveErr)
+			}
+			if tt.saveErr == nil && url == "" {
+				t.Fatal("Execute returned no payment url")
+			}
+			if !reflect.DeepEqual(payments.expired, tt.wantExpired) {
+				t.Fatalf("expired sessions = %v, want %v", payments.expired, tt.wantExpired)
+			}
+		})
+	}
+}
@@ -2,10 +2,13 @@ package controller
 
 import (
 	"context"
+	"errors"
 
 	"fmt"
 
+	"marketplace/internal/order-service/domain"
 	"marketplace/internal/order-service/transport/messaging/usecase"
+	"marketplace/pkg/messaging"
 	eventsProto "marketplace/pkg/proto/events"
 
 	"github.com/google/uuid"
@@ -21,25 +24,24 @@ func NewPaymentFailureHandler(usecase usecase.PaymentFailureUseCase) *PaymentFai
 	}
 }
 
-func (h *PaymentFailureHandler) Handle(ctx context.Context, msg *eventsProto.Message) error {
-
-	data := msg.GetPaymentFailedData()
-	if data == nil {
-		return fmt.Errorf("payload is nil or not PaymentFailedData for message ID: %s", msg.Id)
-	}
-
+func (h *PaymentFailureHandler) Handle(ctx context.Context, data *eventsProto.PaymentFailedData, meta messaging.Meta) error {
 	orderIDUUID, err := uuid.Parse(data.OrderId)
 	if err != nil {
-		return fmt.Errorf("invalid order id format: %w", err)
+		return messaging.Permanent(fmt.Errorf("invalid order id format: %w", err))
 	}
 	userIDUUID, err := uuid.Parse(data.UserId)
 	if err != nil {
-		return fmt.Errorf("invalid seller user id format: %w", err)
+		return messaging.Permanent(fmt.Errorf("invalid seller user id format: %w", err))
 	}
 	errorMessage := data.ErrorMessage
 	if errorMessage == "" {
-		return fmt.Errorf("invalid error message format: %w", err)
+		return messaging.Permanent(fmt.Errorf("invalid error message format: %w", err))
 	}
 
-	return h.usecase.Execute(ctx, orderIDUUID, userIDUUID, errorMessage)
+	err = h.usecase.Execute(ctx, orderIDUUID, userIDUUID, errorMessage)
+	// Bilinmeyen sipariş tekrar denemekle ortaya çıkmaz; retry etmeden DLQ'ya gitsin.
+	if errors.Is(err, domain.ErrOrderNotFound) {
+		return messaging.Permanent(err)
+	}
+	return err
 }
@@ -2,10 +2,13 @@ package controller
 
 import (
 	"context"
+	"errors"
 
 	"fmt"
 
+	"marketplace/internal/order-service/domain"
 	"marketplace/internal/order-service/transport/messaging/usecase"
+	"marketplace/pkg/messaging"
 	eventsProto "marketplace/pkg/proto/events"
 
 	"github.com/google/uuid"
@@ -21,29 +24,28 @@ func NewPaymentSuccessHandler(usecase usecase.PaymentSuccessUseCase) *PaymentSuc
 	}
 }
 
-func (h *PaymentSuccessHandler) Handle(ctx context.Context, msg *eventsProto.Message) error {
-
-	data := msg.GetPaymentSuccessfulData()
-	if data == nil {
-		return fmt.Errorf("payload is nil or not PaymentSuccessfulData for message ID: %s", msg.Id)
-	}
-
+func (h *PaymentSuccessHandler) Handle(ctx context.Context, data *eventsProto.PaymentSuccessfulData, meta messaging.Meta) error {
 	orderIDUUID, err := uuid.Parse(data.OrderId)
 	if err != nil {
-		return fmt.Errorf("invalid order id format: %w", err)
+		return messaging.Permanent(fmt.Errorf("invalid order id format: %w", err))
 	}
 	userIDUUID, err := uuid.Parse(data.UserId)
 	if err != nil {
-		return fmt.Errorf("invalid seller user id format: %w", err)
+		return messaging.Permanent(fmt.Errorf("invalid seller user id format: %w", err))
 	}
 	amount := data.Amount
 	if amount <= 0 {
-		return fmt.Errorf("invalid amount format: %w", err)
+		return messaging.Permanent(fmt.Errorf("invalid amount format: %w", err))
 	}
 	stripeSessionId := data.StripeSessionId
 	if stripeSessionId == "" {
-		return fmt.Errorf("invalid stripe session id format: %w", err)
+		return messaging.Permanent(fmt.Errorf("invalid stripe session id format: %w", err))
 	}
 
-	return h.usecase.Execute(ctx, orderIDUUID, userIDUUID, amount, stripeSessionId)
+	err = h.usecase.Execute(ctx, orderIDUUID, userIDUUID, amount, stripeSessionId)
+	// Bilinmeyen sipariş tekrar denemekle ortaya çıkmaz; retry etmeden DLQ'ya gitsin.
+	if errors.Is(err, domain.ErrOrderNotFound) {
+		return messaging.Permanent(err)
+	}
+	return err
 }
@@ -2,16 +2,19 @@
 package messaginghandler
 
 import (
+	"errors"
+
 	"marketplace/internal/order-service/domain"
 	"marketplace/internal/order-service/transport/messaging/controller"
 	"marketplace/internal/order-service/transport/messaging/usecase"
+	"marketplace/pkg/messaging"
 
 	eventsProto "marketplace/pkg/proto/events"
 )
 
 type Handlers struct {
-	PaymentSuccess domain.MessageHandler
-	PaymentFailure domain.MessageHandler
+	PaymentSuccess *controller.PaymentSuccessHandler
+	PaymentFailure *controller.PaymentFailureHandler
 }
 
 func NewMessageHandlers(repository domain.OrderRepository) *Handlers {
@@ -25,10 +28,16 @@ func NewMessageHandlers(repository domain.OrderRepository) *Handlers {
 	}
 }
 
-func SetupMessageHandlers(repository domain.OrderRepository) map[eventsProto.MessageType]domain.MessageHandler {
+func SetupMessageHandlers(repository domain.OrderRepository) (*messaging.Router, error) {
 	h := NewMessageHandlers(repository)
-	return map[eventsProto.MessageType]domain.MessageHandler{
-		eventsProto.MessageType_PAYMENT_SUCCESSFUL: h.PaymentSuccess,
-		eventsProto.MessageType_PAYMENT_FAILED:     h.PaymentFailure,
+	router := messaging.NewRouter()
+
+	err := errors.Join(
+		messaging.Handle(router, eventsProto.MessageType_PAYMENT_SUCCESSFUL, h.PaymentSuccess.Handle),
+		messaging.Handle(router, eventsProto.MessageType_PAYMENT_FAILED, h.PaymentFailure.Handle),
+	)
+	if err != nil {
+		return nil, err
 	}
+	return router, nil
 }
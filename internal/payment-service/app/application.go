@@ -21,23 +21,32 @@ import (
 )
 
 type App struct {
-	cfg      config.Config
-	server   *server.Server
-	consumer *kafka.Consumer
-	repo     domain.PaymentRepository // ✅ Repo eklenmeli (Close için)
+	cfg       config.Config
+	server    *server.Server
+	consumer  *kafka.Consumer
+	repo      domain.PaymentRepository // ✅ Repo eklenmeli (Close için)
+	messaging domain.Messaging
 }
 
+// NewApp, uygulamayı Kafka broker'ı ile kurar.
 func NewApp(cfg config.Config) (*App, error) {
-	container, err := buildContainer(cfg)
+	return NewAppWithBroker(cfg, messaging.NewKafkaBroker)
+}
+
+// NewAppWithBroker, uygulamayı verilen broker factory ile kurar. Üretici ve tüketiciler
+// aynı factory'den bağlanır; testlerde messaging.MemoryBroker.Connect verilerek Kafka gerekmez.
+func NewAppWithBroker(cfg config.Config, connect messaging.BrokerFactory) (*App, error) {
+	container, err := buildContainer(cfg, connect)
 	if err != nil {
 		return nil, fmt.Errorf("bootstrap failed: %w", err)
 	}
 
 	return &App{
-		cfg:      cfg,
-		server:   container.server,
-		consumer: container.consumer,
-		repo:     container.repo,
+		cfg:       cfg,
+		server:    container.server,
+		consumer:  container.consumer,
+		repo:      container.repo,
+		messaging: container.messaging,
 	}, nil
 }
 
@@ -46,6 +55,8 @@ func (a *App) Start() error {
 	defer cancel()
 
 	go a.consumer.Start(ctx)
+	// Outbox'taki olayları Kafka'ya aktaran relay; Kafka erişilemezken olaylar DB'de bekler.
+	go a.repo.Outbox().Run(ctx, a.messaging)
 
 	go graceful.WaitForShutdown(a.server.FiberApp(), 5*time.Second, ctx)
 
@@ -54,6 +65,12 @@ func (a *App) Start() error {
 		return fmt.Errorf("server exited with error: %w", err)
 	}
 
+	// Consumer'ları durdur; çalışan handler'lar bitip commit'ler yazılana kadar bekle.
+	cancel()
+	if err := a.consumer.Close(); err != nil {
+		log.Printf("kafka consumer close error: %v", err)
+	}
+
 	log.Println("server stopped, closing repository")
 	return a.repo.Close()
 }
@@ -73,9 +90,9 @@ func createMessagingConfig(cfg config.MessagingConfig) messaging.KafkaConfig {
 	kafkaBrokers := []string{broker}
 	return messaging.KafkaConfig{
 		Brokers:              kafkaBrokers,
-		Topic:                "main-events", // Ana olay topic'i
-		RetryTopic:           "main-events-retry",
-		DLQTopic:             "main-events-dlq",
+		Topic:                messaging.DefaultTopic, // Ana olay topic'i
+		RetryTopic:           messaging.DefaultRetryTopic,
+		DLQTopic:             messaging.DefaultDLQTopic,
 		ServiceType:          eventsProto.ServiceType_PAYMENT_SERVICE,
 		EnableRetry:          true,
 		MaxRetries:           10,
@@ -83,7 +100,7 @@ func createMessagingConfig(cfg config.MessagingConfig) messaging.KafkaConfig {
 		CriticalMessageTypes: []eventsProto.MessageType{eventsProto.MessageType_ORDER_CREATED},
 	}
 }
-func buildContainer(cfg config.Config) (*container, error) {
+func buildContainer(cfg config.Config, connect messaging.BrokerFactory) (*container, error) {
 	// 1. Storage
 	repo, err := postgres.NewRepository(cfg)
 	if err != nil {
@@ -95,20 +112,25 @@ func buildContainer(cfg config.Config) (*container, error) {
 
 	// 3. Messaging (Kafka Client & Consumer)
 	mCfg := createMessagingConfig(cfg.Messaging)
-	kafkaClient, err := messaging.NewKafkaClient(mCfg)
+	kafkaClient, err := connect(mCfg)
 	if err != nil {
 		return nil, err
 	}
 
-	msgHandlers := messaginghandler.SetupMessageHandlers() // ✅ Repo ve Svc ekle
-	consumer, err := kafka.NewConsumer(cfg.Messaging, msgHandlers)
+	msgHandlers, err := messaginghandler.SetupMessageHandlers() // ✅ Repo ve Svc ekle
+	if err != nil {
+		return nil, fmt.Errorf("init message handlers: %w", err)
+	}
+	consumer, err := kafka.NewConsumerWithBroker(cfg.Messaging, msgHandlers, connect)
 	if err != nil {
 		return nil, err
 	}
 
 	// 4. Transport (HTTP & gRPC)
 
-	h := httptransport.NewHandlers(repo, stripeSvc, kafkaClient)
+	// Webhook olayları doğrudan Kafka'ya değil outbox'a yazılır; Stripe'a 200 dönüldüğünde
+	// olay kalıcıdır ve relay Kafka'ya aktarır.
+	h := httptransport.NewHandlers(repo, stripeSvc, repo.Outbox())
 	router := httptransport.NewRouter(h)
 	grpcHandler := grpctransport.NewPaymentGrpcHandler(repo, stripeSvc)
 
@@ -121,6 +143,7 @@ func buildContainer(cfg config.Config) (*container, error) {
 }
 func getServerConfig(cfg config.Config) server.Config {
 	return server.Config{
+		Name:         "payment-service",
 		Port:         cfg.Server.Port,
 		GrpcPort:     cfg.Server.GrpcPort,
 		IdleTimeout:  5 * time.Second,
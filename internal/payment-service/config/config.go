@@ -33,11 +33,19 @@ type DatabaseConfig struct {
 	DB       string `mapstructure:"db"`
 	Host     string `mapstructure:"host"`
 }
+
+// OutboxConfig, outbox relay modunu seçer: "poll" (varsayılan) veya "cdc".
+// cdc modu Postgres'te wal_level=logical gerektirir; kullanılamazsa relay polling'e düşer.
+type OutboxConfig struct {
+	Relay string `mapstructure:"relay"`
+}
+
 type Config struct {
 	Server    ServerConfig    `mapstructure:"server"`
 	Stripe    StripeConfig    `mapstructure:"stripe"`
 	Database  DatabaseConfig  `mapstructure:"database"`
 	Messaging MessagingConfig `mapstructure:"messaging"`
+	Outbox    OutboxConfig    `mapstructure:"outbox"`
 }
 
 func Read() Config {
@@ -69,6 +77,7 @@ func Read() Config {
 	v.SetDefault("database.password", "password")
 	v.SetDefault("database.db", "marketplace")
 	v.SetDefault("messaging.brokers", []string{"localhost:9092"})
+	v.SetDefault("outbox.relay", "poll")
 	var cfg Config
 	if err := v.Unmarshal(&cfg); err != nil {
 		panic("Config unmarshal error: " + err.Error())
@@ -1,5 +1,10 @@
 package domain
 
+import "marketplace/pkg/outbox"
+
 type PaymentRepository interface {
+	// Outbox, Stripe webhook olaylarının yazıldığı transactional outbox'tır.
+	// Domain.Messaging'i uyguladığı için use case'lere doğrudan verilebilir.
+	Outbox() *outbox.Outbox
 	Close() error
 }
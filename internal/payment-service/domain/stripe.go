@@ -12,6 +12,7 @@ type CreatePaymentSessionRequest struct {
 
 type CreatePaymentSessionResponse struct {
 	PaymentURL string `json:"payment_url"`
+	SessionID  string `json:"session_id"`
 }
 
 type PaymentCompletedEvent struct {
@@ -21,6 +22,7 @@ type PaymentCompletedEvent struct {
 }
 
 type StripeService interface {
-	CreatePaymentSession(req CreatePaymentSessionRequest) (string, error)
+	CreatePaymentSession(req CreatePaymentSessionRequest) (*CreatePaymentSessionResponse, error)
+	ExpirePaymentSession(sessionID string) error
 	GetWebhookSecret() string
 }
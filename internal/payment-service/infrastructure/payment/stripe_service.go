@@ -18,7 +18,7 @@ func NewStripeService(key string, webhookSecret string) *StripeService {
 	return &StripeService{secretKey: key, webhookSecret: webhookSecret}
 }
 
-func (s *StripeService) CreatePaymentSession(req domain.CreatePaymentSessionRequest) (string, error) {
+func (s *StripeService) CreatePaymentSession(req domain.CreatePaymentSessionRequest) (*domain.CreatePaymentSessionResponse, error) {
 	expiresAt := time.Now().Add(30 * time.Minute).Unix()
 	params := &stripe.CheckoutSessionParams{
 		PaymentMethodTypes: stripe.StringSlice([]string{"card"}),
@@ -49,10 +49,16 @@ func (s *StripeService) CreatePaymentSession(req domain.CreatePaymentSessionRequ
 
 	sess, err := session.New(params)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
-	return sess.URL, nil
+	return &domain.CreatePaymentSessionResponse{PaymentURL: sess.URL, SessionID: sess.ID}, nil
+}
+
+// ExpirePaymentSession, henüz ödenmemiş bir checkout oturumunu kapatır; link artık ödeme almaz.
+func (s *StripeService) ExpirePaymentSession(sessionID string) error {
+	_, err := session.Expire(sessionID, nil)
+	return err
 }
 
 func (s *StripeService) GetWebhookSecret() string {
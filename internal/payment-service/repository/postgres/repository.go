@@ -6,6 +6,7 @@ import (
 	"errors"
 	"marketplace/internal/payment-service/config"
 	"marketplace/internal/payment-service/domain"
+	"marketplace/pkg/outbox"
 
 	_ "github.com/lib/pq"
 )
@@ -23,7 +24,8 @@ var (
 )
 
 type Repository struct {
-	db *sql.DB
+	db     *sql.DB
+	outbox *outbox.Outbox
 }
 
 func NewRepository(cfg config.Config) (domain.PaymentRepository, error) {
@@ -36,11 +38,20 @@ func NewRepository(cfg config.Config) (domain.PaymentRepository, error) {
 		return nil, err
 	}
 
-	repo := &Repository{db: db}
+	ob, err := outbox.New(db, outbox.Config{Relay: cfg.Outbox.Relay})
+	if err != nil {
+		return nil, err
+	}
+
+	repo := &Repository{db: db, outbox: ob}
 
 	return repo, nil
 }
 
+func (r *Repository) Outbox() *outbox.Outbox {
+	return r.outbox
+}
+
 func (r *Repository) Close() error {
 	if r.db != nil {
 		return r.db.Close()
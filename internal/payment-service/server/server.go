@@ -4,6 +4,9 @@ package server
 import (
 	"fmt"
 	"log"
+	"marketplace/pkg/discovery"
+	"marketplace/pkg/metrics"
+	"marketplace/pkg/tracing"
 	"net"
 	"net/http"
 	"time"
@@ -19,6 +22,8 @@ type RouteRegistrar interface {
 }
 
 type Config struct {
+	// Name, servisin gateway.yaml'daki adıdır; self-registration bu adla yapılır.
+	Name         string
 	GrpcPort     string
 	Port         string
 	IdleTimeout  time.Duration
@@ -30,6 +35,7 @@ type Server struct {
 	app        *fiber.App
 	cfg        Config
 	grpcServer *grpc.Server
+	announcer  *discovery.Announcer
 }
 type GrpcServerRegistrar interface {
 	Register(server *grpc.Server)
@@ -50,15 +56,17 @@ func New(cfg Config, registrar RouteRegistrar, grpcRegistrar GrpcServerRegistrar
 		AllowCredentials: true,
 	}))
 	app.Use(requestid.New())
+	app.Use(tracing.Middleware())
 
 	app.Get("/health", func(c *fiber.Ctx) error {
 		return c.JSON(fiber.Map{"status": "UP"})
 	})
+	app.Get("/metrics", metrics.Handler(metrics.Default))
 
 	if registrar != nil {
 		registrar.Register(app)
 	}
-	grpcSrv := grpc.NewServer()
+	grpcSrv := grpc.NewServer(tracing.ServerOption())
 
 	if grpcRegistrar != nil {
 		grpcRegistrar.Register(grpcSrv)
@@ -81,6 +89,22 @@ func (s *Server) startGrpc() error {
 }
 
 func (s *Server) Start() error {
+	// Self-registration: DISCOVERY_REDIS_ADDR tanımlıysa instance, HTTP sunucusu dinlemeye
+	// başlayınca Redis'e kaydedilir ve kapanırken kaydı silinir (bkz. pkg/discovery).
+	announcer, err := discovery.FromEnv(s.cfg.Name, s.cfg.Port)
+	if err != nil {
+		return fmt.Errorf("discovery: %w", err)
+	}
+	s.announcer = announcer
+	s.app.Hooks().OnListen(func(fiber.ListenData) error {
+		s.announcer.Start()
+		return nil
+	})
+	s.app.Hooks().OnShutdown(func() error {
+		s.announcer.Stop()
+		return nil
+	})
+	defer s.announcer.Stop()
 
 	go func() {
 		if err := s.startGrpc(); err != nil && err != http.ErrServerClosed {
@@ -51,15 +51,26 @@ func (h *PaymentGrpcHandler) CreatePaymentSession(ctx context.Context, req *pp.C
 		UserEmail: req.GetUserEmail(),
 		UserName:  req.GetUserName(),
 	}
-	paymentURL, err := h.stripeService.CreatePaymentSession(paymentSessionRequest)
+	session, err := h.stripeService.CreatePaymentSession(paymentSessionRequest)
 	if err != nil {
 		fmt.Println("error creating payment session", err)
 		return nil, err
 	}
 
 	return &pp.CreatePaymentResponse{
-		PaymentUrl: paymentURL,
-		SessionId:  "session id",
+		PaymentUrl: session.PaymentURL,
+		SessionId:  session.SessionID,
 	}, nil
 
 }
+
+func (h *PaymentGrpcHandler) ExpirePaymentSession(ctx context.Context, req *pp.ExpirePaymentSessionRequest) (*pp.ExpirePaymentSessionResponse, error) {
+	if req.GetSessionId() == "" {
+		return nil, errors.New("Session id cannot be empty.")
+	}
+	if err := h.stripeService.ExpirePaymentSession(req.GetSessionId()); err != nil {
+		fmt.Println("error expiring payment session", err)
+		return nil, err
+	}
+	return &pp.ExpirePaymentSessionResponse{}, nil
+}
@@ -1,8 +1,7 @@
 package messaginghandler
 
 import (
-	"marketplace/internal/payment-service/domain"
-	eventsProto "marketplace/pkg/proto/events"
+	"marketplace/pkg/messaging"
 )
 
 type Handlers struct {
@@ -12,7 +11,7 @@ func NewMessageHandlers() *Handlers {
 	return &Handlers{}
 }
 
-func SetupMessageHandlers() map[eventsProto.MessageType]domain.MessageHandler {
+func SetupMessageHandlers() (*messaging.Router, error) {
 
-	return map[eventsProto.MessageType]domain.MessageHandler{}
+	return messaging.NewRouter(), nil
 }
@@ -29,25 +29,32 @@ type App struct {
 	cfg        config.Config
 	server     *server.Server
 	repository domain.ProductRepository
-	//messaging     domain.Messaging
-	consumer *kafka.Consumer
+	messaging  domain.Messaging
+	consumer   *kafka.Consumer
 	//cloudinarySvc domain.ImageService
 	//aiProvider    domain.AiProvider
 	asynqClient *asynq.Client
 	// worker        domain.Worker
 }
 
+// NewApp, uygulamayı Kafka broker'ı ile kurar.
 func NewApp(cfg config.Config) (*App, error) {
-	container, err := buildContainer(cfg)
+	return NewAppWithBroker(cfg, messaging.NewKafkaBroker)
+}
+
+// NewAppWithBroker, uygulamayı verilen broker factory ile kurar. Üretici ve tüketiciler
+// aynı factory'den bağlanır; testlerde messaging.MemoryBroker.Connect verilerek Kafka gerekmez.
+func NewAppWithBroker(cfg config.Config, connect messaging.BrokerFactory) (*App, error) {
+	container, err := buildContainer(cfg, connect)
 	if err != nil {
 		return nil, fmt.Errorf("bootstrap failed: %w", err)
 	}
 
 	return &App{
-		cfg:        cfg,
-		server:     container.server,
-		repository: container.repo,
-		//messaging:   container.messaging,
+		cfg:         cfg,
+		server:      container.server,
+		repository:  container.repo,
+		messaging:   container.messaging,
 		consumer:    container.consumer,
 		asynqClient: container.asynqClient,
 		// worker:      container.worker,
@@ -61,8 +68,9 @@ func (a *App) Start() error {
 	// 1. Kafka Consumer'ı başlat
 	a.consumer.Start(ctx)
 
-	// 2. Transactional Outbox Relay'i başlat (Eğer Product DB'de outbox varsa)
-	// go a.startOutboxRelay(ctx)
+	// 2. Transactional Outbox Relay'i ve işlenmiş mesaj temizliğini başlat
+	go a.repository.Outbox().Run(ctx, a.messaging)
+	go a.repository.IdempotencyStore().RunCleanup(ctx)
 
 	log.Printf("starting product-service on %s (gRPC: %s)", a.cfg.Server.Port, a.cfg.Server.GrpcPort)
 
@@ -70,6 +78,12 @@ func (a *App) Start() error {
 		return fmt.Errorf("server exited with error: %w", err)
 	}
 
+	// Consumer'ları durdur; çalışan handler'lar bitip commit'ler yazılana kadar bekle.
+	cancel()
+	if err := a.consumer.Close(); err != nil {
+		log.Printf("kafka consumer close error: %v", err)
+	}
+
 	return nil
 }
 
@@ -91,9 +105,9 @@ func getKafkaSettings(cfg config.MessagingConfig) messaging.KafkaConfig {
 	}
 	return messaging.KafkaConfig{
 		Brokers:              []string{broker},
-		Topic:                "main-events", // Ana olay topic'i
-		RetryTopic:           "main-events-retry",
-		DLQTopic:             "main-events-dlq",
+		Topic:                messaging.DefaultTopic, // Ana olay topic'i
+		RetryTopic:           messaging.DefaultRetryTopic,
+		DLQTopic:             messaging.DefaultDLQTopic,
 		ServiceType:          pb.ServiceType_PRODUCT_SERVICE,
 		EnableRetry:          true,
 		MaxRetries:           10,
@@ -101,7 +115,7 @@ func getKafkaSettings(cfg config.MessagingConfig) messaging.KafkaConfig {
 		CriticalMessageTypes: []pb.MessageType{pb.MessageType_PRODUCT_PRICE_UPDATED},
 	}
 }
-func buildContainer(cfg config.Config) (*container, error) {
+func buildContainer(cfg config.Config, connect messaging.BrokerFactory) (*container, error) {
 	// Veritabanı
 	repo, err := initStorage(cfg)
 	if err != nil {
@@ -130,15 +144,18 @@ func buildContainer(cfg config.Config) (*container, error) {
 	}()
 
 	// Messaging (Kafka)
-	msgHandlers := messaginghandler.SetupMessageHandlers(repo)
-	kafkaConsumer, err := kafka.NewConsumer(cfg.Messaging, msgHandlers)
+	msgHandlers, err := messaginghandler.SetupMessageHandlers(repo)
+	if err != nil {
+		return nil, fmt.Errorf("init message handlers: %w", err)
+	}
+	kafkaConsumer, err := kafka.NewConsumerWithBroker(cfg.Messaging, msgHandlers, repo.IdempotencyStore(), repo.CriticalStore(), connect)
 	if err != nil {
 		return nil, err
 	}
 
 	// Transport (HTTP & gRPC)
 	productService := domain.NewProductService(repo)
-	httpRouter := setupHttpRouter(cfg, productService, repo, cloudinarySvc, aiProvider, wrk, kafkaConsumer.Client())
+	httpRouter := setupHttpRouter(cfg, productService, repo, cloudinarySvc, aiProvider, wrk)
 	grpcHandler := grpctransport.NewProductGrpcHandler(repo)
 
 	return &container{
@@ -159,14 +176,15 @@ func initStorage(cfg config.Config) (domain.ProductRepository, error) {
 
 	return repo, nil
 }
-func setupHttpRouter(cfg config.Config, p domain.ProductService, r domain.ProductRepository, c domain.ImageService, a domain.AiProvider, w domain.Worker, m domain.Messaging) server.RouteRegistrar {
+func setupHttpRouter(cfg config.Config, p domain.ProductService, r domain.ProductRepository, c domain.ImageService, a domain.AiProvider, w domain.Worker) server.RouteRegistrar {
 
-	httpHandlers := httptransport.NewHandlers(p, r, c, a, w, m)
+	httpHandlers := httptransport.NewHandlers(p, r, c, a, w)
 	return httptransport.NewRouter(httpHandlers)
 }
 
 func getServerConfig(cfg config.Config) server.Config {
 	return server.Config{
+		Name:         "product-service",
 		Port:         cfg.Server.Port,
 		IdleTimeout:  5 * time.Second,
 		ReadTimeout:  10 * time.Second,
@@ -33,11 +33,18 @@ type CloudinaryConfig struct {
 	APISecret string `mapstructure:"apiSecret"`
 }
 
+// OutboxConfig, outbox relay modunu seçer: "poll" (varsayılan) veya "cdc".
+// cdc modu Postgres'te wal_level=logical gerektirir; kullanılamazsa relay polling'e düşer.
+type OutboxConfig struct {
+	Relay string `mapstructure:"relay"`
+}
+
 type Config struct {
 	Database   DatabaseConfig   `mapstructure:"database"`
 	Server     ServerConfig     `mapstructure:"server"`
 	Messaging  MessagingConfig  `mapstructure:"messaging"`
 	Cloudinary CloudinaryConfig `mapstructure:"cloudinary"`
+	Outbox     OutboxConfig     `mapstructure:"outbox"`
 }
 
 func Read() Config {
@@ -72,6 +79,7 @@ func Read() Config {
 	v.SetDefault("database.user", "postgres")
 	v.SetDefault("database.password", "password")
 	v.SetDefault("database.db", "marketplace")
+	v.SetDefault("outbox.relay", "poll")
 
 	var cfg Config
 	if err := v.Unmarshal(&cfg); err != nil {
@@ -2,6 +2,9 @@ package domain
 
 import (
 	"context"
+	"marketplace/pkg/messaging"
+	"marketplace/pkg/outbox"
+	pb "marketplace/pkg/proto/events"
 
 	"github.com/google/uuid"
 )
@@ -25,12 +28,17 @@ type ProductRepository interface {
 	CheckLocalUserExists(ctx context.Context, userID uuid.UUID) (bool, error)
 	GetUserFavorites(ctx context.Context, userID uuid.UUID) ([]*FavoriteItem, error)
 	GetProductByID(ctx context.Context, id uuid.UUID) (*Product, error)
-	UpdateProduct(ctx context.Context, p *Product) error
+	// UpdateProduct, verilen olayları ürün güncellemesiyle aynı transaction'da outbox'a yazar.
+	UpdateProduct(ctx context.Context, p *Product, events ...*pb.Message) error
 	SoftDeleteProduct(ctx context.Context, productID uuid.UUID) error
 	SoftDeleteAllProductImages(ctx context.Context, productID uuid.UUID) error
 
 	ReserveStocks(ctx context.Context, orderID uuid.UUID, items []OrderItemReserve) ([]ProductInfo, error)
 	ConfirmStock(ctx context.Context, orderID uuid.UUID) error
 	ReleaseStock(ctx context.Context, orderID uuid.UUID) error
+	// IdempotencyStore, işlenmiş mesaj kayıtlarıdır; eski kayıtlar RunCleanup ile silinir.
+	IdempotencyStore() *messaging.PostgresIdempotencyStore
+	CriticalStore() messaging.CriticalStore
+	Outbox() *outbox.Outbox
 	Close() error
 }
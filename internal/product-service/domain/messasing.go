@@ -9,6 +9,3 @@ type Messaging interface {
 	Close() error
 	PublishMessage(ctx context.Context, msg *pb.Message) error
 }
-type MessageHandler interface {
-	Handle(ctx context.Context, msg *pb.Message) error
-}
@@ -3,6 +3,7 @@ package postgres
 import (
 	"context"
 	"fmt"
+	"marketplace/pkg/messaging"
 
 	"github.com/google/uuid"
 )
@@ -32,5 +33,10 @@ func (r *Repository) ReleaseStock(ctx context.Context, orderID uuid.UUID) error
 		return fmt.Errorf("failed to delete reservations: %w", err)
 	}
 
+	// Kafka mesajından geliyorsak "işlendi" kaydı bu transaction ile birlikte commit edilir.
+	if err := messaging.MarkProcessedInTx(ctx, tx); err != nil {
+		return err
+	}
+
 	return tx.Commit()
 }
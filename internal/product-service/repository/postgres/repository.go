@@ -6,6 +6,8 @@ import (
 	"errors"
 	"marketplace/internal/product-service/config"
 	"marketplace/internal/product-service/domain"
+	"marketplace/pkg/messaging"
+	"marketplace/pkg/outbox"
 
 	_ "github.com/lib/pq"
 )
@@ -23,7 +25,10 @@ var (
 )
 
 type Repository struct {
-	db *sql.DB
+	db          *sql.DB
+	idempotency *messaging.PostgresIdempotencyStore
+	critical    *messaging.PostgresCriticalStore
+	outbox      *outbox.Outbox
 }
 
 func NewRepository(cfg config.Config) (domain.ProductRepository, error) {
@@ -36,11 +41,41 @@ func NewRepository(cfg config.Config) (domain.ProductRepository, error) {
 		return nil, err
 	}
 
-	repo := &Repository{db: db}
+	// Kafka tüketicisinin işlenmiş mesaj kayıtları da aynı veritabanında tutulur;
+	// böylece handler'lar kaydı kendi transaction'ları içinde atabilir.
+	idempotency, err := messaging.NewPostgresIdempotencyStore(db)
+	if err != nil {
+		return nil, err
+	}
+
+	// DLQ'ya düşen kritik mesajlar da servisin veritabanında saklanır (bkz. messaging.CriticalStore).
+	critical, err := messaging.NewPostgresCriticalStore(db)
+	if err != nil {
+		return nil, err
+	}
+
+	ob, err := outbox.New(db, outbox.Config{Relay: cfg.Outbox.Relay})
+	if err != nil {
+		return nil, err
+	}
+
+	repo := &Repository{db: db, idempotency: idempotency, critical: critical, outbox: ob}
 
 	return repo, nil
 }
 
+func (r *Repository) IdempotencyStore() *messaging.PostgresIdempotencyStore {
+	return r.idempotency
+}
+
+func (r *Repository) CriticalStore() messaging.CriticalStore {
+	return r.critical
+}
+
+func (r *Repository) Outbox() *outbox.Outbox {
+	return r.outbox
+}
+
 func (r *Repository) Close() error {
 	if r.db != nil {
 		return r.db.Close()
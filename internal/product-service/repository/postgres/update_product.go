@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"marketplace/internal/product-service/domain"
+	pb "marketplace/pkg/proto/events"
 )
 
 const UPDATE_PRODUCT = `
@@ -21,14 +22,20 @@ const UPDATE_PRODUCT = `
     WHERE id = $8 AND seller_id = $9
 `
 
-func (r *Repository) UpdateProduct(ctx context.Context, p *domain.Product) error {
+func (r *Repository) UpdateProduct(ctx context.Context, p *domain.Product, events ...*pb.Message) error {
 	// Attributes map'ini JSON formatına çeviriyoruz
 	attrJSON, err := json.Marshal(p.Attributes)
 	if err != nil {
 		return fmt.Errorf("failed to marshal attributes: %w", err)
 	}
 
-	_, err = r.db.ExecContext(ctx, UPDATE_PRODUCT,
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("transaction begin failed: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, UPDATE_PRODUCT,
 		p.Name,
 		p.Description,
 		p.Price,
@@ -39,5 +46,15 @@ func (r *Repository) UpdateProduct(ctx context.Context, p *domain.Product) error
 		p.ID,
 		p.SellerID,
 	)
-	return err
+	if err != nil {
+		return err
+	}
+
+	for _, event := range events {
+		if err := r.outbox.Add(ctx, tx, event); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
 }
@@ -48,13 +48,12 @@ func NewHandlers(
 	imgSvc domain.ImageService,
 	ai domain.AiProvider,
 	wrk domain.Worker,
-	msg domain.Messaging,
 ) *Handlers {
 	// Tüm UseCase ve Controller'lar uygulama ayağa kalkarken bir kez oluşturulur.
 	return &Handlers{
 		Product: &productHandlers{
 			Create:      controller.NewCreateProductController(usecase.NewCreateProductUseCase(repo, ai)),
-			Update:      controller.NewUpdateProductController(usecase.NewUpdateProductUseCase(repo, ai, msg)),
+			Update:      controller.NewUpdateProductController(usecase.NewUpdateProductUseCase(repo, ai)),
 			Delete:      controller.NewDeleteProductController(usecase.NewDeleteProductUseCase(repo)),
 			Get:         controller.NewGetProductController(usecase.NewGetProductUseCase(repo, wrk)),
 			UploadImage: controller.NewUploadProductImagesController(usecase.NewUploadProductImagesUseCase(repo, imgSvc, wrk)),
@@ -17,14 +17,12 @@ type UpdateProductUseCase interface {
 type updateProductUseCase struct {
 	productRepository domain.ProductRepository
 	aiProvider        domain.AiProvider
-	messaging         domain.Messaging
 }
 
-func NewUpdateProductUseCase(productRepository domain.ProductRepository, aiProvider domain.AiProvider, messaging domain.Messaging) UpdateProductUseCase {
+func NewUpdateProductUseCase(productRepository domain.ProductRepository, aiProvider domain.AiProvider) UpdateProductUseCase {
 	return &updateProductUseCase{
 		productRepository: productRepository,
 		aiProvider:        aiProvider,
-		messaging:         messaging,
 	}
 }
 
@@ -66,7 +64,14 @@ func (u *updateProductUseCase) Execute(ctx context.Context, userID uuid.UUID, p
 	if p.Attributes != nil {
 		existingProduct.Attributes = p.Attributes
 	}
-	err = u.productRepository.UpdateProduct(ctx, existingProduct)
+	// Fiyat değiştiyse olay, güncellemeyle aynı transaction'da outbox'a yazılır.
+	// Neden? Eskiden fire-and-forget goroutine ile yayınlanıyordu; Kafka hatasında
+	// sepetler eski fiyatla kalıyordu.
+	var events []*pb.Message
+	if p.Price != nil {
+		events = append(events, newProductPriceUpdatedEvent(existingProduct.ID, *p.Price))
+	}
+	err = u.productRepository.UpdateProduct(ctx, existingProduct, events...)
 	if err != nil {
 		return err
 	}
@@ -84,21 +89,20 @@ func (u *updateProductUseCase) Execute(ctx context.Context, userID uuid.UUID, p
 		}(existingProduct.ID, existingProduct.Name, existingProduct.Description)
 	}
 
-	if p.Price != nil {
-		go func(pID uuid.UUID, price float64) {
-			u.messaging.PublishMessage(context.Background(), &pb.Message{
-				Type:        pb.MessageType_PRODUCT_PRICE_UPDATED,
-				FromService: pb.ServiceType_PRODUCT_SERVICE,
-				Critical:    false,
-				RetryCount:  2,
-				ToServices:  []pb.ServiceType{pb.ServiceType_BASKET_SERVICE},
-				Payload: &pb.Message_ProductPriceUpdatedData{ProductPriceUpdatedData: &pb.ProductPriceUpdatedData{
-					ProductId: pID.String(),
-					Price:     float32(price),
-				}},
-			})
-		}(existingProduct.ID, *p.Price)
-	}
-
 	return nil
 }
+
+func newProductPriceUpdatedEvent(pID uuid.UUID, price float64) *pb.Message {
+	return &pb.Message{
+		Id:          uuid.New().String(),
+		Type:        pb.MessageType_PRODUCT_PRICE_UPDATED,
+		FromService: pb.ServiceType_PRODUCT_SERVICE,
+		Critical:    false,
+		RetryCount:  2,
+		ToServices:  []pb.ServiceType{pb.ServiceType_BASKET_SERVICE},
+		Payload: &pb.Message_ProductPriceUpdatedData{ProductPriceUpdatedData: &pb.ProductPriceUpdatedData{
+			ProductId: pID.String(),
+			Price:     float32(price),
+		}},
+	}
+}
@@ -12,33 +12,40 @@ import (
 )
 
 type Consumer struct {
-	client   *messaging.KafkaClient
-	handlers map[pb.MessageType]domain.MessageHandler
-	cfg      messaging.KafkaConfig
+	client messaging.Broker
+	router *messaging.Router
+	cfg    messaging.KafkaConfig
 }
 
-func NewConsumer(cfg config.MessagingConfig, handlers map[pb.MessageType]domain.MessageHandler) (*Consumer, error) {
+func NewConsumer(cfg config.MessagingConfig, router *messaging.Router, idempotency messaging.IdempotencyStore, critical messaging.CriticalStore) (*Consumer, error) {
+	return NewConsumerWithBroker(cfg, router, idempotency, critical, messaging.NewKafkaBroker)
+}
+
+// NewConsumerWithBroker, mesajlaşma altyapısını dışarıdan alır.
+// Testlerde messaging.MemoryBroker.Connect verilerek servis Kafka olmadan çalıştırılabilir.
+func NewConsumerWithBroker(cfg config.MessagingConfig, router *messaging.Router, idempotency messaging.IdempotencyStore, critical messaging.CriticalStore, connect messaging.BrokerFactory) (*Consumer, error) {
 	kafkaConfig := createKafkaConfig(cfg)
-	client, err := messaging.NewKafkaClient(kafkaConfig)
+	kafkaConfig.IdempotencyStore = idempotency
+	kafkaConfig.CriticalStore = critical
+	// Servise izin verilen her mesaj tipinin bir handler'ı olmalı; aksi halde mesajlar DLQ'ya düşer.
+	if err := router.Covers(kafkaConfig.AllowedMessageTypes[kafkaConfig.ServiceType]); err != nil {
+		return nil, fmt.Errorf("validate message handlers: %w", err)
+	}
+
+	client, err := connect(kafkaConfig)
 	if err != nil {
 		return nil, fmt.Errorf("init kafka messaging: %w", err)
 	}
 
 	return &Consumer{
-		client:   client,
-		handlers: handlers,
-		cfg:      kafkaConfig,
+		client: client,
+		router: router,
+		cfg:    kafkaConfig,
 	}, nil
 }
 
 func (c *Consumer) Start(ctx context.Context) {
-	messageRouter := func(ctx context.Context, msg *pb.Message) error {
-		handler, ok := c.handlers[msg.Type]
-		if !ok {
-			return nil
-		}
-		return handler.Handle(ctx, msg)
-	}
+	messageRouter := c.router.Dispatch
 	ctx, cancel := context.WithCancel(ctx)
 	// Ana Consumer
 	go func() {
@@ -53,10 +60,9 @@ func (c *Consumer) Start(ctx context.Context) {
 
 	go func() {
 		log.Println("Starting Kafka consumer for retry-events...")
-		// Aynı KafkaClient'ı kullanarak, sadece farklı bir topic ve grup adı veriyoruz.
+		// Aynı KafkaClient'ı kullanarak retry topic'ini ve tüm gecikme kademelerini dinliyoruz.
 		groupID := c.cfg.ServiceType.String() + "-retry-group"
-		topic := c.cfg.RetryTopic
-		if err := c.client.ConsumeMessages(ctx, messageRouter, &topic, &groupID); err != nil {
+		if err := c.client.ConsumeRetryMessages(ctx, messageRouter, &groupID); err != nil {
 			log.Printf("Retry consumer error: %v", err)
 			cancel()
 		}
@@ -76,6 +82,12 @@ func (c *Consumer) Client() domain.Messaging {
 	return c.client
 }
 
+// Close, çalışan handler'ların bitmesini ve commit'lerin yazılmasını bekleyip client'ı kapatır.
+// Start'a verilen context iptal edildikten sonra çağrılmalıdır.
+func (c *Consumer) Close() error {
+	return c.client.Close()
+}
+
 func createKafkaConfig(cfg config.MessagingConfig) messaging.KafkaConfig {
 	broker := cfg.Brokers[0]
 	if broker == "" {
@@ -84,20 +96,20 @@ func createKafkaConfig(cfg config.MessagingConfig) messaging.KafkaConfig {
 	kafkaBrokers := []string{broker}
 	return messaging.KafkaConfig{
 		Brokers:               kafkaBrokers,
-		Topic:                 "main-events",
-		RetryTopic:            "retry-events",
-		DLQTopic:              "dlq-events",
+		Topic:                 messaging.DefaultTopic,
+		RetryTopic:            messaging.DefaultRetryTopic,
+		DLQTopic:              messaging.DefaultDLQTopic,
 		ServiceType:           pb.ServiceType_PRODUCT_SERVICE,
 		EnableRetry:           true,
 		MaxRetries:            3,
 		ConnectionTimeout:     10 * time.Second,
 		MaxConcurrentHandlers: 10,
+		// Aynı siparişe/kullanıcıya ait olaylar (Örn: ORDER_CREATED -> PAYMENT_FAILED) sırayla işlenir.
+		OrderedProcessing: true,
 		AllowedMessageTypes: map[pb.ServiceType][]pb.MessageType{
 			pb.ServiceType_PRODUCT_SERVICE: {
 				pb.MessageType_SELLER_APPROVED,
-				pb.MessageType_SELLER_REJECTED,
 				pb.MessageType_USER_CREATED,
-				pb.MessageType_ORDER_CREATED,
 				pb.MessageType_PAYMENT_SUCCESSFUL,
 				pb.MessageType_PAYMENT_FAILED,
 			},
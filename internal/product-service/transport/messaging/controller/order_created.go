@@ -6,6 +6,7 @@ import (
 	"fmt"
 
 	"marketplace/internal/product-service/transport/messaging/usecase"
+	"marketplace/pkg/messaging"
 	pb "marketplace/pkg/proto/events"
 
 	"github.com/google/uuid"
@@ -21,21 +22,15 @@ func NewOrderCreatedHandler(usecase usecase.OrderCreatedUseCase) *OrderCreatedHa
 	}
 }
 
-func (h *OrderCreatedHandler) Handle(ctx context.Context, msg *pb.Message) error {
-
-	data := msg.GetOrderCreatedData()
-	if data == nil {
-		return fmt.Errorf("payload is nil or not OrderCreatedData for message ID: %s", msg.Id)
-	}
-
+func (h *OrderCreatedHandler) Handle(ctx context.Context, data *pb.OrderCreatedData, meta messaging.Meta) error {
 	// 2. UUID doğrulaması yap
 	orderIDUUID, err := uuid.Parse(data.OrderId) // 'event' yerine doğrudan 'data' kullan
 	if err != nil {
-		return fmt.Errorf("invalid seller user id format: %w", err)
+		return messaging.Permanent(fmt.Errorf("invalid seller user id format: %w", err))
 	}
 	userIDUUID, err := uuid.Parse(data.UserId)
 	if err != nil {
-		return fmt.Errorf("invalid seller user id format: %w", err)
+		return messaging.Permanent(fmt.Errorf("invalid seller user id format: %w", err))
 	}
 
 	// 3. Usecase'e gönder
@@ -6,6 +6,7 @@ import (
 	"fmt"
 
 	"marketplace/internal/product-service/transport/messaging/usecase"
+	"marketplace/pkg/messaging"
 	eventsProto "marketplace/pkg/proto/events"
 
 	"github.com/google/uuid"
@@ -21,21 +22,15 @@ func NewPaymentFailureHandler(usecase usecase.PaymentFailureUseCase) *PaymentFai
 	}
 }
 
-func (h *PaymentFailureHandler) Handle(ctx context.Context, msg *eventsProto.Message) error {
-
-	data := msg.GetPaymentFailedData()
-	if data == nil {
-		return fmt.Errorf("payload is nil or not PaymentFailedData for message ID: %s", msg.Id)
-	}
-
+func (h *PaymentFailureHandler) Handle(ctx context.Context, data *eventsProto.PaymentFailedData, meta messaging.Meta) error {
 	orderIDUUID, err := uuid.Parse(data.OrderId)
 	if err != nil {
-		return fmt.Errorf("invalid order id format: %w", err)
+		return messaging.Permanent(fmt.Errorf("invalid order id format: %w", err))
 	}
 
 	errorMessage := data.ErrorMessage
 	if errorMessage == "" {
-		return fmt.Errorf("invalid error message format: %w", err)
+		return messaging.Permanent(fmt.Errorf("invalid error message format: %w", err))
 	}
 
 	return h.usecase.Execute(ctx, orderIDUUID, errorMessage)
@@ -6,6 +6,7 @@ import (
 	"fmt"
 
 	"marketplace/internal/product-service/transport/messaging/usecase"
+	"marketplace/pkg/messaging"
 	eventsProto "marketplace/pkg/proto/events"
 
 	"github.com/google/uuid"
@@ -21,16 +22,10 @@ func NewPaymentSuccessHandler(usecase usecase.PaymentSuccessUseCase) *PaymentSuc
 	}
 }
 
-func (h *PaymentSuccessHandler) Handle(ctx context.Context, msg *eventsProto.Message) error {
-
-	data := msg.GetPaymentSuccessfulData()
-	if data == nil {
-		return fmt.Errorf("payload is nil or not PaymentSuccessfulData for message ID: %s", msg.Id)
-	}
-
+func (h *PaymentSuccessHandler) Handle(ctx context.Context, data *eventsProto.PaymentSuccessfulData, meta messaging.Meta) error {
 	orderIDUUID, err := uuid.Parse(data.OrderId)
 	if err != nil {
-		return fmt.Errorf("invalid order id format: %w", err)
+		return messaging.Permanent(fmt.Errorf("invalid order id format: %w", err))
 	}
 
 	return h.usecase.Execute(ctx, orderIDUUID)
@@ -6,6 +6,7 @@ import (
 	"fmt"
 
 	"marketplace/internal/product-service/transport/messaging/usecase"
+	"marketplace/pkg/messaging"
 	pb "marketplace/pkg/proto/events"
 
 	"github.com/google/uuid"
@@ -21,21 +22,15 @@ func NewSellerApprovedHandler(usecase usecase.SellerApprovedUseCase) *SellerAppr
 	}
 }
 
-func (h *SellerApprovedHandler) Handle(ctx context.Context, msg *pb.Message) error {
-
-	data := msg.GetSellerApprovedData()
-	if data == nil {
-		return fmt.Errorf("payload is nil or not SellerApprovedData for message ID: %s", msg.Id)
-	}
-
+func (h *SellerApprovedHandler) Handle(ctx context.Context, data *pb.SellerApprovedData, meta messaging.Meta) error {
 	// 2. UUID doğrulaması yap
 	sellerIDUUID, err := uuid.Parse(data.SellerId) // 'event' yerine doğrudan 'data' kullan
 	if err != nil {
-		return fmt.Errorf("invalid seller user id format: %w", err)
+		return messaging.Permanent(fmt.Errorf("invalid seller user id format: %w", err))
 	}
 	userIDUUID, err := uuid.Parse(data.UserId)
 	if err != nil {
-		return fmt.Errorf("invalid seller user id format: %w", err)
+		return messaging.Permanent(fmt.Errorf("invalid seller user id format: %w", err))
 	}
 
 	// 3. Usecase'e gönder
@@ -6,6 +6,7 @@ import (
 	"fmt"
 
 	"marketplace/internal/product-service/transport/messaging/usecase"
+	"marketplace/pkg/messaging"
 	pb "marketplace/pkg/proto/events"
 
 	"github.com/google/uuid"
@@ -21,16 +22,10 @@ func NewUserCreatedHandler(usecase usecase.UserCreatedUseCase) *UserCreatedHandl
 	}
 }
 
-func (h *UserCreatedHandler) Handle(ctx context.Context, msg *pb.Message) error {
-
-	data := msg.GetUserCreatedData()
-	if data == nil {
-		return fmt.Errorf("payload is nil or not UserCreatedData for message ID: %s", msg.Id)
-	}
-
+func (h *UserCreatedHandler) Handle(ctx context.Context, data *pb.UserCreatedData, meta messaging.Meta) error {
 	userIDUUID, err := uuid.Parse(data.UserId)
 	if err != nil {
-		return fmt.Errorf("invalid user id format: %w", err)
+		return messaging.Permanent(fmt.Errorf("invalid user id format: %w", err))
 	}
 
 	return h.usecase.Execute(ctx, userIDUUID, data.Username, data.Email)
@@ -2,18 +2,21 @@
 package messaginghandler
 
 import (
+	"errors"
+
 	"marketplace/internal/product-service/domain"
 	"marketplace/internal/product-service/transport/messaging/controller"
 	"marketplace/internal/product-service/transport/messaging/usecase"
+	"marketplace/pkg/messaging"
 
 	pb "marketplace/pkg/proto/events"
 )
 
 type Handlers struct {
-	SellerApproved domain.MessageHandler
-	UserCreated    domain.MessageHandler
-	PaymentSuccess domain.MessageHandler
-	PaymentFailure domain.MessageHandler
+	SellerApproved *controller.SellerApprovedHandler
+	UserCreated    *controller.UserCreatedHandler
+	PaymentSuccess *controller.PaymentSuccessHandler
+	PaymentFailure *controller.PaymentFailureHandler
 }
 
 func NewMessageHandlers(repo domain.ProductRepository) *Handlers {
@@ -33,13 +36,18 @@ func NewMessageHandlers(repo domain.ProductRepository) *Handlers {
 	}
 }
 
-func SetupMessageHandlers(repo domain.ProductRepository) map[pb.MessageType]domain.MessageHandler {
+func SetupMessageHandlers(repo domain.ProductRepository) (*messaging.Router, error) {
 	h := NewMessageHandlers(repo)
+	router := messaging.NewRouter()
 
-	return map[pb.MessageType]domain.MessageHandler{
-		pb.MessageType_SELLER_APPROVED:    h.SellerApproved,
-		pb.MessageType_USER_CREATED:       h.UserCreated,
-		pb.MessageType_PAYMENT_SUCCESSFUL: h.PaymentSuccess,
-		pb.MessageType_PAYMENT_FAILED:     h.PaymentFailure,
+	err := errors.Join(
+		messaging.Handle(router, pb.MessageType_SELLER_APPROVED, h.SellerApproved.Handle),
+		messaging.Handle(router, pb.MessageType_USER_CREATED, h.UserCreated.Handle),
+		messaging.Handle(router, pb.MessageType_PAYMENT_SUCCESSFUL, h.PaymentSuccess.Handle),
+		messaging.Handle(router, pb.MessageType_PAYMENT_FAILED, h.PaymentFailure.Handle),
+	)
+	if err != nil {
+		return nil, err
 	}
+	return router, nil
 }
@@ -0,0 +1,331 @@
+// Package saga_test, sipariş → ödeme → stok → bildirim akışını servislerin gerçek
+// use case ve handler'larıyla, tek bir in-memory broker üzerinde uçtan uca çalıştırır.
+// Veritabanı ve gRPC bağımlılıkları sahte implementasyonlarla değiştirilir; Kafka gerekmez.
+package saga_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stripe/stripe-go/v84/webhook"
+
+	notificationconfig "marketplace/internal/notification-service/config"
+	notificationdomain "marketplace/internal/notification-service/domain"
+	notificationkafka "marketplace/internal/notification-service/transport/kafka"
+	notificationhandler "marketplace/internal/notification-service/transport/messaging"
+	orderconfig "marketplace/internal/order-service/config"
+	orderdomain "marketplace/internal/order-service/domain"
+	orderusecase "marketplace/internal/order-service/transport/http/usecase"
+	orderkafka "marketplace/internal/order-service/transport/kafka"
+	orderhandler "marketplace/internal/order-service/transport/messaging"
+	paymentconfig "marketplace/internal/payment-service/config"
+	paymentdomain "marketplace/internal/payment-service/domain"
+	paymentusecase "marketplace/internal/payment-service/transport/http/usecase"
+	paymentkafka "marketplace/internal/payment-service/transport/kafka"
+	paymenthandler "marketplace/internal/payment-service/transport/messaging"
+	productconfig "marketplace/internal/product-service/config"
+	productdomain "marketplace/internal/product-service/domain"
+	productkafka "marketplace/internal/product-service/transport/kafka"
+	producthandler "marketplace/internal/product-service/transport/messaging"
+	"marketplace/pkg/messaging"
+	pb "marketplace/pkg/proto/basket"
+	cp "marketplace/pkg/proto/common"
+	eventsProto "marketplace/pkg/proto/events"
+	pPayment "marketplace/pkg/proto/payment"
+	pp "marketplace/pkg/proto/product"
+)
+
+const webhookSecret = "whsec_saga_test"
+
+func TestOrderPaymentStockNotificationSaga(t *testing.T) {
+	broker := messaging.NewMemoryBroker()
+	broker.RetryDelay = func(int32) time.Duration { return 0 }
+	brokers := []string{"memory"}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Order service: PAYMENT_SUCCESSFUL'u tüketir, ORDER_CREATED'ı outbox üzerinden yayınlar.
+	orders := &orderRepo{statuses: make(map[uuid.UUID]orderdomain.OrderStatus)}
+	orderRouter, err := orderhandler.SetupMessageHandlers(orders)
+	if err != nil {
+		t.Fatal(err)
+	}
+	orderConsumer, err := orderkafka.NewConsumerWithBroker(orderconfig.MessagingConfig{Brokers: brokers}, orderRouter, nil, nil, broker.Connect)
+	if err != nil {
+		t.Fatal(err)
+	}
+	orders.relay = orderConsumer.Client()
+
+	// Product service: ödeme başarılı olunca rezerve edilen stoku onaylar.
+	products := &productRepo{}
+	productRouter, err := producthandler.SetupMessageHandlers(products)
+	if err != nil {
+		t.Fatal(err)
+	}
+	productConsumer, err := productkafka.NewConsumerWithBroker(productconfig.MessagingConfig{Brokers: brokers}, productRouter, nil, nil, broker.Connect)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Notification service: sipariş ve ödeme için kullanıcıya e-posta gönderir.
+	userID := uuid.New()
+	mailer := &emailProvider{}
+	notificationRouter, err := notificationhandler.SetupMessageHandlers(mailer, templates{}, notificationRepo{userID: userID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	notificationConsumer, err := notificationkafka.NewConsumerWithBroker(notificationconfig.MessagingConfig{Brokers: brokers}, notificationRouter, nil, nil, broker.Connect)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Payment service: olay tüketmez; Stripe webhook'u PAYMENT_SUCCESSFUL yayınlar.
+	paymentRouter, err := paymenthandler.SetupMessageHandlers()
+	if err != nil {
+		t.Fatal(err)
+	}
+	paymentConsumer, err := paymentkafka.NewConsumerWithBroker(paymentconfig.MessagingConfig{Brokers: brokers}, paymentRouter, broker.Connect)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	consumers := []interface {
+		Start(context.Context)
+		Close() error
+	}{orderConsumer, productConsumer, notificationConsumer, paymentConsumer}
+	for _, c := range consumers {
+		c.Start(ctx)
+	}
+	defer func() {
+		cancel()
+		for _, c := range consumers {
+			c.Close()
+		}
+	}()
+
+	productID := uuid.New()
+	createOrder := orderusecase.NewCreateOrderUseCase(orders, productClient{}, basketClient{productID: productID}, paymentClient{})
+	paymentURL, err := createOrder.Execute(ctx, userID)
+	if err != nil {
+		t.Fatalf("create order: %v", err)
+	}
+	if paymentURL == "" {
+		t.Fatal("create order returned an empty payment url")
+	}
+	orderID := orders.created()
+
+	webhookUC := paymentusecase.NewStripeWebhookUseCase(stripeService{}, paymentConsumer.Client())
+	payload, signature := checkoutCompleted(t, orderID, userID)
+	if err := webhookUC.Execute(ctx, payload, signature); err != nil {
+		t.Fatalf("stripe webhook: %v", err)
+	}
+
+	waitFor(t, "order marked as paid", func() bool { return orders.status(orderID) == orderdomain.OrderPaid })
+	waitFor(t, "stock confirmed", func() bool { return products.confirmed(orderID) })
+	waitFor(t, "order and payment emails sent", func() bool {
+		return mailer.sent("Create Order") && mailer.sent("Payment Success")
+	})
+
+	if dlq := broker.DLQ(messaging.DefaultDLQTopic); len(dlq) != 0 {
+		t.Fatalf("expected empty DLQ, got %d messages (first: %v)", len(dlq), dlq[0].ErrorReason)
+	}
+}
+
+func waitFor(t *testing.T, what string, done func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !done() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for: %s", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// checkoutCompleted, Stripe'ın göndereceği checkout.session.completed olayını imzalı olarak üretir.
+func checkoutCompleted(t *testing.T, orderID, userID uuid.UUID) ([]byte, string) {
+	t.Helper()
+	payload, err := json.Marshal(map[string]any{
+		"id":     "evt_saga_test",
+		"object": "event",
+		"type":   "checkout.session.completed",
+		"data": map[string]any{
+			"object": map[string]any{
+				"id":           "cs_saga_test",
+				"object":       "checkout.session",
+				"amount_total": 2000,
+				"metadata": map[string]string{
+					"order_id":   orderID.String(),
+					"user_id":    userID.String(),
+					"user_name":  "saga",
+					"user_email": "saga@example.com",
+				},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	signed := webhook.GenerateTestSignedPayload(&webhook.UnsignedPayload{Payload: payload, Secret: webhookSecret})
+	return signed.Payload, signed.Header
+}
+
+// orderRepo, siparişleri bellekte tutar. CreateOrder olayları outbox yerine doğrudan
+// yayınlar; relay'in commit sonrası yaptığı işin aynısıdır.
+type orderRepo struct {
+	orderdomain.OrderRepository
+
+	relay orderdomain.Messaging
+
+	mu       sync.Mutex
+	order    uuid.UUID
+	statuses map[uuid.UUID]orderdomain.OrderStatus
+}
+
+func (r *orderRepo) CreateOrder(ctx context.Context, order *orderdomain.Order, events ...*eventsProto.Message) error {
+	r.mu.Lock()
+	r.order = order.ID
+	r.statuses[order.ID] = order.Status
+	r.mu.Unlock()
+
+	for _, msg := range events {
+		if err := r.relay.PublishMessage(ctx, msg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *orderRepo) UpdateOrderStatus(_ context.Context, orderID uuid.UUID, status orderdomain.OrderStatus) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.statuses[orderID]; !ok {
+		return orderdomain.ErrOrderNotFound
+	}
+	r.statuses[orderID] = status
+	return nil
+}
+
+func (r *orderRepo) created() uuid.UUID {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.order
+}
+
+func (r *orderRepo) status(orderID uuid.UUID) orderdomain.OrderStatus {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.statuses[orderID]
+}
+
+type basketClient struct {
+	orderdomain.BasketClient
+	productID uuid.UUID
+}
+
+func (c basketClient) GetBasket(_ context.Context, userID string) (*pb.BasketResponse, error) {
+	return &pb.BasketResponse{
+		UserId: userID,
+		Items:  []*pb.BasketItem{{ProductId: c.productID.String(), Quantity: 2, Price: 10}},
+	}, nil
+}
+
+type productClient struct {
+	orderdomain.ProductClient
+}
+
+func (productClient) ReserveStock(_ context.Context, _ string, items []*cp.OrderItemData) (*pp.ReserveStockResponse, error) {
+	resp := &pp.ReserveStockResponse{Success: true}
+	for _, item := range items {
+		resp.Products = append(resp.Products, &pp.ProductResponse{
+			Id:       item.ProductId,
+			Name:     "Saga Product",
+			Price:    10,
+			SellerId: uuid.NewString(),
+		})
+	}
+	return resp, nil
+}
+
+type paymentClient struct {
+	orderdomain.PaymentClient
+}
+
+func (paymentClient) CreatePaymentSession(_ context.Context, orderID, _, _ string, _ float64) (*pPayment.CreatePaymentResponse, error) {
+	return &pPayment.CreatePaymentResponse{PaymentUrl: "https://checkout.test/" + orderID, SessionId: "cs_saga_test"}, nil
+}
+
+type stripeService struct {
+	paymentdomain.StripeService
+}
+
+func (stripeService) GetWebhookSecret() string { return webhookSecret }
+
+// productRepo, sadece stok onayını kaydeder.
+type productRepo struct {
+	productdomain.ProductRepository
+
+	mu    sync.Mutex
+	stock map[uuid.UUID]bool
+}
+
+func (r *productRepo) ConfirmStock(_ context.Context, orderID uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.stock == nil {
+		r.stock = make(map[uuid.UUID]bool)
+	}
+	r.stock[orderID] = true
+	return nil
+}
+
+func (r *productRepo) confirmed(orderID uuid.UUID) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.stock[orderID]
+}
+
+type notificationRepo struct {
+	notificationdomain.NotificationRepository
+	userID uuid.UUID
+}
+
+func (r notificationRepo) GetUser(_ context.Context, userID uuid.UUID) (*notificationdomain.User, error) {
+	if userID != r.userID {
+		return nil, fmt.Errorf("user %s not found", userID)
+	}
+	return &notificationdomain.User{ID: userID.String(), Username: "saga", Email: "saga@example.com"}, nil
+}
+
+type templates struct{}
+
+func (templates) Render(name string, _ interface{}) (string, error) { return name, nil }
+
+type emailProvider struct {
+	mu       sync.Mutex
+	subjects []string
+}
+
+func (p *emailProvider) SendEmail(_ string, subject string, _ string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.subjects = append(p.subjects, subject)
+	return nil
+}
+
+func (p *emailProvider) sent(subject string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, s := range p.subjects {
+		if s == subject {
+			return true
+		}
+	}
+	return false
+}
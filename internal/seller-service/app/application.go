@@ -26,8 +26,15 @@ type App struct {
 	cloudinarySvc domain.ImageService
 }
 
+// NewApp, uygulamayı Kafka broker'ı ile kurar.
 func NewApp(cfg config.Config) (*App, error) {
-	container, err := buildContainer(cfg)
+	return NewAppWithBroker(cfg, messaging.NewKafkaBroker)
+}
+
+// NewAppWithBroker, uygulamayı verilen broker factory ile kurar. Üretici ve tüketiciler
+// aynı factory'den bağlanır; testlerde messaging.MemoryBroker.Connect verilerek Kafka gerekmez.
+func NewAppWithBroker(cfg config.Config, connect messaging.BrokerFactory) (*App, error) {
+	container, err := buildContainer(cfg, connect)
 	if err != nil {
 		return nil, fmt.Errorf("bootstrap failed: %w", err)
 	}
@@ -45,6 +52,8 @@ func (a *App) Start() error {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
+	// Outbox'taki olayları Kafka'ya aktaran relay; Kafka erişilemezken olaylar DB'de bekler.
+	go a.repository.Outbox().Run(ctx, a.messaging)
 	go graceful.WaitForShutdown(a.server.FiberApp(), 5*time.Second, ctx)
 
 	log.Printf("starting seller-service on %s", a.server.Address())
@@ -70,9 +79,9 @@ func getKafkaSettings(cfg config.MessagingConfig) messaging.KafkaConfig {
 	}
 	return messaging.KafkaConfig{
 		Brokers:              []string{broker},
-		Topic:                "main-events", // Ana olay topic'i
-		RetryTopic:           "main-events-retry",
-		DLQTopic:             "main-events-dlq",
+		Topic:                messaging.DefaultTopic, // Ana olay topic'i
+		RetryTopic:           messaging.DefaultRetryTopic,
+		DLQTopic:             messaging.DefaultDLQTopic,
 		ServiceType:          pb.ServiceType_SELLER_SERVICE,
 		EnableRetry:          true,
 		MaxRetries:           3,
@@ -81,14 +90,14 @@ func getKafkaSettings(cfg config.MessagingConfig) messaging.KafkaConfig {
 	}
 }
 
-func buildContainer(cfg config.Config) (*container, error) {
+func buildContainer(cfg config.Config, connect messaging.BrokerFactory) (*container, error) {
 	repo, err := initStorage(cfg)
 	if err != nil {
 		return nil, fmt.Errorf("init postgres repository: %w", err)
 	}
 
 	messagingConfig := getKafkaSettings(cfg.Messaging)
-	kafkaClient, err := messaging.NewKafkaClient(messagingConfig)
+	kafkaClient, err := connect(messagingConfig)
 	if err != nil {
 		return nil, err
 	}
@@ -96,7 +105,7 @@ func buildContainer(cfg config.Config) (*container, error) {
 	if err != nil {
 		return nil, fmt.Errorf("init cloudinary service: %w", err)
 	}
-	httpRouter := setupHttpRouter(cfg, repo, kafkaClient, cloudinarySvc)
+	httpRouter := setupHttpRouter(cfg, repo, cloudinarySvc)
 
 	return &container{
 		repo:          repo,
@@ -114,14 +123,15 @@ func initStorage(cfg config.Config) (domain.SellerRepository, error) {
 	return repo, nil
 }
 
-func setupHttpRouter(cfg config.Config, r domain.SellerRepository, m domain.Messaging, c domain.ImageService) server.RouteRegistrar {
+func setupHttpRouter(cfg config.Config, r domain.SellerRepository, c domain.ImageService) server.RouteRegistrar {
 
-	httpHandlers := httptransport.NewHandlers(r, m, c)
+	httpHandlers := httptransport.NewHandlers(r, c)
 	return httptransport.NewRouter(httpHandlers)
 }
 
 func getServerConfig(cfg config.Config) server.Config {
 	return server.Config{
+		Name:         "seller-service",
 		Port:         cfg.Server.Port,
 		IdleTimeout:  5 * time.Second,
 		ReadTimeout:  10 * time.Second,
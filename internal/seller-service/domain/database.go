@@ -4,16 +4,22 @@ package domain
 import (
 	"context"
 
+	"marketplace/pkg/outbox"
+	pb "marketplace/pkg/proto/events"
+
 	"github.com/google/uuid"
 )
 
 type SellerRepository interface {
 	Close() error
 	Create(ctx context.Context, seller *Seller) (string, error)
-	ApproveSeller(ctx context.Context, sellerId, approvedBy string) (string, error)
-	RejectSeller(ctx context.Context, sellerId string, rejectedBy string, rejectionReason string) (string, error)
+	// ApproveSeller ve RejectSeller, newEvent'in ürettiği olayı durum değişikliğiyle
+	// aynı transaction'da outbox'a yazar. newEvent satıcının user_id'sini alır.
+	ApproveSeller(ctx context.Context, sellerId, approvedBy string, newEvent func(sellerUserID string) *pb.Message) (string, error)
+	RejectSeller(ctx context.Context, sellerId string, rejectedBy string, rejectionReason string, newEvent func(sellerUserID string) *pb.Message) (string, error)
 	GetSellerByUserID(ctx context.Context, userID uuid.UUID) (*Seller, error)
 	UpdateForReapplication(ctx context.Context, seller *Seller) error
 	UpdateStoreLogo(ctx context.Context, userID uuid.UUID, sellerID uuid.UUID, storeLogo string) error
 	UpdateStoreBanner(ctx context.Context, userID uuid.UUID, sellerID uuid.UUID, storeBanner string) error
+	Outbox() *outbox.Outbox
 }
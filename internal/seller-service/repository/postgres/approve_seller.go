@@ -1,6 +1,11 @@
 package postgres
 
-import "context"
+import (
+	"context"
+	"fmt"
+
+	pb "marketplace/pkg/proto/events"
+)
 
 const approveSellerQuery = `
     UPDATE sellers
@@ -11,11 +16,27 @@ const approveSellerQuery = `
     RETURNING user_id;
 `
 
-func (r *Repository) ApproveSeller(ctx context.Context, sellerId string, approvedBy string) (string, error) {
-	row := r.db.QueryRowContext(ctx, approveSellerQuery, approvedBy, sellerId)
+func (r *Repository) ApproveSeller(ctx context.Context, sellerId string, approvedBy string, newEvent func(string) *pb.Message) (string, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return "", fmt.Errorf("transaction begin failed: %w", err)
+	}
+	defer tx.Rollback()
+
+	row := tx.QueryRowContext(ctx, approveSellerQuery, approvedBy, sellerId)
 	var userId string
 	if err := row.Scan(&userId); err != nil {
 		return "", err
 	}
+
+	if newEvent != nil {
+		if err := r.outbox.Add(ctx, tx, newEvent(userId)); err != nil {
+			return "", err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return "", fmt.Errorf("transaction commit failed: %w", err)
+	}
 	return userId, nil
 }
@@ -1,6 +1,11 @@
 package postgres
 
-import "context"
+import (
+	"context"
+	"fmt"
+
+	pb "marketplace/pkg/proto/events"
+)
 
 const rejectSellerQuery = `
     UPDATE sellers
@@ -17,15 +22,31 @@ const rejectSellerHistoryQuery = `
         VALUES ($1, 'rejected', $2, $3);
 `
 
-func (r *Repository) RejectSeller(ctx context.Context, sellerId string, rejectedBy string, rejectionReason string) (string, error) {
-	row := r.db.QueryRowContext(ctx, rejectSellerQuery, rejectedBy, rejectionReason, sellerId)
+func (r *Repository) RejectSeller(ctx context.Context, sellerId string, rejectedBy string, rejectionReason string, newEvent func(string) *pb.Message) (string, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return "", fmt.Errorf("transaction begin failed: %w", err)
+	}
+	defer tx.Rollback()
+
+	row := tx.QueryRowContext(ctx, rejectSellerQuery, rejectedBy, rejectionReason, sellerId)
 	var userId string
 	if err := row.Scan(&userId); err != nil {
 		return "", err
 	}
-	_, err := r.db.ExecContext(ctx, rejectSellerHistoryQuery, sellerId, rejectionReason, rejectedBy)
+	_, err = tx.ExecContext(ctx, rejectSellerHistoryQuery, sellerId, rejectionReason, rejectedBy)
 	if err != nil {
 		return "", err
 	}
+
+	if newEvent != nil {
+		if err := r.outbox.Add(ctx, tx, newEvent(userId)); err != nil {
+			return "", err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return "", fmt.Errorf("transaction commit failed: %w", err)
+	}
 	return userId, nil
 }
@@ -4,11 +4,13 @@ package postgres
 import (
 	"database/sql"
 	"errors"
+	"fmt"
 
 	_ "github.com/lib/pq"
 
 	"marketplace/internal/seller-service/config"
 	"marketplace/internal/seller-service/domain"
+	"marketplace/pkg/outbox"
 )
 
 var (
@@ -24,7 +26,8 @@ var (
 )
 
 type Repository struct {
-	db *sql.DB
+	db     *sql.DB
+	outbox *outbox.Outbox
 }
 
 func NewRepository(cfg config.Config) (domain.SellerRepository, error) {
@@ -37,11 +40,20 @@ func NewRepository(cfg config.Config) (domain.SellerRepository, error) {
 		return nil, err
 	}
 
-	repo := &Repository{db: db}
+	ob, err := outbox.New(db, outbox.Config{Relay: cfg.Outbox.Relay})
+	if err != nil {
+		return nil, fmt.Errorf("init outbox: %w", err)
+	}
+
+	repo := &Repository{db: db, outbox: ob}
 
 	return repo, nil
 }
 
+func (r *Repository) Outbox() *outbox.Outbox {
+	return r.outbox
+}
+
 func (r *Repository) Close() error {
 	if r.db != nil {
 		return r.db.Close()
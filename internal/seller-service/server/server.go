@@ -3,6 +3,9 @@ package server
 import (
 	"fmt"
 	"log"
+	"marketplace/pkg/discovery"
+	"marketplace/pkg/metrics"
+	"marketplace/pkg/tracing"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
@@ -15,6 +18,8 @@ type RouteRegistrar interface {
 }
 
 type Config struct {
+	// Name, servisin gateway.yaml'daki adıdır; self-registration bu adla yapılır.
+	Name         string
 	GrpcPort     string
 	Port         string
 	IdleTimeout  time.Duration
@@ -23,8 +28,9 @@ type Config struct {
 }
 
 type Server struct {
-	app *fiber.App
-	cfg Config
+	app       *fiber.App
+	cfg       Config
+	announcer *discovery.Announcer
 }
 
 func New(cfg Config, registrar RouteRegistrar) *Server {
@@ -42,10 +48,12 @@ func New(cfg Config, registrar RouteRegistrar) *Server {
 		AllowCredentials: true,
 	}))
 	app.Use(requestid.New())
+	app.Use(tracing.Middleware())
 
 	app.Get("/health", func(c *fiber.Ctx) error {
 		return c.JSON(fiber.Map{"status": "UP"})
 	})
+	app.Get("/metrics", metrics.Handler(metrics.Default))
 
 	if registrar != nil {
 		registrar.Register(app)
@@ -58,6 +66,22 @@ func New(cfg Config, registrar RouteRegistrar) *Server {
 }
 
 func (s *Server) Start() error {
+	// Self-registration: DISCOVERY_REDIS_ADDR tanımlıysa instance, HTTP sunucusu dinlemeye
+	// başlayınca Redis'e kaydedilir ve kapanırken kaydı silinir (bkz. pkg/discovery).
+	announcer, err := discovery.FromEnv(s.cfg.Name, s.cfg.Port)
+	if err != nil {
+		return fmt.Errorf("discovery: %w", err)
+	}
+	s.announcer = announcer
+	s.app.Hooks().OnListen(func(fiber.ListenData) error {
+		s.announcer.Start()
+		return nil
+	})
+	s.app.Hooks().OnShutdown(func() error {
+		s.announcer.Stop()
+		return nil
+	})
+	defer s.announcer.Stop()
 
 	// 2. HTTP Fiber sunucusunu başlatın (Bu çağrı bloklayıcıdır)
 	log.Printf("🌐 HTTP sunucusu %s adresinde dinliyor...", s.cfg.Port)
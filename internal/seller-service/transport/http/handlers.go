@@ -27,7 +27,6 @@ type adminHandlers struct {
 
 func NewHandlers(
 	repository domain.SellerRepository,
-	messaging domain.Messaging,
 	cloudinary domain.ImageService,
 ) *Handlers {
 	return &Handlers{
@@ -38,8 +37,8 @@ func NewHandlers(
 			UploadBanner: controller.NewUploadStoreBannerController(usecase.NewUploadStoreBannerUseCase(repository, cloudinary)),
 		},
 		Admin: &adminHandlers{
-			Approve: controller.NewApproveSellerController(usecase.NewApproveSellerUseCase(repository, messaging)),
-			Reject:  controller.NewRejectSellerController(usecase.NewRejectSellerUseCase(repository, messaging)),
+			Approve: controller.NewApproveSellerController(usecase.NewApproveSellerUseCase(repository)),
+			Reject:  controller.NewRejectSellerController(usecase.NewRejectSellerUseCase(repository)),
 		},
 	}
 }
@@ -2,7 +2,6 @@ package usecase
 
 import (
 	"context"
-	"fmt"
 	"marketplace/internal/seller-service/domain"
 	pb "marketplace/pkg/proto/events"
 
@@ -14,41 +13,34 @@ type ApproveSellerUseCase interface {
 }
 type approveSellerUseCase struct {
 	sellerRepository domain.SellerRepository
-	messaging        domain.Messaging
 }
 
-func NewApproveSellerUseCase(repository domain.SellerRepository, messaging domain.Messaging) ApproveSellerUseCase {
+func NewApproveSellerUseCase(repository domain.SellerRepository) ApproveSellerUseCase {
 	return &approveSellerUseCase{
 		sellerRepository: repository,
-		messaging:        messaging,
 	}
 }
 
 func (u *approveSellerUseCase) Execute(ctx context.Context, sellerId, approvedBy string) error {
+	// SELLER_APPROVED olayı onayla aynı transaction'da outbox'a yazılır;
+	// Kafka'ya aktarımı outbox relay yapar.
+	_, err := u.sellerRepository.ApproveSeller(ctx, sellerId, approvedBy, func(sellerUserId string) *pb.Message {
+		return newSellerApprovedEvent(sellerId, approvedBy, sellerUserId)
+	})
+	return err
+}
 
-	sellerUserId, err := u.sellerRepository.ApproveSeller(ctx, sellerId, approvedBy)
-	if err != nil {
-		return err
-	}
-	fmt.Println("seller user id ", sellerUserId)
-
-	// Publish message to Kafka
-
+func newSellerApprovedEvent(sellerId, approvedBy, sellerUserId string) *pb.Message {
 	data := &pb.SellerApprovedData{
 		SellerId:   sellerId,
 		ApprovedBy: approvedBy,
 		UserId:     sellerUserId,
 	}
-	message := &pb.Message{
+	return &pb.Message{
 		Id:          uuid.New().String(),
 		Type:        pb.MessageType_SELLER_APPROVED,
 		FromService: pb.ServiceType_SELLER_SERVICE,
 		ToServices:  []pb.ServiceType{pb.ServiceType_USER_SERVICE, pb.ServiceType_PRODUCT_SERVICE, pb.ServiceType_NOTIFICATION_SERVICE},
 		Payload:     &pb.Message_SellerApprovedData{SellerApprovedData: data},
 	}
-
-	if err := u.messaging.PublishMessage(ctx, message); err != nil {
-		return fmt.Errorf("failed to publish message: %w", err)
-	}
-	return nil
 }
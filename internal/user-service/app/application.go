@@ -20,8 +20,6 @@ import (
 	pb "marketplace/pkg/proto/events"
 
 	"time"
-
-	"google.golang.org/protobuf/proto"
 )
 
 type App struct {
@@ -34,8 +32,15 @@ type App struct {
 	cloudinarySvc domain.ImageService
 }
 
+// NewApp, uygulamayı Kafka broker'ı ile kurar.
 func NewApp(cfg config.Config) (*App, error) {
-	container, err := buildContainer(cfg)
+	return NewAppWithBroker(cfg, messaging.NewKafkaBroker)
+}
+
+// NewAppWithBroker, uygulamayı verilen broker factory ile kurar. Üretici ve tüketiciler
+// aynı factory'den bağlanır; testlerde messaging.MemoryBroker.Connect verilerek Kafka gerekmez.
+func NewAppWithBroker(cfg config.Config, connect messaging.BrokerFactory) (*App, error) {
+	container, err := buildContainer(cfg, connect)
 	if err != nil {
 		return nil, fmt.Errorf("bootstrap failed: %w", err)
 	}
@@ -57,7 +62,8 @@ func (a *App) Start() error {
 
 	// Start Kafka consumer
 	a.consumer.Start(ctx)
-	go a.startOutboxRelay(ctx)
+	// Outbox'taki olayları Kafka'ya aktaran relay; Kafka erişilemezken olaylar DB'de bekler.
+	go a.repository.Outbox().Run(ctx, a.messaging)
 	go graceful.WaitForShutdown(a.server.FiberApp(), 5*time.Second, ctx)
 
 	log.Printf("starting user-service on %s", a.server.Address())
@@ -65,60 +71,14 @@ func (a *App) Start() error {
 		return fmt.Errorf("server exited with error: %w", err)
 	}
 
-	log.Println("server stopped, closing repository")
-	return a.repository.Close()
-}
-
-func (a *App) startOutboxRelay(ctx context.Context) {
-	// Her 2 saniyede bir kontrol et (İhtiyaca göre 500ms de olabilir)
-	ticker := time.NewTicker(2 * time.Second)
-	defer ticker.Stop()
-
-	log.Println("Outbox Relay worker started...")
-
-	for {
-		select {
-		case <-ctx.Done(): // Uygulama kapanırken işçiyi de durdururuz
-			log.Println("Outbox Relay worker stopping...")
-			return
-		case <-ticker.C:
-			a.processOutboxMessages(ctx)
-		}
-	}
-}
-func (a *App) processOutboxMessages(ctx context.Context) {
-	// 1. Bekleyen mesajları çek (Örn: her seferinde 10 tane)
-	messages, err := a.repository.GetPendingOutboxMessages(ctx, 10)
-	if err != nil {
-		log.Printf("Relay: error fetching messages: %v", err)
-		return
-	}
-
-	if len(messages) == 0 {
-		return // Bekleyen mesaj yoksa bir sonraki ticker'ı bekle
+	// Consumer'ları durdur; çalışan handler'lar bitip commit'ler yazılana kadar bekle.
+	cancel()
+	if err := a.consumer.Close(); err != nil {
+		log.Printf("kafka consumer close error: %v", err)
 	}
 
-	for _, msg := range messages {
-		// 2. Mesajı Kafka'ya gönder
-		// msg.Payload zaten []byte olduğu için doğrudan gönderebiliriz
-		// Eğer PublishMessage metodun pb.Message istiyorsa Unmarshal etmen gerekir
-
-		var protoMsg pb.Message
-		if err := proto.Unmarshal(msg.Payload, &protoMsg); err != nil {
-			log.Printf("Relay: unmarshal error: %v", err)
-			continue
-		}
-
-		err := a.messaging.PublishMessage(ctx, &protoMsg)
-		if err != nil {
-			log.Printf("Relay: failed to publish message %s: %v", msg.ID, err)
-			continue // Bir sonrakine geç, bu PENDING kalmaya devam edecek
-		}
-		// 3. Başarılıysa DB'de PROCESSED yap
-		if err := a.repository.MarkOutboxAsProcessed(ctx, msg.ID); err != nil {
-			log.Printf("Relay: failed to mark message %s as processed: %v", msg.ID, err)
-		}
-	}
+	log.Println("server stopped, closing repository")
+	return a.repository.Close()
 }
 
 type container struct {
@@ -138,9 +98,9 @@ func createMessagingConfig(cfg config.MessagingConfig) messaging.KafkaConfig {
 	kafkaBrokers := []string{broker}
 	return messaging.KafkaConfig{
 		Brokers:              kafkaBrokers,
-		Topic:                "main-events", // Ana olay topic'i
-		RetryTopic:           "main-events-retry",
-		DLQTopic:             "main-events-dlq",
+		Topic:                messaging.DefaultTopic, // Ana olay topic'i
+		RetryTopic:           messaging.DefaultRetryTopic,
+		DLQTopic:             messaging.DefaultDLQTopic,
 		ServiceType:          pb.ServiceType_USER_SERVICE,
 		EnableRetry:          true,
 		MaxRetries:           3,
@@ -148,7 +108,7 @@ func createMessagingConfig(cfg config.MessagingConfig) messaging.KafkaConfig {
 		CriticalMessageTypes: []pb.MessageType{pb.MessageType_USER_CREATED},
 	}
 }
-func buildContainer(cfg config.Config) (*container, error) {
+func buildContainer(cfg config.Config, connect messaging.BrokerFactory) (*container, error) {
 
 	repo, sessionRepo, err := initStorage(cfg)
 	if err != nil {
@@ -164,14 +124,17 @@ func buildContainer(cfg config.Config) (*container, error) {
 		return nil, fmt.Errorf("cloudinary init failed: %w", err)
 	}
 
-	messagingHandlers := messaginghandler.SetupMessageHandlers(repo)
-	kafkaConsumer, err := kafka.NewConsumer(cfg.Messaging, messagingHandlers)
+	messagingHandlers, err := messaginghandler.SetupMessageHandlers(repo)
+	if err != nil {
+		return nil, fmt.Errorf("message handlers init failed: %w", err)
+	}
+	kafkaConsumer, err := kafka.NewConsumerWithBroker(cfg.Messaging, messagingHandlers, connect)
 	if err != nil {
 		return nil, fmt.Errorf("kafka init failed: %w", err)
 	}
 	msgClient := kafkaConsumer.Client()
 
-	httpRouter := setupHttpRouter(cfg, repo, sessionRepo, msgClient, cloudinarySvc)
+	httpRouter := setupHttpRouter(cfg, repo, sessionRepo, cloudinarySvc)
 	grpcHandler := grpctransport.NewAuthGrpcHandler(sessionRepo)
 
 	return &container{
@@ -186,6 +149,7 @@ func buildContainer(cfg config.Config) (*container, error) {
 
 func getServerConfig(cfg config.Config) server.Config {
 	return server.Config{
+		Name:         "user-service",
 		Port:         cfg.Server.Port,
 		GrpcPort:     cfg.Server.GrpcPort,
 		IdleTimeout:  5 * time.Second,
@@ -208,9 +172,9 @@ func initStorage(cfg config.Config) (domain.UserRepository, domain.SessionReposi
 	return repo, sessionRepo, nil
 }
 
-func setupHttpRouter(cfg config.Config, r domain.UserRepository, s domain.SessionRepository, m domain.Messaging, c domain.ImageService) server.RouteRegistrar {
+func setupHttpRouter(cfg config.Config, r domain.UserRepository, s domain.SessionRepository, c domain.ImageService) server.RouteRegistrar {
 	userService := domain.NewUserService(r)
 
-	httpHandlers := httptransport.NewHandlers(userService, r, s, m, c)
+	httpHandlers := httptransport.NewHandlers(userService, r, s, c)
 	return httptransport.NewRouter(httpHandlers)
 }
@@ -33,6 +33,12 @@ type ServerConfig struct {
 type MessagingConfig struct {
 	Brokers []string `mapstructure:"brokers"`
 }
+
+// OutboxConfig, outbox relay modunu seçer: "poll" (varsayılan) veya "cdc".
+// cdc modu Postgres'te wal_level=logical gerektirir; kullanılamazsa relay polling'e düşer.
+type OutboxConfig struct {
+	Relay string `mapstructure:"relay"`
+}
 type RedisSessionConfig struct {
 	Addr     string `mapstructure:"addr"`
 	Password string `mapstructure:"password"`
@@ -45,6 +51,7 @@ type Config struct {
 	RedisSession RedisSessionConfig `mapstructure:"redisSession"`
 	Messaging    MessagingConfig    `mapstructure:"messaging"`
 	Cloudinary   CloudinaryConfig   `mapstructure:"cloudinary"`
+	Outbox       OutboxConfig       `mapstructure:"outbox"`
 }
 
 func Read() Config {
@@ -79,6 +86,7 @@ func Read() Config {
 	v.SetDefault("database.user", "postgres")
 	v.SetDefault("database.password", "password")
 	v.SetDefault("database.db", "marketplace")
+	v.SetDefault("outbox.relay", "poll")
 
 	var cfg Config
 	if err := v.Unmarshal(&cfg); err != nil {
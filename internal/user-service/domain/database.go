@@ -2,7 +2,8 @@ package domain
 
 import (
 	"context"
-	"time"
+	"marketplace/pkg/outbox"
+	pb "marketplace/pkg/proto/events"
 
 	"github.com/google/uuid"
 )
@@ -10,19 +11,18 @@ import (
 type UserRepository interface {
 	Close() error
 	SignUp(ctx context.Context, user *User) (uuid.UUID, string, error)
-	UserActivate(ctx context.Context, activationID uuid.UUID, code string) (*User, error)
+	UserActivate(ctx context.Context, activationID uuid.UUID, code string, newEvent func(*User) *pb.Message) (*User, error)
 	SignIn(ctx context.Context, identifier, password string) (*User, error)
 	AddUserRole(ctx context.Context, userID uuid.UUID, roleName string) error
 	CreateRole(ctx context.Context, createdBy uuid.UUID, name string, permissions int64) (uuid.UUID, error)
-	ForgotPassword(ctx context.Context, identifier string) (*ForgotPasswordResult, error)
+	ForgotPassword(ctx context.Context, identifier string, newEvent func(*ForgotPasswordResult) *pb.Message) (*ForgotPasswordResult, error)
 	ResetPassword(ctx context.Context, recordID uuid.UUID, newPassword string) (uuid.UUID, error)
 	ChangePassword(ctx context.Context, userID uuid.UUID, oldPassword string, newPassword string) error
 	UpdateAvatar(ctx context.Context, userID uuid.UUID, avatarURL string) error
 
-	CreateWithOutbox(ctx context.Context, user *User, outbox *OutboxMessage) error
-	SignUpWithOutbox(ctx context.Context, user *User, payload []byte) (uuid.UUID, string, error)
-	GetPendingOutboxMessages(ctx context.Context, limit int) ([]OutboxMessage, error)
-	MarkOutboxAsProcessed(ctx context.Context, id uuid.UUID) error
+	SignUpWithOutbox(ctx context.Context, user *User, event *pb.Message) (uuid.UUID, string, error)
+	// Outbox, bu veritabanına yazılan olayları Kafka'ya taşıyan relay'i döner.
+	Outbox() *outbox.Outbox
 }
 type ForgotPasswordResult struct {
 	UserID   uuid.UUID
@@ -30,12 +30,3 @@ type ForgotPasswordResult struct {
 	Email    string
 	Token    string
 }
-
-type OutboxMessage struct {
-	ID        uuid.UUID
-	Payload   []byte
-	Topic     string
-	Status    string
-	CreatedAt time.Time
-	UpdatedAt time.Time
-}
@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"marketplace/internal/user-service/domain"
+	pb "marketplace/pkg/proto/events"
 	"time"
 
 	"github.com/google/uuid"
@@ -18,7 +19,9 @@ const (
 	insertQuery        = `INSERT INTO forgot_passwords (user_id, expires_at) VALUES ($1, $2) RETURNING id`
 )
 
-func (r *Repository) ForgotPassword(ctx context.Context, identifier string) (*domain.ForgotPasswordResult, error) {
+// ForgotPassword, sıfırlama token'ını üretir. newEvent verilmişse dönen olay
+// token ile aynı transaction'da outbox'a yazılır.
+func (r *Repository) ForgotPassword(ctx context.Context, identifier string, newEvent func(*domain.ForgotPasswordResult) *pb.Message) (*domain.ForgotPasswordResult, error) {
 
 	var (
 		userID    uuid.UUID
@@ -56,15 +59,23 @@ func (r *Repository) ForgotPassword(ctx context.Context, identifier string) (*do
 		return nil, err
 	}
 
+	result := &domain.ForgotPasswordResult{
+		UserID:   userID,
+		Username: username,
+		Email:    email,
+		Token:    tokenID.String(),
+	}
+
+	if newEvent != nil {
+		if err := r.outbox.Add(ctx, tx, newEvent(result)); err != nil {
+			return nil, err
+		}
+	}
+
 	// Transaction'ı onayla
 	if err := tx.Commit(); err != nil {
 		return nil, err
 	}
 
-	return &domain.ForgotPasswordResult{
-		UserID: userID,
-		Username: username,
-		Email:    email,
-		Token:    tokenID.String(),
-	}, nil
+	return result, nil
 }
@@ -4,12 +4,14 @@ package postgres
 import (
 	"database/sql"
 	"errors"
+	"fmt"
 	"time"
 
 	_ "github.com/lib/pq"
 
 	"marketplace/internal/user-service/config"
 	"marketplace/internal/user-service/domain"
+	"marketplace/pkg/outbox"
 )
 
 var (
@@ -25,7 +27,8 @@ var (
 )
 
 type Repository struct {
-	db *sql.DB
+	db     *sql.DB
+	outbox *outbox.Outbox
 }
 
 func NewRepository(cfg config.Config) (domain.UserRepository, error) {
@@ -38,12 +41,21 @@ func NewRepository(cfg config.Config) (domain.UserRepository, error) {
 		return nil, err
 	}
 
-	repo := &Repository{db: db}
+	ob, err := outbox.New(db, outbox.Config{Relay: cfg.Outbox.Relay})
+	if err != nil {
+		return nil, fmt.Errorf("init outbox: %w", err)
+	}
+
+	repo := &Repository{db: db, outbox: ob}
 	go repo.startCleanupJob(10 * time.Minute)
 
 	return repo, nil
 }
 
+func (r *Repository) Outbox() *outbox.Outbox {
+	return r.outbox
+}
+
 func (r *Repository) Close() error {
 	if r.db != nil {
 		return r.db.Close()
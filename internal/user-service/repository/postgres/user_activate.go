@@ -6,12 +6,14 @@ import (
 	"errors"
 	"fmt"
 	"marketplace/internal/user-service/domain"
+	pb "marketplace/pkg/proto/events"
 	"time"
 
 	"github.com/google/uuid"
 )
 
-func (r *Repository) UserActivate(ctx context.Context, activationID uuid.UUID, code string) (*domain.User, error) {
+// UserActivate, kullanıcıyı aktif eder. newEvent verilmişse dönen olay aynı transaction'da outbox'a yazılır.
+func (r *Repository) UserActivate(ctx context.Context, activationID uuid.UUID, code string, newEvent func(*domain.User) *pb.Message) (*domain.User, error) {
 	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
 		return nil, fmt.Errorf("transaction begin failed: %w", err)
@@ -46,7 +48,14 @@ func (r *Repository) UserActivate(ctx context.Context, activationID uuid.UUID, c
 		return nil, fmt.Errorf("failed to assign default buyer role: %w", err)
 	}
 
-	// 3. Adım: İşlemi onayla
+	// 3. Adım: USER_CREATED olayını outbox'a yaz
+	if newEvent != nil {
+		if err := r.outbox.Add(ctx, tx, newEvent(&user)); err != nil {
+			return nil, err
+		}
+	}
+
+	// 4. Adım: İşlemi onayla
 	if err := tx.Commit(); err != nil {
 		return nil, fmt.Errorf("transaction commit failed: %w", err)
 	}
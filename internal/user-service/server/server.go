@@ -3,6 +3,9 @@ package server
 import (
 	"fmt"
 	"log"
+	"marketplace/pkg/discovery"
+	"marketplace/pkg/metrics"
+	"marketplace/pkg/tracing"
 	"net"
 	"net/http"
 	"time"
@@ -18,6 +21,8 @@ type RouteRegistrar interface {
 }
 
 type Config struct {
+	// Name, servisin gateway.yaml'daki adıdır; self-registration bu adla yapılır.
+	Name         string
 	GrpcPort     string
 	Port         string
 	IdleTimeout  time.Duration
@@ -29,6 +34,7 @@ type Server struct {
 	app        *fiber.App
 	cfg        Config
 	grpcServer *grpc.Server
+	announcer  *discovery.Announcer
 }
 type GrpcServerRegistrar interface {
 	Register(server *grpc.Server)
@@ -49,16 +55,18 @@ func New(cfg Config, registrar RouteRegistrar, grpcRegistrar GrpcServerRegistrar
 		AllowCredentials: true,
 	}))
 	app.Use(requestid.New())
+	app.Use(tracing.Middleware())
 
 	app.Get("/health", func(c *fiber.Ctx) error {
 		return c.JSON(fiber.Map{"status": "UP"})
 	})
+	app.Get("/metrics", metrics.Handler(metrics.Default))
 
 	if registrar != nil {
 		registrar.Register(app)
 	}
 
-	grpcSrv := grpc.NewServer()
+	grpcSrv := grpc.NewServer(tracing.ServerOption())
 
 	// GrpcRegistrar varsa, implementasyonları kaydet
 	if grpcRegistrar != nil {
@@ -81,6 +89,23 @@ func (s *Server) startGrpc() error {
 	return s.grpcServer.Serve(listen)
 }
 func (s *Server) Start() error {
+	// Self-registration: DISCOVERY_REDIS_ADDR tanımlıysa instance, HTTP sunucusu dinlemeye
+	// başlayınca Redis'e kaydedilir ve kapanırken kaydı silinir (bkz. pkg/discovery).
+	announcer, err := discovery.FromEnv(s.cfg.Name, s.cfg.Port)
+	if err != nil {
+		return fmt.Errorf("discovery: %w", err)
+	}
+	s.announcer = announcer
+	s.app.Hooks().OnListen(func(fiber.ListenData) error {
+		s.announcer.Start()
+		return nil
+	})
+	s.app.Hooks().OnShutdown(func() error {
+		s.announcer.Stop()
+		return nil
+	})
+	defer s.announcer.Stop()
+
 	// 1. gRPC sunucusunu bir goroutine içinde başlatın
 	// Fiber'in Listen() çağrısı bloklayıcı olduğu için bunu yapmalıyız.
 	go func() {
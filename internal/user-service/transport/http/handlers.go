@@ -44,18 +44,17 @@ func NewHandlers(
 	userService domain.UserService,
 	repository domain.UserRepository,
 	sessionRepo domain.SessionRepository,
-	messaging domain.Messaging,
 	cloudinary domain.ImageService,
 ) *Handlers {
 
 	return &Handlers{
 		Auth: &authHandlers{
-			SignUp:         controller.NewSignUpController(usecase.NewSignUpUseCase(repository, messaging)),
+			SignUp:         controller.NewSignUpController(usecase.NewSignUpUseCase(repository)),
 			SignIn:         controller.NewSignInController(usecase.NewSignInUseCase(repository, sessionRepo)),
 			SignOut:        controller.NewSignOutController(usecase.NewSignOutUseCase(sessionRepo)),
 			AllSignOut:     controller.NewAllSignOutController(usecase.NewAllSignOutUseCase(sessionRepo)),
-			UserActivate:   controller.NewUserActivateController(usecase.NewUserActivateUseCase(repository, messaging)),
-			ForgotPassword: controller.NewForgotPasswordController(usecase.NewForgotPasswordUseCase(repository, messaging)),
+			UserActivate:   controller.NewUserActivateController(usecase.NewUserActivateUseCase(repository)),
+			ForgotPassword: controller.NewForgotPasswordController(usecase.NewForgotPasswordUseCase(repository)),
 			ResetPassword:  controller.NewResetPasswordController(usecase.NewResetPasswordUseCase(repository)),
 			ChangePassword: controller.NewChangePasswordController(usecase.NewChangePasswordUseCase(repository, sessionRepo)),
 		},
@@ -2,7 +2,6 @@ package usecase
 
 import (
 	"context"
-	"fmt"
 	"marketplace/internal/user-service/domain"
 	pb "marketplace/pkg/proto/events"
 
@@ -13,28 +12,28 @@ type ForgotPasswordUseCase interface {
 	Execute(ctx context.Context, identifier string) error
 }
 type forgotPasswordUseCase struct {
-	repo      domain.UserRepository
-	messaging domain.Messaging
+	repo domain.UserRepository
 }
 
-func NewForgotPasswordUseCase(repo domain.UserRepository, messaging domain.Messaging) ForgotPasswordUseCase {
+func NewForgotPasswordUseCase(repo domain.UserRepository) ForgotPasswordUseCase {
 	return &forgotPasswordUseCase{
-		repo:      repo,
-		messaging: messaging,
+		repo: repo,
 	}
 }
 
 func (u *forgotPasswordUseCase) Execute(ctx context.Context, identifier string) error {
 
-	result, err := u.repo.ForgotPassword(ctx, identifier)
-	if err != nil {
-		return err
-	}
+	// Olay, token ile aynı transaction'da outbox'a yazılır.
+	_, err := u.repo.ForgotPassword(ctx, identifier, newForgotPasswordEvent)
+	return err
+}
+
+func newForgotPasswordEvent(result *domain.ForgotPasswordResult) *pb.Message {
 	data := &pb.UserForgotPasswordData{
 		UserId: result.UserID.String(),
 		Token:  result.Token,
 	}
-	message := &pb.Message{
+	return &pb.Message{
 		Id:          uuid.New().String(),
 		Type:        pb.MessageType_USER_FORGOT_PASSWORD,
 		FromService: pb.ServiceType_USER_SERVICE,
@@ -43,8 +42,4 @@ func (u *forgotPasswordUseCase) Execute(ctx context.Context, identifier string)
 		ToServices:  []pb.ServiceType{pb.ServiceType_NOTIFICATION_SERVICE},
 		Payload:     &pb.Message_UserForgotPasswordData{UserForgotPasswordData: data},
 	}
-	if err := u.messaging.PublishMessage(ctx, message); err != nil {
-		return fmt.Errorf("failed to publish message: %w", err)
-	}
-	return nil
 }
@@ -10,7 +10,6 @@ import (
 	"math/big"
 
 	"github.com/google/uuid"
-	"google.golang.org/protobuf/proto"
 )
 
 type SignUpUseCase interface {
@@ -18,7 +17,6 @@ type SignUpUseCase interface {
 }
 type signUpUseCase struct {
 	userRepository domain.UserRepository
-	messaging      domain.Messaging
 }
 
 type SignUpRequest struct {
@@ -27,10 +25,9 @@ type SignUpRequest struct {
 	Password string
 }
 
-func NewSignUpUseCase(repository domain.UserRepository, messaging domain.Messaging) SignUpUseCase {
+func NewSignUpUseCase(repository domain.UserRepository) SignUpUseCase {
 	return &signUpUseCase{
 		userRepository: repository,
-		messaging:      messaging,
 	}
 }
 
@@ -60,15 +57,8 @@ func (u *signUpUseCase) Execute(ctx context.Context, user *domain.User) error {
 		Payload:     &pb.Message_UserActivationEmailData{UserActivationEmailData: data},
 	}
 
-	// 3. Mesajı byte dizisine çevir (DB'ye kaydetmek için)
-	payload, err := proto.Marshal(message)
-	if err != nil {
-		return fmt.Errorf("marshal error: %w", err)
-	}
-
-	// 4. Repository'ye gönder
-	// Artık repository'ye ID'yi de biz söylüyoruz
-	_, _, err = u.userRepository.SignUpWithOutbox(ctx, user, payload)
+	// 3. Repository'ye gönder: kullanıcı ve olay aynı transaction'da kaydedilir (outbox)
+	_, _, err := u.userRepository.SignUpWithOutbox(ctx, user, message)
 	if err != nil {
 		return err
 	}
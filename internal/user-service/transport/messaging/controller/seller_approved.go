@@ -6,6 +6,7 @@ import (
 	"fmt"
 
 	"marketplace/internal/user-service/transport/messaging/usecase"
+	"marketplace/pkg/messaging"
 	pb "marketplace/pkg/proto/events"
 
 	"github.com/google/uuid"
@@ -22,12 +23,7 @@ func NewSellerApprovedHandler(usecase usecase.SellerApprovedUseCase) *SellerAppr
 	}
 }
 
-func (h *SellerApprovedUserHandler) Handle(ctx context.Context, msg *pb.Message) error {
-
-	data := msg.GetSellerApprovedData()
-	if data == nil {
-		return fmt.Errorf("payload is nil for message ID: %s", msg.Id)
-	}
+func (h *SellerApprovedUserHandler) Handle(ctx context.Context, data *pb.SellerApprovedData, meta messaging.Meta) error {
 	fmt.Println("Seller approved use case executed", data)
 
 	// Safely convert payload to struct
@@ -37,16 +33,16 @@ func (h *SellerApprovedUserHandler) Handle(ctx context.Context, msg *pb.Message)
 	// This handles both map[string]interface{} (from JSON) and struct (if passed internally) cases reliably
 	payloadBytes, err := proto.Marshal(data)
 	if err != nil {
-		return fmt.Errorf("failed to marshal payload: %w", err)
+		return messaging.Permanent(fmt.Errorf("failed to marshal payload: %w", err))
 	}
 
 	if err := proto.Unmarshal(payloadBytes, &event); err != nil {
-		return fmt.Errorf("failed to unmarshal payload to SellerApprovedData: %w", err)
+		return messaging.Permanent(fmt.Errorf("failed to unmarshal payload to SellerApprovedData: %w", err))
 	}
 
 	idUUID, err := uuid.Parse(event.UserId)
 	if err != nil {
-		return fmt.Errorf("invalid seller user id format: %w", err)
+		return messaging.Permanent(fmt.Errorf("invalid seller user id format: %w", err))
 	}
 
 	return h.usecase.Execute(ctx, idUUID)
@@ -5,12 +5,13 @@ import (
 	"marketplace/internal/user-service/domain"
 	"marketplace/internal/user-service/transport/messaging/controller"
 	"marketplace/internal/user-service/transport/messaging/usecase"
+	"marketplace/pkg/messaging"
 
 	pb "marketplace/pkg/proto/events"
 )
 
 type Handlers struct {
-	SellerApproved domain.MessageHandler
+	SellerApproved *controller.SellerApprovedUserHandler
 }
 
 func NewHandlers(repo domain.UserRepository) *Handlers {
@@ -22,10 +23,12 @@ func NewHandlers(repo domain.UserRepository) *Handlers {
 	}
 }
 
-func SetupMessageHandlers(repo domain.UserRepository) map[pb.MessageType]domain.MessageHandler {
+func SetupMessageHandlers(repo domain.UserRepository) (*messaging.Router, error) {
 	h := NewHandlers(repo)
+	router := messaging.NewRouter()
 
-	return map[pb.MessageType]domain.MessageHandler{
-		pb.MessageType_SELLER_APPROVED: h.SellerApproved,
+	if err := messaging.Handle(router, pb.MessageType_SELLER_APPROVED, h.SellerApproved.Handle); err != nil {
+		return nil, err
 	}
+	return router, nil
 }
@@ -0,0 +1,129 @@
+package discovery
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// DefaultTTL, heartbeat gelmezse kaydın geçerli kaldığı süredir. Heartbeat TTL/3'te bir atılır;
+// iki heartbeat kaçsa bile kayıt düşmez.
+const DefaultTTL = 15 * time.Second
+
+// Announcer, bir instance'ı Redis'e kaydeder ve kapanana kadar kaydı tazeler.
+// Tüm metotlar nil Announcer üzerinde çağrılabilir; self-registration kapalıyken
+// sunucu kodu ayrıca kontrol yapmaz.
+type Announcer struct {
+	client  *redis.Client
+	service string
+	url     string
+	ttl     time.Duration
+
+	startOnce sync.Once
+	stopOnce  sync.Once
+	cancel    context.CancelFunc
+	done      chan struct{}
+}
+
+// FromEnv, DISCOVERY_REDIS_ADDR tanımlıysa servisi port üzerinden duyuran bir Announcer
+// oluşturur; tanımlı değilse nil döner.
+//
+//	DISCOVERY_ADVERTISE_URL   gateway'in instance'a ulaşacağı adres (varsayılan http://<hostname>:<port>)
+//	DISCOVERY_TTL             kaydın geçerlilik süresi (varsayılan 15s)
+func FromEnv(service, port string) (*Announcer, error) {
+	client, err := NewClientFromEnv()
+	if err != nil || client == nil {
+		return nil, err
+	}
+
+	url := os.Getenv("DISCOVERY_ADVERTISE_URL")
+	if url == "" {
+		host, err := os.Hostname()
+		if err != nil {
+			return nil, fmt.Errorf("resolve hostname: %w", err)
+		}
+		url = "http://" + host + ":" + port
+	}
+
+	ttl := DefaultTTL
+	if v := os.Getenv("DISCOVERY_TTL"); v != "" {
+		if ttl, err = time.ParseDuration(v); err != nil || ttl <= 0 {
+			return nil, fmt.Errorf("invalid DISCOVERY_TTL %q", v)
+		}
+	}
+
+	return &Announcer{client: client, service: service, url: url, ttl: ttl}, nil
+}
+
+// Start, instance'ı kaydeder ve heartbeat'i başlatır. Sunucu dinlemeye başladıktan sonra
+// çağrılmalıdır; gateway kaydı görür görmez istek gönderir.
+func (a *Announcer) Start() {
+	if a == nil {
+		return
+	}
+	a.startOnce.Do(func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		a.cancel = cancel
+		a.done = make(chan struct{})
+		go a.run(ctx)
+	})
+}
+
+func (a *Announcer) run(ctx context.Context) {
+	defer close(a.done)
+
+	ticker := time.NewTicker(a.ttl / 3)
+	defer ticker.Stop()
+
+	registered := false
+	for {
+		if err := a.heartbeat(ctx); err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			log.Printf("⚠️ Discovery heartbeat failed [%s -> %s]: %v", a.service, a.url, err)
+		} else if !registered {
+			registered = true
+			log.Printf("📣 Registered in discovery: %s -> %s (ttl: %v)", a.service, a.url, a.ttl)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (a *Announcer) heartbeat(ctx context.Context) error {
+	expiresAt := time.Now().Add(a.ttl).UnixMilli()
+	return a.client.ZAdd(ctx, Key(a.service), redis.Z{Score: float64(expiresAt), Member: a.url}).Err()
+}
+
+// Stop, heartbeat'i durdurur ve kaydı siler; gateway instance'ı TTL'i beklemeden listeden çıkarır.
+// Birden fazla kez çağrılabilir.
+func (a *Announcer) Stop() {
+	if a == nil {
+		return
+	}
+	a.stopOnce.Do(func() {
+		if a.cancel != nil {
+			a.cancel()
+			<-a.done
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		if err := a.client.ZRem(ctx, Key(a.service), a.url).Err(); err != nil {
+			log.Printf("⚠️ Discovery deregistration failed [%s -> %s]: %v", a.service, a.url, err)
+		} else {
+			log.Printf("👋 Deregistered from discovery: %s -> %s", a.service, a.url)
+		}
+		a.client.Close()
+	})
+}
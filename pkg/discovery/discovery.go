@@ -0,0 +1,64 @@
+// Package discovery, backend servislerinin kendilerini Redis'e kaydetmesini (self-registration)
+// ve gateway'in bu kayıtları okumasını sağlar.
+//
+// Her servis için Redis'te bir sorted set tutulur: discovery:<service>. Üyeler instance
+// URL'leri, skorlar kaydın geçerlilik sonudur (unix ms). Servis TTL dolmadan heartbeat ile
+// skoru ileri taşır; çöken bir instance'ın kaydı TTL sonunda kendiliğinden geçersiz olur.
+// Neden sorted set? Instance başına ayrı anahtar + TTL kullanılsaydı gateway listeyi almak
+// için SCAN yapmak zorunda kalırdı; sorted set tek bir ZRANGEBYSCORE ile okunur.
+package discovery
+
+import (
+	"context"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// keyPrefix, servis kayıtlarının Redis anahtar önekidir.
+const keyPrefix = "discovery:"
+
+// Key, servisin kayıtlarının tutulduğu sorted set'in adıdır.
+func Key(service string) string {
+	return keyPrefix + service
+}
+
+// Instances, servisin süresi dolmamış instance URL'lerini döner. Süresi dolmuş kayıtlar
+// okurken temizlenir.
+func Instances(ctx context.Context, client redis.Cmdable, service string) ([]string, error) {
+	now := strconv.FormatInt(time.Now().UnixMilli(), 10)
+	if err := client.ZRemRangeByScore(ctx, Key(service), "-inf", "("+now).Err(); err != nil {
+		return nil, err
+	}
+	return client.ZRangeByScore(ctx, Key(service), &redis.ZRangeBy{Min: now, Max: "+inf"}).Result()
+}
+
+// NewClientFromEnv, DISCOVERY_REDIS_ADDR tanımlıysa kayıtların tutulduğu Redis'e bağlanan
+// client'ı döner; tanımlı değilse nil döner (self-registration kapalı).
+//
+//	DISCOVERY_REDIS_ADDR      Redis adresi (Örn: localhost:6379)
+//	DISCOVERY_REDIS_PASSWORD  şifre (opsiyonel)
+//	DISCOVERY_REDIS_DB        veritabanı numarası (varsayılan 0)
+func NewClientFromEnv() (*redis.Client, error) {
+	addr := os.Getenv("DISCOVERY_REDIS_ADDR")
+	if addr == "" {
+		return nil, nil
+	}
+
+	db := 0
+	if v := os.Getenv("DISCOVERY_REDIS_DB"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, err
+		}
+		db = n
+	}
+
+	return redis.NewClient(&redis.Options{
+		Addr:     addr,
+		Password: os.Getenv("DISCOVERY_REDIS_PASSWORD"),
+		DB:       db,
+	}), nil
+}
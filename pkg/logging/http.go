@@ -0,0 +1,91 @@
+package logging
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// HeaderRequestID, istek kimliğini taşıyan HTTP header'ıdır. Gateway üretir, backend'e
+// iletir ve cevaba da yazar; istemci hata bildirirken bu değeri verebilir.
+const HeaderRequestID = fiber.HeaderXRequestID
+
+// localsRequestID, fiber'ın requestid middleware'inin kimliği koyduğu Locals anahtarıdır.
+const localsRequestID = "requestid"
+
+// Middleware, gateway'in istek kimliği middleware'idir: gelen X-Request-ID'yi kullanır,
+// yoksa üretir. Kimlik backend'e iletilsin diye istek header'ına da yazılır.
+// Backend servisleri kimliği fiber'ın requestid middleware'i ile alır (bkz. Bind).
+func Middleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id := c.Get(HeaderRequestID)
+		if id == "" {
+			id = uuid.New().String()
+			c.Request().Header.Set(HeaderRequestID, id)
+		}
+		c.Set(HeaderRequestID, id)
+		c.Locals(localsRequestID, id)
+		c.SetUserContext(WithRequestID(c.UserContext(), id))
+		return c.Next()
+	}
+}
+
+// Bind, isteğin kimliğini c.UserContext()'e ekler ve yeni context'i döner.
+// Kimlik önce requestid middleware'inin Locals değerinden, yoksa header'dan okunur.
+// handler.HandleBasic ve HandleWithFiber, controller'ı çağırmadan önce kullanır; controller'ların
+// yayınladığı Kafka mesajları kimliği bu context'ten alır.
+func Bind(c *fiber.Ctx) context.Context {
+	id, _ := c.Locals(localsRequestID).(string)
+	if id == "" {
+		id = c.Get(HeaderRequestID)
+	}
+	ctx := WithRequestID(c.UserContext(), id)
+	c.SetUserContext(ctx)
+	return ctx
+}
+
+// RequestError, handler'ın döndüğü hatayı isteğin kimliği ile loglar. 5xx hatalar error,
+// diğerleri (doğrulama, yetki, bulunamadı) warn seviyesindedir.
+func RequestError(c *fiber.Ctx, status int, err error) {
+	level := slog.LevelWarn
+	if status >= fiber.StatusInternalServerError {
+		level = slog.LevelError
+	}
+	slog.Log(c.UserContext(), level, "✗ Request failed",
+		"method", c.Method(),
+		"path", c.Path(),
+		"status", status,
+		"error", err,
+	)
+}
+
+// AccessLog, her isteği yöntem, yol, durum kodu ve süre ile loglar. Middleware'den sonra
+// kullanılmalıdır; request_id ve trace_id kayda context'ten eklenir.
+func AccessLog() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		start := time.Now()
+		err := c.Next()
+
+		status := c.Response().StatusCode()
+		if err != nil {
+			// Hata cevabı henüz yazılmadı; fiber'ın ErrorHandler'ı ile aynı kodu loglarız.
+			status = fiber.StatusInternalServerError
+			var fiberErr *fiber.Error
+			if errors.As(err, &fiberErr) {
+				status = fiberErr.Code
+			}
+		}
+		slog.InfoContext(c.UserContext(), "HTTP request",
+			"method", c.Method(),
+			"path", c.Path(),
+			"status", status,
+			"latency", time.Since(start),
+			"ip", c.IP(),
+		)
+		return err
+	}
+}
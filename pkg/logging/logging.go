@@ -0,0 +1,108 @@
+// Package logging, servislerin ortak slog kurulumunu ve istek kimliğinin (request ID)
+// context üzerinden taşınmasını sağlar.
+// Neden? Gateway'e gelen bir isteğin backend'deki log'ları ve o isteğin ürettiği Kafka
+// olaylarını işleyen consumer'ların log'ları aynı request_id ile aranabilmelidir.
+// Init'ten sonra standart log paketi de slog'a yönlenir; mevcut log.Printf satırları aynı
+// formatta (JSON veya text) çıkar, request_id sadece context'li slog çağrılarında eklenir.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Ortam değişkenleri:
+//   - LOG_FORMAT: "json" veya "text". Boşsa APP_ENV production/staging ise json, değilse text.
+//   - LOG_LEVEL: "debug", "info", "warn" veya "error" (varsayılan info).
+//   - APP_ENV: servisin çalıştığı ortam; log'lara "env" alanı olarak da eklenir.
+const (
+	EnvFormat      = "LOG_FORMAT"
+	EnvLevel       = "LOG_LEVEL"
+	EnvEnvironment = "APP_ENV"
+)
+
+// Init, servis adını ve ortamı her kayda ekleyen logger'ı kurar ve slog ile log paketinin
+// varsayılanı yapar. Servislerin main'i, başka bir şey loglamadan önce bir kez çağırır.
+func Init(service string) error {
+	env := strings.ToLower(os.Getenv(EnvEnvironment))
+
+	format := strings.ToLower(os.Getenv(EnvFormat))
+	if format == "" {
+		format = "text"
+		if env == "production" || env == "staging" {
+			format = "json"
+		}
+	}
+
+	level := new(slog.LevelVar)
+	if raw := os.Getenv(EnvLevel); raw != "" {
+		if err := level.UnmarshalText([]byte(raw)); err != nil {
+			return fmt.Errorf("invalid %s %q: %w", EnvLevel, raw, err)
+		}
+	}
+
+	opts := &slog.HandlerOptions{Level: level}
+	var handler slog.Handler
+	switch format {
+	case "json":
+		handler = slog.NewJSONHandler(os.Stderr, opts)
+	case "text":
+		handler = slog.NewTextHandler(os.Stderr, opts)
+	default:
+		return fmt.Errorf("unknown %s %q (want json or text)", EnvFormat, format)
+	}
+
+	attrs := []slog.Attr{slog.String("service", service)}
+	if env != "" {
+		attrs = append(attrs, slog.String("env", env))
+	}
+	logger := slog.New(contextHandler{handler.WithAttrs(attrs)})
+	slog.SetDefault(logger)
+	return nil
+}
+
+type requestIDKey struct{}
+
+// WithRequestID, istek kimliğini context'e ekler. Boş kimlik context'i değiştirmez.
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	if requestID == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, requestIDKey{}, requestID)
+}
+
+// RequestID, context'teki istek kimliğini döner; yoksa boş string.
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// contextHandler, kayda context'teki request_id'yi ve (varsa) aktif span'in trace_id'sini ekler.
+// Böylece çağıranlar her log satırında kimliği elle geçmek zorunda kalmaz:
+// slog.InfoContext(ctx, "...") yeterlidir.
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if id := RequestID(ctx); id != "" {
+		r.AddAttrs(slog.String("request_id", id))
+	}
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		r.AddAttrs(slog.String("trace_id", sc.TraceID().String()))
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
@@ -0,0 +1,38 @@
+package messaging
+
+import (
+	"context"
+
+	pb "marketplace/pkg/proto/events"
+)
+
+// Broker, servislerin mesajlaşma altyapısından beklediği tüm işlemleri tanımlar:
+// yayınlama, ana topic'i tüketme, retry kademelerini tüketme ve DLQ kurtarma.
+// Neden? Servisler somut *KafkaClient'a bağlı olduğunda hiçbir akış Kafka olmadan
+// test edilemiyordu. KafkaClient ve MemoryClient bu arayüzü uygular.
+type Broker interface {
+	PublishMessage(ctx context.Context, msg *pb.Message) error
+	ConsumeMessages(ctx context.Context, handler MessageHandler, topic *string, groupID *string) error
+	ConsumeRetryMessages(ctx context.Context, handler MessageHandler, groupID *string) error
+	ConsumeDLQWithRecovery(ctx context.Context, handler MessageHandler) error
+	Close() error
+}
+
+// BrokerFactory, servisin KafkaConfig'inden bir Broker üretir.
+// Servis consumer'ları yapılandırmayı kendileri kurar; hangi altyapının kullanılacağına
+// ise factory karar verir (üretimde NewKafkaBroker, testlerde MemoryBroker.Connect).
+type BrokerFactory func(config KafkaConfig) (Broker, error)
+
+var (
+	_ Broker = (*KafkaClient)(nil)
+	_ Broker = (*MemoryClient)(nil)
+)
+
+// NewKafkaBroker, NewKafkaClient'ın BrokerFactory imzasına uyan halidir.
+func NewKafkaBroker(config KafkaConfig) (Broker, error) {
+	client, err := NewKafkaClient(config)
+	if err != nil {
+		return nil, err
+	}
+	return client, nil
+}
@@ -12,48 +12,66 @@ import (
 // Neden? Bağlantı kurulumu sırasında otomatik olarak Topic oluşturma ve
 // Producer ayarlarını yapmak, manuel hata riskini ortadan kaldırır.
 func NewKafkaClient(config KafkaConfig) (*KafkaClient, error) {
+	if config.EnableRetry && len(config.RetryDelayTiers) == 0 {
+		config.RetryDelayTiers = DefaultRetryDelayTiers
+	}
+
 	kc := &KafkaClient{
 		config:      config,
 		serviceType: config.ServiceType,
 		workerPool:  make(chan struct{}, config.MaxConcurrentHandlers),
 	}
+	kc.trackWorkerCapacity(1)
 
-	// Topic yönetimi: Servis ayağa kalkarken topic yoksa oluşturur.
-	// Avantajı: Yeni bir servis eklediğinizde Kafka panelinden manuel topic oluşturma zahmetinden kurtarır.
-	if err := kc.createTopicsIfNotExists(); err != nil {
-		log.Printf("Warning: Failed to create topics: %v", err)
+	// Topic yönetimi: Servis ayağa kalkarken tanımlı topic'leri oluşturur ve
+	// var olanların ayarlarını tanımlarla karşılaştırır (bkz. KafkaConfig.Topics).
+	if err := kc.setupTopics(); err != nil {
+		return nil, err
 	}
 
 	// Producer ayarları: RequiredAcks: kafka.RequireAll kullanıldı.
 	// Neden? Mesajın tüm kopyalarına (replicalara) yazıldığından emin olmak için.
 	// Bu, veri kaybını (data loss) önlemek için en güvenli ayardır.
+	// Balancer: kafka.Hash, aynı anahtarı (bkz. partitionKey) her zaman aynı partition'a yazar.
 	kc.producer = &kafka.Writer{
 		Addr:         kafka.TCP(config.Brokers...),
 		Topic:        config.Topic,
-		Balancer:     &kafka.LeastBytes{},
+		Balancer:     &kafka.Hash{},
 		RequiredAcks: kafka.RequireAll,
 		Async:        false, // Veri güvenliği için senkron gönderim tercih edildi.
 	}
 
 	if config.EnableRetry && config.RetryTopic != "" {
+		// Topic bilerek boş bırakıldı: her retry mesajı, gecikmesine uygun kademe topic'ine yazılır.
 		kc.retryProducer = &kafka.Writer{
 			Addr:         kafka.TCP(config.Brokers...),
-			Topic:        config.RetryTopic,
-			Balancer:     &kafka.LeastBytes{},
+			Balancer:     &kafka.Hash{},
 			WriteTimeout: 5 * time.Second,
 			RequiredAcks: kafka.RequireOne, // Retry için 'One' yeterlidir, performans sağlar
 			MaxAttempts:  3,
 		}
-		log.Printf("✓ Retry producer initialized on topic: %s", config.RetryTopic)
+		log.Printf("✓ Retry producer initialized on topics: %v", kc.retryTopics())
 	} else {
 		log.Printf("⚠ Warning: Retry mechanism is DISABLED (Check EnableRetry or RetryTopic config)")
 	}
 
+	// DLQ trafiği azdır; yine de her mesaj için writer açıp kapatmak yerine tek writer paylaşılır.
+	if config.DLQTopic != "" {
+		kc.dlqProducer = &kafka.Writer{
+			Addr:         kafka.TCP(config.Brokers...),
+			Topic:        config.DLQTopic,
+			Balancer:     &kafka.Hash{},
+			RequiredAcks: kafka.RequireOne,
+		}
+	}
+
 	return kc, nil
 }
 
 // Close, Kafka bağlantılarını ve aktif işçileri (workers) güvenli bir şekilde kapatır.
 // Neden? Bekleyen mesajların yarım kalmasını önlemek ve 'zombi process' oluşumunu engellemek için.
+// Not: Consumer'ların context'i iptal edildikten sonra çağrılmalıdır; aksi halde
+// çalışan consumer döngüleri bitmeyeceği için Close da dönmez.
 func (kc *KafkaClient) Close() error {
 	kc.mu.Lock()
 	if kc.closed {
@@ -62,10 +80,24 @@ func (kc *KafkaClient) Close() error {
 	}
 	kc.closed = true
 	kc.mu.Unlock()
+	defer kc.trackWorkerCapacity(-1)
+
+	kc.stopReplies()
 
 	// WaitGroup (wg) kullanarak içeride hala işlenen mesajların bitmesini bekleriz.
 	kc.wg.Wait()
+	kc.closeReplyWriter()
 
+	if kc.retryProducer != nil {
+		if err := kc.retryProducer.Close(); err != nil {
+			log.Printf("✗ Retry producer close failed: %v", err)
+		}
+	}
+	if kc.dlqProducer != nil {
+		if err := kc.dlqProducer.Close(); err != nil {
+			log.Printf("✗ DLQ producer close failed: %v", err)
+		}
+	}
 	if kc.producer != nil {
 		return kc.producer.Close()
 	}
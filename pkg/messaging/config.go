@@ -22,6 +22,74 @@ type KafkaConfig struct {
 
 	AllowedMessageTypes  map[pb.ServiceType][]pb.MessageType
 	CriticalMessageTypes []pb.MessageType
+
+	// RetryDelayTiers, gecikmeli retry mesajlarının yazılacağı kademeli topic'leri belirler.
+	// Her kademe "<RetryTopic>-<saniye>s" isimli ayrı bir topic'tir (Örn: retry-events-10s).
+	// Boş bırakılırsa DefaultRetryDelayTiers kullanılır.
+	RetryDelayTiers []time.Duration
+
+	// IdempotencyStore (opsiyonel), daha önce işlenmiş mesajların handler'a tekrar
+	// verilmesini engeller. nil ise her teslimat işlenir (at-least-once).
+	IdempotencyStore IdempotencyStore
+
+	// OrderedProcessing açıkken aynı anahtara (sipariş ID, kullanıcı ID...) sahip mesajlar
+	// sırayla işlenir ve offset'ler sadece öncekilerin hepsi bittiğinde commit edilir.
+	// OrderedWorkers boşsa MaxConcurrentHandlers kadar işçi açılır.
+	// OrderingKey boşsa mesajın partition anahtarı kullanılır.
+	OrderedProcessing bool
+	OrderedWorkers    int
+	OrderingKey       func(*pb.Message) string
+
+	// PartitionKey, PublishMessage'ın Kafka anahtarını mesaj tipine göre belirleyen resolver'dır.
+	// Boşsa DefaultOrderingKey kullanılır (bkz. partitionKey).
+	PartitionKey func(*pb.Message) string
+
+	// Schemas, yayınlanan mesajlara şema sürümü yazmak ve tüketilen eski sürümleri handler'dan
+	// önce güncel sürüme taşımak için kullanılır. Boşsa DefaultSchemaRegistry kullanılır.
+	Schemas *SchemaRegistry
+
+	// Topics, servisin açılışta oluşturacağı ve ayarlarını doğrulayacağı topic'lerdir.
+	// Boşsa Topic, RetryTopic (ve kademeleri) ve DLQTopic için DefaultTopicSpecs kullanılır.
+	// StrictTopics açıksa Kafka'daki bir topic tanımdan farklıysa NewKafkaClient hata döner;
+	// kapalıysa sadece uyarı loglanır.
+	Topics       []TopicSpec
+	StrictTopics bool
+
+	// CriticalStore, DLQ'ya düşen kritik mesajların (CriticalMessageTypes) kasasıdır.
+	// Boşsa DefaultCriticalDir'de bir FileCriticalStore kullanılır.
+	CriticalStore CriticalStore
+
+	// Security (opsiyonel), mesaj tipi bazında payload şifreleme ve imzalamayı açar.
+	// nil ise mesajlar düz yazılır; şifreli bir mesaj gelirse kalıcı hata ile DLQ'ya gider.
+	Security *MessageSecurity
+
+	// ReplyTopic, RequestReply cevaplarının bu instance'a döneceği topic'tir.
+	// Boşsa "<DefaultReplyTopicPrefix>-<servis>-<ClientID veya hostname>" kullanılır.
+	ReplyTopic string
+
+	// DrainTimeout, kapanışta çalışan handler'lara tanınan süredir. Consumer context'i iptal
+	// edildiğinde yeni mesaj çekilmez; bu süre dolunca handler'ların context'i de iptal edilir.
+	// Boşsa DefaultDrainTimeout kullanılır.
+	DrainTimeout time.Duration
+}
+
+// DefaultDrainTimeout, kapanış başladığında çalışan handler'lara tanınan varsayılan süredir.
+// Handler'ların kendilerine ait bir zaman aşımı yoktur; 30s, e-posta gönderimi veya gRPC çağrısı
+// gibi normal bir işin bitmesine yeter, orchestrator'ların (Örn: Kubernetes'te varsayılan 30s)
+// süreci zorla durdurmasından önce de biter.
+const DefaultDrainTimeout = 30 * time.Second
+
+// DefaultRetryDelayTiers, calculateRetryDelay'in ürettiği backoff değerleriyle birebir örtüşür (5s, 10s, 20s ... 300s).
+// Neden? Aynı kademe topic'indeki mesajların hepsi aynı gecikmeye sahip olduğu için
+// partition başındaki mesaj her zaman en erken zamanı gelecek mesajdır; arkadakileri bekletmez.
+var DefaultRetryDelayTiers = []time.Duration{
+	5 * time.Second,
+	10 * time.Second,
+	20 * time.Second,
+	40 * time.Second,
+	80 * time.Second,
+	160 * time.Second,
+	300 * time.Second,
 }
 
 func NewDefaultConfig(kafkaBrokers []string) KafkaConfig {
@@ -31,12 +99,13 @@ func NewDefaultConfig(kafkaBrokers []string) KafkaConfig {
 
 	return KafkaConfig{
 		Brokers:              kafkaBrokers,
-		Topic:                "main-events",
-		RetryTopic:           "main-events-retry",
-		DLQTopic:             "main-events-dlq",
+		Topic:                DefaultTopic,
+		RetryTopic:           DefaultRetryTopic,
+		DLQTopic:             DefaultDLQTopic,
 		ServiceType:          pb.ServiceType_UNKNOWN_SERVICE,
 		EnableRetry:          true,
 		MaxRetries:           3,
+		RetryDelayTiers:      DefaultRetryDelayTiers,
 		ConnectionTimeout:    10 * time.Second,
 		CriticalMessageTypes: []pb.MessageType{},
 	}
@@ -2,7 +2,10 @@ package messaging
 
 import (
 	"context"
+	"errors"
 	"log"
+	"log/slog"
+	"sync"
 	"time"
 
 	pb "marketplace/pkg/proto/events"
@@ -18,6 +21,11 @@ func (kc *KafkaClient) ConsumeMessages(ctx context.Context, handler MessageHandl
 	consumerGroupID := kc.getConsumerGroupID(groupID)
 	consumerTopic := kc.getConsumerTopic(topic)
 
+	// Consumer döngüsü de wg'ye dahildir: Close(), reader kapanıp bekleyen commit'ler
+	// Kafka'ya yazılmadan dönmemelidir.
+	kc.wg.Add(1)
+	defer kc.wg.Done()
+
 	reader := kafka.NewReader(kafka.ReaderConfig{
 		Brokers:        kc.config.Brokers,
 		GroupID:        consumerGroupID,
@@ -26,87 +34,258 @@ func (kc *KafkaClient) ConsumeMessages(ctx context.Context, handler MessageHandl
 		CommitInterval: 1 * time.Second,  // Her saniye işlenen mesajları onayla
 	})
 	defer reader.Close()
+	defer kc.watchLag(reader)()
 
 	log.Printf("🚀 [Consumer] Started [service=%s, topic=%s, group=%s]", kc.serviceType.String(), consumerTopic, consumerGroupID)
 
+	// Handler'lar consumer context'inden türeyen ama onunla birlikte hemen iptal edilmeyen
+	// bir context ile çalışır (bkz. drainContext).
+	handlerCtx, stopHandlers := kc.drainContext(ctx)
+	defer stopHandlers()
+
+	if kc.config.OrderedProcessing {
+		return kc.consumeOrdered(ctx, handlerCtx, reader, handler)
+	}
+
+	// inFlight, bu reader üzerinden başlatılan ve henüz commit edilmemiş işleri sayar.
+	// Neden? Reader kapanmadan önce tüm commit'lerin yapılmasını beklememiz gerekir.
+	var inFlight sync.WaitGroup
+	defer inFlight.Wait()
+
 	for {
-		select {
-		case <-ctx.Done(): // Uygulama kapanıyorsa döngüden çık
-			return nil
-		default:
-			// Mesajı Kafka'dan çekiyoruz
-			m, err := reader.FetchMessage(ctx)
-			if err != nil {
-				if err != context.Canceled {
-					log.Printf("✗ [Consumer] Fetch error: %v", err)
-				}
-				continue
+		// Mesajı Kafka'dan çekiyoruz
+		m, err := reader.FetchMessage(ctx)
+		if err != nil {
+			if ctx.Err() != nil { // Uygulama kapanıyorsa döngüden çık
+				return nil
 			}
+			log.Printf("✗ [Consumer] Fetch error: %v", err)
+			continue
+		}
+
+		// Gelen mesajı protobuf nesnesine çeviriyoruz
+		message := &pb.Message{}
+		if err := proto.Unmarshal(m.Value, message); err != nil {
+			log.Printf("✗ [Consumer] Unmarshal failed: %v", err)
+			reader.CommitMessages(ctx, m) // Bozuk mesajı geçmek için commit et
+			continue
+		}
 
-			// Gelen mesajı protobuf nesnesine çeviriyoruz
-			var message pb.Message
-			if err := proto.Unmarshal(m.Value, &message); err != nil {
-				log.Printf("✗ [Consumer] Unmarshal failed: %v", err)
-				reader.CommitMessages(ctx, m) // Bozuk mesajı geçmek için commit et
-				continue
+		// Filtreleme: main-events tüm servislerle ortaktır. Bize gönderilmemiş veya
+		// bu servise izin verilmemiş mesajları handler'a hiç sokmadan geçiyoruz.
+		if !kc.shouldProcessMessage(message) {
+			kc.commit(ctx, reader, m, message.Id)
+			continue
+		}
+
+		// --- DELAY (BEKLETME) MANTIĞI ---
+		// Eğer mesajın bir 'RetryAfter' zamanı varsa ve o zaman henüz gelmediyse
+		// commit etmeden bekliyoruz. Süreç bu sırada kapanırsa mesaj kalan gecikmesine
+		// uygun retry kademesine geri yazılıp commit edilir; yazılamazsa Kafka'da kalır
+		// ve yeniden başlatıldığında tekrar okunur.
+		if due, requeued := kc.holdUntilDue(ctx, message); !due {
+			if requeued {
+				kc.commit(ctx, reader, m, message.Id)
+			}
+			if ctx.Err() != nil {
+				return nil
 			}
+			continue
+		}
+
+		// Backpressure: Slot goroutine açılmadan önce alınır. Havuz doluysa fetch döngüsü
+		// burada bekler; böylece bellekte en fazla MaxConcurrentHandlers kadar mesaj işlenir.
+		// Kapanış sırasında slot beklenirken çıkılırsa mesaj commit edilmez ve tekrar okunur.
+		if !kc.acquireWorker(ctx) {
+			return nil
+		}
+
+		kc.startHandler(handlerCtx, &inFlight, message, handler, func() {
+			kc.commit(ctx, reader, m, message.Id) // İşlem bitince Kafka'ya "okundu" de.
+		})
+	}
+}
 
-			// --- DELAY (BEKLETME) MANTIĞI ---
-			// Eğer mesajın bir 'RetryAfter' zamanı varsa ve o zaman henüz gelmediyse:
-			if message.RetryAfter != nil {
-				now := time.Now()
-				retryTime := message.RetryAfter.AsTime()
-
-				if now.Before(retryTime) {
-					waitDuration := retryTime.Sub(now)
-					log.Printf("⏳ [Consumer] Delaying message [id=%s, wait=%v]", message.Id, waitDuration.Round(time.Second))
-
-					// Mesajı Kafka'dan onaylıyoruz (Commit) çünkü hafızada bekleteceğiz.
-					reader.CommitMessages(ctx, m)
-
-					// Ayrı bir goroutine'de bekletip sonra işliyoruz.
-					kc.wg.Add(1)
-					go func(msg pb.Message) {
-						defer kc.wg.Done()
-						time.Sleep(waitDuration)
-						kc.executeWithWorkerPool(ctx, &msg, handler)
-					}(message)
-
-					continue
-				}
+// startHandler, worker slotu alınmış mesajı ayrı bir goroutine'de işler; handler bitince
+// done (commit) çağrılır ve slot bırakılır. İş kc.wg'ye eklenir, bu yüzden Close çalışan
+// handler'ları (en fazla DrainTimeout kadar) bekler.
+func (kc *KafkaClient) startHandler(handlerCtx context.Context, inFlight *sync.WaitGroup, msg *pb.Message, handler MessageHandler, done func()) {
+	kc.wg.Add(1)
+	inFlight.Add(1)
+	go func() {
+		defer kc.wg.Done()
+		defer inFlight.Done()
+		defer kc.releaseWorker()
+		kc.execute(handlerCtx, msg, handler)
+		done()
+	}()
+}
+
+// drainContext, handler'ların kullanacağı context'i döner.
+// Neden? Consumer context'i iptal edildiğinde (servis kapanıyor) yeni mesaj çekilmez ama
+// çalışan handler'lar yarıda kesilmez; işlerini bitirip commit edebilmeleri için
+// DrainTimeout kadar süreleri vardır. Süre dolunca handler context'i de iptal edilir.
+func (kc *KafkaClient) drainContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	handlerCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	stop := context.AfterFunc(ctx, func() {
+		log.Printf("⏸ [Consumer] Draining in-flight handlers [timeout=%v]", kc.drainTimeout())
+		time.AfterFunc(kc.drainTimeout(), cancel)
+	})
+	return handlerCtx, func() {
+		stop()
+		cancel()
+	}
+}
+
+func (kc *KafkaClient) drainTimeout() time.Duration {
+	if kc.config.DrainTimeout > 0 {
+		return kc.config.DrainTimeout
+	}
+	return DefaultDrainTimeout
+}
+
+// ConsumeRetryMessages, RetryTopic'i ve tüm gecikme kademesi topic'lerini aynı anda dinler.
+// Neden? Her kademe kendi topic'inde sıralı beklediği için 5 saniyelik bir retry,
+// 5 dakikalık bir retry'ın arkasında kuyrukta kalmaz.
+// Her kademe için grup ID'sine kademe eki eklenir (Örn: ORDER_SERVICE-retry-group-10s).
+// Retry mesajları yalnızca hatayı alan servise aittir (bkz. sendToRetry); ToServices
+// filtresi ConsumeMessages içinde uygulanır.
+func (kc *KafkaClient) ConsumeRetryMessages(ctx context.Context, handler MessageHandler, groupID *string) error {
+	if !kc.config.EnableRetry || kc.config.RetryTopic == "" {
+		return errors.New("retry topic not configured")
+	}
+
+	baseGroupID := kc.getConsumerGroupID(groupID)
+
+	var wg sync.WaitGroup
+	errCh := make(chan error, len(kc.retryTopics()))
+	for _, topic := range kc.retryTopics() {
+		group := baseGroupID
+		if topic != kc.config.RetryTopic {
+			group = baseGroupID + topic[len(kc.config.RetryTopic):]
+		}
+
+		wg.Add(1)
+		go func(topic, group string) {
+			defer wg.Done()
+			if err := kc.ConsumeMessages(ctx, handler, &topic, &group); err != nil {
+				errCh <- err
 			}
+		}(topic, group)
+	}
+
+	wg.Wait()
+	close(errCh)
+	return <-errCh
+}
+
+// waitUntilDue, mesajın RetryAfter zamanı gelene kadar bekler.
+// Neden? Eskiden mesaj commit edilip bir goroutine içinde time.Sleep ile bekletiliyordu;
+// süreç yeniden başladığında bekleyen tüm retry'lar kayboluyordu. Artık mesaj commit
+// edilmeden beklenir, yani sorumluluk Kafka'da kalır.
+// Bekleme en fazla maxRetryWait kadar sürer; zamanı hâlâ gelmemişse veya context iptal
+// edilirse false döner ve mesaj işlenmemelidir (bkz. holdUntilDue).
+func (kc *KafkaClient) waitUntilDue(ctx context.Context, msg *pb.Message) bool {
+	if msg.RetryAfter == nil {
+		return true
+	}
+
+	waitDuration := time.Until(msg.RetryAfter.AsTime())
+	if waitDuration <= 0 {
+		return true
+	}
+
+	log.Printf("⏳ [Consumer] Delaying message [id=%s, wait=%v]", msg.Id, waitDuration.Round(time.Second))
+
+	capped := waitDuration > kc.maxRetryWait()
+	if capped {
+		waitDuration = kc.maxRetryWait()
+	}
+
+	timer := time.NewTimer(waitDuration)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return !capped
+	case <-ctx.Done():
+		log.Printf("⏸ [Consumer] Shutdown while delaying [id=%s]", msg.Id)
+		return false
+	}
+}
 
-			// Normal Mesaj İşleme: Worker pool'dan izin alarak çalıştır.
-			kc.wg.Add(1)
-			go func(kafkaMsg kafka.Message, msg pb.Message) {
-				defer kc.wg.Done()
-				kc.executeWithWorkerPool(ctx, &msg, handler)
-				reader.CommitMessages(ctx, kafkaMsg) // İşlem bitince Kafka'ya "okundu" de.
-			}(m, message)
+// holdUntilDue, waitUntilDue'yu çağırır ve zamanı gelmemiş mesajı kalan gecikmesine uygun retry
+// kademesine geri yazar (requeued=true); çağıran offset'i commit edip sonraki mesaja geçer.
+// Neden? Handler en büyük kademeden uzun bir RetryAfter isteyebilir (Örn: Stripe 1 saat);
+// mesaj partition'ı tüm gecikme boyunca bekletmek yerine en büyük kademede tur atar.
+// Geri yazılamazsa (Kafka'ya erişilemiyor) mesaj bırakılmaz, beklemeye devam edilir.
+// Context iptal edildiyse due=false döner; requeued=false ise mesaj Kafka'da kalır ve
+// yeniden başlatıldığında tekrar okunur.
+func (kc *KafkaClient) holdUntilDue(ctx context.Context, msg *pb.Message) (due, requeued bool) {
+	for {
+		if kc.waitUntilDue(ctx, msg) {
+			return true, false
+		}
+		if kc.requeueDelayed(ctx, msg) {
+			return false, true
+		}
+		if ctx.Err() != nil {
+			return false, false
 		}
 	}
 }
 
+// maxRetryWait, bir mesajın partition'ı en fazla ne kadar bekletebileceğidir: en büyük retry
+// kademesi. Kademe yoksa calculateRetryDelay'in üst sınırıdır.
+func (kc *KafkaClient) maxRetryWait() time.Duration {
+	if tiers := kc.config.RetryDelayTiers; len(tiers) > 0 {
+		return tiers[len(tiers)-1]
+	}
+	return 300 * time.Second
+}
+
+// commit, mesajı Kafka'ya "okundu" olarak bildirir.
+// Uygulama kapanırken de işlenmiş mesajların commit edilebilmesi için iptal edilmeyen bir context kullanılır.
+func (kc *KafkaClient) commit(ctx context.Context, reader *kafka.Reader, kafkaMsg kafka.Message, id string) {
+	commitCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+	defer cancel()
+
+	if err := reader.CommitMessages(commitCtx, kafkaMsg); err != nil {
+		log.Printf("✗ [Worker] Commit failed [id=%s]: %v", id, err)
+	}
+}
+
 // executeWithWorkerPool, mesajı işlerken sistem kaynaklarını korur.
 // Neden? Aynı anda MaxConcurrentHandlers kadar işin yapılmasını sağlar.
-func (kc *KafkaClient) executeWithWorkerPool(ctx context.Context, msg *pb.Message, handler MessageHandler) {
+// Slot beklenirken ctx iptal edilirse mesaj işlenmez ve false döner.
+func (kc *KafkaClient) executeWithWorkerPool(ctx context.Context, msg *pb.Message, handler MessageHandler) bool {
 	// Pool'dan bir slot al (eğer doluysa burada bekler)
-	kc.workerPool <- struct{}{}
-	defer func() { <-kc.workerPool }() // İş bitince slotu boşalt
+	if !kc.acquireWorker(ctx) {
+		return false
+	}
+	defer kc.releaseWorker() // İş bitince slotu boşalt
+
+	kc.execute(ctx, msg, handler)
+	return true
+}
 
-	log.Printf("⚙ [Worker] Processing [id=%s, type=%s]", msg.Id, msg.Type.String())
+// execute, handler'ı çalıştırır ve hata durumunda retry/DLQ kararını verir.
+// Çağıran worker pool slotunu zaten almış olmalıdır.
+func (kc *KafkaClient) execute(ctx context.Context, msg *pb.Message, handler MessageHandler) {
+	logCtx := messageContext(ctx, msg)
+	slog.InfoContext(logCtx, "⚙ [Worker] Processing", "id", msg.Id, "type", msg.Type.String())
 
-	if err := handler(ctx, msg); err != nil {
-		log.Printf("✗ [Worker] Handler failed [id=%s]: %v", msg.Id, err)
+	if err := kc.runHandler(ctx, msg, handler); err != nil {
+		slog.ErrorContext(logCtx, "✗ [Worker] Handler failed", "id", msg.Id, "type", msg.Type.String(), "error", err)
 		// Burada ileride retry.go içinde yazacağımız hata yönetimi devreye girecek
 		kc.handleFailure(ctx, msg, err)
 	} else {
-		log.Printf("✓ [Worker] Processed [id=%s]", msg.Id)
+		slog.InfoContext(logCtx, "✓ [Worker] Processed", "id", msg.Id, "type", msg.Type.String())
 	}
 }
 
 // processMessage, Kafka'dan bir mesajı çeker, filtrelerden geçirir ve
-// ya hemen işler ya da gecikmeli (RetryAfter) işleme sırasına sokar.
+// ya hemen işler ya da zamanı gelene kadar (RetryAfter) commit etmeden bekletir.
 func (kc *KafkaClient) processMessage(ctx context.Context, reader *kafka.Reader, handler MessageHandler) error {
 	fetchCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
@@ -116,36 +295,36 @@ func (kc *KafkaClient) processMessage(ctx context.Context, reader *kafka.Reader,
 		return err // Context iptali veya timeout
 	}
 
-	var message pb.Message
-	if err := proto.Unmarshal(m.Value, &message); err != nil {
+	message := &pb.Message{}
+	if err := proto.Unmarshal(m.Value, message); err != nil {
 		log.Printf("✗ [Consumer] Unmarshal failed: %v", err)
 		return reader.CommitMessages(ctx, m)
 	}
 
 	// 1. Filtreleme: Bu mesaj bizimle mi ilgili?
-	if !kc.shouldProcessMessage(&message) {
+	if !kc.shouldProcessMessage(message) {
 		return reader.CommitMessages(ctx, m)
 	}
 
 	// 2. Gecikme Kontrolü (RetryAfter): Mesajın bekleme süresi doldu mu?
-	if message.RetryAfter != nil {
-		retryTime := message.RetryAfter.AsTime()
-		if time.Now().Before(retryTime) {
-			// Henüz zamanı gelmemiş, arka planda bekletip işleyelim
-			kc.handleDelayedMessage(ctx, reader, m, &message, handler)
-			return nil
+	if due, requeued := kc.holdUntilDue(ctx, message); !due {
+		if requeued {
+			return reader.CommitMessages(ctx, m)
 		}
+		return ctx.Err()
 	}
 
 	// 3. Normal İşleme: Hemen worker pool'a gönder
-	kc.workerPool <- struct{}{}
+	if !kc.acquireWorker(ctx) {
+		return ctx.Err()
+	}
 	kc.wg.Add(1)
 	go func() {
 		defer func() {
-			<-kc.workerPool
+			kc.releaseWorker()
 			kc.wg.Done()
 		}()
-		kc.handleMessage(ctx, reader, m, &message, handler)
+		kc.handleMessage(ctx, reader, m, message, handler)
 	}()
 
 	return nil
@@ -163,47 +342,20 @@ func (kc *KafkaClient) handleMessage(
 	defer cancel()
 
 	// 1. Handler'ı çalıştır
-	err := handler(handlerCtx, message)
+	err := kc.runHandler(handlerCtx, message, handler)
 
+	// Log'lar mesajın request_id'sini taşır; gateway'deki istekten consumer'a kadar izlenebilir.
+	logCtx := messageContext(ctx, message)
 	if err != nil {
-		log.Printf("✗ [Worker] Handler failed [id=%s]: %v", message.Id, err)
+		slog.ErrorContext(logCtx, "✗ [Worker] Handler failed", "id", message.Id, "type", message.Type.String(), "error", err)
 
 		kc.handleFailure(ctx, message, err)
 	} else {
-		log.Printf("✓ [Worker] Processed successfully [id=%s]", message.Id)
+		slog.InfoContext(logCtx, "✓ [Worker] Processed successfully", "id", message.Id, "type", message.Type.String())
 	}
 
 	// 2. Mesajı her durumda Kafka'dan onayla (Commit)
 	// Neden? Çünkü hata aldıysa zaten Retry topic'ine gönderdik veya DLQ'ya attık.
 	// Orijinal topic'te asılı kalıp consumer'ı bloklamamalı.
-	if commitErr := reader.CommitMessages(ctx, kafkaMsg); commitErr != nil {
-		log.Printf("✗ [Worker] Commit failed [id=%s]: %v", message.Id, commitErr)
-	}
-}
-
-func (kc *KafkaClient) handleDelayedMessage(ctx context.Context, reader *kafka.Reader, m kafka.Message, msg *pb.Message, handler MessageHandler) {
-	waitDuration := msg.RetryAfter.AsTime().Sub(time.Now())
-
-	// Mesajı Kafka'dan siliyoruz çünkü artık sorumluluk bizim hafızamızda (goroutine).
-	reader.CommitMessages(ctx, m)
-
-	kc.wg.Add(1)
-	go func(copyMsg pb.Message) {
-		defer kc.wg.Done()
-
-		select {
-		case <-time.After(waitDuration):
-			kc.workerPool <- struct{}{}
-			defer func() { <-kc.workerPool }()
-
-			hCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-			defer cancel()
-
-			if err := handler(hCtx, &copyMsg); err != nil {
-				kc.handleFailureAfterDelay(context.Background(), &copyMsg, err)
-			}
-		case <-ctx.Done():
-			return
-		}
-	}(*msg)
+	kc.commit(ctx, reader, kafkaMsg, message.Id)
 }
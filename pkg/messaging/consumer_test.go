@@ -0,0 +1,79 @@
+package messaging
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	pb "marketplace/pkg/proto/events"
+)
+
+func TestCloseDrainsInFlightHandlers(t *testing.T) {
+	tests := []struct {
+		name         string
+		drainTimeout time.Duration
+		work         time.Duration // handler'ın işini bitirmesi için gereken süre
+		wantCanceled bool
+	}{
+		{name: "handler finishing within the drain timeout completes", drainTimeout: time.Second, work: 100 * time.Millisecond},
+		{name: "handler outliving the drain timeout is canceled", drainTimeout: 100 * time.Millisecond, work: time.Hour, wantCanceled: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kc := &KafkaClient{
+				config:      KafkaConfig{DrainTimeout: tt.drainTimeout},
+				serviceType: pb.ServiceType_ORDER_SERVICE,
+				workerPool:  make(chan struct{}, 1),
+			}
+			consumerCtx, stopConsumer := context.WithCancel(context.Background())
+			handlerCtx, stopHandlers := kc.drainContext(consumerCtx)
+			defer stopHandlers()
+
+			started := make(chan struct{})
+			var canceled, committed atomic.Bool
+			handler := func(ctx context.Context, _ *pb.Message) error {
+				close(started)
+				select {
+				case <-time.After(tt.work):
+					return nil
+				case <-ctx.Done():
+					canceled.Store(true)
+					return ctx.Err()
+				}
+			}
+
+			if !kc.acquireWorker(consumerCtx) {
+				t.Fatal("acquireWorker failed")
+			}
+			var inFlight sync.WaitGroup
+			kc.startHandler(handlerCtx, &inFlight, &pb.Message{Id: "m1", Type: pb.MessageType_ORDER_CREATED}, handler, func() {
+				committed.Store(true)
+			})
+			<-started
+
+			// Servis kapanıyor: önce consumer context'i, ardından Close.
+			begin := time.Now()
+			stopConsumer()
+			if err := kc.Close(); err != nil {
+				t.Fatal(err)
+			}
+			elapsed := time.Since(begin)
+
+			if !committed.Load() {
+				t.Fatal("Close returned before the in-flight handler was committed")
+			}
+			if canceled.Load() != tt.wantCanceled {
+				t.Fatalf("handler canceled = %v, want %v", canceled.Load(), tt.wantCanceled)
+			}
+			if tt.wantCanceled && elapsed < tt.drainTimeout {
+				t.Fatalf("handler canceled after %v, before the %v drain timeout", elapsed, tt.drainTimeout)
+			}
+			if elapsed > tt.drainTimeout+time.Second {
+				t.Fatalf("Close took %v, drain timeout is %v", elapsed, tt.drainTimeout)
+			}
+		})
+	}
+}
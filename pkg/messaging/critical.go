@@ -0,0 +1,327 @@
+package messaging
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	pb "marketplace/pkg/proto/events"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// CriticalStatus, kritik mesaj kaydının yaşam döngüsüdür:
+// stored (DLQ'ya düştü, bekliyor) -> replayed (ana topic'e tekrar basıldı) -> resolved (işlendi).
+// Tekrar basılan mesaj yine başarısız olursa kayıt tekrar stored olur.
+type CriticalStatus string
+
+const (
+	CriticalStored   CriticalStatus = "stored"
+	CriticalReplayed CriticalStatus = "replayed"
+	CriticalResolved CriticalStatus = "resolved"
+)
+
+// EnvCriticalDir, dosya tabanlı store'un varsayılan dizinini değiştirir (bkz. DefaultCriticalDir).
+const EnvCriticalDir = "CRITICAL_MESSAGES_DIR"
+
+// ErrCriticalNotFound, güncellenmek istenen kayıt store'da yoksa döner.
+var ErrCriticalNotFound = errors.New("critical message not found")
+
+// CriticalEntry, kritik mesaj kasasındaki tek bir kayıttır. Anahtar, servis ve mesaj ID'sidir;
+// aynı mesaj tekrar kaydedilirse yeni kayıt açılmaz, Attempts artar.
+type CriticalEntry struct {
+	Service   pb.ServiceType
+	MessageID string
+	Type      pb.MessageType
+	Status    CriticalStatus
+	// Attempts, mesajın kaç kez kasaya düştüğüdür (her başarısız kurtarma denemesi bir kayıt).
+	Attempts  int
+	LastError string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	// Message, yayınlanacağı haliyle (şifreleme politikası varsa şifreli) saklanır.
+	// Şifrelenmemiş mesajın sır alanları maskelidir (bkz. redacted).
+	Message *pb.Message
+}
+
+// CriticalQuery, kasadaki kayıtları süzer. Boş alanlar filtre uygulamaz.
+// Since/Until, kaydın son güncellenme zamanına bakar.
+type CriticalQuery struct {
+	IDs      []string
+	Types    []pb.MessageType
+	Services []pb.ServiceType
+	Statuses []CriticalStatus
+	Since    time.Time
+	Until    time.Time
+	Limit    int
+}
+
+// Match, kaydın sorguya uyup uymadığını söyler. Limit burada değil, store'da uygulanır.
+func (q CriticalQuery) Match(e CriticalEntry) bool {
+	if len(q.IDs) > 0 && !containsString(q.IDs, e.MessageID) {
+		return false
+	}
+	if len(q.Types) > 0 && !containsType(q.Types, e.Type) {
+		return false
+	}
+	if len(q.Services) > 0 && !containsService(q.Services, e.Service) {
+		return false
+	}
+	if len(q.Statuses) > 0 && !containsStatus(q.Statuses, e.Status) {
+		return false
+	}
+	if !q.Since.IsZero() && e.UpdatedAt.Before(q.Since) {
+		return false
+	}
+	if !q.Until.IsZero() && e.UpdatedAt.After(q.Until) {
+		return false
+	}
+	return true
+}
+
+// CriticalStore, asla kaybolmaması gereken mesajların kalıcı kasasıdır.
+// Neden? DLQ'daki kritik mesajlar (Örn: ödeme onayı) Kafka retention'ı dolsa veya DLQ temizlense
+// bile kaybolmamalı; hangi mesajın beklediği, kaç kez denendiği ve neden başarısız olduğu
+// sorgulanabilmeli ve mesaj tekrar basılabilmelidir (bkz. DLQAdmin.ReplayCritical).
+type CriticalStore interface {
+	// Save, mesajı stored olarak kaydeder. Kayıt varsa Attempts artar, mesaj ve son hata güncellenir.
+	Save(ctx context.Context, service pb.ServiceType, msg *pb.Message) error
+	// SetStatus, kaydın durumunu değiştirir; kayıt yoksa ErrCriticalNotFound döner.
+	SetStatus(ctx context.Context, service pb.ServiceType, messageID string, status CriticalStatus) error
+	// Query, sorguya uyan kayıtları oluşturulma sırasıyla döner.
+	Query(ctx context.Context, q CriticalQuery) ([]CriticalEntry, error)
+}
+
+// DefaultCriticalDir, KafkaConfig.CriticalStore boşken kullanılan dizindir:
+// EnvCriticalDir veya ~/.marketplace/critical_messages. Süreç hangi dizinde çalışırsa çalışsın
+// aynı yere yazılır.
+func DefaultCriticalDir() (string, error) {
+	if dir := os.Getenv(EnvCriticalDir); dir != "" {
+		return filepath.Abs(dir)
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("resolve critical messages dir (set %s): %w", EnvCriticalDir, err)
+	}
+	return filepath.Join(home, ".marketplace", "critical_messages"), nil
+}
+
+// FileCriticalStore, her kaydı dizinde "<servis>_<mesaj ID>.json" dosyası olarak tutar.
+// Veritabanı olmayan servisler (Örn: Redis kullanan basket-service) içindir. Aynı dizini
+// paylaşan birden fazla süreç arasında kilit yoktur; her instance kendi dizinini kullanmalıdır.
+type FileCriticalStore struct {
+	dir string
+	mu  sync.Mutex
+}
+
+// NewFileCriticalStore, dizini (yoksa) oluşturur.
+func NewFileCriticalStore(dir string) (*FileCriticalStore, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("create critical messages dir: %w", err)
+	}
+	return &FileCriticalStore{dir: dir}, nil
+}
+
+// Dir, kayıtların yazıldığı mutlak dizindir.
+func (s *FileCriticalStore) Dir() string {
+	return s.dir
+}
+
+// fileCriticalEntry, CriticalEntry'nin diskteki halidir. Mesaj protobuf olarak (base64) yazılır;
+// sır içeren alanlar şifreleme politikasıyla korunur, dosyada okunabilir payload bulunmaz.
+type fileCriticalEntry struct {
+	Service   string         `json:"service"`
+	MessageID string         `json:"message_id"`
+	Type      string         `json:"type"`
+	Status    CriticalStatus `json:"status"`
+	Attempts  int            `json:"attempts"`
+	LastError string         `json:"last_error,omitempty"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	Message   []byte         `json:"message"`
+}
+
+func (s *FileCriticalStore) Save(ctx context.Context, service pb.ServiceType, msg *pb.Message) error {
+	data, err := proto.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("marshal message: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	path := s.path(service, msg.Id)
+	now := time.Now().UTC()
+
+	entry, err := readCriticalFile(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		entry = &fileCriticalEntry{
+			Service:   service.String(),
+			MessageID: msg.Id,
+			CreatedAt: now,
+		}
+	case err != nil:
+		return err
+	}
+
+	entry.Type = msg.Type.String()
+	entry.Status = CriticalStored
+	entry.Attempts++
+	entry.LastError = msg.LastError
+	entry.UpdatedAt = now
+	entry.Message = data
+	return writeCriticalFile(path, entry)
+}
+
+func (s *FileCriticalStore) SetStatus(ctx context.Context, service pb.ServiceType, messageID string, status CriticalStatus) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	path := s.path(service, messageID)
+	entry, err := readCriticalFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("%w [service=%s, id=%s]", ErrCriticalNotFound, service, messageID)
+	}
+	if err != nil {
+		return err
+	}
+
+	entry.Status = status
+	entry.UpdatedAt = time.Now().UTC()
+	return writeCriticalFile(path, entry)
+}
+
+func (s *FileCriticalStore) Query(ctx context.Context, q CriticalQuery) ([]CriticalEntry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	paths, err := filepath.Glob(filepath.Join(s.dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []CriticalEntry
+	for _, path := range paths {
+		raw, err := readCriticalFile(path)
+		if err != nil {
+			return nil, err
+		}
+		entry, err := raw.decode()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		if q.Match(entry) {
+			entries = append(entries, entry)
+		}
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].CreatedAt.Before(entries[j].CreatedAt)
+	})
+	if q.Limit > 0 && len(entries) > q.Limit {
+		entries = entries[:q.Limit]
+	}
+	return entries, nil
+}
+
+// path, kaydın dosya yoludur. Mesaj ID'si dışarıdan geldiği için dizin ayırıcıları temizlenir.
+func (s *FileCriticalStore) path(service pb.ServiceType, messageID string) string {
+	safeID := strings.NewReplacer("/", "_", `\`, "_", "..", "_").Replace(messageID)
+	return filepath.Join(s.dir, fmt.Sprintf("%s_%s.json", service, safeID))
+}
+
+func (e *fileCriticalEntry) decode() (CriticalEntry, error) {
+	msg := &pb.Message{}
+	if err := proto.Unmarshal(e.Message, msg); err != nil {
+		return CriticalEntry{}, fmt.Errorf("unmarshal message: %w", err)
+	}
+	return CriticalEntry{
+		Service:   pb.ServiceType(pb.ServiceType_value[e.Service]),
+		MessageID: e.MessageID,
+		Type:      pb.MessageType(pb.MessageType_value[e.Type]),
+		Status:    e.Status,
+		Attempts:  e.Attempts,
+		LastError: e.LastError,
+		CreatedAt: e.CreatedAt,
+		UpdatedAt: e.UpdatedAt,
+		Message:   msg,
+	}, nil
+}
+
+func readCriticalFile(path string) (*fileCriticalEntry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	entry := &fileCriticalEntry{}
+	if err := json.Unmarshal(data, entry); err != nil {
+		return nil, fmt.Errorf("decode %s: %w", path, err)
+	}
+	return entry, nil
+}
+
+// writeCriticalFile, önce geçici dosyaya yazıp rename eder; süreç yazarken ölürse eski kayıt bozulmaz.
+func writeCriticalFile(path string, entry *fileCriticalEntry) error {
+	data, err := json.MarshalIndent(entry, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("write %s: %w", tmp, err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("rename %s: %w", tmp, err)
+	}
+	return nil
+}
+
+// criticalStore, yapılandırmadaki store'u veya (ilk kullanımda) DefaultCriticalDir'deki dosya store'unu döner.
+func (kc *KafkaClient) criticalStore() (CriticalStore, error) {
+	if kc.config.CriticalStore != nil {
+		return kc.config.CriticalStore, nil
+	}
+
+	kc.criticalOnce.Do(func() {
+		dir, err := DefaultCriticalDir()
+		if err != nil {
+			kc.criticalErr = err
+			return
+		}
+		kc.defaultCritical, kc.criticalErr = NewFileCriticalStore(dir)
+	})
+	if kc.criticalErr != nil {
+		return nil, kc.criticalErr
+	}
+	return kc.defaultCritical, nil
+}
+
+func containsService(list []pb.ServiceType, v pb.ServiceType) bool {
+	for _, s := range list {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
+
+func containsStatus(list []CriticalStatus, v CriticalStatus) bool {
+	for _, s := range list {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
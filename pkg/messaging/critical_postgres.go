@@ -0,0 +1,161 @@
+package messaging
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	pb "marketplace/pkg/proto/events"
+
+	"github.com/lib/pq"
+	"google.golang.org/protobuf/proto"
+)
+
+const createCriticalMessagesTable = `
+	CREATE TABLE IF NOT EXISTS critical_messages (
+		service TEXT NOT NULL,
+		message_id TEXT NOT NULL,
+		message_type TEXT NOT NULL,
+		status TEXT NOT NULL,
+		attempts INT NOT NULL DEFAULT 1,
+		last_error TEXT NOT NULL DEFAULT '',
+		message BYTEA NOT NULL,
+		created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+		updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+		PRIMARY KEY (service, message_id)
+	)`
+
+// PostgresCriticalStore, kritik mesajları servisin kendi veritabanındaki critical_messages
+// tablosunda tutar. Dosya store'undan farkı: birden fazla instance aynı kasayı paylaşabilir ve
+// kayıtlar SQL ile de sorgulanabilir.
+type PostgresCriticalStore struct {
+	db *sql.DB
+}
+
+// NewPostgresCriticalStore, tabloyu (yoksa) oluşturur ve store'u döner.
+func NewPostgresCriticalStore(db *sql.DB) (*PostgresCriticalStore, error) {
+	if _, err := db.Exec(createCriticalMessagesTable); err != nil {
+		return nil, fmt.Errorf("failed to create critical_messages table: %w", err)
+	}
+	return &PostgresCriticalStore{db: db}, nil
+}
+
+func (s *PostgresCriticalStore) Save(ctx context.Context, service pb.ServiceType, msg *pb.Message) error {
+	const query = `
+		INSERT INTO critical_messages (service, message_id, message_type, status, last_error, message)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (service, message_id) DO UPDATE SET
+			message_type = EXCLUDED.message_type,
+			status = EXCLUDED.status,
+			attempts = critical_messages.attempts + 1,
+			last_error = EXCLUDED.last_error,
+			message = EXCLUDED.message,
+			updated_at = NOW()`
+
+	data, err := proto.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("marshal message: %w", err)
+	}
+	_, err = s.db.ExecContext(ctx, query, service.String(), msg.Id, msg.Type.String(), string(CriticalStored), msg.LastError, data)
+	return err
+}
+
+func (s *PostgresCriticalStore) SetStatus(ctx context.Context, service pb.ServiceType, messageID string, status CriticalStatus) error {
+	const query = `
+		UPDATE critical_messages SET status = $3, updated_at = NOW()
+		WHERE service = $1 AND message_id = $2`
+
+	res, err := s.db.ExecContext(ctx, query, service.String(), messageID, string(status))
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return fmt.Errorf("%w [service=%s, id=%s]", ErrCriticalNotFound, service, messageID)
+	}
+	return nil
+}
+
+func (s *PostgresCriticalStore) Query(ctx context.Context, q CriticalQuery) ([]CriticalEntry, error) {
+	var (
+		where []string
+		args  []any
+	)
+	add := func(cond string, arg any) {
+		args = append(args, arg)
+		where = append(where, fmt.Sprintf(cond, len(args)))
+	}
+
+	if len(q.IDs) > 0 {
+		add("message_id = ANY($%d)", pq.Array(q.IDs))
+	}
+	if len(q.Types) > 0 {
+		names := make([]string, len(q.Types))
+		for i, t := range q.Types {
+			names[i] = t.String()
+		}
+		add("message_type = ANY($%d)", pq.Array(names))
+	}
+	if len(q.Services) > 0 {
+		names := make([]string, len(q.Services))
+		for i, svc := range q.Services {
+			names[i] = svc.String()
+		}
+		add("service = ANY($%d)", pq.Array(names))
+	}
+	if len(q.Statuses) > 0 {
+		names := make([]string, len(q.Statuses))
+		for i, st := range q.Statuses {
+			names[i] = string(st)
+		}
+		add("status = ANY($%d)", pq.Array(names))
+	}
+	if !q.Since.IsZero() {
+		add("updated_at >= $%d", q.Since)
+	}
+	if !q.Until.IsZero() {
+		add("updated_at <= $%d", q.Until)
+	}
+
+	query := `SELECT service, message_id, message_type, status, attempts, last_error, message, created_at, updated_at
+		FROM critical_messages`
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	query += " ORDER BY created_at"
+	if q.Limit > 0 {
+		query += fmt.Sprintf(" LIMIT %d", q.Limit)
+	}
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []CriticalEntry
+	for rows.Next() {
+		var (
+			e                CriticalEntry
+			service, msgType string
+			status           string
+			data             []byte
+		)
+		if err := rows.Scan(&service, &e.MessageID, &msgType, &status, &e.Attempts, &e.LastError, &data, &e.CreatedAt, &e.UpdatedAt); err != nil {
+			return nil, err
+		}
+		e.Service = pb.ServiceType(pb.ServiceType_value[service])
+		e.Type = pb.MessageType(pb.MessageType_value[msgType])
+		e.Status = CriticalStatus(status)
+		e.Message = &pb.Message{}
+		if err := proto.Unmarshal(data, e.Message); err != nil {
+			return nil, fmt.Errorf("unmarshal %s: %w", e.MessageID, err)
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
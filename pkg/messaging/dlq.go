@@ -0,0 +1,718 @@
+package messaging
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	pb "marketplace/pkg/proto/events"
+
+	"github.com/segmentio/kafka-go"
+	"google.golang.org/protobuf/proto"
+)
+
+// DLQ mesajlarına sendToDLQ tarafından eklenen Kafka header'ları.
+const (
+	HeaderErrorReason   = "ErrorReason"
+	HeaderOriginalTopic = "OriginalTopic"
+	HeaderFailedAt      = "FailedAt"
+	HeaderReplayedAt    = "ReplayedAt"
+)
+
+// DLQMessage, DLQ topic'inden okunmuş tek bir kayıttır.
+type DLQMessage struct {
+	Partition     int
+	Offset        int64
+	Key           string
+	ErrorReason   string
+	OriginalTopic string
+	FailedAt      time.Time
+	Message       *pb.Message
+}
+
+// DLQFilter, DLQ mesajlarını seçmek için kullanılır. Boş alanlar filtre uygulamaz.
+// Services, mesajı basan servis (FromService) veya hedef servis (ToServices) ile eşleşir;
+// DLQ'daki mesajlarda ToServices hatayı alan servistir (bkz. sendToDLQ).
+type DLQFilter struct {
+	IDs      []string
+	Types    []pb.MessageType
+	Services []pb.ServiceType
+	Since    time.Time
+	Until    time.Time
+	Limit    int
+}
+
+// IsEmpty, filtrenin hiçbir koşul içermediğini (tüm mesajları seçtiğini) bildirir.
+func (f DLQFilter) IsEmpty() bool {
+	return len(f.IDs) == 0 && len(f.Types) == 0 && len(f.Services) == 0 &&
+		f.Since.IsZero() && f.Until.IsZero()
+}
+
+// Match, mesajın filtreye uyup uymadığını kontrol eder (Limit burada dikkate alınmaz).
+func (f DLQFilter) Match(m DLQMessage) bool {
+	if len(f.IDs) > 0 && !containsString(f.IDs, m.Message.Id) {
+		return false
+	}
+	if len(f.Types) > 0 && !containsType(f.Types, m.Message.Type) {
+		return false
+	}
+	if len(f.Services) > 0 && !f.matchService(m.Message) {
+		return false
+	}
+	if !f.Since.IsZero() && m.FailedAt.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && !m.FailedAt.Before(f.Until) {
+		return false
+	}
+	return true
+}
+
+func (f DLQFilter) matchService(msg *pb.Message) bool {
+	for _, svc := range f.Services {
+		if msg.FromService == svc {
+			return true
+		}
+		for _, target := range msg.ToServices {
+			if target == svc {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// DLQAdmin, DLQ topic'ini incelemek, mesajları ana topic'e geri basmak ve temizlemek için kullanılır.
+// Neden? ConsumeDLQWithRecovery mesajları körlemesine tekrar dener; kalıcı hatalı mesajlar
+// için operatörün neyi, ne zaman geri basacağına karar verebilmesi gerekir.
+// DLQAdmin bir consumer group kullanmaz; listeleme servislerin offset'lerini etkilemez.
+type DLQAdmin struct {
+	config KafkaConfig
+
+	// newWriter, verilen topic'e yazan writer'ı oluşturur; testlerde Kafka yerine sahte yazıcı verilir.
+	newWriter func(topic string) messageWriter
+}
+
+// ErrDLQConsumersActive, Purge sırasında DLQ'yu okuyan aktif bir consumer group varsa döner.
+var ErrDLQConsumersActive = errors.New("DLQ topic has active consumers")
+
+// NewDLQAdmin, verilen yapılandırmanın Brokers, Topic, DLQTopic ve PartitionKey alanlarını kullanır.
+func NewDLQAdmin(config KafkaConfig) (*DLQAdmin, error) {
+	if len(config.Brokers) == 0 {
+		return nil, errors.New("no kafka brokers configured")
+	}
+	if config.DLQTopic == "" {
+		return nil, errors.New("DLQ topic not configured")
+	}
+	a := &DLQAdmin{config: config}
+	a.newWriter = a.kafkaWriter
+	return a, nil
+}
+
+// List, DLQ'daki filtreye uyan mesajları partition ve offset sırasıyla döner.
+func (a *DLQAdmin) List(ctx context.Context, filter DLQFilter) ([]DLQMessage, error) {
+	var result []DLQMessage
+	err := a.scan(ctx, func(m DLQMessage) bool {
+		if !filter.Match(m) {
+			return true
+		}
+		result = append(result, m)
+		return filter.Limit <= 0 || len(result) < filter.Limit
+	})
+	return result, err
+}
+
+// Replay, filtreye uyan mesajları OriginalTopic'e (yoksa ana topic'e) tekrar basar.
+// Retry sayacı, RetryAfter ve LastError sıfırlanır; mesaj ilk kez gelmiş gibi işlenir.
+// Mesaj ID'si korunur, bu yüzden IdempotencyStore'a zaten işlendi olarak yazılmış bir mesaj tekrar işlenmez.
+// Not: Mesaj DLQ'dan silinmez; gerekirse ardından Purge çağrılmalıdır.
+func (a *DLQAdmin) Replay(ctx context.Context, filter DLQFilter) ([]DLQMessage, error) {
+	messages, err := a.List(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, m := range messages {
+		if err := a.publish(ctx, m.Message, m.OriginalTopic); err != nil {
+			return messages[:i], fmt.Errorf("replay message %s: %w", m.Message.Id, err)
+		}
+		log.Printf("↺ [DLQ Admin] Replayed [id=%s, type=%s, topic=%s]", m.Message.Id, m.Message.Type, a.targetTopic(m.OriginalTopic))
+	}
+	return messages, nil
+}
+
+// ReplayCritical, kasadaki (bkz. CriticalStore) sorguya uyan kayıtları ana topic'e tekrar basar
+// ve replayed olarak işaretler. Mesajı işleyen servis kaydı resolved yapar; tekrar başarısız
+// olursa kayıt DLQ recovery'de yeniden stored olur.
+func (a *DLQAdmin) ReplayCritical(ctx context.Context, store CriticalStore, q CriticalQuery) ([]CriticalEntry, error) {
+	entries, err := store.Query(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, e := range entries {
+		if err := a.publish(ctx, e.Message, ""); err != nil {
+			return entries[:i], fmt.Errorf("replay critical message %s: %w", e.MessageID, err)
+		}
+		if err := store.SetStatus(ctx, e.Service, e.MessageID, CriticalReplayed); err != nil {
+			return entries[:i+1], fmt.Errorf("mark %s as replayed: %w", e.MessageID, err)
+		}
+		log.Printf("↺ [DLQ Admin] Replayed critical [id=%s, type=%s, service=%s]", e.MessageID, e.Type, e.Service)
+	}
+	return entries, nil
+}
+
+// CriticalFile, eski sürümlerin "critical_messages/" dizinine yazdığı tek bir .pb dosyasıdır.
+// Yeni kayıtlar CriticalStore'a yazılır; bu dosyalar sadece "dlq-admin import" ile taşınmak için okunur.
+type CriticalFile struct {
+	Path    string
+	Message *pb.Message
+}
+
+// LoadCriticalFiles, dizindeki kritik mesaj dosyalarını (*.pb) okur.
+// Okunamayan dosyalar atlanmaz, hata olarak döner; yarım bir import yapmaktansa durmayı tercih ediyoruz.
+func LoadCriticalFiles(dir string) ([]CriticalFile, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.pb"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+
+	files := make([]CriticalFile, 0, len(paths))
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("read %s: %w", path, err)
+		}
+		msg := &pb.Message{}
+		if err := proto.Unmarshal(data, msg); err != nil {
+			return nil, fmt.Errorf("unmarshal %s: %w", path, err)
+		}
+		files = append(files, CriticalFile{Path: path, Message: msg})
+	}
+	return files, nil
+}
+
+// ImportCriticalFiles, diskteki kritik mesajları ana topic'e geri basar.
+// Başarıyla basılan dosyalar ".imported" ekiyle yeniden adlandırılır; böylece komut
+// tekrar çalıştırıldığında aynı mesajlar ikinci kez basılmaz.
+func (a *DLQAdmin) ImportCriticalFiles(ctx context.Context, files []CriticalFile) ([]CriticalFile, error) {
+	for i, f := range files {
+		if err := a.publish(ctx, f.Message, ""); err != nil {
+			return files[:i], fmt.Errorf("import %s: %w", f.Path, err)
+		}
+		if err := os.Rename(f.Path, f.Path+".imported"); err != nil {
+			return files[:i+1], fmt.Errorf("mark %s as imported: %w", f.Path, err)
+		}
+		log.Printf("↺ [DLQ Admin] Imported [id=%s, type=%s, file=%s]", f.Message.Id, f.Message.Type, f.Path)
+	}
+	return files, nil
+}
+
+// Purge, filtreye uyan mesajları DLQ'dan siler ve silinen mesaj sayısını döner.
+// Kafka kayıt silmeyi sadece offset'e kadar kırparak (DeleteRecords) destekler ve kafka-go bu
+// isteği sunmaz; bu yüzden topic silinip aynı partition sayısı ve topic ayarlarıyla yeniden
+// oluşturulur, filtreye uymayan mesajlar yeni topic'e geri yazılır.
+// Tutulan mesajlar topic silinmeden önce backup dosyasına yazılır (bkz. WriteDLQBackup); geri
+// yazma yarıda kalırsa mesajlar RestoreBackup ile tekrar basılabilir.
+// Servislerin ConsumeDLQWithRecovery döngüleri DLQ'yu okurken topic silinirse okuyucular hata
+// alır ve silme sırasında DLQ'ya yazılan mesajlar kaybolur. Bu yüzden DLQ'dan partition almış
+// bir consumer group varsa Purge ErrDLQConsumersActive ile durur; servisler (veya recovery
+// consumer'ları) durdurulmadan silmek için force verilmelidir.
+// Recovery consumer group'larının offset'leri topic'le birlikte silinir; tutulan mesajlar
+// tekrar denenir, daha önce kurtarılmış olanları IdempotencyStore atlar.
+func (a *DLQAdmin) Purge(ctx context.Context, filter DLQFilter, backupPath string, force bool) (int, error) {
+	active, err := a.activeConsumerGroups(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("check consumers of %s: %w", a.config.DLQTopic, err)
+	}
+	if len(active) > 0 {
+		if !force {
+			return 0, fmt.Errorf("%w: %s", ErrDLQConsumersActive, strings.Join(active, ", "))
+		}
+		log.Printf("⚠ [DLQ Admin] Purging while consumer groups read %s: %s", a.config.DLQTopic, strings.Join(active, ", "))
+	}
+
+	var keep []kafka.Message
+	purged := 0
+	err = a.scanRaw(ctx, func(raw kafka.Message, m *DLQMessage) bool {
+		if m != nil && filter.Match(*m) && (filter.Limit <= 0 || purged < filter.Limit) {
+			purged++
+			return true
+		}
+		// Bozuk (unmarshal edilemeyen) kayıtlar sadece filtresiz purge ile silinir.
+		if m == nil && filter.IsEmpty() {
+			purged++
+			return true
+		}
+		keep = append(keep, raw)
+		return true
+	})
+	if err != nil {
+		return 0, err
+	}
+	if purged == 0 {
+		return 0, nil
+	}
+
+	if len(keep) > 0 {
+		if err := WriteDLQBackup(backupPath, keep); err != nil {
+			return 0, fmt.Errorf("backup %d kept messages: %w", len(keep), err)
+		}
+		log.Printf("💾 [DLQ Admin] Backed up %d kept messages to %s", len(keep), backupPath)
+	}
+
+	if err := a.recreateTopic(ctx); err != nil {
+		return 0, err
+	}
+
+	if len(keep) > 0 {
+		if err := a.write(ctx, keep); err != nil {
+			return purged, fmt.Errorf("restore %d kept messages (re-run with \"dlq-admin restore -file %s\"): %w", len(keep), backupPath, err)
+		}
+	}
+
+	log.Printf("🗑 [DLQ Admin] Purged %d messages from %s (kept %d)", purged, a.config.DLQTopic, len(keep))
+	return purged, nil
+}
+
+// RestoreBackup, Purge'ün yazdığı backup dosyasındaki mesajları DLQ'ya geri yazar.
+// Mesajlar anahtar, header ve zamanlarıyla yazılır; partition'ları anahtardan tekrar hesaplanır.
+func (a *DLQAdmin) RestoreBackup(ctx context.Context, path string) (int, error) {
+	messages, err := LoadDLQBackup(path)
+	if err != nil {
+		return 0, err
+	}
+	if err := a.write(ctx, messages); err != nil {
+		return 0, err
+	}
+	log.Printf("↺ [DLQ Admin] Restored %d messages from %s to %s", len(messages), path, a.config.DLQTopic)
+	return len(messages), nil
+}
+
+// write, ham kayıtları DLQ topic'ine yazar. Okunan kayıtların Topic/Partition/Offset alanları
+// temizlenir; kafka-go Topic'i dolu mesajı writer'ın topic'iyle birlikte kabul etmez.
+func (a *DLQAdmin) write(ctx context.Context, messages []kafka.Message) error {
+	out := make([]kafka.Message, 0, len(messages))
+	for _, m := range messages {
+		out = append(out, kafka.Message{Key: m.Key, Value: m.Value, Headers: m.Headers, Time: m.Time})
+	}
+
+	writer := a.newWriter(a.config.DLQTopic)
+	defer writer.Close()
+	return writer.WriteMessages(ctx, out...)
+}
+
+// dlqBackupRecord, backup dosyasındaki tek satırdır. Partition ve Offset sadece bilgi amaçlıdır.
+type dlqBackupRecord struct {
+	Partition int            `json:"partition"`
+	Offset    int64          `json:"offset"`
+	Key       []byte         `json:"key"`
+	Value     []byte         `json:"value"`
+	Headers   []kafka.Header `json:"headers"`
+	Time      time.Time      `json:"time"`
+}
+
+// WriteDLQBackup, kayıtları satır başına bir JSON olacak şekilde yeni bir dosyaya yazar ve
+// diske senkronlar. Var olan dosyanın üzerine yazılmaz; önceki bir purge'ün backup'ı kaybolmasın.
+func WriteDLQBackup(path string, messages []kafka.Message) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return err
+	}
+
+	w := bufio.NewWriter(f)
+	enc := json.NewEncoder(w)
+	for _, m := range messages {
+		rec := dlqBackupRecord{Partition: m.Partition, Offset: m.Offset, Key: m.Key, Value: m.Value, Headers: m.Headers, Time: m.Time}
+		if err := enc.Encode(rec); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// LoadDLQBackup, WriteDLQBackup'ın yazdığı dosyayı okur.
+func LoadDLQBackup(path string) ([]kafka.Message, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var messages []kafka.Message
+	dec := json.NewDecoder(f)
+	for dec.More() {
+		var rec dlqBackupRecord
+		if err := dec.Decode(&rec); err != nil {
+			return nil, fmt.Errorf("decode %s: %w", path, err)
+		}
+		messages = append(messages, kafka.Message{Partition: rec.Partition, Offset: rec.Offset, Key: rec.Key, Value: rec.Value, Headers: rec.Headers, Time: rec.Time})
+	}
+	return messages, nil
+}
+
+// publish, mesajı sıfırlanmış retry bilgisiyle hedef topic'e yazar.
+func (a *DLQAdmin) publish(ctx context.Context, msg *pb.Message, originalTopic string) error {
+	msg.RetryCount = 0
+	msg.RetryAfter = nil
+	msg.LastError = ""
+
+	value, err := proto.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("failed to marshal protobuf: %w", err)
+	}
+
+	writer := a.newWriter(a.targetTopic(originalTopic))
+	defer writer.Close()
+
+	return writer.WriteMessages(ctx, kafka.Message{
+		Key:   []byte(resolvePartitionKey(a.config.PartitionKey, msg)),
+		Value: value,
+		Headers: []kafka.Header{
+			{Key: "EventType", Value: []byte(msg.Type.String())},
+			{Key: "FromService", Value: []byte(msg.FromService.String())},
+			{Key: HeaderReplayedAt, Value: []byte(time.Now().Format(time.RFC3339))},
+		},
+	})
+}
+
+func (a *DLQAdmin) targetTopic(originalTopic string) string {
+	if originalTopic != "" {
+		return originalTopic
+	}
+	return a.config.Topic
+}
+
+func (a *DLQAdmin) kafkaWriter(topic string) messageWriter {
+	return &kafka.Writer{
+		Addr:         kafka.TCP(a.config.Brokers...),
+		Topic:        topic,
+		Balancer:     &kafka.Hash{},
+		RequiredAcks: kafka.RequireAll,
+	}
+}
+
+// scan, DLQ'daki çözümlenebilen her mesajı fn'e verir. fn false dönerse tarama durur.
+func (a *DLQAdmin) scan(ctx context.Context, fn func(DLQMessage) bool) error {
+	return a.scanRaw(ctx, func(_ kafka.Message, m *DLQMessage) bool {
+		if m == nil {
+			return true
+		}
+		return fn(*m)
+	})
+}
+
+// scanRaw, DLQ topic'inin tüm partition'larını baştan, tarama başladığı andaki son offset'e kadar okur.
+// Unmarshal edilemeyen kayıtlar için m nil'dir.
+func (a *DLQAdmin) scanRaw(ctx context.Context, fn func(raw kafka.Message, m *DLQMessage) bool) error {
+	partitions, err := a.partitions(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, partition := range partitions {
+		first, last, err := a.offsets(ctx, partition)
+		if err != nil {
+			return err
+		}
+		if first >= last {
+			continue
+		}
+
+		reader := kafka.NewReader(kafka.ReaderConfig{
+			Brokers:   a.config.Brokers,
+			Topic:     a.config.DLQTopic,
+			Partition: partition,
+			MaxWait:   time.Second,
+		})
+		if err := reader.SetOffset(first); err != nil {
+			reader.Close()
+			return err
+		}
+
+		for {
+			raw, err := reader.ReadMessage(ctx)
+			if err != nil {
+				reader.Close()
+				return fmt.Errorf("read %s/%d: %w", a.config.DLQTopic, partition, err)
+			}
+
+			if !fn(raw, decodeDLQMessage(raw)) {
+				reader.Close()
+				return nil
+			}
+			if raw.Offset >= last-1 {
+				break
+			}
+		}
+		reader.Close()
+	}
+	return nil
+}
+
+func decodeDLQMessage(raw kafka.Message) *DLQMessage {
+	msg := &pb.Message{}
+	if err := proto.Unmarshal(raw.Value, msg); err != nil {
+		return nil
+	}
+
+	m := &DLQMessage{
+		Partition: raw.Partition,
+		Offset:    raw.Offset,
+		Key:       string(raw.Key),
+		FailedAt:  raw.Time,
+		Message:   msg,
+	}
+	for _, h := range raw.Headers {
+		switch h.Key {
+		case HeaderErrorReason:
+			m.ErrorReason = string(h.Value)
+		case HeaderOriginalTopic:
+			m.OriginalTopic = string(h.Value)
+		case HeaderFailedAt:
+			if t, err := time.Parse(time.RFC3339, string(h.Value)); err == nil {
+				m.FailedAt = t
+			}
+		}
+	}
+	if m.ErrorReason == "" {
+		m.ErrorReason = msg.LastError
+	}
+	return m
+}
+
+func (a *DLQAdmin) partitions(ctx context.Context) ([]int, error) {
+	conn, err := kafka.DialContext(ctx, "tcp", a.config.Brokers[0])
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	parts, err := conn.ReadPartitions(a.config.DLQTopic)
+	if err != nil {
+		return nil, fmt.Errorf("read partitions of %s: %w", a.config.DLQTopic, err)
+	}
+
+	ids := make([]int, 0, len(parts))
+	for _, p := range parts {
+		ids = append(ids, p.ID)
+	}
+	sort.Ints(ids)
+	return ids, nil
+}
+
+func (a *DLQAdmin) offsets(ctx context.Context, partition int) (first, last int64, err error) {
+	conn, err := kafka.DialLeader(ctx, "tcp", a.config.Brokers[0], a.config.DLQTopic, partition)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer conn.Close()
+	return conn.ReadOffsets()
+}
+
+// recreateTopic, DLQ topic'ini silip aynı partition ve replika sayısıyla yeniden oluşturur.
+// Topic'e özel ayarlar (Örn: retention.ms) silmeden önce okunup yeni topic'e aynen verilir.
+func (a *DLQAdmin) recreateTopic(ctx context.Context) error {
+	conn, err := kafka.DialContext(ctx, "tcp", a.config.Brokers[0])
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	parts, err := conn.ReadPartitions(a.config.DLQTopic)
+	if err != nil || len(parts) == 0 {
+		return fmt.Errorf("read partitions of %s: %w", a.config.DLQTopic, err)
+	}
+
+	configs, err := a.topicConfigs(ctx)
+	if err != nil {
+		return fmt.Errorf("read configs of %s: %w", a.config.DLQTopic, err)
+	}
+
+	controller, err := conn.Controller()
+	if err != nil {
+		return err
+	}
+	controllerConn, err := kafka.DialContext(ctx, "tcp", fmt.Sprintf("%s:%d", controller.Host, controller.Port))
+	if err != nil {
+		return err
+	}
+	defer controllerConn.Close()
+
+	if err := controllerConn.DeleteTopics(a.config.DLQTopic); err != nil {
+		return fmt.Errorf("delete %s: %w", a.config.DLQTopic, err)
+	}
+
+	topic := kafka.TopicConfig{
+		Topic:             a.config.DLQTopic,
+		NumPartitions:     len(parts),
+		ReplicationFactor: len(parts[0].Replicas),
+		ConfigEntries:     configs,
+	}
+
+	// Silme işlemi broker tarafında asenkron tamamlanır; topic gerçekten gidene kadar tekrar deniyoruz.
+	for attempt := 0; ; attempt++ {
+		err = controllerConn.CreateTopics(topic)
+		if err == nil || !errors.Is(err, kafka.TopicAlreadyExists) || attempt >= 20 {
+			break
+		}
+		select {
+		case <-time.After(500 * time.Millisecond):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("recreate %s: %w", a.config.DLQTopic, err)
+	}
+	return nil
+}
+
+// activeConsumerGroups, DLQ topic'inden partition atanmış üyesi olan consumer group'ları döner.
+func (a *DLQAdmin) activeConsumerGroups(ctx context.Context) ([]string, error) {
+	client := &kafka.Client{Addr: kafka.TCP(a.config.Brokers...)}
+	listed, err := client.ListGroups(ctx, &kafka.ListGroupsRequest{})
+	if err != nil {
+		return nil, err
+	}
+	if listed.Error != nil {
+		return nil, listed.Error
+	}
+	if len(listed.Groups) == 0 {
+		return nil, nil
+	}
+
+	ids := make([]string, 0, len(listed.Groups))
+	for _, g := range listed.Groups {
+		ids = append(ids, g.GroupID)
+	}
+	described, err := client.DescribeGroups(ctx, &kafka.DescribeGroupsRequest{GroupIDs: ids})
+	if err != nil {
+		return nil, err
+	}
+	return groupsReading(described.Groups, a.config.DLQTopic)
+}
+
+// groupsReading, üyelerinden birine topic'in partition'ı atanmış grupları döner.
+func groupsReading(groups []kafka.DescribeGroupsResponseGroup, topic string) ([]string, error) {
+	var active []string
+	for _, g := range groups {
+		if g.Error != nil {
+			return nil, fmt.Errorf("describe group %s: %w", g.GroupID, g.Error)
+		}
+	members:
+		for _, m := range g.Members {
+			for _, t := range m.MemberAssignments.Topics {
+				if t.Topic == topic && len(t.Partitions) > 0 {
+					active = append(active, g.GroupID)
+					break members
+				}
+			}
+		}
+	}
+	sort.Strings(active)
+	return active, nil
+}
+
+// dynamicTopicConfig, DescribeConfigs'te topic'e özel olarak ayarlanmış değerin kaynağıdır
+// (DYNAMIC_TOPIC_CONFIG). Broker varsayılanları yeni topic'e zaten uygulanır.
+const dynamicTopicConfig = 1
+
+// topicConfigs, DLQ topic'inin varsayılandan farklı ayarlarını döner.
+func (a *DLQAdmin) topicConfigs(ctx context.Context) ([]kafka.ConfigEntry, error) {
+	client := &kafka.Client{Addr: kafka.TCP(a.config.Brokers...)}
+	resp, err := client.DescribeConfigs(ctx, &kafka.DescribeConfigsRequest{
+		Resources: []kafka.DescribeConfigRequestResource{{
+			ResourceType: kafka.ResourceTypeTopic,
+			ResourceName: a.config.DLQTopic,
+		}},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []kafka.ConfigEntry
+	for _, res := range resp.Resources {
+		if res.Error != nil {
+			return nil, res.Error
+		}
+		for _, e := range res.ConfigEntries {
+			if e.ConfigSource == dynamicTopicConfig {
+				entries = append(entries, kafka.ConfigEntry{ConfigName: e.ConfigName, ConfigValue: e.ConfigValue})
+			}
+		}
+	}
+	return entries, nil
+}
+
+// ParseMessageTypes, virgülle ayrılmış mesaj tipi isimlerini (Örn: "ORDER_CREATED,PAYMENT_FAILED") çözer.
+func ParseMessageTypes(s string) ([]pb.MessageType, error) {
+	var types []pb.MessageType
+	for _, name := range splitList(s) {
+		v, ok := pb.MessageType_value[strings.ToUpper(name)]
+		if !ok {
+			return nil, fmt.Errorf("unknown message type %q", name)
+		}
+		types = append(types, pb.MessageType(v))
+	}
+	return types, nil
+}
+
+// ParseServiceTypes, virgülle ayrılmış servis isimlerini (Örn: "ORDER_SERVICE") çözer.
+func ParseServiceTypes(s string) ([]pb.ServiceType, error) {
+	var services []pb.ServiceType
+	for _, name := range splitList(s) {
+		v, ok := pb.ServiceType_value[strings.ToUpper(name)]
+		if !ok {
+			return nil, fmt.Errorf("unknown service type %q", name)
+		}
+		services = append(services, pb.ServiceType(v))
+	}
+	return services, nil
+}
+
+func splitList(s string) []string {
+	var out []string
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
+
+func containsString(list []string, v string) bool {
+	for _, s := range list {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
+
+func containsType(list []pb.MessageType, v pb.MessageType) bool {
+	for _, t := range list {
+		if t == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,124 @@
+package messaging
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+func TestDLQBackupRestoreRoundTrip(t *testing.T) {
+	failedAt := time.Date(2026, 3, 1, 12, 30, 0, 123456789, time.UTC)
+	kept := []kafka.Message{
+		{
+			Topic: "dlq-events", Partition: 2, Offset: 41,
+			Key:   []byte("order-1"),
+			Value: []byte{0x0a, 0x03, 'm', '-', '1', 0x00, 0xff},
+			Headers: []kafka.Header{
+				{Key: HeaderErrorReason, Value: []byte("stock service unavailable")},
+				{Key: HeaderOriginalTopic, Value: []byte("main-events")},
+			},
+			Time: failedAt,
+		},
+		{Topic: "dlq-events", Partition: 0, Offset: 7, Key: nil, Value: []byte("not a protobuf"), Time: failedAt.Add(time.Minute)},
+	}
+
+	path := filepath.Join(t.TempDir(), "dlq-backup.jsonl")
+	if err := WriteDLQBackup(path, kept); err != nil {
+		t.Fatal(err)
+	}
+	// Önceki bir purge'ün backup'ı ezilmemeli.
+	if err := WriteDLQBackup(path, kept[:1]); err == nil {
+		t.Fatal("WriteDLQBackup overwrote an existing backup")
+	}
+
+	w := &recordingWriter{}
+	var writerTopic string
+	admin := &DLQAdmin{
+		config: KafkaConfig{DLQTopic: "dlq-events"},
+		newWriter: func(topic string) messageWriter {
+			writerTopic = topic
+			return w
+		},
+	}
+
+	restored, err := admin.RestoreBackup(context.Background(), path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if restored != len(kept) || len(w.msgs) != len(kept) {
+		t.Fatalf("restored %d (wrote %d), want %d", restored, len(w.msgs), len(kept))
+	}
+	if writerTopic != "dlq-events" {
+		t.Fatalf("restored to %q, want the DLQ topic", writerTopic)
+	}
+
+	for i, got := range w.msgs {
+		want := kept[i]
+		if !bytes.Equal(got.Key, want.Key) || !bytes.Equal(got.Value, want.Value) || !got.Time.Equal(want.Time) {
+			t.Fatalf("message %d = %+v, want %+v", i, got, want)
+		}
+		if !reflect.DeepEqual(got.Headers, want.Headers) {
+			t.Fatalf("message %d headers = %v, want %v", i, got.Headers, want.Headers)
+		}
+		// Partition anahtardan yeniden hesaplanır; eski konum writer'a verilmez.
+		if got.Topic != "" || got.Partition != 0 || got.Offset != 0 {
+			t.Fatalf("message %d kept its old position: topic=%q partition=%d offset=%d", i, got.Topic, got.Partition, got.Offset)
+		}
+	}
+}
+
+func TestGroupsReading(t *testing.T) {
+	member := func(topics ...kafka.GroupMemberTopic) kafka.DescribeGroupsResponseMember {
+		return kafka.DescribeGroupsResponseMember{MemberAssignments: kafka.DescribeGroupsResponseAssignments{Topics: topics}}
+	}
+	dlq := kafka.GroupMemberTopic{Topic: "dlq-events", Partitions: []int{0, 1}}
+	events := kafka.GroupMemberTopic{Topic: "main-events", Partitions: []int{0}}
+
+	tests := []struct {
+		name   string
+		groups []kafka.DescribeGroupsResponseGroup
+		want   []string
+	}{
+		{"no groups", nil, nil},
+		{
+			name: "recovery consumers are active",
+			groups: []kafka.DescribeGroupsResponseGroup{
+				{GroupID: "ORDER_SERVICE-dlq-recovery", Members: []kafka.DescribeGroupsResponseMember{member(dlq)}},
+				{GroupID: "ORDER_SERVICE", Members: []kafka.DescribeGroupsResponseMember{member(events)}},
+				{GroupID: "BASKET_SERVICE-dlq-recovery", Members: []kafka.DescribeGroupsResponseMember{member(events), member(dlq)}},
+			},
+			want: []string{"BASKET_SERVICE-dlq-recovery", "ORDER_SERVICE-dlq-recovery"},
+		},
+		{
+			name: "stopped group has no members",
+			groups: []kafka.DescribeGroupsResponseGroup{
+				{GroupID: "ORDER_SERVICE-dlq-recovery", GroupState: "Empty"},
+			},
+		},
+		{
+			name: "member without dlq partitions",
+			groups: []kafka.DescribeGroupsResponseGroup{
+				{GroupID: "ORDER_SERVICE-dlq-recovery", Members: []kafka.DescribeGroupsResponseMember{
+					member(kafka.GroupMemberTopic{Topic: "dlq-events"}),
+				}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := groupsReading(tt.groups, "dlq-events")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("groupsReading = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
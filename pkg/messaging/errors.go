@@ -0,0 +1,82 @@
+package messaging
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Handler hata sınıfları. Handler'lar döndükleri hatayı bunlarla sararak handleFailure'a
+// mesajın ne olacağını söyler:
+//
+//	Permanent(err)      -> tekrar denemek sonucu değiştirmez (geçersiz UUID, bilinmeyen sipariş); doğrudan DLQ.
+//	Transient(err)      -> geçici hata; yapılandırılmış backoff ile MaxRetries'a kadar tekrar denenir.
+//	RetryAfter(d, err)  -> geçici hata, ama bekleme süresini handler belirler (Örn: e-posta sağlayıcısının rate limit'i).
+//
+// Sarılmamış hatalar Transient kabul edilir; eski davranış budur.
+// Neden? Her hatayı MaxRetries kez denemek, hiç düzelmeyecek mesajları dakikalarca retry
+// topic'lerinde dolaştırır ve DLQ'ya gerçek sebebin geç düşmesine yol açar.
+
+// PermanentError, tekrar denenmemesi gereken bir handler hatasıdır.
+type PermanentError struct {
+	Err error
+}
+
+func (e *PermanentError) Error() string { return "permanent: " + e.Err.Error() }
+func (e *PermanentError) Unwrap() error { return e.Err }
+
+// TransientError, tekrar denenebilecek bir handler hatasıdır.
+// Delay sıfırdan büyükse backoff yerine bu süre kadar beklenir.
+type TransientError struct {
+	Err   error
+	Delay time.Duration
+}
+
+func (e *TransientError) Error() string {
+	if e.Delay > 0 {
+		return fmt.Sprintf("transient (retry after %v): %v", e.Delay, e.Err)
+	}
+	return "transient: " + e.Err.Error()
+}
+
+func (e *TransientError) Unwrap() error { return e.Err }
+
+// Permanent, err'i tekrar denenmeyecek şekilde işaretler. err nil ise nil döner.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &PermanentError{Err: err}
+}
+
+// Transient, err'i yapılandırılmış backoff ile tekrar denenecek şekilde işaretler. err nil ise nil döner.
+func Transient(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &TransientError{Err: err}
+}
+
+// RetryAfter, err'i d kadar sonra tekrar denenecek şekilde işaretler.
+// Deneme yine MaxRetries'a sayılır. err nil ise nil döner.
+func RetryAfter(d time.Duration, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &TransientError{Err: err, Delay: d}
+}
+
+// IsPermanent, hata zincirinde PermanentError olup olmadığını söyler.
+func IsPermanent(err error) bool {
+	var permanent *PermanentError
+	return errors.As(err, &permanent)
+}
+
+// retryDelayHint, handler'ın RetryAfter ile istediği gecikmeyi döner; yoksa false.
+func retryDelayHint(err error) (time.Duration, bool) {
+	var transient *TransientError
+	if errors.As(err, &transient) && transient.Delay > 0 {
+		return transient.Delay, true
+	}
+	return 0, false
+}
@@ -0,0 +1,129 @@
+package messaging
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+
+	pb "marketplace/pkg/proto/events"
+)
+
+// IdempotencyStore, bir mesajın daha önce başarıyla işlenip işlenmediğini takip eder.
+// Neden? Aynı mesaj birden fazla kez gelebilir: DLQ recovery tekrar işler, outbox relay
+// iki kez basabilir, commit'ten önce süreç ölebilir. Store sayesinde handler'lar
+// "at-least-once" teslimatta bile etkisini bir kez gösterir.
+// Anahtar, mesaj ID'si ve tüketici grubudur (bkz. KafkaClient.idempotencyGroup).
+type IdempotencyStore interface {
+	IsProcessed(ctx context.Context, group, messageID string) (bool, error)
+	MarkProcessed(ctx context.Context, group, messageID string) error
+}
+
+// TxIdempotencyStore, kaydı handler'ın kendi veritabanı transaction'ı içinde atabilen store'dur.
+// Handler'lar bunu doğrudan değil, MarkProcessedInTx üzerinden kullanır.
+type TxIdempotencyStore interface {
+	IdempotencyStore
+	MarkProcessedTx(ctx context.Context, tx *sql.Tx, group, messageID string) error
+}
+
+type idempotencyKey struct{}
+
+// idempotencyScope, işlenmekte olan mesajın tekilleştirme bilgisini context ile taşır.
+type idempotencyScope struct {
+	store     TxIdempotencyStore
+	group     string
+	messageID string
+	marked    bool
+}
+
+// MarkProcessedInTx, Kafka'dan gelen mesajı handler'ın transaction'ı içinde "işlendi" olarak kaydeder.
+// Neden? İş verisi ve tekilleştirme kaydı aynı commit ile yazılır; biri olup diğerinin
+// olmaması mümkün değildir. Aynı mesaj eşzamanlı iki kez işlenirse ikinci transaction
+// birincil anahtar çakışmasıyla geri alınır.
+// Context'te Kafka mesajı yoksa (Örn: HTTP isteği) veya store transaction desteklemiyorsa hiçbir şey yapmaz.
+func MarkProcessedInTx(ctx context.Context, tx *sql.Tx) error {
+	scope, ok := ctx.Value(idempotencyKey{}).(*idempotencyScope)
+	if !ok || scope.store == nil {
+		return nil
+	}
+	if err := scope.store.MarkProcessedTx(ctx, tx, scope.group, scope.messageID); err != nil {
+		return fmt.Errorf("mark message %s as processed: %w", scope.messageID, err)
+	}
+	scope.marked = true
+	return nil
+}
+
+// idempotencyGroup, tekilleştirme anahtarındaki grup adını döner.
+// Ana topic, retry kademeleri ve DLQ recovery farklı Kafka grupları kullansa da
+// aynı servisin mesajı bir kez işlemesi gerektiği için hepsi aynı grubu paylaşır.
+func (kc *KafkaClient) idempotencyGroup() string {
+	if kc.config.GroupID != "" {
+		return kc.config.GroupID
+	}
+	return kc.serviceType.String()
+}
+
+// runHandler, handler'ı tekilleştirme kontrolü ile birlikte çalıştırır.
+// Store tanımlı değilse doğrudan handler'ı çağırır. Mesaj daha önce işlendiyse
+// handler çalıştırılmaz ve nil döner.
+// Ana consumer, retry, sıralı işleme, DLQ recovery ve in-memory broker hep buradan geçtiği
+// için işlem sayaçları, süre histogramı ve consumer span'i burada tutulur. İmza doğrulama ve
+// şifre çözme de burada yapılır; handler düz mesajı alır, hata durumunda retry/DLQ'ya şifreli
+// orijinal gider.
+func (kc *KafkaClient) runHandler(ctx context.Context, msg *pb.Message, handler MessageHandler) error {
+	start := time.Now()
+	ctx, span := kc.startConsumeSpan(messageContext(ctx, msg), msg)
+	opened, err := kc.openMessage(msg)
+	if err == nil {
+		err = kc.runIdempotent(ctx, opened, handler)
+	}
+	endSpan(span, err)
+	kc.observeHandler(msg, start, err)
+	return err
+}
+
+func (kc *KafkaClient) runIdempotent(ctx context.Context, msg *pb.Message, handler MessageHandler) error {
+	// Handler'lar sadece güncel şemayı bilir; eski sürümde yazılmış mesajlar önce taşınır.
+	if err := kc.schemas().Upcast(msg); err != nil {
+		return err
+	}
+
+	store := kc.config.IdempotencyStore
+	if store == nil || msg.Id == "" {
+		return handler(ctx, msg)
+	}
+
+	group := kc.idempotencyGroup()
+
+	processed, err := store.IsProcessed(ctx, group, msg.Id)
+	if err != nil {
+		// Store erişilemiyorsa mesajı kaybetmektense tekrar işlemeyi tercih ediyoruz.
+		log.Printf("⚠ [Idempotency] Lookup failed, processing anyway [id=%s]: %v", msg.Id, err)
+	} else if processed {
+		log.Printf("↷ [Idempotency] Already processed, skipping [id=%s, group=%s]", msg.Id, group)
+		return nil
+	}
+
+	scope := &idempotencyScope{group: group, messageID: msg.Id}
+	if txStore, ok := store.(TxIdempotencyStore); ok {
+		scope.store = txStore
+	}
+
+	if err := handler(context.WithValue(ctx, idempotencyKey{}, scope), msg); err != nil {
+		return err
+	}
+
+	// Handler kaydı kendi transaction'ında attıysa tekrar yazmaya gerek yok.
+	if scope.marked {
+		return nil
+	}
+
+	markCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+	defer cancel()
+
+	if err := store.MarkProcessed(markCtx, group, msg.Id); err != nil {
+		log.Printf("⚠ [Idempotency] Mark failed [id=%s]: %v", msg.Id, err)
+	}
+	return nil
+}
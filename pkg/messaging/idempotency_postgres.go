@@ -0,0 +1,107 @@
+package messaging
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+)
+
+const (
+	// IdempotencyRetention, processed_messages kayıtlarının saklanma süresidir. Kafka'nın
+	// varsayılan retention süresiyle (7 gün) aynıdır; daha eski bir mesaj tekrar teslim edilemez.
+	IdempotencyRetention = 7 * 24 * time.Hour
+
+	// idempotencyCleanupInterval, RunCleanup'ın eski kayıtları silme sıklığıdır.
+	idempotencyCleanupInterval = time.Hour
+)
+
+const createProcessedMessagesTable = `
+	CREATE TABLE IF NOT EXISTS processed_messages (
+		consumer_group TEXT NOT NULL,
+		message_id TEXT NOT NULL,
+		processed_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+		PRIMARY KEY (consumer_group, message_id)
+	)`
+
+// PostgresIdempotencyStore, işlenmiş mesajları servisin kendi veritabanındaki
+// processed_messages tablosunda tutar. Handler aynı veritabanını kullanıyorsa
+// kayıt MarkProcessedInTx ile iş verisiyle aynı transaction'da atılabilir.
+type PostgresIdempotencyStore struct {
+	db *sql.DB
+}
+
+// NewPostgresIdempotencyStore, tabloyu (yoksa) oluşturur ve store'u döner.
+func NewPostgresIdempotencyStore(db *sql.DB) (*PostgresIdempotencyStore, error) {
+	if _, err := db.Exec(createProcessedMessagesTable); err != nil {
+		return nil, fmt.Errorf("failed to create processed_messages table: %w", err)
+	}
+	return &PostgresIdempotencyStore{db: db}, nil
+}
+
+func (s *PostgresIdempotencyStore) IsProcessed(ctx context.Context, group, messageID string) (bool, error) {
+	const query = `SELECT EXISTS (SELECT 1 FROM processed_messages WHERE consumer_group = $1 AND message_id = $2)`
+
+	var exists bool
+	if err := s.db.QueryRowContext(ctx, query, group, messageID).Scan(&exists); err != nil {
+		return false, err
+	}
+	return exists, nil
+}
+
+func (s *PostgresIdempotencyStore) MarkProcessed(ctx context.Context, group, messageID string) error {
+	const query = `
+		INSERT INTO processed_messages (consumer_group, message_id)
+		VALUES ($1, $2) ON CONFLICT DO NOTHING`
+
+	_, err := s.db.ExecContext(ctx, query, group, messageID)
+	return err
+}
+
+// MarkProcessedTx, kaydı çağıranın transaction'ı içinde atar.
+// Neden ON CONFLICT yok? Aynı mesajı eşzamanlı işleyen ikinci transaction'ın
+// birincil anahtar hatası alıp tüm iş değişiklikleriyle birlikte geri alınması istenir.
+func (s *PostgresIdempotencyStore) MarkProcessedTx(ctx context.Context, tx *sql.Tx, group, messageID string) error {
+	const query = `INSERT INTO processed_messages (consumer_group, message_id) VALUES ($1, $2)`
+
+	_, err := tx.ExecContext(ctx, query, group, messageID)
+	return err
+}
+
+// Cleanup, retention süresinden eski kayıtları siler. Kafka'daki mesajlar da bu süre sonunda
+// silindiği için daha eski kayıtları tutmanın anlamı yoktur.
+func (s *PostgresIdempotencyStore) Cleanup(ctx context.Context, olderThan time.Duration) (int64, error) {
+	const query = `DELETE FROM processed_messages WHERE processed_at < $1`
+
+	res, err := s.db.ExecContext(ctx, query, time.Now().Add(-olderThan))
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// RunCleanup, IdempotencyRetention'dan eski kayıtları başlangıçta ve saatte bir siler;
+// ctx iptal edilene kadar bloklar. Servisler outbox relay'inin yanında çalıştırır.
+// Neden? Temizlenmeyen tablo işlenen her mesajla büyür ve her mesajda çalışan IsProcessed
+// sorgusu zamanla yavaşlar.
+func (s *PostgresIdempotencyStore) RunCleanup(ctx context.Context) {
+	ticker := time.NewTicker(idempotencyCleanupInterval)
+	defer ticker.Stop()
+
+	for {
+		removed, err := s.Cleanup(ctx, IdempotencyRetention)
+		switch {
+		case err != nil && ctx.Err() == nil:
+			log.Printf("⚠️ [Idempotency] Cleanup failed: %v", err)
+		case removed > 0:
+			log.Printf("🧹 [Idempotency] Removed %d processed message records older than %v", removed, IdempotencyRetention)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
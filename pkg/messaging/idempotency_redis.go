@@ -0,0 +1,43 @@
+package messaging
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisIdempotencyStore, işlenmiş mesaj anahtarlarını TTL ile Redis'te tutar.
+// Neden? Veritabanı olmayan veya Redis üzerinde çalışan servisler (Örn: basket-service)
+// için hafif bir alternatiftir. Transaction desteği yoktur; kayıt handler başarıyla
+// döndükten sonra atılır.
+type RedisIdempotencyStore struct {
+	client redis.UniversalClient
+	ttl    time.Duration
+}
+
+// NewRedisIdempotencyStore, ttl süresince işlenmiş mesajları hatırlayan bir store döner.
+// ttl, Kafka topic retention süresinden kısa olmamalıdır.
+func NewRedisIdempotencyStore(client redis.UniversalClient, ttl time.Duration) *RedisIdempotencyStore {
+	if ttl <= 0 {
+		ttl = 7 * 24 * time.Hour
+	}
+	return &RedisIdempotencyStore{client: client, ttl: ttl}
+}
+
+func (s *RedisIdempotencyStore) IsProcessed(ctx context.Context, group, messageID string) (bool, error) {
+	n, err := s.client.Exists(ctx, s.key(group, messageID)).Result()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+func (s *RedisIdempotencyStore) MarkProcessed(ctx context.Context, group, messageID string) error {
+	return s.client.Set(ctx, s.key(group, messageID), time.Now().Unix(), s.ttl).Err()
+}
+
+func (s *RedisIdempotencyStore) key(group, messageID string) string {
+	return fmt.Sprintf("processed:%s:%s", group, messageID)
+}
@@ -0,0 +1,265 @@
+package messaging
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	pb "marketplace/pkg/proto/events"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+// processedTable, PostgresIdempotencyStore'un çalıştırdığı sorguları hafızadaki bir
+// processed_messages tablosu üzerinde cevaplayan sahte database/sql sürücüsüdür.
+type processedTable struct {
+	mu   sync.Mutex
+	rows map[string]time.Time // group/messageID -> processed_at
+}
+
+func newProcessedTable() *processedTable {
+	return &processedTable{rows: make(map[string]time.Time)}
+}
+
+func (p *processedTable) open(t *testing.T) *sql.DB {
+	t.Helper()
+	db := sql.OpenDB(p)
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func (p *processedTable) seed(group, messageID string, processedAt time.Time) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.rows[group+"/"+messageID] = processedAt
+}
+
+func (p *processedTable) has(group, messageID string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	_, ok := p.rows[group+"/"+messageID]
+	return ok
+}
+
+func (p *processedTable) Connect(context.Context) (driver.Conn, error) { return processedConn{p}, nil }
+func (p *processedTable) Driver() driver.Driver                        { return nil }
+
+type processedConn struct{ table *processedTable }
+
+func (c processedConn) Prepare(string) (driver.Stmt, error) {
+	return nil, errors.New("prepared statements are not supported")
+}
+
+func (c processedConn) Close() error { return nil }
+
+func (c processedConn) Begin() (driver.Tx, error) {
+	return nil, errors.New("transactions are not supported")
+}
+
+func (c processedConn) ExecContext(_ context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	p := c.table
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	switch q := strings.TrimSpace(query); {
+	case strings.HasPrefix(q, "CREATE TABLE"):
+		return driver.RowsAffected(0), nil
+	case strings.HasPrefix(q, "INSERT INTO processed_messages"):
+		key := fmt.Sprintf("%s/%s", args[0].Value, args[1].Value)
+		if _, ok := p.rows[key]; ok {
+			return driver.RowsAffected(0), nil
+		}
+		p.rows[key] = time.Now()
+		return driver.RowsAffected(1), nil
+	case strings.HasPrefix(q, "DELETE FROM processed_messages WHERE processed_at <"):
+		cutoff := args[0].Value.(time.Time)
+		var removed int64
+		for key, processedAt := range p.rows {
+			if processedAt.Before(cutoff) {
+				delete(p.rows, key)
+				removed++
+			}
+		}
+		return driver.RowsAffected(removed), nil
+	}
+	return nil, fmt.Errorf("unexpected exec: %s", query)
+}
+
+func (c processedConn) QueryContext(_ context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	if !strings.HasPrefix(strings.TrimSpace(query), "SELECT EXISTS") {
+		return nil, fmt.Errorf("unexpected query: %s", query)
+	}
+	p := c.table
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	_, ok := p.rows[fmt.Sprintf("%s/%s", args[0].Value, args[1].Value)]
+	return &existsRows{value: ok}, nil
+}
+
+type existsRows struct {
+	value bool
+	done  bool
+}
+
+func (r *existsRows) Columns() []string { return []string{"exists"} }
+func (r *existsRows) Close() error      { return nil }
+func (r *existsRows) Next(dest []driver.Value) error {
+	if r.done {
+		return io.EOF
+	}
+	r.done = true
+	dest[0] = r.value
+	return nil
+}
+
+func TestRunIdempotent(t *testing.T) {
+	errBoom := errors.New("boom")
+
+	stores := []struct {
+		name string
+		open func(t *testing.T) IdempotencyStore
+	}{
+		{"postgres", func(t *testing.T) IdempotencyStore {
+			store, err := NewPostgresIdempotencyStore(newProcessedTable().open(t))
+			if err != nil {
+				t.Fatal(err)
+			}
+			return store
+		}},
+		{"redis", func(t *testing.T) IdempotencyStore {
+			client := redis.NewClient(&redis.Options{Addr: miniredis.RunT(t).Addr()})
+			t.Cleanup(func() { client.Close() })
+			return NewRedisIdempotencyStore(client, time.Hour)
+		}},
+	}
+
+	// delivery, aynı mesajın bir kez daha teslim edilmesidir.
+	type delivery struct {
+		handlerErr error
+		wantCalls  int // bu teslimattan sonra handler'ın toplam çağrılma sayısı
+		wantMarked bool
+	}
+
+	tests := []struct {
+		name       string
+		deliveries []delivery
+	}{
+		{
+			name: "duplicate delivery is skipped",
+			deliveries: []delivery{
+				{wantCalls: 1, wantMarked: true},
+				{wantCalls: 1, wantMarked: true},
+			},
+		},
+		{
+			name: "handler error leaves the message unmarked",
+			deliveries: []delivery{
+				{handlerErr: errBoom, wantCalls: 1},
+				{handlerErr: errBoom, wantCalls: 2},
+				{wantCalls: 3, wantMarked: true},
+				{wantCalls: 3, wantMarked: true},
+			},
+		},
+	}
+
+	for _, s := range stores {
+		for _, tt := range tests {
+			t.Run(s.name+"/"+tt.name, func(t *testing.T) {
+				store := s.open(t)
+				kc := &KafkaClient{config: KafkaConfig{GroupID: "order-service", IdempotencyStore: store}}
+				msg := &pb.Message{Id: "msg-1", Type: pb.MessageType_ORDER_CREATED}
+
+				calls := 0
+				for i, d := range tt.deliveries {
+					err := kc.runIdempotent(context.Background(), msg, func(context.Context, *pb.Message) error {
+						calls++
+						return d.handlerErr
+					})
+					if !errors.Is(err, d.handlerErr) {
+						t.Fatalf("delivery %d: error = %v, want %v", i, err, d.handlerErr)
+					}
+					if calls != d.wantCalls {
+						t.Fatalf("delivery %d: handler calls = %d, want %d", i, calls, d.wantCalls)
+					}
+					marked, err := store.IsProcessed(context.Background(), "order-service", msg.Id)
+					if err != nil {
+						t.Fatal(err)
+					}
+					if marked != d.wantMarked {
+						t.Fatalf("delivery %d: marked = %v, want %v", i, marked, d.wantMarked)
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestRunIdempotentGroupsAreIndependent(t *testing.T) {
+	store, err := NewPostgresIdempotencyStore(newProcessedTable().open(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := &pb.Message{Id: "msg-1", Type: pb.MessageType_ORDER_CREATED}
+
+	calls := 0
+	handler := func(context.Context, *pb.Message) error { calls++; return nil }
+	for _, group := range []string{"order-service", "notification-service", "order-service"} {
+		kc := &KafkaClient{config: KafkaConfig{GroupID: group, IdempotencyStore: store}}
+		if err := kc.runIdempotent(context.Background(), msg, handler); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if calls != 2 {
+		t.Fatalf("handler calls = %d, want one per consumer group", calls)
+	}
+}
+
+func TestPostgresIdempotencyStoreRunCleanup(t *testing.T) {
+	table := newProcessedTable()
+	store, err := NewPostgresIdempotencyStore(table.open(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	table.seed("g", "expired", now.Add(-IdempotencyRetention-time.Hour))
+	table.seed("g", "about-to-expire", now.Add(-IdempotencyRetention+time.Hour))
+	table.seed("g", "fresh", now)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		store.RunCleanup(ctx)
+		close(done)
+	}()
+
+	// İlk temizlik başlangıçta, ticker'ı beklemeden yapılır.
+	deadline := time.Now().Add(5 * time.Second)
+	for table.has("g", "expired") {
+		if time.Now().After(deadline) {
+			t.Fatal("RunCleanup did not remove the expired record")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("RunCleanup did not return after ctx was cancelled")
+	}
+
+	for _, id := range []string{"about-to-expire", "fresh"} {
+		if !table.has("g", id) {
+			t.Fatalf("record %q within retention was removed", id)
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package messaging
+
+import (
+	"context"
+
+	"marketplace/pkg/logging"
+	pb "marketplace/pkg/proto/events"
+)
+
+// HeaderRequestID, mesajı doğuran HTTP isteğinin kimliğidir (gateway'in X-Request-ID'si).
+// Handler'ın yayınladığı mesajlar kimliği context'ten devralır; böylece bir isteğin
+// tetiklediği olay zinciri boyunca aynı request_id loglanır.
+const HeaderRequestID = "request-id"
+
+// StampRequestID, ctx'teki istek kimliğini mesaja yazar. Mesaj zaten bir kimlik taşıyorsa
+// (Örn: retry'dan tekrar basılan) dokunulmaz. İmzadan önce çağrılır.
+// Outbox da mesajı kaydederken çağırır; relay'in context'inde istek kimliği yoktur.
+func StampRequestID(ctx context.Context, msg *pb.Message) {
+	id := logging.RequestID(ctx)
+	if id == "" || msg.Headers[HeaderRequestID] != "" {
+		return
+	}
+	if msg.Headers == nil {
+		msg.Headers = make(map[string]string)
+	}
+	msg.Headers[HeaderRequestID] = id
+}
+
+// messageContext, mesajın istek kimliğini handler'a verilecek context'e ekler.
+func messageContext(ctx context.Context, msg *pb.Message) context.Context {
+	return logging.WithRequestID(ctx, msg.Headers[HeaderRequestID])
+}
@@ -0,0 +1,407 @@
+package messaging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	pb "marketplace/pkg/proto/events"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// MemoryBroker, tek bir süreç içinde çalışan Kafka benzeri bir mesaj aracısıdır.
+// Neden? Sipariş -> ödeme -> stok -> bildirim saga'sını tek bir `go test` içinde,
+// Kafka ayağa kaldırmadan uçtan uca çalıştırabilmek için.
+// Her servis Connect ile kendi MemoryClient'ını alır; topic'ler ve consumer group'lar
+// tüm client'lar arasında ortaktır, tıpkı Kafka'daki gibi.
+//
+// Kafka'dan farkları:
+//   - Topic'ler tek partition'lıdır; mesajlar yayınlanma sırasıyla teslim edilir.
+//   - Yeni bir consumer group topic'i en baştan okur. Böylece testlerde consumer'lar
+//     başlamadan yayınlanan mesajlar kaybolmaz.
+//   - Kritik mesajlar diske yazılmaz.
+type MemoryBroker struct {
+	mu     sync.Mutex
+	topics map[string]*memTopic
+
+	// RetryDelay, n. retry'ın ne kadar bekleyeceğini belirler. Handler RetryAfter ile
+	// bir süre istediyse o önceliklidir. nil ise KafkaClient ile aynı exponential backoff
+	// (5s, 10s, 20s ...) kullanılır;
+	// testlerde genelde sıfır döndüren bir fonksiyon verilir.
+	RetryDelay func(retryCount int32) time.Duration
+}
+
+type memTopic struct {
+	records []memRecord
+	offsets map[string]int // consumer group -> sıradaki okunacak index
+	notify  chan struct{}  // her yeni kayıtta kapatılıp yenilenir
+}
+
+type memRecord struct {
+	key     string
+	value   []byte
+	headers map[string]string
+	at      time.Time
+}
+
+func NewMemoryBroker() *MemoryBroker {
+	return &MemoryBroker{topics: make(map[string]*memTopic)}
+}
+
+// Connect, BrokerFactory imzasına uyar ve servise ait bir MemoryClient döner.
+func (b *MemoryBroker) Connect(config KafkaConfig) (Broker, error) {
+	return b.NewClient(config), nil
+}
+
+// NewClient, verilen yapılandırma ile broker'a bağlı yeni bir client oluşturur.
+// ToServices, AllowedMessageTypes, MaxRetries, RetryTopic, DLQTopic ve IdempotencyStore
+// KafkaClient'taki anlamlarıyla kullanılır.
+func (b *MemoryBroker) NewClient(config KafkaConfig) *MemoryClient {
+	workers := config.MaxConcurrentHandlers
+	if workers <= 0 {
+		workers = 1
+	}
+	return &MemoryClient{
+		broker: b,
+		core: &KafkaClient{
+			config:      config,
+			serviceType: config.ServiceType,
+			workerPool:  make(chan struct{}, workers),
+		},
+	}
+}
+
+// Messages, bir topic'e yazılmış tüm mesajları yazılma sırasıyla döner.
+func (b *MemoryBroker) Messages(topic string) []*pb.Message {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	t, ok := b.topics[topic]
+	if !ok {
+		return nil
+	}
+	messages := make([]*pb.Message, 0, len(t.records))
+	for _, r := range t.records {
+		msg := &pb.Message{}
+		if err := proto.Unmarshal(r.value, msg); err == nil {
+			messages = append(messages, msg)
+		}
+	}
+	return messages
+}
+
+// DLQ, bir DLQ topic'indeki mesajları DLQAdmin.List ile aynı biçimde döner.
+func (b *MemoryBroker) DLQ(topic string) []DLQMessage {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	t, ok := b.topics[topic]
+	if !ok {
+		return nil
+	}
+	var messages []DLQMessage
+	for i, r := range t.records {
+		msg := &pb.Message{}
+		if err := proto.Unmarshal(r.value, msg); err != nil {
+			continue
+		}
+		m := DLQMessage{
+			Offset:        int64(i),
+			Key:           r.key,
+			ErrorReason:   r.headers[HeaderErrorReason],
+			OriginalTopic: r.headers[HeaderOriginalTopic],
+			FailedAt:      r.at,
+			Message:       msg,
+		}
+		messages = append(messages, m)
+	}
+	return messages
+}
+
+func (b *MemoryBroker) topic(name string) *memTopic {
+	t, ok := b.topics[name]
+	if !ok {
+		t = &memTopic{offsets: make(map[string]int), notify: make(chan struct{})}
+		b.topics[name] = t
+	}
+	return t
+}
+
+func (b *MemoryBroker) append(topic string, r memRecord) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	t := b.topic(topic)
+	t.records = append(t.records, r)
+	close(t.notify)
+	t.notify = make(chan struct{})
+}
+
+// next, grubun sıradaki kaydını döner; kayıt yoksa yenisi gelene veya ctx bitene kadar bekler.
+// Kayıt alındığı anda grubun offset'i ilerler (in-memory'de commit ayrı bir adım değildir).
+func (b *MemoryBroker) next(ctx context.Context, topic, group string) (memRecord, bool) {
+	r, offset, ok := b.fetch(ctx, topic, group)
+	if ok {
+		b.commit(topic, group, offset)
+	}
+	return r, ok
+}
+
+// fetch, grubun sıradaki kaydını offset'ini ilerletmeden döner; kayıt yoksa yenisi gelene veya
+// ctx bitene kadar bekler. Kayıt, commit çağrılana kadar grubun sıradaki kaydı olarak kalır.
+func (b *MemoryBroker) fetch(ctx context.Context, topic, group string) (memRecord, int, bool) {
+	for {
+		b.mu.Lock()
+		t := b.topic(topic)
+		offset := t.offsets[group]
+		if offset < len(t.records) {
+			r := t.records[offset]
+			b.mu.Unlock()
+			return r, offset, true
+		}
+		notify := t.notify
+		b.mu.Unlock()
+
+		select {
+		case <-notify:
+		case <-ctx.Done():
+			return memRecord{}, 0, false
+		}
+	}
+}
+
+// commit, grubun offset'ini verilen kaydın sonrasına taşır.
+func (b *MemoryBroker) commit(topic, group string, offset int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.topic(topic).offsets[group] = offset + 1
+}
+
+// recoveryDelay, kurtarılamayan DLQ mesajının attempt. denemeden sonra ne kadar bekleyeceğidir.
+// KafkaClient.ConsumeDLQWithRecovery ile aynı backoff'u, broker'ın RetryDelay'i varsa onu kullanır.
+func (b *MemoryBroker) recoveryDelay(core *KafkaClient, attempt int) time.Duration {
+	if b.RetryDelay != nil {
+		return b.RetryDelay(int32(attempt))
+	}
+	return time.Duration(core.calculateRetryDelay(attempt)) * time.Second
+}
+
+// retryDelay, handler'ın RetryAfter ile istediği süreye öncelik verir; yoksa broker'ın
+// RetryDelay fonksiyonunu, o da yoksa KafkaClient'ın backoff'unu kullanır.
+func (b *MemoryBroker) retryDelay(core *KafkaClient, msg *pb.Message, err error) time.Duration {
+	if delay, ok := retryDelayHint(err); ok {
+		return delay
+	}
+	if b.RetryDelay != nil {
+		return b.RetryDelay(msg.RetryCount)
+	}
+	return core.retryDelay(msg, err)
+}
+
+// MemoryClient, bir servisin MemoryBroker'a bağlı Broker implementasyonudur.
+// Filtreleme, idempotency ve retry kararları KafkaClient ile aynı kodu kullanır;
+// sadece taşıma katmanı bellektedir. Mesajlar her consumer döngüsünde sırayla işlenir.
+type MemoryClient struct {
+	broker *MemoryBroker
+	core   *KafkaClient // Kafka bağlantısı açmaz; sadece yapılandırma ve karar mantığı için
+
+	mu     sync.RWMutex
+	closed bool
+	wg     sync.WaitGroup
+}
+
+func (c *MemoryClient) PublishMessage(ctx context.Context, msg *pb.Message) error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.closed {
+		return fmt.Errorf("memory client is already closed")
+	}
+	if c.core.config.Topic == "" {
+		return errors.New("topic not configured")
+	}
+
+	c.core.stampMessage(msg)
+	StampRequestID(ctx, msg)
+	_, span := c.core.startPublishSpan(ctx, c.core.config.Topic, msg)
+	wire, err := c.core.sealMessage(msg)
+	if err == nil {
+		err = c.write(c.core.config.Topic, wire, nil)
+	}
+	endSpan(span, err)
+	return err
+}
+
+func (c *MemoryClient) ConsumeMessages(ctx context.Context, handler MessageHandler, topic *string, groupID *string) error {
+	return c.consume(ctx, c.core.getConsumerTopic(topic), c.core.getConsumerGroupID(groupID), handler)
+}
+
+// ConsumeRetryMessages, RetryTopic'i dinler. In-memory broker'da gecikme kademeleri yoktur;
+// her retry mesajı RetryAfter zamanı gelene kadar bekletilir.
+func (c *MemoryClient) ConsumeRetryMessages(ctx context.Context, handler MessageHandler, groupID *string) error {
+	if !c.core.config.EnableRetry || c.core.config.RetryTopic == "" {
+		return errors.New("retry topic not configured")
+	}
+	return c.consume(ctx, c.core.config.RetryTopic, c.core.getConsumerGroupID(groupID), handler)
+}
+
+// ConsumeDLQWithRecovery, KafkaClient'taki gibi DLQ'daki mesajları tekrar dener. Kurtarılamayan
+// mesaj commit edilmez; sonuçlanana kadar (başarı veya kalıcı hata) artan aralıklarla yerinde
+// tekrar denenir ve arkasındaki mesajlar bekler. Context iptal edilirse mesaj grubun sıradaki
+// kaydı olarak kalır ve bir sonraki recovery döngüsünde tekrar okunur.
+func (c *MemoryClient) ConsumeDLQWithRecovery(ctx context.Context, handler MessageHandler) error {
+	if c.core.config.DLQTopic == "" {
+		return fmt.Errorf("DLQ topic not configured")
+	}
+
+	c.wg.Add(1)
+	defer c.wg.Done()
+
+	topic := c.core.config.DLQTopic
+	group := c.core.serviceType.String() + "-dlq-recovery"
+	for {
+		r, offset, ok := c.broker.fetch(ctx, topic, group)
+		if !ok {
+			return nil
+		}
+
+		msg := &pb.Message{}
+		if err := proto.Unmarshal(r.value, msg); err != nil || !c.core.shouldProcessMessage(msg) {
+			c.broker.commit(topic, group, offset)
+			continue
+		}
+
+		for attempt := 1; !c.recoverDLQMessage(ctx, msg, handler); attempt++ {
+			delay := c.broker.recoveryDelay(c.core, attempt)
+			log.Printf("⏳ [Memory] Holding DLQ at offset %d [id=%s, wait=%v]", offset, msg.Id, delay)
+
+			timer := time.NewTimer(delay)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return nil
+			case <-timer.C:
+			}
+		}
+		c.broker.commit(topic, group, offset)
+	}
+}
+
+// recoverDLQMessage, KafkaClient.recoverDLQMessage gibi mesajı bir kez kurtarmayı dener ve mesaj
+// sonuçlandıysa true döner. In-memory broker'da kritik mesajlar kasaya yazılmaz.
+func (c *MemoryClient) recoverDLQMessage(ctx context.Context, msg *pb.Message, handler MessageHandler) bool {
+	err := c.core.runHandler(ctx, msg, handler)
+	switch {
+	case err == nil:
+		return true
+	case isUnroutable(err) || IsPermanent(err):
+		log.Printf("✗ [Memory] Giving up on DLQ message [id=%s]: %v", msg.Id, err)
+		return true
+	default:
+		log.Printf("✗ [Memory] Recovery failed [id=%s]: %v", msg.Id, err)
+		return false
+	}
+}
+
+// Close, çalışan consumer döngülerinin bitmesini bekler. Consumer context'leri iptal
+// edildikten sonra çağrılmalıdır.
+func (c *MemoryClient) Close() error {
+	c.mu.Lock()
+	c.closed = true
+	c.mu.Unlock()
+
+	c.wg.Wait()
+	return nil
+}
+
+func (c *MemoryClient) consume(ctx context.Context, topic, group string, handler MessageHandler) error {
+	c.wg.Add(1)
+	defer c.wg.Done()
+
+	for {
+		r, ok := c.broker.next(ctx, topic, group)
+		if !ok {
+			return nil
+		}
+
+		msg := &pb.Message{}
+		if err := proto.Unmarshal(r.value, msg); err != nil {
+			log.Printf("✗ [Memory] Unmarshal failed: %v", err)
+			continue
+		}
+		if !c.core.shouldProcessMessage(msg) {
+			continue
+		}
+		if !c.core.waitUntilDue(ctx, msg) {
+			// In-memory'de kayıt okunduğu anda offset ilerler; bekleyen mesaj kaybolmasın diye geri yazılır.
+			if err := c.write(topic, msg, nil); err != nil {
+				log.Printf("✗ [Memory] Requeue failed [id=%s]: %v", msg.Id, err)
+			}
+			if ctx.Err() != nil {
+				return nil
+			}
+			continue
+		}
+
+		if err := c.core.runHandler(ctx, msg, handler); err != nil {
+			c.handleFailure(ctx, msg, err)
+		}
+	}
+}
+
+// handleFailure, KafkaClient.handleFailure ile aynı kararları verir: yönlendirilemeyen veya
+// kalıcı hatalı mesaj doğrudan DLQ'ya, limit dolmadıysa retry topic'ine, aksi halde DLQ'ya.
+func (c *MemoryClient) handleFailure(ctx context.Context, msg *pb.Message, err error) {
+	msg.LastError = err.Error()
+
+	if isUnroutable(err) || IsPermanent(err) || !c.core.shouldRetry(msg) || c.core.config.RetryTopic == "" {
+		c.sendToDLQ(msg, err)
+		return
+	}
+
+	msg.RetryCount++
+	msg.ToServices = []pb.ServiceType{c.core.serviceType}
+	msg.RetryAfter = timestamppb.New(time.Now().Add(c.broker.retryDelay(c.core, msg, err)))
+
+	if err := c.write(c.core.config.RetryTopic, msg, nil); err != nil {
+		c.sendToDLQ(msg, err)
+		return
+	}
+	c.core.observeRetry(msg)
+}
+
+func (c *MemoryClient) sendToDLQ(msg *pb.Message, reason error) {
+	if c.core.config.DLQTopic == "" {
+		log.Printf("✗ [Memory] DLQ not configured for [id=%s]", msg.Id)
+		return
+	}
+	msg.ToServices = []pb.ServiceType{c.core.serviceType}
+	headers := map[string]string{
+		HeaderErrorReason:   reason.Error(),
+		HeaderOriginalTopic: c.core.config.Topic,
+		HeaderFailedAt:      time.Now().Format(time.RFC3339),
+	}
+	if err := c.write(c.core.config.DLQTopic, msg, headers); err != nil {
+		log.Printf("✗ [Memory] DLQ write failed [id=%s]: %v", msg.Id, err)
+		return
+	}
+	c.core.observeDLQ(msg)
+}
+
+func (c *MemoryClient) write(topic string, msg *pb.Message, headers map[string]string) error {
+	value, err := proto.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("failed to marshal protobuf: %w", err)
+	}
+	c.broker.append(topic, memRecord{
+		key:     c.core.partitionKey(msg),
+		value:   value,
+		headers: headers,
+		at:      time.Now(),
+	})
+	return nil
+}
@@ -0,0 +1,143 @@
+package messaging
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	pb "marketplace/pkg/proto/events"
+)
+
+// recoveryLog, DLQ recovery handler'ının hangi mesajı hangi sırayla denediğini kaydeder.
+type recoveryLog struct {
+	mu    sync.Mutex
+	calls []string
+}
+
+func (l *recoveryLog) add(id string) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.calls = append(l.calls, id)
+	n := 0
+	for _, c := range l.calls {
+		if c == id {
+			n++
+		}
+	}
+	return n
+}
+
+func (l *recoveryLog) snapshot() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]string(nil), l.calls...)
+}
+
+func newDLQTestClient(broker *MemoryBroker) *MemoryClient {
+	return broker.NewClient(KafkaConfig{
+		Topic:       "main-events",
+		DLQTopic:    "dlq-events",
+		ServiceType: pb.ServiceType_ORDER_SERVICE,
+	})
+}
+
+// runRecovery, recovery döngüsünü want kadar handler çağrısı olana kadar çalıştırır.
+func runRecovery(t *testing.T, client *MemoryClient, attempts *recoveryLog, want int, handler MessageHandler) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- client.ConsumeDLQWithRecovery(ctx, handler) }()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for len(attempts.snapshot()) < want && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("ConsumeDLQWithRecovery: %v", err)
+	}
+}
+
+func TestMemoryDLQRecovery(t *testing.T) {
+	errDown := errors.New("stock service unavailable")
+
+	tests := []struct {
+		name     string
+		failures map[string]int    // mesaj ID'si -> kaç kez başarısız olacağı
+		errFor   func(error) error // başarısız denemede dönen hata
+		want     []string
+	}{
+		{
+			name: "recovered messages are processed in order",
+			want: []string{"m1", "m2"},
+		},
+		{
+			name:     "failed message holds the DLQ until it recovers",
+			failures: map[string]int{"m1": 2},
+			errFor:   func(err error) error { return err },
+			want:     []string{"m1", "m1", "m1", "m2"},
+		},
+		{
+			name:     "permanent failure is given up",
+			failures: map[string]int{"m1": 1},
+			errFor:   Permanent,
+			want:     []string{"m1", "m2"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			broker := NewMemoryBroker()
+			broker.RetryDelay = func(int32) time.Duration { return 0 }
+			client := newDLQTestClient(broker)
+			for _, id := range []string{"m1", "m2"} {
+				client.sendToDLQ(&pb.Message{Id: id, Type: pb.MessageType_ORDER_CREATED}, errDown)
+			}
+
+			attempts := &recoveryLog{}
+			runRecovery(t, client, attempts, len(tt.want), func(_ context.Context, msg *pb.Message) error {
+				if attempts.add(msg.Id) <= tt.failures[msg.Id] {
+					return tt.errFor(errDown)
+				}
+				return nil
+			})
+
+			if got := attempts.snapshot(); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("recovery attempts = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMemoryDLQRecoveryKeepsMessageOnShutdown(t *testing.T) {
+	broker := NewMemoryBroker()
+	broker.RetryDelay = func(int32) time.Duration { return time.Hour }
+	client := newDLQTestClient(broker)
+	for _, id := range []string{"m1", "m2"} {
+		client.sendToDLQ(&pb.Message{Id: id, Type: pb.MessageType_ORDER_CREATED}, errors.New("boom"))
+	}
+
+	// İlk döngü m1'i kurtaramaz ve beklerken kapatılır.
+	first := &recoveryLog{}
+	runRecovery(t, client, first, 1, func(_ context.Context, msg *pb.Message) error {
+		first.add(msg.Id)
+		return errors.New("still down")
+	})
+
+	// Yeniden başlatılan döngü m1'den devam etmeli.
+	second := &recoveryLog{}
+	runRecovery(t, client, second, 2, func(_ context.Context, msg *pb.Message) error {
+		second.add(msg.Id)
+		return nil
+	})
+
+	if got, want := first.snapshot(), []string{"m1"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("first run attempts = %v, want %v", got, want)
+	}
+	if got, want := second.snapshot(), []string{"m1", "m2"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("restarted run attempts = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,233 @@
+package messaging
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"marketplace/pkg/metrics"
+	pb "marketplace/pkg/proto/events"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/segmentio/kafka-go"
+)
+
+// Kafka hattının Prometheus metrikleri. Servisler bunları kendi HTTP sunucularındaki
+// /metrics endpoint'inden (metrics.Default) sunar.
+// Neden? "✓ [Worker] Processed" log satırlarından lag'in büyüdüğünü, handler'ların
+// yavaşladığını veya worker pool'un tıkandığını görmek mümkün değil.
+// Etiketler: service = mesajı işleyen servis, type = MessageType.
+var (
+	factory = promauto.With(metrics.Default)
+
+	messagesProcessed = factory.NewCounterVec(prometheus.CounterOpts{
+		Name: "messaging_messages_processed_total",
+		Help: "Messages handled successfully.",
+	}, []string{"service", "type"})
+	messagesFailed = factory.NewCounterVec(prometheus.CounterOpts{
+		Name: "messaging_messages_failed_total",
+		Help: "Messages whose handler returned an error.",
+	}, []string{"service", "type"})
+	messagesRetried = factory.NewCounterVec(prometheus.CounterOpts{
+		Name: "messaging_messages_retried_total",
+		Help: "Failed messages scheduled on a retry topic.",
+	}, []string{"service", "type"})
+	messagesDLQ = factory.NewCounterVec(prometheus.CounterOpts{
+		Name: "messaging_messages_dlq_total",
+		Help: "Messages written to the dead letter queue.",
+	}, []string{"service", "type"})
+	handlerDuration = factory.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "messaging_handler_duration_seconds",
+		Help:    "Handler latency, including idempotency checks and upcasting.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"service", "type"})
+	consumerLag = factory.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "messaging_consumer_lag",
+		Help: "Messages between the consumer group's committed offset and the partition's end offset, refreshed periodically.",
+	}, []string{"service", "topic", "partition"})
+	workerPoolInUse = factory.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "messaging_worker_pool_in_use",
+		Help: "Handler slots currently busy.",
+	}, []string{"service"})
+	workerPoolCapacity = factory.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "messaging_worker_pool_capacity",
+		Help: "Handler slots available (MaxConcurrentHandlers).",
+	}, []string{"service"})
+)
+
+// lagPollInterval, lag gauge'unun Kafka'dan tazelenme sıklığıdır.
+const lagPollInterval = 10 * time.Second
+
+// observeHandler, runHandler'ın sonucunu sayaçlara ve süre histogramına yazar.
+func (kc *KafkaClient) observeHandler(msg *pb.Message, start time.Time, err error) {
+	service, msgType := kc.serviceType.String(), msg.Type.String()
+	handlerDuration.WithLabelValues(service, msgType).Observe(time.Since(start).Seconds())
+	if err != nil {
+		messagesFailed.WithLabelValues(service, msgType).Inc()
+		return
+	}
+	messagesProcessed.WithLabelValues(service, msgType).Inc()
+}
+
+func (kc *KafkaClient) observeRetry(msg *pb.Message) {
+	messagesRetried.WithLabelValues(kc.serviceType.String(), msg.Type.String()).Inc()
+}
+
+func (kc *KafkaClient) observeDLQ(msg *pb.Message) {
+	messagesDLQ.WithLabelValues(kc.serviceType.String(), msg.Type.String()).Inc()
+}
+
+// watchLag, reader'ın consumer group'unun lag'ini partition bazında lagPollInterval'de bir
+// gauge'a yazar; dönen fonksiyon izlemeyi durdurur ve serileri siler.
+// Neden ticker? Gauge sadece mesaj çekildiğinde güncellenseydi handler takıldığında veya
+// consumer durduğunda son değerde donardı.
+// Neden reader.Stats().Lag değil? Consumer group'ta reader birden fazla partition okur ama
+// Stats().Lag tek bir partition'ın değeridir; geri kalan partition'larda biriken mesajlar görünmez.
+// Lag, partition'ların son offset'leri ile grubun commit ettiği offset'ler karşılaştırılarak
+// hesaplanır; aynı grubun diğer replikalarının okuduğu partition'lar da dahildir.
+func (kc *KafkaClient) watchLag(reader *kafka.Reader) (stop func()) {
+	cfg := reader.Config()
+	service := kc.serviceType.String()
+	done := make(chan struct{})
+	finished := make(chan struct{})
+	reported := make(map[int]bool)
+
+	go func() {
+		defer close(finished)
+		ticker := time.NewTicker(lagPollInterval)
+		defer ticker.Stop()
+		failing := false
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				ctx, cancel := context.WithTimeout(context.Background(), lagPollInterval)
+				lags, err := kc.partitionLag(ctx, cfg.Topic, cfg.GroupID, cfg.StartOffset)
+				cancel()
+				if err != nil {
+					// Kafka erişilemezken her turda log basılmaz; sadece ilk hata yazılır.
+					if !failing {
+						log.Printf("⚠ [Metrics] Lag check failed [topic=%s, group=%s]: %v", cfg.Topic, cfg.GroupID, err)
+					}
+					failing = true
+					continue
+				}
+				failing = false
+				for partition, lag := range lags {
+					consumerLag.WithLabelValues(service, cfg.Topic, strconv.Itoa(partition)).Set(float64(lag))
+					reported[partition] = true
+				}
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		<-finished
+		for partition := range reported {
+			consumerLag.DeleteLabelValues(service, cfg.Topic, strconv.Itoa(partition))
+		}
+	}
+}
+
+// partitionLag, topic'in her partition'ının son offset'ini ve grubun commit ettiği offset'i
+// Kafka'dan okur ve partition bazında lag'i döner.
+func (kc *KafkaClient) partitionLag(ctx context.Context, topic, groupID string, startOffset int64) (map[int]int64, error) {
+	client := &kafka.Client{Addr: kafka.TCP(kc.config.Brokers...)}
+
+	meta, err := client.Metadata(ctx, &kafka.MetadataRequest{Topics: []string{topic}})
+	if err != nil {
+		return nil, err
+	}
+	if len(meta.Topics) != 1 {
+		return nil, fmt.Errorf("metadata for %s not found", topic)
+	}
+	if meta.Topics[0].Error != nil {
+		return nil, meta.Topics[0].Error
+	}
+	var (
+		partitions []int
+		requests   []kafka.OffsetRequest
+	)
+	for _, p := range meta.Topics[0].Partitions {
+		partitions = append(partitions, p.ID)
+		requests = append(requests, kafka.FirstOffsetOf(p.ID), kafka.LastOffsetOf(p.ID))
+	}
+
+	listed, err := client.ListOffsets(ctx, &kafka.ListOffsetsRequest{Topics: map[string][]kafka.OffsetRequest{topic: requests}})
+	if err != nil {
+		return nil, err
+	}
+	committed, err := client.OffsetFetch(ctx, &kafka.OffsetFetchRequest{GroupID: groupID, Topics: map[string][]int{topic: partitions}})
+	if err != nil {
+		return nil, err
+	}
+	if committed.Error != nil {
+		return nil, committed.Error
+	}
+	return partitionLags(listed.Topics[topic], committed.Topics[topic], startOffset)
+}
+
+// partitionLags, partition'ların offset aralığından ve grubun commit ettiği offset'lerden lag'i
+// hesaplar. Grup bir partition'a hiç commit etmediyse (offset < 0) reader'ın başlangıç noktası
+// esas alınır: FirstOffset ile partition'daki her mesaj, LastOffset ile hiçbiri beklemiyordur.
+func partitionLags(offsets []kafka.PartitionOffsets, committed []kafka.OffsetFetchPartition, startOffset int64) (map[int]int64, error) {
+	positions := make(map[int]int64, len(committed))
+	for _, c := range committed {
+		if c.Error != nil {
+			return nil, fmt.Errorf("committed offset of partition %d: %w", c.Partition, c.Error)
+		}
+		positions[c.Partition] = c.CommittedOffset
+	}
+
+	lags := make(map[int]int64, len(offsets))
+	for _, o := range offsets {
+		if o.Error != nil {
+			return nil, fmt.Errorf("offsets of partition %d: %w", o.Partition, o.Error)
+		}
+		position, ok := positions[o.Partition]
+		if !ok || position < 0 {
+			position = o.LastOffset
+			if startOffset == kafka.FirstOffset {
+				position = o.FirstOffset
+			}
+		}
+		// Retention commit edilen offset'in önündeki mesajları silmiş olabilir; onlar artık okunamaz.
+		if position < o.FirstOffset {
+			position = o.FirstOffset
+		}
+		lag := o.LastOffset - position
+		if lag < 0 {
+			lag = 0
+		}
+		lags[o.Partition] = lag
+	}
+	return lags, nil
+}
+
+// acquireWorker, worker pool'dan bir slot alır (doluysa bekler) ve doluluk metriğini günceller.
+// Slot beklenirken ctx iptal edilirse false döner.
+func (kc *KafkaClient) acquireWorker(ctx context.Context) bool {
+	select {
+	case kc.workerPool <- struct{}{}:
+	case <-ctx.Done():
+		return false
+	}
+	workerPoolInUse.WithLabelValues(kc.serviceType.String()).Inc()
+	return true
+}
+
+func (kc *KafkaClient) releaseWorker() {
+	workerPoolInUse.WithLabelValues(kc.serviceType.String()).Dec()
+	<-kc.workerPool
+}
+
+// trackWorkerCapacity, client'ın havuz kapasitesini servis toplamına ekler (Close'da delta negatiftir).
+// Aynı süreçte birden fazla client olabileceği için Set yerine Add kullanılır.
+func (kc *KafkaClient) trackWorkerCapacity(sign float64) {
+	workerPoolCapacity.WithLabelValues(kc.serviceType.String()).Add(sign * float64(cap(kc.workerPool)))
+}
@@ -0,0 +1,84 @@
+package messaging
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/segmentio/kafka-go"
+)
+
+func TestPartitionLags(t *testing.T) {
+	offsets := []kafka.PartitionOffsets{
+		{Partition: 0, FirstOffset: 0, LastOffset: 100},
+		{Partition: 1, FirstOffset: 0, LastOffset: 40},
+		{Partition: 2, FirstOffset: 50, LastOffset: 80},
+	}
+
+	tests := []struct {
+		name        string
+		committed   []kafka.OffsetFetchPartition
+		startOffset int64
+		want        map[int]int64
+	}{
+		{
+			name: "each partition has its own lag",
+			committed: []kafka.OffsetFetchPartition{
+				{Partition: 0, CommittedOffset: 90},
+				{Partition: 1, CommittedOffset: 40},
+				{Partition: 2, CommittedOffset: 60},
+			},
+			startOffset: kafka.LastOffset,
+			want:        map[int]int64{0: 10, 1: 0, 2: 20},
+		},
+		{
+			name: "uncommitted partition of a new consumer has no lag",
+			committed: []kafka.OffsetFetchPartition{
+				{Partition: 0, CommittedOffset: 90},
+				{Partition: 1, CommittedOffset: -1},
+			},
+			startOffset: kafka.LastOffset,
+			want:        map[int]int64{0: 10, 1: 0, 2: 0},
+		},
+		{
+			name: "uncommitted partition of a consumer reading from the start",
+			committed: []kafka.OffsetFetchPartition{
+				{Partition: 0, CommittedOffset: 90},
+				{Partition: 1, CommittedOffset: -1},
+			},
+			startOffset: kafka.FirstOffset,
+			want:        map[int]int64{0: 10, 1: 40, 2: 30},
+		},
+		{
+			name: "offset removed by retention counts from the first offset",
+			committed: []kafka.OffsetFetchPartition{
+				{Partition: 0, CommittedOffset: 100},
+				{Partition: 1, CommittedOffset: 40},
+				{Partition: 2, CommittedOffset: 10},
+			},
+			startOffset: kafka.LastOffset,
+			want:        map[int]int64{0: 0, 1: 0, 2: 30},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := partitionLags(offsets, tt.committed, tt.startOffset)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("partitionLags = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPartitionLagsReportsPartitionErrors(t *testing.T) {
+	errLeader := errors.New("not leader for partition")
+	offsets := []kafka.PartitionOffsets{{Partition: 0, LastOffset: 10}, {Partition: 1, Error: errLeader}}
+
+	if _, err := partitionLags(offsets, nil, kafka.LastOffset); !errors.Is(err, errLeader) {
+		t.Fatalf("error = %v, want %v", err, errLeader)
+	}
+}
@@ -0,0 +1,216 @@
+package messaging
+
+import (
+	"context"
+	"hash/fnv"
+	"log"
+	"sync"
+
+	pb "marketplace/pkg/proto/events"
+
+	"github.com/segmentio/kafka-go"
+	"google.golang.org/protobuf/proto"
+)
+
+// DefaultOrderingKey, mesajın ait olduğu iş varlığını (aggregate) bulur.
+// Sipariş ve ödeme olayları sipariş ID'si, kullanıcı olayları kullanıcı ID'si,
+// satıcı ve ürün olayları da kendi ID'leri ile gruplanır. Anahtar bulunamazsa
+// mesaj ID'si döner; yani mesaj başka hiçbir mesajla sıralanmaz.
+func DefaultOrderingKey(msg *pb.Message) string {
+	switch p := msg.Payload.(type) {
+	case *pb.Message_OrderCreatedData:
+		return p.OrderCreatedData.GetOrderId()
+	case *pb.Message_PaymentSuccessfulData:
+		return p.PaymentSuccessfulData.GetOrderId()
+	case *pb.Message_PaymentFailedData:
+		return p.PaymentFailedData.GetOrderId()
+	case *pb.Message_UserCreatedData:
+		return p.UserCreatedData.GetUserId()
+	case *pb.Message_UserForgotPasswordData:
+		return p.UserForgotPasswordData.GetUserId()
+	case *pb.Message_SellerApprovedData:
+		return p.SellerApprovedData.GetSellerId()
+	case *pb.Message_SellerRejectedData:
+		return p.SellerRejectedData.GetSellerId()
+	case *pb.Message_ProductPriceUpdatedData:
+		return p.ProductPriceUpdatedData.GetProductId()
+	case *pb.Message_ProductStockZeroData:
+		return p.ProductStockZeroData.GetProductId()
+	case *pb.Message_ProductDeletedData:
+		return p.ProductDeletedData.GetProductId()
+	}
+	return msg.Id
+}
+
+// orderingKey, yapılandırmadaki anahtar fonksiyonunu kullanır; yoksa mesajın partition anahtarına düşer.
+// Böylece aynı partition'a yazılan olaylar tüketici tarafında da aynı işçide sıralanır.
+func (kc *KafkaClient) orderingKey(msg *pb.Message) string {
+	if kc.config.OrderingKey != nil {
+		if key := kc.config.OrderingKey(msg); key != "" {
+			return key
+		}
+	}
+	return kc.partitionKey(msg)
+}
+
+// orderedJob, sıralı işçiye gönderilen tek bir mesajdır.
+type orderedJob struct {
+	kafkaMsg kafka.Message
+	msg      *pb.Message
+}
+
+// consumeOrdered, OrderedProcessing açıkken ConsumeMessages'ın yerine çalışan döngüdür.
+// Neden? Her mesaja ayrı goroutine açmak aynı siparişe ait PAYMENT_FAILED'ın ORDER_CREATED'dan
+// önce işlenmesine yol açabilir. Burada aynı anahtara sahip mesajlar hep aynı işçiye gider
+// ve o işçi tarafından sırayla işlenir. Farklı anahtarlar ise işçiler arasında paralel çalışır.
+// Not: Handler hata alıp mesaj retry topic'ine giderse o anahtar için sıra garantisi o mesajda biter.
+// Kapanışta kuyrukta bekleyen ama başlamamış işler işlenmez ve commit edilmez; servis yeniden
+// başladığında Kafka'dan tekrar okunur. Başlamış işler handlerCtx ile DrainTimeout kadar sürer.
+func (kc *KafkaClient) consumeOrdered(ctx, handlerCtx context.Context, reader *kafka.Reader, handler MessageHandler) error {
+	workerCount := kc.config.OrderedWorkers
+	if workerCount <= 0 {
+		workerCount = cap(kc.workerPool)
+	}
+	if workerCount <= 0 {
+		workerCount = 1
+	}
+
+	tracker := newOffsetTracker()
+	complete := func(kafkaMsg kafka.Message, id string) {
+		if committable, ok := tracker.complete(kafkaMsg); ok {
+			kc.commit(ctx, reader, committable, id)
+		}
+	}
+
+	// Her işçinin kendi kuyruğu vardır; kuyruk dolarsa fetch döngüsü bekler (doğal backpressure).
+	queues := make([]chan orderedJob, workerCount)
+	var workers sync.WaitGroup
+	for i := range queues {
+		queues[i] = make(chan orderedJob, 64)
+		workers.Add(1)
+		go func(queue <-chan orderedJob) {
+			defer workers.Done()
+			for job := range queue {
+				if ctx.Err() != nil {
+					continue // Drain: kuyruğu boşalt ama işleme
+				}
+				if kc.executeWithWorkerPool(handlerCtx, job.msg, handler) {
+					complete(job.kafkaMsg, job.msg.Id)
+				}
+			}
+		}(queues[i])
+	}
+
+	// Kapanışta: yeni mesaj almayı bırak, kuyruktakileri bitir, sonra commit'leri yaz.
+	defer func() {
+		for _, queue := range queues {
+			close(queue)
+		}
+		workers.Wait()
+	}()
+
+	log.Printf("🔀 [Consumer] Ordered processing enabled [workers=%d]", workerCount)
+
+	for {
+		m, err := reader.FetchMessage(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			log.Printf("✗ [Consumer] Fetch error: %v", err)
+			continue
+		}
+
+		// Her mesaj (atlanacak olsa bile) takibe alınır; aksi halde daha büyük bir offset'in
+		// commit'i henüz bitmemiş küçük bir offset'i gizleyebilir.
+		tracker.track(m)
+
+		message := &pb.Message{}
+		if err := proto.Unmarshal(m.Value, message); err != nil {
+			log.Printf("✗ [Consumer] Unmarshal failed: %v", err)
+			complete(m, "")
+			continue
+		}
+
+		if !kc.shouldProcessMessage(message) {
+			complete(m, message.Id)
+			continue
+		}
+
+		if due, requeued := kc.holdUntilDue(ctx, message); !due {
+			// Retry topic'ine geri yazılan mesaj tamamlanmış sayılır; önündeki işler
+			// drain sırasında atlandıysa commit yine onlarda durur.
+			if requeued {
+				complete(m, message.Id)
+			}
+			if ctx.Err() != nil {
+				return nil
+			}
+			continue
+		}
+
+		queue := queues[workerIndex(kc.orderingKey(message), workerCount)]
+		select {
+		case queue <- orderedJob{kafkaMsg: m, msg: message}:
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+func workerIndex(key string, workerCount int) int {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return int(h.Sum32() % uint32(workerCount))
+}
+
+// offsetTracker, partition başına hangi offset'lerin işlendiğini tutar ve
+// sadece kendisinden küçük tüm offset'ler bitmiş olan mesajın commit edilmesine izin verir.
+type offsetTracker struct {
+	mu         sync.Mutex
+	partitions map[int]*partitionOffsets
+}
+
+type partitionOffsets struct {
+	pending []kafka.Message // Fetch sırasına göre (offset'e göre artan) bekleyen mesajlar
+	done    map[int64]bool
+}
+
+func newOffsetTracker() *offsetTracker {
+	return &offsetTracker{partitions: make(map[int]*partitionOffsets)}
+}
+
+func (t *offsetTracker) track(m kafka.Message) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	p, ok := t.partitions[m.Partition]
+	if !ok {
+		p = &partitionOffsets{done: make(map[int64]bool)}
+		t.partitions[m.Partition] = p
+	}
+	p.pending = append(p.pending, m)
+}
+
+// complete, mesajı bitti olarak işaretler. Baştan itibaren kesintisiz biten en büyük
+// offset'e sahip mesajı döner; commit edilecek yeni bir şey yoksa false döner.
+func (t *offsetTracker) complete(m kafka.Message) (kafka.Message, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	p, ok := t.partitions[m.Partition]
+	if !ok {
+		return kafka.Message{}, false
+	}
+	p.done[m.Offset] = true
+
+	var committable kafka.Message
+	advanced := false
+	for len(p.pending) > 0 && p.done[p.pending[0].Offset] {
+		committable = p.pending[0]
+		delete(p.done, committable.Offset)
+		p.pending = p.pending[1:]
+		advanced = true
+	}
+	return committable, advanced
+}
@@ -0,0 +1,79 @@
+package messaging
+
+import (
+	"testing"
+
+	"github.com/segmentio/kafka-go"
+)
+
+func TestOffsetTrackerComplete(t *testing.T) {
+	type step struct {
+		complete   int64
+		wantOffset int64 // -1: commit edilecek yeni bir şey yok
+	}
+
+	tests := []struct {
+		name    string
+		tracked []int64
+		steps   []step
+	}{
+		{
+			name:    "in order",
+			tracked: []int64{10, 11, 12},
+			steps:   []step{{10, 10}, {11, 11}, {12, 12}},
+		},
+		{
+			name:    "gap holds commit until filled",
+			tracked: []int64{10, 11, 12},
+			steps:   []step{{12, -1}, {11, -1}, {10, 12}},
+		},
+		{
+			name:    "partial advance",
+			tracked: []int64{10, 11, 12, 13},
+			steps:   []step{{11, -1}, {10, 11}, {13, -1}, {12, 13}},
+		},
+		{
+			name:    "sparse offsets after compaction",
+			tracked: []int64{3, 7, 20},
+			steps:   []step{{7, -1}, {3, 7}, {20, 20}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tracker := newOffsetTracker()
+			for _, off := range tt.tracked {
+				tracker.track(kafka.Message{Partition: 0, Offset: off})
+			}
+			for i, s := range tt.steps {
+				got, ok := tracker.complete(kafka.Message{Partition: 0, Offset: s.complete})
+				if s.wantOffset < 0 {
+					if ok {
+						t.Fatalf("step %d: complete(%d) committed %d, want nothing", i, s.complete, got.Offset)
+					}
+					continue
+				}
+				if !ok || got.Offset != s.wantOffset {
+					t.Fatalf("step %d: complete(%d) = (%d, %v), want (%d, true)", i, s.complete, got.Offset, ok, s.wantOffset)
+				}
+			}
+		})
+	}
+}
+
+func TestOffsetTrackerPartitionsAreIndependent(t *testing.T) {
+	tracker := newOffsetTracker()
+	tracker.track(kafka.Message{Partition: 0, Offset: 1})
+	tracker.track(kafka.Message{Partition: 1, Offset: 1})
+	tracker.track(kafka.Message{Partition: 0, Offset: 2})
+
+	if _, ok := tracker.complete(kafka.Message{Partition: 0, Offset: 2}); ok {
+		t.Fatal("partition 0 advanced past unfinished offset 1")
+	}
+	if got, ok := tracker.complete(kafka.Message{Partition: 1, Offset: 1}); !ok || got.Partition != 1 || got.Offset != 1 {
+		t.Fatalf("partition 1 = (%d/%d, %v), want (1/1, true)", got.Partition, got.Offset, ok)
+	}
+	if _, ok := tracker.complete(kafka.Message{Partition: 2, Offset: 1}); ok {
+		t.Fatal("untracked partition reported a commit")
+	}
+}
@@ -0,0 +1,38 @@
+package messaging
+
+import (
+	pb "marketplace/pkg/proto/events"
+)
+
+// PartitionKeyHeader, pb.Message.Headers içinde bu anahtar varsa Kafka partition anahtarı olarak
+// doğrudan kullanılır. Yayıncı, resolver'ın bulamadığı özel bir gruplama istiyorsa bunu set eder.
+const PartitionKeyHeader = "partition-key"
+
+// partitionKey, mesajın Kafka anahtarını belirler. Öncelik sırası:
+//  1. Mesaj header'ındaki PartitionKeyHeader
+//  2. KafkaConfig.PartitionKey resolver'ı
+//  3. DefaultOrderingKey (ORDER_*/PAYMENT_* -> order_id, USER_* -> user_id ...)
+//  4. Mesaj ID'si
+//
+// Neden? Anahtar olarak msg.Id kullanıldığında aynı siparişin olayları farklı partition'lara
+// dağılıyor ve sıraları bozuluyordu. Aynı anahtar hep aynı partition'a gider (kafka.Hash).
+func (kc *KafkaClient) partitionKey(msg *pb.Message) string {
+	return resolvePartitionKey(kc.config.PartitionKey, msg)
+}
+
+// resolvePartitionKey, partitionKey'in KafkaClient'a bağlı olmayan halidir.
+// DLQ yönetim araçları gibi mesajı kendi writer'ı ile tekrar basan yerler de aynı anahtarı üretir.
+func resolvePartitionKey(resolver func(*pb.Message) string, msg *pb.Message) string {
+	if key := msg.Headers[PartitionKeyHeader]; key != "" {
+		return key
+	}
+	if resolver != nil {
+		if key := resolver(msg); key != "" {
+			return key
+		}
+	}
+	if key := DefaultOrderingKey(msg); key != "" {
+		return key
+	}
+	return msg.Id
+}
@@ -0,0 +1,48 @@
+package messaging
+
+import (
+	"testing"
+
+	pb "marketplace/pkg/proto/events"
+)
+
+func TestPartitionKey(t *testing.T) {
+	orderCreated := func(headers map[string]string) *pb.Message {
+		return &pb.Message{
+			Id:      "msg-1",
+			Type:    pb.MessageType_ORDER_CREATED,
+			Headers: headers,
+			Payload: &pb.Message_OrderCreatedData{OrderCreatedData: &pb.OrderCreatedData{OrderId: "order-1"}},
+		}
+	}
+	byUser := func(*pb.Message) string { return "user-1" }
+	empty := func(*pb.Message) string { return "" }
+
+	tests := []struct {
+		name     string
+		resolver func(*pb.Message) string
+		msg      *pb.Message
+		want     string
+	}{
+		{"header wins over resolver", byUser, orderCreated(map[string]string{PartitionKeyHeader: "custom"}), "custom"},
+		{"resolver wins over default", byUser, orderCreated(nil), "user-1"},
+		{"empty resolver falls back to default", empty, orderCreated(nil), "order-1"},
+		{"default ordering key", nil, orderCreated(nil), "order-1"},
+		{"empty header is ignored", nil, orderCreated(map[string]string{PartitionKeyHeader: ""}), "order-1"},
+		{
+			"payment events share the order key", nil,
+			&pb.Message{Id: "msg-2", Payload: &pb.Message_PaymentFailedData{PaymentFailedData: &pb.PaymentFailedData{OrderId: "order-1"}}},
+			"order-1",
+		},
+		{"message id without payload", nil, &pb.Message{Id: "msg-3", Type: pb.MessageType_USER_DELETED}, "msg-3"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kc := &KafkaClient{config: KafkaConfig{PartitionKey: tt.resolver}}
+			if got := kc.partitionKey(tt.msg); got != tt.want {
+				t.Fatalf("partitionKey() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
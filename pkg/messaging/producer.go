@@ -19,7 +19,7 @@ import (
 // 1. Otomatik ID: Mesajın ID'si yoksa UUID atar, böylece sistemde izlenebilirlik (traceability) sağlar.
 // 2. Metadata: Mesajın hangi servisten çıktığını ve ne zaman oluşturulduğunu otomatik ekler.
 // 3. Kritiklik Kontrolü: Mesaj tipine göre otomatik 'Critical' etiketi basar.
-func (kc *KafkaClient) PublishMessage(ctx context.Context, msg *pb.Message) error {
+func (kc *KafkaClient) PublishMessage(ctx context.Context, msg *pb.Message) (err error) {
 	kc.mu.RLock()
 	if kc.closed {
 		kc.mu.RUnlock()
@@ -27,34 +27,38 @@ func (kc *KafkaClient) PublishMessage(ctx context.Context, msg *pb.Message) erro
 	}
 	kc.mu.RUnlock()
 
-	// Mesaj Hazırlığı: Mesajın kimliğini ve zamanını damgalıyoruz.
-	if msg.Id == "" {
-		msg.Id = uuid.New().String()
-	}
-	if msg.Created == nil {
-		msg.Created = timestamppb.Now()
-	}
+	kc.stampMessage(msg)
+	StampRequestID(ctx, msg)
 
-	// Servis Bilgisi: Hangi servisin bu mesajı bastığını kaydediyoruz.
-	msg.FromService = kc.serviceType
-	isCritical := kc.isCriticalMessageType(msg.Type)
-	msg.Critical = isCritical
+	// Tracing: Çağıranın span'i altında producer span'i açılır ve context mesaja yazılır;
+	// consumer tarafında handler aynı trace'e bağlanır.
+	ctx, span := kc.startPublishSpan(ctx, kc.config.Topic, msg)
+	defer func() { endSpan(span, err) }()
+
+	// Güvenlik: Politika gerektiriyorsa payload şifrelenir ve mesaj imzalanır (bkz. MessageSecurity).
+	// Çağıranın mesajı düz kalır; Kafka'ya şifreli kopya yazılır.
+	wire, err := kc.sealMessage(msg)
+	if err != nil {
+		return err
+	}
 
 	// Serileştirme: Protobuf formatına çeviriyoruz.
 	// Neden? JSON'a göre çok daha az yer kaplar ve çok daha hızlıdır.
-	messageBytes, err := proto.Marshal(msg)
+	messageBytes, err := proto.Marshal(wire)
 	if err != nil {
 		return fmt.Errorf("failed to marshal protobuf: %w", err)
 	}
 
-	// Kafka Mesajı Oluşturma: Key olarak ID kullanıyoruz.
-	// Neden? Aynı ID'li mesajların (eğer birden fazla partition varsa) aynı partition'a gitmesini sağlar.
+	// Kafka Mesajı Oluşturma: Key olarak iş anahtarını (order_id, user_id...) kullanıyoruz.
+	// Neden? Aynı siparişe ait olayların aynı partition'a gidip sırasını korumasını sağlar.
+	key := kc.partitionKey(wire)
 	kafkaMsg := kafka.Message{
-		Key:   []byte(msg.Id),
+		Key:   []byte(key),
 		Value: messageBytes,
 		Headers: []kafka.Header{
 			{Key: "EventType", Value: []byte(msg.Type.String())},
 			{Key: "FromService", Value: []byte(msg.FromService.String())},
+			{Key: "PartitionKey", Value: []byte(key)},
 		},
 	}
 
@@ -68,6 +72,23 @@ func (kc *KafkaClient) PublishMessage(ctx context.Context, msg *pb.Message) erro
 		return err
 	}
 
-	log.Printf("✓ [Producer] Published [id=%s, type=%s, critical=%v]", msg.Id, msg.Type.String(), isCritical)
+	log.Printf("✓ [Producer] Published [id=%s, type=%s, critical=%v]", msg.Id, msg.Type.String(), msg.Critical)
 	return nil
-}
\ No newline at end of file
+}
+
+// stampMessage, mesajı yayınlamadan önce ID, zaman, kaynak servis ve kritiklik bilgisiyle damgalar.
+// Kafka ve in-memory broker aynı damgalamayı kullanır.
+func (kc *KafkaClient) stampMessage(msg *pb.Message) {
+	// Mesaj Hazırlığı: Mesajın kimliğini ve zamanını damgalıyoruz.
+	if msg.Id == "" {
+		msg.Id = uuid.New().String()
+	}
+	if msg.Created == nil {
+		msg.Created = timestamppb.Now()
+	}
+
+	// Servis Bilgisi: Hangi servisin bu mesajı bastığını kaydediyoruz.
+	msg.FromService = kc.serviceType
+	msg.Critical = kc.isCriticalMessageType(msg.Type)
+	kc.schemas().Stamp(msg)
+}
@@ -0,0 +1,307 @@
+package messaging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	pb "marketplace/pkg/proto/events"
+
+	"github.com/google/uuid"
+	"github.com/segmentio/kafka-go"
+	"google.golang.org/protobuf/proto"
+)
+
+// RequestReply header'ları. İstek ve cevap aynı correlation ID'yi taşır; reply-to,
+// cevabın yazılacağı (isteği yapan instance'a ait) topic'tir.
+const (
+	HeaderCorrelationID = "correlation-id"
+	HeaderReplyTo       = "reply-to"
+)
+
+var (
+	// ErrReplyTimeout, süresi içinde cevap gelmediğinde döner. İstek işlenmiş olabilir;
+	// geç gelen cevap sessizce atılır.
+	ErrReplyTimeout = errors.New("timed out waiting for reply")
+	// ErrNotARequest, Reply'a RequestReply ile gönderilmemiş bir mesaj verildiğinde döner.
+	ErrNotARequest = errors.New("message has no reply-to or correlation-id header")
+)
+
+// replyRetention, cevap topic'lerindeki mesajların saklanma süresidir. Cevaplar sadece
+// bekleyen çağıran için anlamlıdır; topic'ler instance başına açıldığı için kısa tutulur.
+const replyRetention = time.Hour
+
+// RequestReply, mesajı ana topic'e correlation ID ve reply-to header'ı ile yayınlar ve
+// cevap gelene, timeout dolana veya ctx iptal edilene kadar bekler.
+// Neden? Birçok sepet için stok kontrolü gibi toplu sorgular, her biri için ayrı gRPC
+// çağrısı açmadan Kafka üzerinden asenkron yapılabilir. Cevaplayan servis, isteği normal
+// handler'ında alır ve Reply ile cevaplar.
+// Cevap bu instance'a özel bir topic'e yazılır; aynı servisin diğer instance'ları başkasının
+// cevabını görmez. İsteğin hedefi her zamanki gibi msg.ToServices ile belirlenir.
+func (kc *KafkaClient) RequestReply(ctx context.Context, msg *pb.Message, timeout time.Duration) (*pb.Message, error) {
+	router, err := kc.startReplies()
+	if err != nil {
+		return nil, fmt.Errorf("start reply listener: %w", err)
+	}
+
+	correlationID := uuid.New().String()
+	if msg.Headers == nil {
+		msg.Headers = make(map[string]string)
+	}
+	msg.Headers[HeaderCorrelationID] = correlationID
+	msg.Headers[HeaderReplyTo] = router.topic
+
+	// Kayıt yayından önce yapılır; cevap, PublishMessage dönmeden gelebilir.
+	replyCh := router.register(correlationID)
+	defer router.unregister(correlationID)
+
+	if err := kc.PublishMessage(ctx, msg); err != nil {
+		return nil, err
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case reply := <-replyCh:
+		return reply, nil
+	case <-timer.C:
+		return nil, fmt.Errorf("%w [id=%s, type=%s, timeout=%v]", ErrReplyTimeout, msg.Id, msg.Type, timeout)
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Reply, RequestReply ile gelen isteğe cevap yazar. Cevap, isteğin reply-to topic'ine
+// isteğin correlation ID'si ile gider ve sadece isteği yapan servise adreslenir.
+// İstek reply-to taşımıyorsa kalıcı hata döner; handler bunu doğrudan döndürebilir.
+func (kc *KafkaClient) Reply(ctx context.Context, request, reply *pb.Message) (err error) {
+	replyTo := request.Headers[HeaderReplyTo]
+	correlationID := request.Headers[HeaderCorrelationID]
+	if replyTo == "" || correlationID == "" {
+		return Permanent(fmt.Errorf("%w [id=%s]", ErrNotARequest, request.Id))
+	}
+
+	kc.stampMessage(reply)
+	reply.Headers[HeaderCorrelationID] = correlationID
+	reply.ToServices = []pb.ServiceType{request.FromService}
+	StampRequestID(ctx, reply)
+
+	ctx, span := kc.startPublishSpan(ctx, replyTo, reply)
+	defer func() { endSpan(span, err) }()
+
+	wire, err := kc.sealMessage(reply)
+	if err != nil {
+		return err
+	}
+	value, err := proto.Marshal(wire)
+	if err != nil {
+		return fmt.Errorf("failed to marshal protobuf: %w", err)
+	}
+
+	writeCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	if err := kc.getReplyWriter().WriteMessages(writeCtx, kafka.Message{
+		Topic: replyTo,
+		Key:   []byte(correlationID),
+		Value: value,
+	}); err != nil {
+		return fmt.Errorf("write reply to %s: %w", replyTo, err)
+	}
+
+	log.Printf("↩ [Reply] Sent [id=%s, correlation=%s, topic=%s]", reply.Id, correlationID, replyTo)
+	return nil
+}
+
+// replyTopic, bu instance'ın cevap topic'ini döner.
+func (kc *KafkaClient) replyTopic() string {
+	if kc.config.ReplyTopic != "" {
+		return kc.config.ReplyTopic
+	}
+	instance := kc.config.ClientID
+	if instance == "" {
+		instance, _ = os.Hostname()
+	}
+	if instance == "" {
+		instance = uuid.New().String()
+	}
+	return fmt.Sprintf("%s-%s-%s", DefaultReplyTopicPrefix, strings.ToLower(kc.serviceType.String()), instance)
+}
+
+// getReplyWriter, cevaplar için topic'i mesaj başına belirlenen bir writer döner.
+// Ana producer'ın Topic'i sabit olduğu için cevaplarda kullanılamaz.
+func (kc *KafkaClient) getReplyWriter() *kafka.Writer {
+	kc.replyMu.Lock()
+	defer kc.replyMu.Unlock()
+
+	if kc.replyWriter == nil {
+		kc.replyWriter = &kafka.Writer{
+			Addr:         kafka.TCP(kc.config.Brokers...),
+			Balancer:     &kafka.Hash{},
+			RequiredAcks: kafka.RequireOne,
+		}
+	}
+	return kc.replyWriter
+}
+
+// replyRouter, cevap topic'ini okur ve her cevabı correlation ID'sine göre bekleyen çağırana iletir.
+type replyRouter struct {
+	topic  string
+	cancel context.CancelFunc
+
+	mu      sync.Mutex
+	pending map[string]chan *pb.Message
+}
+
+// startReplies, ilk RequestReply çağrısında cevap topic'ini oluşturur ve dinlemeye başlar.
+// Başlatma başarısız olursa sonraki çağrı tekrar dener.
+func (kc *KafkaClient) startReplies() (*replyRouter, error) {
+	kc.mu.RLock()
+	closed := kc.closed
+	kc.mu.RUnlock()
+	if closed {
+		return nil, fmt.Errorf("kafka client is already closed")
+	}
+
+	kc.replyMu.Lock()
+	defer kc.replyMu.Unlock()
+
+	if kc.replies != nil {
+		return kc.replies, nil
+	}
+
+	topic := kc.replyTopic()
+	spec := TopicSpec{Name: topic, Partitions: 1, ReplicationFactor: 1, Retention: replyRetention}
+	if err := kc.ensureTopics([]TopicSpec{spec}); err != nil {
+		var mismatch *TopicMismatchError
+		if !errors.As(err, &mismatch) {
+			return nil, err
+		}
+		log.Printf("⚠ [Reply] %v", err)
+	}
+
+	// Sadece bu instance'ın cevapları okunur; consumer group'a gerek yoktur. Okuma, dinleyici
+	// başladığı andaki sondan başlar: önceki çalıştırmadan kalan cevapların bekleyeni yoktur.
+	conn, err := kafka.DialLeader(context.Background(), "tcp", kc.config.Brokers[0], topic, 0)
+	if err != nil {
+		return nil, fmt.Errorf("dial %s leader: %w", topic, err)
+	}
+	last, err := conn.ReadLastOffset()
+	conn.Close()
+	if err != nil {
+		return nil, fmt.Errorf("read %s offset: %w", topic, err)
+	}
+
+	reader := kafka.NewReader(kafka.ReaderConfig{
+		Brokers:   kc.config.Brokers,
+		Topic:     topic,
+		Partition: 0,
+		MaxWait:   100 * time.Millisecond,
+	})
+	if err := reader.SetOffset(last); err != nil {
+		reader.Close()
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	router := &replyRouter{topic: topic, cancel: cancel, pending: make(map[string]chan *pb.Message)}
+
+	kc.wg.Add(1)
+	go func() {
+		defer kc.wg.Done()
+		defer reader.Close()
+		router.listen(ctx, reader, kc.openMessage)
+	}()
+
+	log.Printf("✓ [Reply] Listening for replies [topic=%s]", topic)
+	kc.replies = router
+	return router, nil
+}
+
+// listen, cevapları okur; imzalı/şifreli cevaplar handler'a giden mesajlar gibi açılır.
+func (r *replyRouter) listen(ctx context.Context, reader *kafka.Reader, open func(*pb.Message) (*pb.Message, error)) {
+	for {
+		m, err := reader.ReadMessage(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			log.Printf("✗ [Reply] Read error: %v", err)
+			continue
+		}
+
+		reply := &pb.Message{}
+		if err := proto.Unmarshal(m.Value, reply); err != nil {
+			log.Printf("✗ [Reply] Unmarshal failed: %v", err)
+			continue
+		}
+		opened, err := open(reply)
+		if err != nil {
+			log.Printf("✗ [Reply] Dropping reply [id=%s]: %v", reply.Id, err)
+			continue
+		}
+		r.deliver(opened)
+	}
+}
+
+func (r *replyRouter) register(correlationID string) <-chan *pb.Message {
+	ch := make(chan *pb.Message, 1)
+	r.mu.Lock()
+	r.pending[correlationID] = ch
+	r.mu.Unlock()
+	return ch
+}
+
+func (r *replyRouter) unregister(correlationID string) {
+	r.mu.Lock()
+	delete(r.pending, correlationID)
+	r.mu.Unlock()
+}
+
+// deliver, cevabı bekleyen çağırana iletir. Bekleyen yoksa (timeout dolmuş veya aynı cevap
+// ikinci kez gelmiş) cevap atılır.
+func (r *replyRouter) deliver(reply *pb.Message) {
+	correlationID := reply.Headers[HeaderCorrelationID]
+
+	r.mu.Lock()
+	ch, ok := r.pending[correlationID]
+	if ok {
+		delete(r.pending, correlationID)
+	}
+	r.mu.Unlock()
+
+	if !ok {
+		log.Printf("⚠ [Reply] No caller waiting, dropping reply [id=%s, correlation=%s]", reply.Id, correlationID)
+		return
+	}
+	ch <- reply
+}
+
+// stopReplies, cevap dinleyicisini durdurur. Close, wg.Wait'ten önce çağırır; dinleyici
+// goroutine'i de wg'dedir. Bekleyen RequestReply çağrıları timeout veya ctx ile döner.
+func (kc *KafkaClient) stopReplies() {
+	kc.replyMu.Lock()
+	defer kc.replyMu.Unlock()
+
+	if kc.replies != nil {
+		kc.replies.cancel()
+	}
+}
+
+// closeReplyWriter, handler'lar bittikten sonra çağrılır; işlenmekte olan istekler hâlâ Reply yazabilir.
+func (kc *KafkaClient) closeReplyWriter() {
+	kc.replyMu.Lock()
+	defer kc.replyMu.Unlock()
+
+	if kc.replyWriter != nil {
+		if err := kc.replyWriter.Close(); err != nil {
+			log.Printf("✗ Reply writer close failed: %v", err)
+		}
+	}
+}
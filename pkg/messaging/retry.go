@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"log/slog"
 	"time"
 
 	pb "marketplace/pkg/proto/events"
@@ -20,41 +21,63 @@ func (kc *KafkaClient) handleFailure(ctx context.Context, message *pb.Message, e
 	if err != nil {
 		message.LastError = err.Error()
 	}
+	logCtx := messageContext(ctx, message)
+
+	// Yönlendirme hataları (handler yok / payload uyuşmuyor) tekrar denemekle düzelmez.
+	if isUnroutable(err) {
+		slog.WarnContext(logCtx, "⚠ [Failure] Unroutable message, sending to DLQ", "id", message.Id, "error", err)
+		kc.sendToDLQ(ctx, message, err)
+		return
+	}
+
+	// Handler hatanın kalıcı olduğunu söylediyse (bkz. Permanent) retry hakkı harcanmaz.
+	if IsPermanent(err) {
+		slog.WarnContext(logCtx, "⚠ [Failure] Permanent error, sending to DLQ", "id", message.Id, "error", err)
+		kc.sendToDLQ(ctx, message, err)
+		return
+	}
 
 	// Yeniden deneme (Retry) limiti dolmadıysa tekrar gönder
 	if kc.shouldRetry(message) {
 		message.RetryCount++
-		log.Printf("⟳ [Failure] Retrying [id=%s, count=%d]", message.Id, message.RetryCount)
-		kc.sendToRetry(ctx, message)
+		delay := kc.retryDelay(message, err)
+		slog.InfoContext(logCtx, "⟳ [Failure] Retrying", "id", message.Id, "count", message.RetryCount, "delay", delay)
+		kc.sendToRetry(ctx, message, delay)
 	} else {
 		// Limit dolduysa mesajı Dead Letter Queue (DLQ) topic'ine at
-		log.Printf("⚠ [Failure] Max retries reached, sending to DLQ [id=%s]", message.Id)
+		slog.WarnContext(logCtx, "⚠ [Failure] Max retries reached, sending to DLQ", "id", message.Id)
 		kc.sendToDLQ(ctx, message, err)
 	}
 }
 
 // sendToRetry, mesajı gecikmeli olarak tekrar işlenmek üzere Retry Topic'ine gönderir.
-// Neden? 'Exponential Backoff' algoritması kullanarak sistemin (veya veritabanının)
-// toparlanması için zaman tanır. İlk hata 5sn, ikinci 10sn, üçüncü 20sn bekletir.
-func (kc *KafkaClient) sendToRetry(ctx context.Context, msg *pb.Message) {
+// Neden? Sistemin (veya veritabanının) toparlanması için zaman tanır; gecikme retryDelay'den gelir.
+// Mesaj, gecikmesine uygun kademe topic'ine yazılır; bekleme hafızada değil Kafka'da yapılır.
+func (kc *KafkaClient) sendToRetry(ctx context.Context, msg *pb.Message, delay time.Duration) {
 	if kc.retryProducer == nil {
 		log.Printf("✗ [Retry] CRITICAL: Retry producer nil! Sending [id=%s] directly to DLQ", msg.Id)
 		kc.sendToDLQ(ctx, msg, fmt.Errorf("retry producer not configured"))
 		return
 	}
 
-	// Gecikme süresini hesapla ve mesajın üzerine 'RetryAfter' olarak damgala
-	delaySeconds := kc.calculateRetryDelay(int(msg.RetryCount))
-	retryTime := time.Now().Add(time.Duration(delaySeconds) * time.Second)
+	// Retry mesajı yalnızca hatayı alan servis içindir. Retry topic'lerini dinleyen
+	// diğer servislerin aynı mesajı tekrar işlemesini engeller.
+	msg.ToServices = []pb.ServiceType{kc.serviceType}
+
+	// Gecikme süresini mesajın üzerine 'RetryAfter' olarak damgala
+	retryTime := time.Now().Add(delay)
 	msg.RetryAfter = timestamppb.New(retryTime)
+	retryTopic := kc.retryTierTopic(delay)
 
 	messageBytes, _ := proto.Marshal(msg)
 
-	retryCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	// Uygulama kapanırken bile retry yazımı yarıda kesilmemeli; aksi halde mesaj kaybolur.
+	retryCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
 	defer cancel()
 
 	err := kc.retryProducer.WriteMessages(retryCtx, kafka.Message{
-		Key:   []byte(msg.Id),
+		Topic: retryTopic,
+		Key:   []byte(kc.partitionKey(msg)),
 		Value: messageBytes,
 		Headers: []kafka.Header{
 			{Key: "RetryCount", Value: []byte(fmt.Sprintf("%d", msg.RetryCount))},
@@ -67,11 +90,92 @@ func (kc *KafkaClient) sendToRetry(ctx context.Context, msg *pb.Message) {
 		// Kafka yazamazsa yine DLQ'ya yedekle
 		kc.sendToDLQ(ctx, msg, err)
 	} else {
-		log.Printf("⟳ [Retry] Success: Scheduled for %v (Delay: %ds)",
-			retryTime.Format("15:04:05"), delaySeconds)
+		kc.observeRetry(msg)
+		log.Printf("⟳ [Retry] Success: Scheduled for %v (Delay: %v, topic=%s)",
+			retryTime.Format("15:04:05"), delay, retryTopic)
 	}
 }
 
+// requeueDelayed, kapanış sırasında RetryAfter zamanı henüz gelmemiş mesajı, kalan
+// gecikmesine uygun retry kademesine geri yazar. Başarılıysa çağıran offset'i commit edebilir.
+// Neden? Mesaj Kafka'da commit edilmeden bırakılırsa yeniden başlatmada bulunduğu kademenin
+// başında tekrar bekler; örneğin 300s kademesinden 10 saniyesi kalmış bir mesaj, 10s
+// kademesine taşınarak zamanında işlenir. RetryCount ve RetryAfter aynen korunur.
+func (kc *KafkaClient) requeueDelayed(ctx context.Context, msg *pb.Message) bool {
+	if kc.retryProducer == nil || msg.RetryAfter == nil {
+		return false
+	}
+
+	retryTime := msg.RetryAfter.AsTime()
+	retryTopic := kc.retryTierTopic(time.Until(retryTime))
+
+	messageBytes, err := proto.Marshal(msg)
+	if err != nil {
+		log.Printf("✗ [Retry] Requeue marshal failed [id=%s]: %v", msg.Id, err)
+		return false
+	}
+
+	requeueCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+	defer cancel()
+
+	err = kc.retryProducer.WriteMessages(requeueCtx, kafka.Message{
+		Topic: retryTopic,
+		Key:   []byte(kc.partitionKey(msg)),
+		Value: messageBytes,
+		Headers: []kafka.Header{
+			{Key: "RetryCount", Value: []byte(fmt.Sprintf("%d", msg.RetryCount))},
+			{Key: "RetryAfter", Value: []byte(retryTime.Format(time.RFC3339))},
+		},
+	})
+	if err != nil {
+		log.Printf("✗ [Retry] Requeue failed, message left in Kafka [id=%s]: %v", msg.Id, err)
+		return false
+	}
+
+	log.Printf("↩ [Retry] Delayed message requeued [id=%s, topic=%s]", msg.Id, retryTopic)
+	return true
+}
+
+// retryTierTopic, verilen gecikmeyi karşılayan en küçük kademe topic'ini döner.
+// Gecikme tüm kademelerden büyükse en büyük kademe kullanılır; tüketici kademe kadar
+// bekledikten sonra mesajı aynı kademeye tekrar yazar (bkz. holdUntilDue).
+func (kc *KafkaClient) retryTierTopic(delay time.Duration) string {
+	tiers := kc.config.RetryDelayTiers
+	if len(tiers) == 0 {
+		return kc.config.RetryTopic
+	}
+	for _, tier := range tiers {
+		if delay <= tier {
+			return tierTopicName(kc.config.RetryTopic, tier)
+		}
+	}
+	return tierTopicName(kc.config.RetryTopic, tiers[len(tiers)-1])
+}
+
+// retryTopics, retry için dinlenmesi gereken tüm topic'leri döner.
+// Ana RetryTopic de listededir; kademe öncesinden kalmış mesajlar da böylece tüketilir.
+func (kc *KafkaClient) retryTopics() []string {
+	topics := []string{kc.config.RetryTopic}
+	for _, tier := range kc.config.RetryDelayTiers {
+		topics = append(topics, tierTopicName(kc.config.RetryTopic, tier))
+	}
+	return topics
+}
+
+func tierTopicName(retryTopic string, tier time.Duration) string {
+	return fmt.Sprintf("%s-%ds", retryTopic, int(tier.Seconds()))
+}
+
+// retryDelay, mesajın bir sonraki denemeden önce ne kadar bekleyeceğini belirler.
+// Handler RetryAfter ile bir süre istediyse o kullanılır (Örn: Stripe'ın Retry-After header'ı);
+// aksi halde 'Exponential Backoff' uygulanır: ilk hata 5sn, ikinci 10sn, üçüncü 20sn bekletir.
+func (kc *KafkaClient) retryDelay(msg *pb.Message, err error) time.Duration {
+	if delay, ok := retryDelayHint(err); ok {
+		return delay
+	}
+	return time.Duration(kc.calculateRetryDelay(int(msg.RetryCount))) * time.Second
+}
+
 // calculateRetryDelay, 'Exponential Backoff' stratejisi ile bekleme süresi üretir.
 // Neden? Hata anında servisi mesaj yağmuruna tutmak yerine (thundering herd),
 // aradaki süreyi katlayarak açar.
@@ -96,35 +200,34 @@ func (kc *KafkaClient) shouldRetry(msg *pb.Message) bool {
 
 // sendToDLQ, hata alan mesajları DLQ topic'ine gönderir.
 func (kc *KafkaClient) sendToDLQ(ctx context.Context, msg *pb.Message, errReason error) {
-	if kc.config.DLQTopic == "" {
+	if kc.dlqProducer == nil {
 		log.Printf("✗ [DLQ] Not configured for [id=%s]", msg.Id)
 		return
 	}
 
-	// DLQ için geçici bir writer oluşturuyoruz (Genelde DLQ trafiği azdır)
-	dlqProducer := &kafka.Writer{
-		Addr:         kafka.TCP(kc.config.Brokers...),
-		Topic:        kc.config.DLQTopic,
-		Balancer:     &kafka.LeastBytes{},
-		RequiredAcks: kafka.RequireOne,
-	}
-	defer dlqProducer.Close()
+	// DLQ mesajı yalnızca hatayı alan servis içindir (bkz. sendToRetry). Aksi halde mesaj
+	// tipine izin veren her servis DLQ recovery'de mesajı tekrar işler (Örn: çift e-posta).
+	msg.ToServices = []pb.ServiceType{kc.serviceType}
 
 	messageBytes, _ := proto.Marshal(msg)
 
 	kafkaMsg := kafka.Message{
-		Key:   []byte(msg.Id),
+		Key:   []byte(kc.partitionKey(msg)),
 		Value: messageBytes,
 		Headers: []kafka.Header{
-			{Key: "ErrorReason", Value: []byte(errReason.Error())},
-			{Key: "OriginalTopic", Value: []byte(kc.config.Topic)},
-			{Key: "FailedAt", Value: []byte(time.Now().Format(time.RFC3339))},
+			{Key: HeaderErrorReason, Value: []byte(errReason.Error())},
+			{Key: HeaderOriginalTopic, Value: []byte(kc.config.Topic)},
+			{Key: HeaderFailedAt, Value: []byte(time.Now().Format(time.RFC3339))},
 		},
 	}
 
-	if err := dlqProducer.WriteMessages(ctx, kafkaMsg); err != nil {
+	dlqCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+	defer cancel()
+
+	if err := kc.dlqProducer.WriteMessages(dlqCtx, kafkaMsg); err != nil {
 		log.Printf("✗ [DLQ] Send failed: %v", err)
 	} else {
+		kc.observeDLQ(msg)
 		log.Printf("⚠ [DLQ] Message moved to DLQ: %s", msg.Id)
 	}
 }
@@ -142,6 +245,7 @@ func (kc *KafkaClient) ConsumeDLQWithRecovery(ctx context.Context, handler Messa
 		StartOffset: kafka.FirstOffset, // En baştan başla ki hiçbir şey kaçmasın
 	})
 	defer reader.Close()
+	defer kc.watchLag(reader)()
 
 	for {
 		m, err := reader.FetchMessage(ctx)
@@ -155,32 +259,60 @@ func (kc *KafkaClient) ConsumeDLQWithRecovery(ctx context.Context, handler Messa
 			continue
 		}
 
-		// KRİTİK MESAJ KONTROLÜ: Eğer kritikse hem diske yaz hem kurtar
-		if kc.isCriticalMessageType(message.Type) {
-			log.Printf("🆘 [Recovery] Critical message found: %s", message.Id)
-			kc.saveCriticalMessageToStorage(&message)
+		// DLQ tüm servislerle ortaktır; sadece bu servise ait mesajlarla ilgileniyoruz.
+		if !kc.shouldProcessMessage(&message) {
+			reader.CommitMessages(ctx, m)
+			continue
 		}
 
-		// Handler ile tekrar dene
-		if err := handler(ctx, &message); err == nil {
-			log.Printf("✨ [Recovery] Success for id: %s", message.Id)
-			reader.CommitMessages(ctx, m)
+		// Kurtarılamayan mesaj commit edilmeden geçilirse, aynı partition'da sonraki mesajın commit'i
+		// onu da "okundu" sayar ve mesaj kaybolur. Reader consumer group'ta olduğu için seek yapılamaz;
+		// bu yüzden mesaj sonuçlanana kadar yerinde, artan aralıklarla tekrar denenir.
+		for attempt := 1; !kc.recoverDLQMessage(ctx, &message, handler); attempt++ {
+			delay := time.Duration(kc.calculateRetryDelay(attempt)) * time.Second
+			log.Printf("⏳ [Recovery] Holding DLQ partition %d at offset %d [id=%s, wait=%v]", m.Partition, m.Offset, message.Id, delay)
+
+			timer := time.NewTimer(delay)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return ctx.Err()
+			case <-timer.C:
+			}
 		}
+		reader.CommitMessages(ctx, m)
 	}
 }
 
-// handleFailureAfterDelay, gecikmeli (retry) olarak işlenen bir mesaj tekrar hata aldığında ne olacağını belirler.
-func (kc *KafkaClient) handleFailureAfterDelay(ctx context.Context, message *pb.Message, err error) {
-	if err != nil {
-		message.LastError = err.Error()
+// recoverDLQMessage, DLQ'daki mesajı bir kez kurtarmayı dener. Mesaj sonuçlandıysa (kurtarıldı
+// veya hiçbir zaman kurtarılamaz) true döner; commit edilebilir.
+func (kc *KafkaClient) recoverDLQMessage(ctx context.Context, message *pb.Message, handler MessageHandler) bool {
+	// KRİTİK MESAJ KONTROLÜ: Eğer kritikse hem kasaya yaz hem kurtar.
+	// Kasaya yazılamazsa mesaj işlenmez; kasaya yazılana kadar tekrar denenir.
+	critical := kc.isCriticalMessageType(message.Type)
+	if critical {
+		log.Printf("🆘 [Recovery] Critical message found: %s", message.Id)
+		if err := kc.saveCriticalMessageToStorage(ctx, message); err != nil {
+			log.Printf("✗ [Storage] %v", err)
+			return false
+		}
 	}
 
-	if kc.shouldRetry(message) {
-		message.RetryCount++
-		log.Printf("⟳ [Retry] Re-scheduling after delayed failure [id=%s, count=%d]", message.Id, message.RetryCount)
-		kc.sendToRetry(ctx, message)
-	} else {
-		log.Printf("⚠ [Retry] Max retries reached for delayed message [id=%s]", message.Id)
-		kc.sendToDLQ(ctx, message, err)
+	// Handler ile tekrar dene
+	err := kc.runHandler(ctx, message, handler)
+	switch {
+	case err == nil:
+		log.Printf("✨ [Recovery] Success for id: %s", message.Id)
+		if critical {
+			kc.resolveCriticalMessage(ctx, message)
+		}
+		return true
+	case isUnroutable(err) || IsPermanent(err):
+		// Handler'ı olmayan, payload'ı bozuk veya handler'ın kalıcı dediği mesaj hiçbir zaman kurtarılamaz.
+		log.Printf("✗ [Recovery] Giving up on unroutable message [id=%s]: %v", message.Id, err)
+		return true
+	default:
+		log.Printf("✗ [Recovery] Failed [id=%s]: %v", message.Id, err)
+		return false
 	}
 }
@@ -0,0 +1,264 @@
+package messaging
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	pb "marketplace/pkg/proto/events"
+
+	"github.com/segmentio/kafka-go"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// recordingWriter, KafkaClient'ın yazdığı mesajları Kafka yerine hafızada toplar.
+type recordingWriter struct {
+	mu   sync.Mutex
+	msgs []kafka.Message
+	err  error
+}
+
+func (w *recordingWriter) WriteMessages(_ context.Context, msgs ...kafka.Message) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.err != nil {
+		return w.err
+	}
+	w.msgs = append(w.msgs, msgs...)
+	return nil
+}
+
+func (w *recordingWriter) Close() error { return nil }
+
+func (w *recordingWriter) written(t *testing.T) []*pb.Message {
+	t.Helper()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	out := make([]*pb.Message, len(w.msgs))
+	for i, m := range w.msgs {
+		out[i] = &pb.Message{}
+		if err := proto.Unmarshal(m.Value, out[i]); err != nil {
+			t.Fatalf("written message %d: %v", i, err)
+		}
+	}
+	return out
+}
+
+func (w *recordingWriter) topics() []string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	topics := make([]string, len(w.msgs))
+	for i, m := range w.msgs {
+		topics[i] = m.Topic
+	}
+	return topics
+}
+
+func TestCalculateRetryDelay(t *testing.T) {
+	kc := &KafkaClient{}
+	tests := []struct {
+		retryCount int
+		want       int
+	}{
+		{0, 5},
+		{1, 5},
+		{2, 10},
+		{3, 20},
+		{4, 40},
+		{6, 160},
+		{7, 300}, // 320 -> üst sınır
+		{20, 300},
+	}
+
+	for _, tt := range tests {
+		if got := kc.calculateRetryDelay(tt.retryCount); got != tt.want {
+			t.Errorf("calculateRetryDelay(%d) = %d, want %d", tt.retryCount, got, tt.want)
+		}
+	}
+}
+
+// Varsayılan kademeler, backoff'un ürettiği her gecikme için birebir bir topic'e sahip olmalı;
+// aksi halde mesaj kademesinden daha uzun bekletilir.
+func TestDefaultRetryTiersMatchBackoff(t *testing.T) {
+	kc := &KafkaClient{config: KafkaConfig{RetryTopic: "retry", RetryDelayTiers: DefaultRetryDelayTiers}}
+	for retryCount := 1; retryCount <= 10; retryCount++ {
+		delay := time.Duration(kc.calculateRetryDelay(retryCount)) * time.Second
+		if want := tierTopicName("retry", delay); kc.retryTierTopic(delay) != want {
+			t.Errorf("retry %d (%v) -> %s, want %s", retryCount, delay, kc.retryTierTopic(delay), want)
+		}
+	}
+}
+
+func TestRetryTierTopic(t *testing.T) {
+	tiers := []time.Duration{5 * time.Second, 30 * time.Second, 300 * time.Second}
+	tests := []struct {
+		name  string
+		tiers []time.Duration
+		delay time.Duration
+		want  string
+	}{
+		{"no tiers uses the retry topic", nil, time.Minute, "retry"},
+		{"already due goes to the smallest tier", tiers, -time.Second, "retry-5s"},
+		{"exact tier", tiers, 5 * time.Second, "retry-5s"},
+		{"rounds up to the next tier", tiers, 6 * time.Second, "retry-30s"},
+		{"largest tier", tiers, 300 * time.Second, "retry-300s"},
+		{"longer than every tier is capped", tiers, time.Hour, "retry-300s"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kc := &KafkaClient{config: KafkaConfig{RetryTopic: "retry", RetryDelayTiers: tt.tiers}}
+			if got := kc.retryTierTopic(tt.delay); got != tt.want {
+				t.Fatalf("retryTierTopic(%v) = %s, want %s", tt.delay, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHoldUntilDue(t *testing.T) {
+	tiers := []time.Duration{10 * time.Millisecond, 30 * time.Millisecond}
+	errKafkaDown := errors.New("kafka down")
+
+	tests := []struct {
+		name         string
+		retryAfter   time.Duration // 0 ise RetryAfter yok
+		writeErr     error
+		timeout      time.Duration
+		wantDue      bool
+		wantRequeued bool
+		wantWrites   bool
+	}{
+		{name: "no retry after", wantDue: true},
+		{name: "already due", retryAfter: -time.Second, wantDue: true},
+		{name: "due within the largest tier", retryAfter: 20 * time.Millisecond, wantDue: true},
+		{name: "longer than the largest tier is requeued", retryAfter: time.Hour, wantRequeued: true, wantWrites: true},
+		{name: "shutdown while waiting requeues", retryAfter: time.Hour, timeout: 5 * time.Millisecond, wantRequeued: true, wantWrites: true},
+		{name: "failed requeue keeps the offset", retryAfter: time.Hour, writeErr: errKafkaDown, timeout: 100 * time.Millisecond},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := &recordingWriter{err: tt.writeErr}
+			kc := &KafkaClient{
+				config:        KafkaConfig{RetryTopic: "retry", RetryDelayTiers: tiers},
+				retryProducer: w,
+			}
+			msg := &pb.Message{Id: "m1", RetryCount: 2}
+			if tt.retryAfter != 0 {
+				msg.RetryAfter = timestamppb.New(time.Now().Add(tt.retryAfter))
+			}
+
+			ctx := context.Background()
+			if tt.timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, tt.timeout)
+				defer cancel()
+			}
+
+			due, requeued := kc.holdUntilDue(ctx, msg)
+			if due != tt.wantDue || requeued != tt.wantRequeued {
+				t.Fatalf("holdUntilDue = (due %v, requeued %v), want (%v, %v)", due, requeued, tt.wantDue, tt.wantRequeued)
+			}
+
+			written := w.written(t)
+			if (len(written) > 0) != tt.wantWrites {
+				t.Fatalf("requeue wrote %d messages, wantWrites %v", len(written), tt.wantWrites)
+			}
+			if tt.wantWrites {
+				if got := w.topics()[0]; got != tierTopicName("retry", tiers[len(tiers)-1]) {
+					t.Fatalf("requeued to %s, want the largest tier", got)
+				}
+				if written[0].RetryCount != msg.RetryCount || !written[0].RetryAfter.AsTime().Equal(msg.RetryAfter.AsTime()) {
+					t.Fatalf("requeue changed retry state: %+v", written[0])
+				}
+			}
+		})
+	}
+}
+
+func TestHandleFailure(t *testing.T) {
+	tiers := []time.Duration{5 * time.Second, 30 * time.Second, 300 * time.Second}
+	errDB := errors.New("connection reset")
+
+	tests := []struct {
+		name       string
+		err        error
+		retryCount int32
+		wantTopic  string        // boşsa mesaj DLQ'ya gitmeli
+		wantDelay  time.Duration // RetryAfter damgasının şimdiden uzaklığı
+	}{
+		{name: "permanent goes to the DLQ without spending a retry", err: Permanent(errDB), retryCount: 1},
+		{name: "transient uses the backoff tier", err: Transient(errDB), retryCount: 1, wantTopic: "retry-30s", wantDelay: 10 * time.Second},
+		{name: "unwrapped error is transient", err: errDB, wantTopic: "retry-5s", wantDelay: 5 * time.Second},
+		{name: "retry after picks the tier of its delay", err: RetryAfter(7*time.Second, errDB), wantTopic: "retry-30s", wantDelay: 7 * time.Second},
+		{name: "retry after longer than every tier is capped at the largest", err: RetryAfter(time.Hour, errDB), wantTopic: "retry-300s", wantDelay: time.Hour},
+		{name: "transient after max retries goes to the DLQ", err: Transient(errDB), retryCount: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			retries, dlq := &recordingWriter{}, &recordingWriter{}
+			kc := &KafkaClient{
+				config: KafkaConfig{
+					Topic:           "events",
+					EnableRetry:     true,
+					MaxRetries:      3,
+					RetryTopic:      "retry",
+					RetryDelayTiers: tiers,
+					DLQTopic:        "dlq",
+				},
+				retryProducer: retries,
+				dlqProducer:   dlq,
+				serviceType:   pb.ServiceType_NOTIFICATION_SERVICE,
+			}
+			msg := &pb.Message{
+				Id:         "m1",
+				Type:       pb.MessageType_ORDER_CREATED,
+				RetryCount: tt.retryCount,
+				ToServices: []pb.ServiceType{pb.ServiceType_NOTIFICATION_SERVICE, pb.ServiceType_PRODUCT_SERVICE},
+			}
+
+			begin := time.Now()
+			kc.handleFailure(context.Background(), msg, tt.err)
+
+			if tt.wantTopic == "" {
+				if len(retries.msgs) != 0 || len(dlq.msgs) != 1 {
+					t.Fatalf("wrote %d retries and %d DLQ messages, want only the DLQ", len(retries.msgs), len(dlq.msgs))
+				}
+				got := dlq.written(t)[0]
+				if got.RetryCount != tt.retryCount {
+					t.Fatalf("RetryCount = %d, want %d", got.RetryCount, tt.retryCount)
+				}
+				assertOnlyService(t, got, pb.ServiceType_NOTIFICATION_SERVICE)
+				return
+			}
+
+			if len(dlq.msgs) != 0 || len(retries.msgs) != 1 {
+				t.Fatalf("wrote %d retries and %d DLQ messages, want one retry", len(retries.msgs), len(dlq.msgs))
+			}
+			if got := retries.topics()[0]; got != tt.wantTopic {
+				t.Fatalf("retried on %s, want %s", got, tt.wantTopic)
+			}
+			got := retries.written(t)[0]
+			if got.RetryCount != tt.retryCount+1 {
+				t.Fatalf("RetryCount = %d, want %d", got.RetryCount, tt.retryCount+1)
+			}
+			// Kademe üst sınırı yalnızca topic'i belirler; istenen bekleme süresi korunur
+			// (bkz. holdUntilDue).
+			if due := got.RetryAfter.AsTime().Sub(begin); due < tt.wantDelay || due > tt.wantDelay+time.Second {
+				t.Fatalf("RetryAfter is %v from now, want %v", due, tt.wantDelay)
+			}
+			assertOnlyService(t, got, pb.ServiceType_NOTIFICATION_SERVICE)
+		})
+	}
+}
+
+func assertOnlyService(t *testing.T, msg *pb.Message, service pb.ServiceType) {
+	t.Helper()
+	if len(msg.ToServices) != 1 || msg.ToServices[0] != service {
+		t.Fatalf("ToServices = %v, want only %v", msg.ToServices, service)
+	}
+}
@@ -0,0 +1,179 @@
+package messaging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	pb "marketplace/pkg/proto/events"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+var (
+	// ErrUnhandledMessage, servise izin verilen ama router'da karşılığı olmayan mesaj tipleri için döner.
+	ErrUnhandledMessage = errors.New("no handler registered for message type")
+	// ErrPayloadMismatch, mesajın oneof payload'ı MessageType ile uyuşmadığında döner.
+	ErrPayloadMismatch = errors.New("message payload does not match message type")
+)
+
+// Meta, typed handler'lara payload ile birlikte iletilen zarf (envelope) bilgileridir.
+// Neden? Handler'ların çoğu sadece payload ile ilgilenir; ID, kaynak servis gibi bilgiler
+// log ve hata mesajları için yeterlidir. Böylece handler'lar pb.Message'a bağımlı kalmaz.
+type Meta struct {
+	ID          string
+	Type        pb.MessageType
+	FromService pb.ServiceType
+	Created     time.Time
+	Critical    bool
+	RetryCount  int32
+	Headers     map[string]string
+}
+
+func newMeta(msg *pb.Message) Meta {
+	meta := Meta{
+		ID:          msg.Id,
+		Type:        msg.Type,
+		FromService: msg.FromService,
+		Critical:    msg.Critical,
+		RetryCount:  msg.RetryCount,
+		Headers:     msg.Headers,
+	}
+	if msg.Created != nil {
+		meta.Created = msg.Created.AsTime()
+	}
+	return meta
+}
+
+// payloadFields, her MessageType'ı pb.Message içindeki oneof payload alanına bağlar.
+// Yeni bir olay tipi eklendiğinde buraya da eklenmelidir; payload'ı olmayan tipler
+// (Örn: USER_DELETED) router'a kaydedilemez.
+var payloadFields = map[pb.MessageType]protoreflect.Name{
+	pb.MessageType_USER_CREATED:          "user_created_data",
+	pb.MessageType_SELLER_APPROVED:       "seller_approved_data",
+	pb.MessageType_SELLER_REJECTED:       "seller_rejected_data",
+	pb.MessageType_PRODUCT_PRICE_UPDATED: "product_price_updated_data",
+	pb.MessageType_PRODUCT_STOCK_ZERO:    "product_stock_zero_data",
+	pb.MessageType_PRODUCT_DELETED:       "product_deleted_data",
+	pb.MessageType_ORDER_CREATED:         "order_created_data",
+	pb.MessageType_PAYMENT_SUCCESSFUL:    "payment_successful_data",
+	pb.MessageType_PAYMENT_FAILED:        "payment_failed_data",
+	pb.MessageType_USER_ACTIVATION_EMAIL: "user_activation_email_data",
+	pb.MessageType_USER_FORGOT_PASSWORD:  "user_forgot_password_data",
+}
+
+var payloadOneof = (&pb.Message{}).ProtoReflect().Descriptor().Oneofs().ByName("payload")
+
+// init, payloadFields tablosunun events.proto ile uyumlu olduğunu servis ayağa kalkarken doğrular.
+// Neden? Proto'da bir alan yeniden adlandırılırsa hatayı ilk mesaj geldiğinde değil, açılışta görmek isteriz.
+func init() {
+	for msgType, name := range payloadFields {
+		field := payloadOneof.Fields().ByName(name)
+		if field == nil {
+			panic(fmt.Sprintf("messaging: payload field %q for %s is not part of pb.Message oneof", name, msgType))
+		}
+	}
+}
+
+// payloadField, mesaj tipine karşılık gelen oneof alanının tanımını döner.
+func payloadField(msgType pb.MessageType) (protoreflect.FieldDescriptor, bool) {
+	name, ok := payloadFields[msgType]
+	if !ok {
+		return nil, false
+	}
+	return payloadOneof.Fields().ByName(name), true
+}
+
+// Router, MessageType'a göre mesajı doğru typed handler'a yönlendirir.
+// Neden? Her servis kendi map[MessageType]Handler yapısını ve payload açma kodunu
+// tekrar yazmak yerine, tip kontrolü yapılmış tek bir yönlendirici kullanır.
+type Router struct {
+	routes map[pb.MessageType]MessageHandler
+}
+
+func NewRouter() *Router {
+	return &Router{routes: make(map[pb.MessageType]MessageHandler)}
+}
+
+// Handle, bir mesaj tipini typed handler'a bağlar.
+// T, mesaj tipinin oneof payload'ı ile aynı proto mesajı olmalıdır (Örn: ORDER_CREATED -> *pb.OrderCreatedData).
+// Uyuşmazlık kayıt anında (servis açılışında) hata olarak döner.
+// Not: Go'da metotlar generic olamadığı için Handle paket seviyesinde bir fonksiyondur.
+func Handle[T proto.Message](r *Router, msgType pb.MessageType, handler func(context.Context, T, Meta) error) error {
+	field, ok := payloadField(msgType)
+	if !ok {
+		return fmt.Errorf("message type %s has no payload binding", msgType)
+	}
+
+	var zero T
+	want := field.Message().FullName()
+	if got := zero.ProtoReflect().Descriptor().FullName(); got != want {
+		return fmt.Errorf("handler for %s expects payload %s, got %s", msgType, want, got)
+	}
+
+	if _, exists := r.routes[msgType]; exists {
+		return fmt.Errorf("handler for %s already registered", msgType)
+	}
+
+	r.routes[msgType] = func(ctx context.Context, msg *pb.Message) error {
+		m := msg.ProtoReflect()
+		if set := m.WhichOneof(payloadOneof); set != field {
+			actual := protoreflect.Name("none")
+			if set != nil {
+				actual = set.Name()
+			}
+			return fmt.Errorf("%w: %s expects %s, got %s [id=%s]", ErrPayloadMismatch, msgType, field.Name(), actual, msg.Id)
+		}
+
+		payload, ok := m.Get(field).Message().Interface().(T)
+		if !ok {
+			return fmt.Errorf("%w: %s payload has unexpected Go type [id=%s]", ErrPayloadMismatch, msgType, msg.Id)
+		}
+		return handler(ctx, payload, newMeta(msg))
+	}
+	return nil
+}
+
+// Dispatch, MessageHandler imzasına uyar ve doğrudan ConsumeMessages'a verilebilir.
+// Kayıtlı olmayan tipler için ErrUnhandledMessage döner; bu hata retry edilmeden DLQ'ya gider.
+func (r *Router) Dispatch(ctx context.Context, msg *pb.Message) error {
+	handler, ok := r.routes[msg.Type]
+	if !ok {
+		return fmt.Errorf("%w: %s [id=%s]", ErrUnhandledMessage, msg.Type, msg.Id)
+	}
+	return handler(ctx, msg)
+}
+
+// MessageTypes, router'a kayıtlı tüm mesaj tiplerini sıralı olarak döner.
+func (r *Router) MessageTypes() []pb.MessageType {
+	types := make([]pb.MessageType, 0, len(r.routes))
+	for t := range r.routes {
+		types = append(types, t)
+	}
+	sort.Slice(types, func(i, j int) bool { return types[i] < types[j] })
+	return types
+}
+
+// Covers, verilen tiplerin (genelde AllowedMessageTypes) hepsinin bir handler'ı olduğunu doğrular.
+// Neden? İzin verilip handler'ı olmayan her mesaj DLQ'ya düşer; bunu açılışta yakalamak daha iyidir.
+func (r *Router) Covers(types []pb.MessageType) error {
+	var missing []string
+	for _, t := range types {
+		if _, ok := r.routes[t]; !ok {
+			missing = append(missing, t.String())
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("no handler registered for allowed message types: %v", missing)
+	}
+	return nil
+}
+
+// isUnroutable, yeniden denemenin anlamsız olduğu yönlendirme hatalarını ayırt eder.
+func isUnroutable(err error) bool {
+	return errors.Is(err, ErrUnhandledMessage) || errors.Is(err, ErrPayloadMismatch) ||
+		errors.Is(err, ErrUnsupportedSchemaVersion)
+}
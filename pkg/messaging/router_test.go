@@ -0,0 +1,156 @@
+package messaging
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	pb "marketplace/pkg/proto/events"
+)
+
+func TestHandleRegistration(t *testing.T) {
+	orderCreated := func(context.Context, *pb.OrderCreatedData, Meta) error { return nil }
+
+	tests := []struct {
+		name     string
+		register func(r *Router) error
+		wantErr  bool
+	}{
+		{
+			name:     "matching payload",
+			register: func(r *Router) error { return Handle(r, pb.MessageType_ORDER_CREATED, orderCreated) },
+		},
+		{
+			name:     "payload of another type",
+			register: func(r *Router) error { return Handle(r, pb.MessageType_PAYMENT_FAILED, orderCreated) },
+			wantErr:  true,
+		},
+		{
+			name:     "type without payload binding",
+			register: func(r *Router) error { return Handle(r, pb.MessageType_USER_DELETED, orderCreated) },
+			wantErr:  true,
+		},
+		{
+			name: "duplicate registration",
+			register: func(r *Router) error {
+				if err := Handle(r, pb.MessageType_ORDER_CREATED, orderCreated); err != nil {
+					return err
+				}
+				return Handle(r, pb.MessageType_ORDER_CREATED, orderCreated)
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.register(NewRouter())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("register error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRouterDispatch(t *testing.T) {
+	var got *pb.OrderCreatedData
+	var gotMeta Meta
+	router := NewRouter()
+	err := Handle(router, pb.MessageType_ORDER_CREATED, func(_ context.Context, data *pb.OrderCreatedData, meta Meta) error {
+		got, gotMeta = data, meta
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		msg       *pb.Message
+		wantErr   error
+		wantOrder string
+	}{
+		{
+			name: "routes payload and meta",
+			msg: &pb.Message{
+				Id:          "msg-1",
+				Type:        pb.MessageType_ORDER_CREATED,
+				FromService: pb.ServiceType_ORDER_SERVICE,
+				Payload:     &pb.Message_OrderCreatedData{OrderCreatedData: &pb.OrderCreatedData{OrderId: "order-1"}},
+			},
+			wantOrder: "order-1",
+		},
+		{
+			name: "payload does not match type",
+			msg: &pb.Message{
+				Id:      "msg-2",
+				Type:    pb.MessageType_ORDER_CREATED,
+				Payload: &pb.Message_PaymentFailedData{PaymentFailedData: &pb.PaymentFailedData{OrderId: "order-1"}},
+			},
+			wantErr: ErrPayloadMismatch,
+		},
+		{
+			name:    "missing payload",
+			msg:     &pb.Message{Id: "msg-3", Type: pb.MessageType_ORDER_CREATED},
+			wantErr: ErrPayloadMismatch,
+		},
+		{
+			name:    "unregistered type",
+			msg:     &pb.Message{Id: "msg-4", Type: pb.MessageType_PAYMENT_FAILED},
+			wantErr: ErrUnhandledMessage,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, gotMeta = nil, Meta{}
+			err := router.Dispatch(context.Background(), tt.msg)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Dispatch error = %v, want %v", err, tt.wantErr)
+				}
+				if !isUnroutable(err) {
+					t.Fatalf("routing error %v is not classified as unroutable", err)
+				}
+				if got != nil {
+					t.Fatal("handler ran for a rejected message")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Dispatch error = %v", err)
+			}
+			if got.GetOrderId() != tt.wantOrder {
+				t.Fatalf("payload order id = %q, want %q", got.GetOrderId(), tt.wantOrder)
+			}
+			if gotMeta.ID != tt.msg.Id || gotMeta.FromService != tt.msg.FromService {
+				t.Fatalf("meta = %+v, want id %q from %s", gotMeta, tt.msg.Id, tt.msg.FromService)
+			}
+		})
+	}
+}
+
+func TestRouterCovers(t *testing.T) {
+	router := NewRouter()
+	if err := Handle(router, pb.MessageType_ORDER_CREATED, func(context.Context, *pb.OrderCreatedData, Meta) error { return nil }); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		types   []pb.MessageType
+		wantErr bool
+	}{
+		{"no allowed types", nil, false},
+		{"all covered", []pb.MessageType{pb.MessageType_ORDER_CREATED}, false},
+		{"missing handler", []pb.MessageType{pb.MessageType_ORDER_CREATED, pb.MessageType_PAYMENT_FAILED}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := router.Covers(tt.types); (err != nil) != tt.wantErr {
+				t.Fatalf("Covers() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
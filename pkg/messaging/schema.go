@@ -0,0 +1,153 @@
+package messaging
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+
+	pb "marketplace/pkg/proto/events"
+)
+
+// SchemaVersionHeader, payload'ın hangi şema sürümüyle yazıldığını taşıyan mesaj header'ıdır.
+// Header, pb.Message.Headers içinde durur; böylece retry/DLQ topic'lerinde, outbox'ta ve
+// kritik mesaj dosyalarında payload ile birlikte saklanır.
+// Header'ı olmayan mesajlar (sürümlemeden önce yazılmış olanlar) 1. sürüm kabul edilir.
+const SchemaVersionHeader = "schema-version"
+
+// ErrUnsupportedSchemaVersion, mesajın sürümü bilinen güncel sürümden yeniyse veya
+// aradaki bir sürüm için upcaster kayıtlı değilse döner. Tekrar denemek sonucu
+// değiştirmeyeceği için mesaj doğrudan DLQ'ya gider; servis güncellendiğinde
+// dlq-admin replay ile tekrar işlenebilir.
+var ErrUnsupportedSchemaVersion = errors.New("unsupported schema version")
+
+// Upcaster, bir mesaj tipinin payload'ını bir sürümden bir sonrakine taşır.
+// Mesajı yerinde değiştirir; header'ı SchemaRegistry günceller.
+type Upcaster func(msg *pb.Message) error
+
+// SchemaRegistry, mesaj tiplerinin güncel şema sürümünü ve eski sürümleri güncele
+// taşıyan upcaster zincirini tutar.
+// Neden? events.proto'daki payload'lar değiştikçe retry/DLQ topic'lerinde ve kritik dosyalarda
+// bekleyen eski mesajlar handler'a yeni anlamıyla okunamaz. Upcaster'lar eski payload'ı
+// handler görmeden önce güncel sürüme çevirir; handler'lar sadece güncel şemayı bilir.
+type SchemaRegistry struct {
+	mu        sync.RWMutex
+	current   map[pb.MessageType]int
+	upcasters map[pb.MessageType]map[int]Upcaster
+}
+
+func NewSchemaRegistry() *SchemaRegistry {
+	return &SchemaRegistry{
+		current:   make(map[pb.MessageType]int),
+		upcasters: make(map[pb.MessageType]map[int]Upcaster),
+	}
+}
+
+// DefaultSchemaRegistry, KafkaConfig.Schemas boşsa kullanılan registry'dir.
+// events.proto için yazılan upcaster'lar upcasters.go'da buraya kaydedilir.
+var DefaultSchemaRegistry = NewSchemaRegistry()
+
+// RegisterUpcaster, msgType'ın from sürümünü from+1 sürümüne taşıyan upcaster'ı kaydeder.
+// Mesaj tipinin güncel sürümü kayıtlı en yüksek from+1 olur. Aynı adım iki kez kaydedilemez.
+func (r *SchemaRegistry) RegisterUpcaster(msgType pb.MessageType, from int, upcaster Upcaster) error {
+	if from < 1 {
+		return fmt.Errorf("schema version must be >= 1, got %d", from)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	steps, ok := r.upcasters[msgType]
+	if !ok {
+		steps = make(map[int]Upcaster)
+		r.upcasters[msgType] = steps
+	}
+	if _, exists := steps[from]; exists {
+		return fmt.Errorf("upcaster for %s v%d already registered", msgType, from)
+	}
+	steps[from] = upcaster
+	if from+1 > r.current[msgType] {
+		r.current[msgType] = from + 1
+	}
+	return nil
+}
+
+// CurrentVersion, mesaj tipinin güncel şema sürümünü döner. Upcaster'ı olmayan tipler 1. sürümdedir.
+func (r *SchemaRegistry) CurrentVersion(msgType pb.MessageType) int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if v, ok := r.current[msgType]; ok {
+		return v
+	}
+	return 1
+}
+
+// Stamp, header'ı olmayan mesaja mesaj tipinin güncel sürümünü yazar.
+// Mevcut header'a dokunulmaz: retry veya replay edilen mesaj yazıldığı sürümü korumalıdır.
+func (r *SchemaRegistry) Stamp(msg *pb.Message) {
+	if _, ok := msg.Headers[SchemaVersionHeader]; ok {
+		return
+	}
+	if msg.Headers == nil {
+		msg.Headers = make(map[string]string)
+	}
+	msg.Headers[SchemaVersionHeader] = strconv.Itoa(r.CurrentVersion(msg.Type))
+}
+
+// Upcast, mesajı yazıldığı sürümden güncel sürüme taşır ve header'ı günceller.
+// Mesaj zaten güncelse hiçbir şey yapmaz.
+func (r *SchemaRegistry) Upcast(msg *pb.Message) error {
+	version, err := SchemaVersion(msg)
+	if err != nil {
+		return err
+	}
+	current := r.CurrentVersion(msg.Type)
+	if version > current {
+		return fmt.Errorf("%w: %s v%d is newer than v%d [id=%s]", ErrUnsupportedSchemaVersion, msg.Type, version, current, msg.Id)
+	}
+
+	for ; version < current; version++ {
+		r.mu.RLock()
+		upcaster, ok := r.upcasters[msg.Type][version]
+		r.mu.RUnlock()
+		if !ok {
+			return fmt.Errorf("%w: no upcaster for %s v%d [id=%s]", ErrUnsupportedSchemaVersion, msg.Type, version, msg.Id)
+		}
+		if err := upcaster(msg); err != nil {
+			return fmt.Errorf("upcast %s v%d -> v%d [id=%s]: %w", msg.Type, version, version+1, msg.Id, err)
+		}
+	}
+
+	if msg.Headers == nil {
+		msg.Headers = make(map[string]string)
+	}
+	msg.Headers[SchemaVersionHeader] = strconv.Itoa(current)
+	return nil
+}
+
+// SchemaVersion, mesajın şema sürümünü header'dan okur. Header yoksa 1 döner.
+func SchemaVersion(msg *pb.Message) (int, error) {
+	raw, ok := msg.Headers[SchemaVersionHeader]
+	if !ok || raw == "" {
+		return 1, nil
+	}
+	v, err := strconv.Atoi(raw)
+	if err != nil || v < 1 {
+		return 0, fmt.Errorf("%w: invalid %s header %q [id=%s]", ErrUnsupportedSchemaVersion, SchemaVersionHeader, raw, msg.Id)
+	}
+	return v, nil
+}
+
+// StampSchemaVersion, mesaja DefaultSchemaRegistry'deki güncel sürümü yazar.
+// KafkaClient dışından mesaj kaydeden bileşenler (Örn: outbox) için vardır.
+func StampSchemaVersion(msg *pb.Message) {
+	DefaultSchemaRegistry.Stamp(msg)
+}
+
+func (kc *KafkaClient) schemas() *SchemaRegistry {
+	if kc.config.Schemas != nil {
+		return kc.config.Schemas
+	}
+	return DefaultSchemaRegistry
+}
@@ -0,0 +1,155 @@
+package messaging
+
+import (
+	"errors"
+	"testing"
+
+	pb "marketplace/pkg/proto/events"
+)
+
+// appendStep, mesajın ID'sine sürüm adımını ekler; zincirin sırasını doğrulamak için kullanılır.
+func appendStep(step string) Upcaster {
+	return func(msg *pb.Message) error {
+		msg.Id += step
+		return nil
+	}
+}
+
+var errBoom = errors.New("boom")
+
+func TestSchemaRegistryUpcast(t *testing.T) {
+	const msgType = pb.MessageType_ORDER_CREATED
+
+	tests := []struct {
+		name        string
+		steps       map[int]Upcaster
+		version     string // SchemaVersionHeader; boşsa header yok
+		wantID      string
+		wantVersion string
+		wantErr     error
+	}{
+		{
+			name:        "no upcasters keeps v1",
+			wantID:      "m",
+			wantVersion: "1",
+		},
+		{
+			name:        "missing header is v1 and runs the whole chain",
+			steps:       map[int]Upcaster{1: appendStep(">2"), 2: appendStep(">3")},
+			wantID:      "m>2>3",
+			wantVersion: "3",
+		},
+		{
+			name:        "starts from the written version",
+			steps:       map[int]Upcaster{1: appendStep(">2"), 2: appendStep(">3")},
+			version:     "2",
+			wantID:      "m>3",
+			wantVersion: "3",
+		},
+		{
+			name:        "current version is untouched",
+			steps:       map[int]Upcaster{1: appendStep(">2"), 2: appendStep(">3")},
+			version:     "3",
+			wantID:      "m",
+			wantVersion: "3",
+		},
+		{
+			name:    "newer than current",
+			steps:   map[int]Upcaster{1: appendStep(">2")},
+			version: "3",
+			wantErr: ErrUnsupportedSchemaVersion,
+		},
+		{
+			name:    "gap in the chain",
+			steps:   map[int]Upcaster{2: appendStep(">3")},
+			wantErr: ErrUnsupportedSchemaVersion,
+		},
+		{
+			name:    "invalid header",
+			steps:   map[int]Upcaster{1: appendStep(">2")},
+			version: "v2",
+			wantErr: ErrUnsupportedSchemaVersion,
+		},
+		{
+			name:    "upcaster failure",
+			steps:   map[int]Upcaster{1: func(*pb.Message) error { return errBoom }},
+			wantErr: errBoom,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			registry := NewSchemaRegistry()
+			for from, upcaster := range tt.steps {
+				if err := registry.RegisterUpcaster(msgType, from, upcaster); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			msg := &pb.Message{Id: "m", Type: msgType}
+			if tt.version != "" {
+				msg.Headers = map[string]string{SchemaVersionHeader: tt.version}
+			}
+
+			err := registry.Upcast(msg)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Upcast error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Upcast error = %v", err)
+			}
+			if msg.Id != tt.wantID {
+				t.Fatalf("upcaster chain produced %q, want %q", msg.Id, tt.wantID)
+			}
+			if got := msg.Headers[SchemaVersionHeader]; got != tt.wantVersion {
+				t.Fatalf("schema version header = %q, want %q", got, tt.wantVersion)
+			}
+		})
+	}
+}
+
+func TestSchemaRegistryRegisterUpcaster(t *testing.T) {
+	registry := NewSchemaRegistry()
+	const msgType = pb.MessageType_ORDER_CREATED
+
+	if err := registry.RegisterUpcaster(msgType, 0, appendStep("")); err == nil {
+		t.Fatal("version 0 was accepted")
+	}
+	if err := registry.RegisterUpcaster(msgType, 2, appendStep("")); err != nil {
+		t.Fatal(err)
+	}
+	if err := registry.RegisterUpcaster(msgType, 2, appendStep("")); err == nil {
+		t.Fatal("duplicate step was accepted")
+	}
+	if err := registry.RegisterUpcaster(msgType, 1, appendStep("")); err != nil {
+		t.Fatal(err)
+	}
+	if got := registry.CurrentVersion(msgType); got != 3 {
+		t.Fatalf("CurrentVersion = %d, want 3", got)
+	}
+	if got := registry.CurrentVersion(pb.MessageType_PAYMENT_FAILED); got != 1 {
+		t.Fatalf("CurrentVersion of a type without upcasters = %d, want 1", got)
+	}
+}
+
+func TestSchemaRegistryStampKeepsWrittenVersion(t *testing.T) {
+	registry := NewSchemaRegistry()
+	if err := registry.RegisterUpcaster(pb.MessageType_ORDER_CREATED, 1, appendStep("")); err != nil {
+		t.Fatal(err)
+	}
+
+	fresh := &pb.Message{Type: pb.MessageType_ORDER_CREATED}
+	registry.Stamp(fresh)
+	if got := fresh.Headers[SchemaVersionHeader]; got != "2" {
+		t.Fatalf("stamped version = %q, want 2", got)
+	}
+
+	replayed := &pb.Message{Type: pb.MessageType_ORDER_CREATED, Headers: map[string]string{SchemaVersionHeader: "1"}}
+	registry.Stamp(replayed)
+	if got := replayed.Headers[SchemaVersionHeader]; got != "1" {
+		t.Fatalf("replayed message version = %q, want 1", got)
+	}
+}
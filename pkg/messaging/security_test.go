@@ -0,0 +1,289 @@
+package messaging
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"testing"
+	"time"
+
+	pb "marketplace/pkg/proto/events"
+
+	"google.golang.org/protobuf/proto"
+)
+
+func testKeyring(t *testing.T, active string, ids ...string) *Keyring {
+	t.Helper()
+	keys := make(map[string][]byte)
+	for _, id := range append([]string{active}, ids...) {
+		keys[id] = bytes.Repeat([]byte(id[:1]), 32)
+	}
+	k, err := NewKeyring(active, keys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return k
+}
+
+func testSecurity(t *testing.T, encryption, signing *Keyring) *MessageSecurity {
+	t.Helper()
+	s, err := NewMessageSecurity(SensitiveMessagePolicies, encryption, signing)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func forgotPassword() *pb.Message {
+	return &pb.Message{
+		Id:          "msg-1",
+		Type:        pb.MessageType_USER_FORGOT_PASSWORD,
+		FromService: pb.ServiceType_USER_SERVICE,
+		ToServices:  []pb.ServiceType{pb.ServiceType_NOTIFICATION_SERVICE, pb.ServiceType_USER_SERVICE},
+		Headers:     map[string]string{"x-request-id": "req-1"},
+		Payload: &pb.Message_UserForgotPasswordData{
+			UserForgotPasswordData: &pb.UserForgotPasswordData{UserId: "user-1", Token: "reset-token-123"},
+		},
+	}
+}
+
+func TestSealOpenRoundTrip(t *testing.T) {
+	s := testSecurity(t, testKeyring(t, "a"), testKeyring(t, "s"))
+	msg := forgotPassword()
+
+	sealed, err := s.seal(msg, "user-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !proto.Equal(msg, forgotPassword()) {
+		t.Fatal("seal changed the caller's message")
+	}
+	if sealed.Payload != nil {
+		t.Fatal("sealed message still carries the payload")
+	}
+	wire, _ := proto.Marshal(sealed)
+	if bytes.Contains(wire, []byte("reset-token-123")) {
+		t.Fatal("token is visible on the wire")
+	}
+	for _, h := range []string{HeaderKeyID, HeaderWrappedKey, HeaderCiphertext, HeaderSignature, HeaderSignatureKeyID, HeaderSignedTargets} {
+		if sealed.Headers[h] == "" {
+			t.Fatalf("sealed message has no %s header", h)
+		}
+	}
+
+	opened, err := s.open(sealed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := forgotPassword()
+	want.Headers[PartitionKeyHeader] = "user-1"
+	if !proto.Equal(opened, want) {
+		t.Fatalf("open = %v, want %v", opened, want)
+	}
+}
+
+func TestOpenRejectsTamperedMessages(t *testing.T) {
+	tests := []struct {
+		name   string
+		tamper func(*pb.Message)
+	}{
+		{"payload", func(m *pb.Message) {
+			ciphertext, _ := base64.StdEncoding.DecodeString(m.Headers[HeaderCiphertext])
+			ciphertext[len(ciphertext)-1] ^= 1
+			m.Headers[HeaderCiphertext] = base64.StdEncoding.EncodeToString(ciphertext)
+		}},
+		{"header", func(m *pb.Message) { m.Headers["x-request-id"] = "req-2" }},
+		{"added header", func(m *pb.Message) { m.Headers["x-admin"] = "true" }},
+		{"source service", func(m *pb.Message) { m.FromService = pb.ServiceType_ORDER_SERVICE }},
+		{"message id", func(m *pb.Message) { m.Id = "msg-2" }},
+		{"signed targets", func(m *pb.Message) { m.Headers[HeaderSignedTargets] = "ORDER_SERVICE" }},
+		{"target outside the signed list", func(m *pb.Message) { m.ToServices = []pb.ServiceType{pb.ServiceType_ORDER_SERVICE} }},
+		{"signature removed", func(m *pb.Message) { delete(m.Headers, HeaderSignature) }},
+	}
+
+	s := testSecurity(t, testKeyring(t, "a"), testKeyring(t, "s"))
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sealed, err := s.seal(forgotPassword(), "user-1")
+			if err != nil {
+				t.Fatal(err)
+			}
+			tt.tamper(sealed)
+
+			_, err = s.open(sealed)
+			if !errors.Is(err, ErrInvalidSignature) || !IsPermanent(err) {
+				t.Fatalf("open error = %v, want a permanent %v", err, ErrInvalidSignature)
+			}
+		})
+	}
+}
+
+// İmza anahtarı olmayan servis imzayı doğrulayamaz; şifreli payload'daki değişikliği yine de
+// AES-GCM yakalar.
+func TestOpenRejectsTamperedCiphertextWithoutSigningKeys(t *testing.T) {
+	encKeys := testKeyring(t, "a")
+	sealed, err := testSecurity(t, encKeys, testKeyring(t, "s")).seal(forgotPassword(), "user-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ciphertext, _ := base64.StdEncoding.DecodeString(sealed.Headers[HeaderCiphertext])
+	ciphertext[len(ciphertext)-1] ^= 1
+	sealed.Headers[HeaderCiphertext] = base64.StdEncoding.EncodeToString(ciphertext)
+
+	encryptOnly := &MessageSecurity{Policies: SensitiveMessagePolicies, EncryptionKeys: encKeys}
+	if _, err := encryptOnly.open(sealed); err == nil || !IsPermanent(err) {
+		t.Fatalf("open error = %v, want a permanent decrypt error", err)
+	}
+}
+
+func TestOpenRejectsUnsignedSensitiveMessage(t *testing.T) {
+	s := testSecurity(t, testKeyring(t, "a"), testKeyring(t, "s"))
+
+	if _, err := s.open(forgotPassword()); !errors.Is(err, ErrInvalidSignature) || !IsPermanent(err) {
+		t.Fatalf("open error = %v, want a permanent %v", err, ErrInvalidSignature)
+	}
+
+	// Politikası olmayan tipler imzasız kabul edilir.
+	plain := &pb.Message{Id: "msg-2", Type: pb.MessageType_ORDER_CREATED}
+	if opened, err := s.open(plain); err != nil || opened != plain {
+		t.Fatalf("open(%s) = %v, %v; want the message unchanged", plain.Type, opened, err)
+	}
+}
+
+func TestOpenAfterKeyRotation(t *testing.T) {
+	before := testSecurity(t, testKeyring(t, "a"), testKeyring(t, "s"))
+	sealed, err := before.seal(forgotPassword(), "user-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Yeni anahtarlar aktif, eskiler listede: eski mesajlar açılır, yeniler yeni anahtarla yazılır.
+	rotated := testSecurity(t, testKeyring(t, "b", "a"), testKeyring(t, "t", "s"))
+	if _, err := rotated.open(sealed); err != nil {
+		t.Fatalf("open after rotation: %v", err)
+	}
+	resealed, err := rotated.seal(forgotPassword(), "user-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resealed.Headers[HeaderKeyID] != "b" || resealed.Headers[HeaderSignatureKeyID] != "t" {
+		t.Fatalf("new message uses keys %s/%s, want b/t", resealed.Headers[HeaderKeyID], resealed.Headers[HeaderSignatureKeyID])
+	}
+
+	tests := []struct {
+		name     string
+		security *MessageSecurity
+	}{
+		{"retired encryption key", testSecurity(t, testKeyring(t, "b"), testKeyring(t, "t", "s"))},
+		{"retired signing key", testSecurity(t, testKeyring(t, "b", "a"), testKeyring(t, "t"))},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.security.open(sealed); !errors.Is(err, ErrUnknownKey) || !IsPermanent(err) {
+				t.Fatalf("open error = %v, want a permanent %v", err, ErrUnknownKey)
+			}
+		})
+	}
+}
+
+// Retry ve DLQ mesajın retry alanlarını ve hedef servislerini değiştirir; imza yine tutmalı.
+func TestSignedMessageVerifiesAfterRetryAndDLQ(t *testing.T) {
+	tests := []struct {
+		name       string
+		retryCount int32
+		toDLQ      bool
+	}{
+		{name: "retry", retryCount: 0},
+		{name: "dlq", retryCount: 3, toDLQ: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			security := testSecurity(t, testKeyring(t, "a"), testKeyring(t, "s"))
+			retries, dlq := &recordingWriter{}, &recordingWriter{}
+			kc := &KafkaClient{
+				config: KafkaConfig{
+					EnableRetry:     true,
+					MaxRetries:      3,
+					RetryTopic:      "retry",
+					RetryDelayTiers: []time.Duration{5 * time.Second},
+					DLQTopic:        "dlq",
+					Security:        security,
+				},
+				retryProducer: retries,
+				dlqProducer:   dlq,
+				serviceType:   pb.ServiceType_NOTIFICATION_SERVICE,
+			}
+			sealed, err := kc.sealMessage(forgotPassword())
+			if err != nil {
+				t.Fatal(err)
+			}
+			sealed.RetryCount = tt.retryCount
+
+			kc.handleFailure(context.Background(), sealed, errors.New("smtp timeout"))
+
+			w := retries
+			if tt.toDLQ {
+				w = dlq
+			}
+			written := w.written(t)
+			if len(written) != 1 {
+				t.Fatalf("wrote %d messages, want 1", len(written))
+			}
+			got := written[0]
+			if len(got.ToServices) != 1 || got.ToServices[0] != pb.ServiceType_NOTIFICATION_SERVICE || got.LastError == "" {
+				t.Fatalf("message was not rerouted: %v", got)
+			}
+
+			opened, err := security.open(got)
+			if err != nil {
+				t.Fatalf("open after %s: %v", tt.name, err)
+			}
+			if opened.GetUserForgotPasswordData().GetToken() != "reset-token-123" {
+				t.Fatalf("payload after %s = %v", tt.name, opened.Payload)
+			}
+		})
+	}
+}
+
+func TestGCMSeal(t *testing.T) {
+	key := bytes.Repeat([]byte{7}, 32)
+	plaintext := []byte("reset-token-123")
+
+	first, err := gcmSeal(key, plaintext, []byte("msg-1|USER_FORGOT_PASSWORD"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := gcmSeal(key, plaintext, []byte("msg-1|USER_FORGOT_PASSWORD"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(first, second) {
+		t.Fatal("two seals of the same plaintext are identical; nonce is reused")
+	}
+
+	got, err := gcmOpen(key, first, []byte("msg-1|USER_FORGOT_PASSWORD"))
+	if err != nil || !bytes.Equal(got, plaintext) {
+		t.Fatalf("gcmOpen = %q, %v; want %q", got, err, plaintext)
+	}
+
+	tests := []struct {
+		name       string
+		key        []byte
+		sealed     []byte
+		additional string
+	}{
+		{"other key", bytes.Repeat([]byte{8}, 32), first, "msg-1|USER_FORGOT_PASSWORD"},
+		{"other message", key, first, "msg-2|USER_FORGOT_PASSWORD"},
+		{"truncated", key, first[:8], "msg-1|USER_FORGOT_PASSWORD"},
+		{"invalid key size", key[:10], first, "msg-1|USER_FORGOT_PASSWORD"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := gcmOpen(tt.key, tt.sealed, []byte(tt.additional)); err == nil {
+				t.Fatal("gcmOpen succeeded")
+			}
+		})
+	}
+}
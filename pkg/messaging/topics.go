@@ -0,0 +1,280 @@
+package messaging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// Ortak topic isimleri. Tüm servisler aynı ana topic'e yazar; retry ve DLQ topic'leri de
+// ortaktır (mesajın kime ait olduğu ToServices ile belirlenir). Bu yüzden her servisin
+// producer ve consumer yapılandırması bu isimleri kullanmalıdır; aksi halde bir servisin
+// retry'ları veya DLQ'su başka bir topic'te kalır ve dlq-admin onları göremez.
+// Gecikme kademeleri "<DefaultRetryTopic>-<saniye>s" olarak türetilir (bkz. tierTopicName).
+// RequestReply cevap topic'leri ise instance'a özeldir (bkz. KafkaClient.replyTopic).
+const (
+	DefaultTopic            = "main-events"
+	DefaultRetryTopic       = "retry-events"
+	DefaultDLQTopic         = "dlq-events"
+	DefaultReplyTopicPrefix = "reply-events"
+)
+
+// TopicSpec, bir topic'in Kafka'da olması gereken ayarlarıdır.
+// Sıfır değerli alanlar broker varsayılanına bırakılır ve başlangıç kontrolünde karşılaştırılmaz.
+type TopicSpec struct {
+	Name              string
+	Partitions        int
+	ReplicationFactor int
+	// Retention, mesajların saklanma süresidir (retention.ms). Negatif değer sınırsız demektir.
+	Retention time.Duration
+	// CleanupPolicy, "delete" veya "compact" (cleanup.policy).
+	CleanupPolicy string
+}
+
+// DefaultTopicSpecs, KafkaConfig.Topics boşsa kullanılan tanımlardır: ana topic, retry ve
+// gecikme kademeleri 3 partition; DLQ, dlq-admin'in sırayla taraması için tek partition.
+func DefaultTopicSpecs(config KafkaConfig) []TopicSpec {
+	var specs []TopicSpec
+	if config.Topic != "" {
+		specs = append(specs, TopicSpec{Name: config.Topic, Partitions: 3, ReplicationFactor: 1})
+	}
+	if config.EnableRetry && config.RetryTopic != "" {
+		specs = append(specs, TopicSpec{Name: config.RetryTopic, Partitions: 3, ReplicationFactor: 1})
+		for _, tier := range config.RetryDelayTiers {
+			specs = append(specs, TopicSpec{Name: tierTopicName(config.RetryTopic, tier), Partitions: 3, ReplicationFactor: 1})
+		}
+	}
+	if config.DLQTopic != "" {
+		specs = append(specs, TopicSpec{Name: config.DLQTopic, Partitions: 1, ReplicationFactor: 1})
+	}
+	return specs
+}
+
+func (s TopicSpec) topicConfig() kafka.TopicConfig {
+	cfg := kafka.TopicConfig{Topic: s.Name, NumPartitions: -1, ReplicationFactor: -1}
+	if s.Partitions > 0 {
+		cfg.NumPartitions = s.Partitions
+	}
+	if s.ReplicationFactor > 0 {
+		cfg.ReplicationFactor = s.ReplicationFactor
+	}
+	if v, ok := s.retentionMs(); ok {
+		cfg.ConfigEntries = append(cfg.ConfigEntries, kafka.ConfigEntry{ConfigName: "retention.ms", ConfigValue: v})
+	}
+	if s.CleanupPolicy != "" {
+		cfg.ConfigEntries = append(cfg.ConfigEntries, kafka.ConfigEntry{ConfigName: "cleanup.policy", ConfigValue: s.CleanupPolicy})
+	}
+	return cfg
+}
+
+func (s TopicSpec) retentionMs() (string, bool) {
+	switch {
+	case s.Retention < 0:
+		return "-1", true
+	case s.Retention > 0:
+		return strconv.FormatInt(s.Retention.Milliseconds(), 10), true
+	}
+	return "", false
+}
+
+// TopicMismatch, Kafka'daki bir topic ayarının tanımdan farklı olduğunu bildirir.
+type TopicMismatch struct {
+	Topic   string
+	Setting string
+	Want    string
+	Got     string
+}
+
+func (m TopicMismatch) String() string {
+	return fmt.Sprintf("%s: %s is %s, want %s", m.Topic, m.Setting, m.Got, m.Want)
+}
+
+// TopicMismatchError, başlangıç kontrolünde bulunan tüm uyumsuzlukları taşır.
+// Neden otomatik düzeltmiyoruz? Partition sayısını azaltmak mümkün değildir, artırmak ise
+// anahtar -> partition eşlemesini değiştirip sıralama garantisini bozar; karar operatöre aittir.
+type TopicMismatchError struct {
+	Mismatches []TopicMismatch
+}
+
+func (e *TopicMismatchError) Error() string {
+	parts := make([]string, len(e.Mismatches))
+	for i, m := range e.Mismatches {
+		parts[i] = m.String()
+	}
+	return "topic spec mismatch: " + strings.Join(parts, "; ")
+}
+
+func (kc *KafkaClient) topicSpecs() []TopicSpec {
+	if len(kc.config.Topics) > 0 {
+		return kc.config.Topics
+	}
+	return DefaultTopicSpecs(kc.config)
+}
+
+// ensureTopics, verilen topic'lerden eksik olanları oluşturur ve var olanların ayarlarını
+// tanımlarla karşılaştırır. Uyumsuzluk varsa *TopicMismatchError döner.
+// Avantajı: Yeni bir servis eklediğinizde Kafka panelinden manuel topic oluşturma zahmetinden kurtarır;
+// elle oluşturulmuş ve farklı ayarlanmış bir topic de servis açılırken fark edilir.
+func (kc *KafkaClient) ensureTopics(specs []TopicSpec) error {
+	if len(specs) == 0 {
+		return nil
+	}
+
+	timeout := kc.config.ConnectionTimeout
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	conn, err := kafka.DialContext(ctx, "tcp", kc.config.Brokers[0])
+	if err != nil {
+		return fmt.Errorf("dial %s: %w", kc.config.Brokers[0], err)
+	}
+	defer conn.Close()
+
+	// Controller'ı bul (Topic yaratma yetkisi ondadır)
+	controller, err := conn.Controller()
+	if err != nil {
+		return fmt.Errorf("find controller: %w", err)
+	}
+	controllerConn, err := kafka.DialContext(ctx, "tcp", net.JoinHostPort(controller.Host, strconv.Itoa(controller.Port)))
+	if err != nil {
+		return fmt.Errorf("dial controller: %w", err)
+	}
+	defer controllerConn.Close()
+
+	configs := make([]kafka.TopicConfig, 0, len(specs))
+	for _, spec := range specs {
+		configs = append(configs, spec.topicConfig())
+	}
+	// Var olan topic'ler için TopicAlreadyExists, kafka-go tarafından yok sayılır.
+	if err := controllerConn.CreateTopics(configs...); err != nil {
+		return fmt.Errorf("create topics: %w", err)
+	}
+
+	mismatches, err := kc.checkTopics(ctx, conn, specs)
+	if err != nil {
+		return err
+	}
+	if len(mismatches) > 0 {
+		return &TopicMismatchError{Mismatches: mismatches}
+	}
+	return nil
+}
+
+// checkTopics, topic'lerin partition, replikasyon ve (tanımlıysa) retention/cleanup ayarlarını okur.
+func (kc *KafkaClient) checkTopics(ctx context.Context, conn *kafka.Conn, specs []TopicSpec) ([]TopicMismatch, error) {
+	names := make([]string, len(specs))
+	for i, spec := range specs {
+		names[i] = spec.Name
+	}
+
+	partitions, err := conn.ReadPartitions(names...)
+	if err != nil {
+		return nil, fmt.Errorf("read partitions: %w", err)
+	}
+	partitionCount := make(map[string]int)
+	replicationFactor := make(map[string]int)
+	for _, p := range partitions {
+		partitionCount[p.Topic]++
+		if len(p.Replicas) > replicationFactor[p.Topic] {
+			replicationFactor[p.Topic] = len(p.Replicas)
+		}
+	}
+
+	configs, err := kc.describeTopicConfigs(ctx, specs)
+	if err != nil {
+		return nil, err
+	}
+
+	var mismatches []TopicMismatch
+	add := func(topic, setting, want, got string) {
+		mismatches = append(mismatches, TopicMismatch{Topic: topic, Setting: setting, Want: want, Got: got})
+	}
+	for _, spec := range specs {
+		if got := partitionCount[spec.Name]; spec.Partitions > 0 && got != spec.Partitions {
+			add(spec.Name, "partitions", strconv.Itoa(spec.Partitions), strconv.Itoa(got))
+		}
+		if got := replicationFactor[spec.Name]; spec.ReplicationFactor > 0 && got != spec.ReplicationFactor {
+			add(spec.Name, "replication factor", strconv.Itoa(spec.ReplicationFactor), strconv.Itoa(got))
+		}
+		if want, ok := spec.retentionMs(); ok {
+			if got := configs[spec.Name]["retention.ms"]; got != want {
+				add(spec.Name, "retention.ms", want, got)
+			}
+		}
+		if spec.CleanupPolicy != "" {
+			if got := configs[spec.Name]["cleanup.policy"]; got != spec.CleanupPolicy {
+				add(spec.Name, "cleanup.policy", spec.CleanupPolicy, got)
+			}
+		}
+	}
+	return mismatches, nil
+}
+
+// describeTopicConfigs, sadece retention veya cleanup policy tanımlanmış topic'lerin ayarlarını okur.
+func (kc *KafkaClient) describeTopicConfigs(ctx context.Context, specs []TopicSpec) (map[string]map[string]string, error) {
+	var resources []kafka.DescribeConfigRequestResource
+	for _, spec := range specs {
+		if _, ok := spec.retentionMs(); ok || spec.CleanupPolicy != "" {
+			resources = append(resources, kafka.DescribeConfigRequestResource{
+				ResourceType: kafka.ResourceTypeTopic,
+				ResourceName: spec.Name,
+				ConfigNames:  []string{"retention.ms", "cleanup.policy"},
+			})
+		}
+	}
+	if len(resources) == 0 {
+		return nil, nil
+	}
+
+	client := &kafka.Client{Addr: kafka.TCP(kc.config.Brokers...)}
+	resp, err := client.DescribeConfigs(ctx, &kafka.DescribeConfigsRequest{Resources: resources})
+	if err != nil {
+		return nil, fmt.Errorf("describe topic configs: %w", err)
+	}
+
+	configs := make(map[string]map[string]string, len(resp.Resources))
+	for _, r := range resp.Resources {
+		if r.Error != nil {
+			return nil, fmt.Errorf("describe %s: %w", r.ResourceName, r.Error)
+		}
+		entries := make(map[string]string, len(r.ConfigEntries))
+		for _, e := range r.ConfigEntries {
+			entries[e.ConfigName] = e.ConfigValue
+		}
+		configs[r.ResourceName] = entries
+	}
+	return configs, nil
+}
+
+// setupTopics, NewKafkaClient içinde çağrılır. Kafka'ya ulaşılamaması servisi durdurmaz
+// (topic'ler sonradan oluşturulabilir); StrictTopics açıksa ayar uyumsuzluğu durdurur.
+func (kc *KafkaClient) setupTopics() error {
+	err := kc.ensureTopics(kc.topicSpecs())
+	if err == nil {
+		return nil
+	}
+
+	var mismatch *TopicMismatchError
+	if errors.As(err, &mismatch) {
+		if kc.config.StrictTopics {
+			return err
+		}
+		for _, m := range mismatch.Mismatches {
+			log.Printf("⚠ [Topics] Mismatch: %s", m)
+		}
+		return nil
+	}
+
+	log.Printf("Warning: Failed to create topics: %v", err)
+	return nil
+}
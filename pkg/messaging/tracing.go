@@ -0,0 +1,65 @@
+package messaging
+
+import (
+	"context"
+
+	pb "marketplace/pkg/proto/events"
+	"marketplace/pkg/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// startPublishSpan, yayın için producer span'i açar ve trace context'ini mesajın header'larına
+// yazar. İmzalama bundan sonra yapıldığı için traceparent da imzanın kapsamındadır.
+// Neden Kafka header'ı değil de pb.Message.Headers? Retry, DLQ ve in-memory broker mesajı
+// protobuf olarak taşır; context, mesaj nereye giderse onunla birlikte gitmelidir.
+func (kc *KafkaClient) startPublishSpan(ctx context.Context, topic string, msg *pb.Message) (context.Context, trace.Span) {
+	ctx, span := tracing.Tracer().Start(ctx, "publish "+msg.Type.String(),
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(messageAttributes(topic, msg)...),
+	)
+	if msg.Headers == nil {
+		msg.Headers = make(map[string]string)
+	}
+	tracing.Inject(ctx, msg.Headers)
+	return ctx, span
+}
+
+// startConsumeSpan, mesajdaki trace context'ini okuyup handler için consumer span'i açar.
+// Yayınlayan servisin span'i parent olur; handler'ın gRPC/Kafka çağrıları bu span'in altına düşer.
+func (kc *KafkaClient) startConsumeSpan(ctx context.Context, msg *pb.Message) (context.Context, trace.Span) {
+	ctx = tracing.Extract(ctx, msg.Headers)
+	return tracing.Tracer().Start(ctx, "process "+msg.Type.String(),
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(messageAttributes("", msg)...),
+		trace.WithAttributes(
+			attribute.String("messaging.consumer.group.name", kc.idempotencyGroup()),
+			attribute.Int("messaging.retry_count", int(msg.RetryCount)),
+		),
+	)
+}
+
+func messageAttributes(topic string, msg *pb.Message) []attribute.KeyValue {
+	attrs := []attribute.KeyValue{
+		attribute.String("messaging.system", "kafka"),
+		attribute.String("messaging.message.id", msg.Id),
+		attribute.String("messaging.message.type", msg.Type.String()),
+		attribute.String("messaging.message.from_service", msg.FromService.String()),
+		attribute.Bool("messaging.message.critical", msg.Critical),
+	}
+	if topic != "" {
+		attrs = append(attrs, attribute.String("messaging.destination.name", topic))
+	}
+	return attrs
+}
+
+// endSpan, hata varsa span'i hatalı işaretleyip kapatır.
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -14,13 +14,21 @@ import (
 // kodun polimorfik (çok biçimli) çalışmasını sağlar.
 type MessageHandler func(context.Context, *pb.Message) error
 
+// messageWriter, KafkaClient'ın retry/DLQ yazarken kullandığı kafka.Writer metodlarıdır.
+// Neden? Retry ve DLQ kararları testlerde Kafka olmadan, yazılan mesajlar yakalanarak doğrulanabilsin.
+type messageWriter interface {
+	WriteMessages(ctx context.Context, msgs ...kafka.Message) error
+	Close() error
+}
+
 // KafkaClient, Kafka üretici (producer) ve tüketici (consumer) operasyonlarını yöneten ana yapıdır.
 // Neden? Tüm Kafka operasyonlarını tek bir struct altında toplamak, servislerin Kafka detaylarını
 // bilmeden (encapsulation) mesaj alıp göndermesini sağlar.
 type KafkaClient struct {
 	config        KafkaConfig
 	producer      *kafka.Writer  // Ana mesaj gönderici
-	retryProducer *kafka.Writer  // Hatalı mesajları tekrar gönderen yardımcı
+	retryProducer messageWriter  // Hatalı mesajları tekrar gönderen yardımcı
+	dlqProducer   messageWriter  // Tekrar denenmeyecek mesajları DLQ topic'ine yazan yardımcı
 	mu            sync.RWMutex   // Thread-safety (eşzamanlı erişim güvenliği) için
 	closed        bool           // Client'ın kapanıp kapanmadığını takip eder
 	serviceType   pb.ServiceType // Hangi servisin bu client'ı kullandığı bilgisi
@@ -30,6 +38,16 @@ type KafkaClient struct {
 	// Bu havuz, kaynak tüketimini (CPU/RAM) kontrol altında tutar.
 	workerPool chan struct{}
 	wg         sync.WaitGroup // Uygulama kapanırken aktif işlerin bitmesini beklemek için
+
+	// RequestReply için cevap topic'i dinleyicisi ve cevap yazıcısı; ilk kullanımda oluşturulur.
+	replyMu     sync.Mutex
+	replies     *replyRouter
+	replyWriter *kafka.Writer
+
+	// KafkaConfig.CriticalStore boşken kullanılan dosya store'u; ilk kritik mesajda açılır.
+	criticalOnce    sync.Once
+	defaultCritical CriticalStore
+	criticalErr     error
 }
 
 // KafkaConfig, Kafka client'ın çalışma parametrelerini içerir.
@@ -0,0 +1,20 @@
+package messaging
+
+import (
+	pb "marketplace/pkg/proto/events"
+)
+
+// mustRegisterUpcaster, events.proto için bir upcaster'ı DefaultSchemaRegistry'ye kaydeder.
+// Şu an kayıtlı upcaster yoktur; bütün olaylar 1. sürümdedir. Bir payload'ın anlamı veya
+// yapısı değiştiğinde:
+//  1. Değişikliği events.proto'ya geriye uyumlu şekilde ekleyin (yeni alan, yeni numara).
+//  2. Eski sürümü yenisine çeviren upcaster'ı bu dosyadaki bir init içinde kaydedin
+//     (Örn: mustRegisterUpcaster(pb.MessageType_ORDER_CREATED, 1, upcastOrderCreatedV1));
+//     güncel sürüm otomatik artar.
+//  3. Önce tüketici servisleri, sonra üreticiyi deploy edin. Yeni tüketiciler eski mesajları
+//     upcaster ile okur; eski tüketiciler ise yeni sürümü bilmez.
+func mustRegisterUpcaster(msgType pb.MessageType, from int, upcaster Upcaster) {
+	if err := DefaultSchemaRegistry.RegisterUpcaster(msgType, from, upcaster); err != nil {
+		panic("messaging: " + err.Error())
+	}
+}
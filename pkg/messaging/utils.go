@@ -4,37 +4,50 @@ import (
 	"context"
 	"fmt"
 	"log"
-	"os"
+	"time"
 
 	pb "marketplace/pkg/proto/events"
-
-	"github.com/segmentio/kafka-go"
-	"google.golang.org/protobuf/proto"
 )
 
-// saveCriticalMessageToStorage, DLQ'ya düşen veya kritik olan mesajları disk'e kalıcı olarak yazar.
+// saveCriticalMessageToStorage, DLQ'ya düşen kritik mesajları kasaya (CriticalStore) kalıcı olarak yazar.
 // Neden? Kafka'da bir sorun olsa bile, kritik verilerin (Örn: Ödeme onayı) kaybolmamasını garanti eder.
-// Bu dosyalar daha sonra manuel olarak veya bir script ile tekrar sisteme sokulabilir.
-func (kc *KafkaClient) saveCriticalMessageToStorage(msg *pb.Message) {
-	os.MkdirAll("critical_messages", 0755)
-
-	data, err := proto.Marshal(msg)
+// Kayıtlar "dlq-admin critical" ile sorgulanıp tekrar sisteme sokulabilir (bkz. DLQAdmin.ReplayCritical).
+// Kasada sır bulunmaz: şifreleme politikası olan mesaj şifreli yazılır; şifrelenmeyen mesajda
+// (Örn: anahtarlar tanımlı değil) token ve aktivasyon kodu gibi alanlar maskelenir.
+func (kc *KafkaClient) saveCriticalMessageToStorage(ctx context.Context, msg *pb.Message) error {
+	store, err := kc.criticalStore()
 	if err != nil {
-		log.Printf("!!! [Storage] Marshal error: %v", err)
-		return
+		return err
 	}
 
-	// Hem binary (proto) hem de okunabilir (txt) formatta kaydediyoruz.
-	filename := fmt.Sprintf("critical_messages/%s_%s.pb", msg.Type.String(), msg.Id)
-	logName := filename + ".txt"
+	sealed, err := kc.sealMessage(msg)
+	if err != nil {
+		return err
+	}
+	if sealed.Headers[HeaderCiphertext] == "" {
+		sealed = redacted(sealed)
+	}
 
-	humanReadable := fmt.Sprintf("ID: %s\nType: %s\nError: %s\nData: %s",
-		msg.Id, msg.Type.String(), msg.LastError, msg.String())
+	saveCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+	defer cancel()
 
-	_ = os.WriteFile(filename, data, 0644)
-	_ = os.WriteFile(logName, []byte(humanReadable), 0644)
+	if err := store.Save(saveCtx, kc.serviceType, sealed); err != nil {
+		return fmt.Errorf("save critical message %s: %w", msg.Id, err)
+	}
 
 	log.Printf("💾 [Storage] Critical message saved safely: %s", msg.Id)
+	return nil
+}
+
+// resolveCriticalMessage, kasadaki kaydı işlendi olarak işaretler.
+func (kc *KafkaClient) resolveCriticalMessage(ctx context.Context, msg *pb.Message) {
+	store, err := kc.criticalStore()
+	if err != nil {
+		return
+	}
+	if err := store.SetStatus(ctx, kc.serviceType, msg.Id, CriticalResolved); err != nil {
+		log.Printf("⚠ [Storage] Resolve failed [id=%s]: %v", msg.Id, err)
+	}
 }
 
 // getConsumerGroupID, grup ID'si yoksa servise özel bir grup üretir.
@@ -53,53 +66,18 @@ func (kc *KafkaClient) getConsumerTopic(topic *string) string {
 	return kc.config.Topic
 }
 
-func (kc *KafkaClient) createTopicsIfNotExists() error {
-	conn, err := kafka.DialContext(context.Background(), "tcp", kc.config.Brokers[0])
-	if err != nil {
-		return err
-	}
-	defer conn.Close()
-
-	// Controller'ı bul (Topic yaratma yetkisi ondadır)
-	controller, _ := conn.Controller()
-	controllerConn, _ := kafka.DialContext(context.Background(), "tcp",
-		fmt.Sprintf("%s:%d", controller.Host, controller.Port))
-	defer controllerConn.Close()
-
-	topics := []kafka.TopicConfig{
-		{Topic: kc.config.Topic, NumPartitions: 3, ReplicationFactor: 1},
-	}
-
-	if kc.config.EnableRetry {
-		topics = append(topics, kafka.TopicConfig{
-			Topic: kc.config.RetryTopic, NumPartitions: 3, ReplicationFactor: 1})
-	}
-
-	if kc.config.DLQTopic != "" {
-		topics = append(topics, kafka.TopicConfig{
-			Topic: kc.config.DLQTopic, NumPartitions: 1, ReplicationFactor: 1})
-	}
-
-	return controllerConn.CreateTopics(topics...)
-}
-
 // shouldProcessMessage, mesajın bu servise gelip gelmemesi gerektiğini kontrol eder.
 func (kc *KafkaClient) shouldProcessMessage(msg *pb.Message) bool {
 	// 1. Hedef Servis Filtresi (ToServices)
-	if len(msg.ToServices) > 0 {
-		isTarget := false
-		for _, svc := range msg.ToServices {
-			if svc == kc.serviceType {
-				isTarget = true
-				break
-			}
-		}
-		if !isTarget {
-			return false
-		}
+	if !kc.isTargetService(msg) {
+		return false
 	}
 
 	// 2. Yetki Filtresi (AllowedMessageTypes)
+	// Hiç tanımlanmamışsa (Örn: NewDefaultConfig) tüm tipler kabul edilir.
+	if len(kc.config.AllowedMessageTypes) == 0 {
+		return true
+	}
 	allowed, ok := kc.config.AllowedMessageTypes[kc.serviceType]
 	if !ok {
 		return false
@@ -113,6 +91,19 @@ func (kc *KafkaClient) shouldProcessMessage(msg *pb.Message) bool {
 	return false
 }
 
+// isTargetService, mesajın ToServices listesi boşsa veya bu servisi içeriyorsa true döner.
+func (kc *KafkaClient) isTargetService(msg *pb.Message) bool {
+	if len(msg.ToServices) == 0 {
+		return true
+	}
+	for _, svc := range msg.ToServices {
+		if svc == kc.serviceType {
+			return true
+		}
+	}
+	return false
+}
+
 // isCriticalMessageType, mesajın kritik (asla kaybolmaması gereken) tipte olup olmadığını kontrol eder.
 func (kc *KafkaClient) isCriticalMessageType(msgType pb.MessageType) bool {
 	for _, t := range kc.config.CriticalMessageTypes {
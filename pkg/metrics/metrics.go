@@ -0,0 +1,22 @@
+// Package metrics, servislerin Prometheus'a açtığı metriklerin registry'sini ve /metrics
+// handler'ını sağlar. Metrik tipleri prometheus/client_golang'dan gelir; paylaşılan paketler
+// (Örn: pkg/messaging) metriklerini Default'a kaydeder.
+package metrics
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Default, servislerin /metrics endpoint'inden sunulan registry'dir.
+// Neden prometheus.DefaultRegisterer değil? Global registry'ye üçüncü parti kütüphaneler de
+// kayıt yapabilir; servisin açtığı seriler burada açıkça kontrol edilir.
+var Default = prometheus.NewRegistry()
+
+// Handler, registry'yi servisin mevcut Fiber sunucusundan sunar.
+// Kullanım: app.Get("/metrics", metrics.Handler(metrics.Default))
+func Handler(g prometheus.Gatherer) fiber.Handler {
+	return adaptor.HTTPHandler(promhttp.HandlerFor(g, promhttp.HandlerOpts{}))
+}
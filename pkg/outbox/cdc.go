@@ -0,0 +1,357 @@
+package outbox
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// CDCConfig, RelayCDC modunun ayarlarıdır. Boş alanlar varsayılan değerleri alır.
+//
+// CDC modu için Postgres'te wal_level=logical olmalı ve servis kullanıcısının
+// REPLICATION yetkisi (veya tablo sahibi olarak publication oluşturma yetkisi) bulunmalıdır.
+// Mod kapatılırsa slot elle silinmelidir; aksi halde Postgres WAL dosyalarını tutmaya devam eder:
+//
+//	SELECT pg_drop_replication_slot('<slot>');
+type CDCConfig struct {
+	Slot          string        // Logical replication slot'u; varsayılan: <table>_slot
+	Publication   string        // Sadece outbox tablosunun insert'lerini içeren publication; varsayılan: <table>_pub
+	StateTable    string        // Son yayınlanan LSN'in saklandığı tablo; varsayılan: outbox_cdc_state
+	MaxChanges    int           // Tek okumada istenen en fazla değişiklik; varsayılan: 500
+	WaitInterval  time.Duration // WAL'de yeni değişiklik yokken bekleme; varsayılan: 200ms
+	SweepInterval time.Duration // Yayını başarısız olmuş satırların tekrar denenme aralığı; varsayılan: 30s
+	RetryInterval time.Duration // Replication kullanılamadığında polling'de kalınacak süre; varsayılan: 1m
+}
+
+func (c CDCConfig) withDefaults(table string) CDCConfig {
+	if c.Slot == "" {
+		c.Slot = table + "_slot"
+	}
+	if c.Publication == "" {
+		c.Publication = table + "_pub"
+	}
+	if c.StateTable == "" {
+		c.StateTable = "outbox_cdc_state"
+	}
+	if c.MaxChanges <= 0 {
+		c.MaxChanges = 500
+	}
+	if c.WaitInterval <= 0 {
+		c.WaitInterval = 200 * time.Millisecond
+	}
+	if c.SweepInterval <= 0 {
+		c.SweepInterval = 30 * time.Second
+	}
+	if c.RetryInterval <= 0 {
+		c.RetryInterval = time.Minute
+	}
+	return c
+}
+
+// errCDCLocked, slot'u başka bir replikanın okuduğunu belirtir.
+// Bir logical replication slot'u aynı anda tek bir oturum tarafından okunabilir.
+var errCDCLocked = errors.New("replication slot is being read by another replica")
+
+// runCDC, outbox insert'lerini bir logical replication slot'undan okuyup yayınlar.
+// Bu bir streaming replication bağlantısı değildir: slot, normal bir SQL oturumundan
+// pg_logical_slot_peek_binary_changes ile WaitInterval'de bir (varsayılan 200ms) yoklanır.
+// Neden? Polling relay tabloyu her PollInterval'de (1s) sorgular ve boş tabloda da index taraması
+// yapar. Slot yoklaması sadece WAL'e yeni yazılanı okur; olay en geç bir WaitInterval gecikir.
+//
+// Değişiklikler pgoutput eklentisinin ikili formatında döner ve burada çözülür; böylece replication
+// protokolü için ayrı bir sürücüye ihtiyaç kalmaz. WAL'den sadece
+// satır ID'leri alınır, yayın polling relay ile aynı yoldan (FOR UPDATE SKIP LOCKED,
+// publishRow) geçer. Bu sayede iki mod aynı satırı iki kez yayınlamaz.
+//
+// Replication kullanılamıyorsa (wal_level, yetki, slot başka replikada) RetryInterval boyunca
+// polling relay çalışır, ardından CDC tekrar denenir.
+func (o *Outbox) runCDC(ctx context.Context, publisher Publisher) {
+	for {
+		err := o.streamChanges(ctx, publisher)
+		if ctx.Err() != nil {
+			return
+		}
+		log.Printf("⚠ [Outbox] CDC relay unavailable, polling for %s [slot=%s]: %v", o.cfg.CDC.RetryInterval, o.cfg.CDC.Slot, err)
+
+		pollCtx, cancel := context.WithTimeout(ctx, o.cfg.CDC.RetryInterval)
+		o.runPolling(pollCtx, publisher)
+		cancel()
+	}
+}
+
+// streamChanges, slot'tan değişiklikleri okuyup yayınlar. ctx iptal edilene kadar
+// veya replication hatasına kadar döner.
+func (o *Outbox) streamChanges(ctx context.Context, publisher Publisher) error {
+	// Slot okuma, LSN ilerletme ve advisory lock aynı oturumda olmalı.
+	conn, err := o.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	var locked bool
+	if err := conn.QueryRowContext(ctx, `SELECT pg_try_advisory_lock(hashtext($1))`, o.cfg.CDC.Slot).Scan(&locked); err != nil {
+		return err
+	}
+	if !locked {
+		return errCDCLocked
+	}
+	// Bağlantı havuza geri döneceği için kilit açıkça bırakılır.
+	defer conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock(hashtext($1))`, o.cfg.CDC.Slot)
+
+	if err := o.setupCDC(ctx, conn); err != nil {
+		return err
+	}
+	lsn, err := o.resumeCDC(ctx, conn)
+	if err != nil {
+		return err
+	}
+	log.Printf("📤 [Outbox] CDC relay started [table=%s, slot=%s, lsn=%s]", o.cfg.Table, o.cfg.CDC.Slot, formatLSN(lsn))
+
+	// Slot oluşturulmadan önce yazılmış veya polling sırasında kalmış satırlar WAL'den gelmez.
+	o.drain(ctx, publisher)
+
+	decoder := newPgoDecoder()
+	sweep := time.NewTicker(o.cfg.CDC.SweepInterval)
+	defer sweep.Stop()
+	cleanup := time.NewTicker(o.cfg.CleanupInterval)
+	defer cleanup.Stop()
+	wait := time.NewTimer(0)
+	defer wait.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Printf("📤 [Outbox] CDC relay stopping [slot=%s]", o.cfg.CDC.Slot)
+			return nil
+		case <-sweep.C:
+			// Yayını başarısız olan satırlar backoff'ları dolunca buradan tekrar denenir.
+			o.drain(ctx, publisher)
+		case <-cleanup.C:
+			o.cleanup(ctx)
+		case <-wait.C:
+			next, n, err := o.readChanges(ctx, conn, decoder, publisher, lsn)
+			if err != nil {
+				return err
+			}
+			lsn = next
+			// Okuma limiti dolduysa arkasında başka değişiklik olabilir; beklemeden devam et.
+			if n >= o.cfg.CDC.MaxChanges {
+				wait.Reset(0)
+			} else {
+				wait.Reset(o.cfg.CDC.WaitInterval)
+			}
+		}
+	}
+}
+
+// setupCDC, publication'ı, slot'u ve LSN tablosunu yoksa oluşturur.
+func (o *Outbox) setupCDC(ctx context.Context, conn *sql.Conn) error {
+	var walLevel string
+	if err := conn.QueryRowContext(ctx, `SHOW wal_level`).Scan(&walLevel); err != nil {
+		return err
+	}
+	if walLevel != "logical" {
+		return fmt.Errorf("wal_level is %q, logical replication requires \"logical\"", walLevel)
+	}
+
+	var exists bool
+	if err := conn.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM pg_publication WHERE pubname = $1)`,
+		o.cfg.CDC.Publication).Scan(&exists); err != nil {
+		return err
+	}
+	if !exists {
+		stmt := `CREATE PUBLICATION ` + o.cfg.CDC.Publication + ` FOR TABLE ` + o.cfg.Table + ` WITH (publish = 'insert')`
+		if _, err := conn.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("create publication: %w", err)
+		}
+	}
+
+	if err := conn.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM pg_replication_slots WHERE slot_name = $1)`,
+		o.cfg.CDC.Slot).Scan(&exists); err != nil {
+		return err
+	}
+	if !exists {
+		if _, err := conn.ExecContext(ctx, `SELECT pg_create_logical_replication_slot($1, 'pgoutput')`, o.cfg.CDC.Slot); err != nil {
+			return fmt.Errorf("create replication slot: %w", err)
+		}
+	}
+
+	stmt := `CREATE TABLE IF NOT EXISTS ` + o.cfg.CDC.StateTable + ` (
+		slot_name TEXT PRIMARY KEY,
+		lsn TEXT NOT NULL,
+		updated_at TIMESTAMP DEFAULT NOW()
+	)`
+	_, err := conn.ExecContext(ctx, stmt)
+	return err
+}
+
+// resumeCDC, saklanan LSN ile slot'un onaylı LSN'ini karşılaştırır ve büyük olanı döner.
+// Saklanan LSN daha ileriyse (LSN yazıldıktan sonra slot ilerletilemeden süreç öldüyse)
+// slot o noktaya ilerletilir; böylece yayınlanmış değişiklikler tekrar okunmaz.
+func (o *Outbox) resumeCDC(ctx context.Context, conn *sql.Conn) (uint64, error) {
+	var confirmed sql.NullString
+	if err := conn.QueryRowContext(ctx, `SELECT confirmed_flush_lsn::text FROM pg_replication_slots WHERE slot_name = $1`,
+		o.cfg.CDC.Slot).Scan(&confirmed); err != nil {
+		return 0, err
+	}
+	var slotLSN uint64
+	if confirmed.Valid {
+		var err error
+		if slotLSN, err = parseLSN(confirmed.String); err != nil {
+			return 0, err
+		}
+	}
+
+	var stored string
+	err := conn.QueryRowContext(ctx, `SELECT lsn FROM `+o.cfg.CDC.StateTable+` WHERE slot_name = $1`, o.cfg.CDC.Slot).Scan(&stored)
+	if errors.Is(err, sql.ErrNoRows) {
+		return slotLSN, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	storedLSN, err := parseLSN(stored)
+	if err != nil {
+		return 0, err
+	}
+
+	if storedLSN > slotLSN {
+		if err := o.advanceSlot(ctx, conn, storedLSN); err != nil {
+			return 0, err
+		}
+		return storedLSN, nil
+	}
+	return slotLSN, nil
+}
+
+// readChanges, slot'taki bekleyen değişiklikleri okur, içindeki outbox satırlarını yayınlar
+// ve slot'u son tamamlanan transaction'ın sonuna ilerletir. Yeni LSN'i ve okunan değişiklik
+// sayısını döner.
+func (o *Outbox) readChanges(ctx context.Context, conn *sql.Conn, decoder *pgoDecoder, publisher Publisher, lsn uint64) (uint64, int, error) {
+	// Okumadan önce alınan flush LSN'inden önce commit edilmiş her transaction bu okumada gelir.
+	// Hiç değişiklik yoksa slot bu noktaya ilerletilir; aksi halde outbox'a yazılmayan sessiz
+	// dönemlerde slot yerinde kalır ve Postgres diğer tabloların WAL'ini biriktirir.
+	var flushed string
+	if err := conn.QueryRowContext(ctx, `SELECT pg_current_wal_flush_lsn()::text`).Scan(&flushed); err != nil {
+		return lsn, 0, err
+	}
+	flushedLSN, err := parseLSN(flushed)
+	if err != nil {
+		return lsn, 0, err
+	}
+
+	rows, err := conn.QueryContext(ctx, `SELECT data FROM pg_logical_slot_peek_binary_changes($1, NULL, $2,
+		'proto_version', '1', 'publication_names', $3)`, o.cfg.CDC.Slot, o.cfg.CDC.MaxChanges, o.cfg.CDC.Publication)
+	if err != nil {
+		return lsn, 0, err
+	}
+
+	var (
+		n       int
+		ids     []string // commit edilmiş transaction'lardaki satırlar
+		pending []string // commit'i henüz okunmamış transaction'daki satırlar
+		endLSN  uint64
+	)
+	for rows.Next() {
+		var data []byte
+		if err := rows.Scan(&data); err != nil {
+			rows.Close()
+			return lsn, n, err
+		}
+		n++
+
+		row, commitEnd, err := decoder.decode(data)
+		if err != nil {
+			rows.Close()
+			return lsn, n, err
+		}
+		if row != nil && row.table == o.cfg.Table {
+			if id, ok := row.values["id"]; ok {
+				pending = append(pending, id)
+			}
+		}
+		if commitEnd != 0 {
+			ids = append(ids, pending...)
+			pending = pending[:0]
+			endLSN = commitEnd
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return lsn, n, err
+	}
+
+	if n == 0 {
+		endLSN = flushedLSN
+	}
+	if endLSN <= lsn {
+		return lsn, n, nil
+	}
+
+	if len(ids) > 0 {
+		if err := o.publishIDs(ctx, publisher, ids); err != nil {
+			return lsn, n, err
+		}
+	}
+
+	// LSN önce tabloya, sonra slot'a yazılır; arada süreç ölürse resumeCDC slot'u yakalar.
+	if err := o.storeLSN(ctx, conn, endLSN); err != nil {
+		return lsn, n, err
+	}
+	if err := o.advanceSlot(ctx, conn, endLSN); err != nil {
+		return lsn, n, err
+	}
+	return endLSN, n, nil
+}
+
+// publishIDs, WAL'den gelen satırları yayınlar. Sadece hâlâ PENDING olan ve sahiplenilmemiş satırlar
+// alınır; polling relay'in veya başka bir replikanın yayınladığı ya da yayınlamakta olduğu satırlar atlanır. Yayını başarısız
+// olan satır PENDING kalır ve sweep ile tekrar denenir.
+// Aynı aggregate'ten birden fazla satır geldiyse önce sıradaki yayınlanır, arkasındakiler
+// bir sonraki tura kalır (bkz. headOfAggregate); bu yüzden sorgu her şey yayınlanana kadar tekrarlanır.
+func (o *Outbox) publishIDs(ctx context.Context, publisher Publisher, ids []string) error {
+	query := `SELECT id, payload, attempts FROM ` + o.cfg.Table + `
+		WHERE id = ANY($1::uuid[]) AND status = $2 AND (next_attempt_at IS NULL OR next_attempt_at <= NOW())
+			AND ` + o.headOfAggregate() + `
+		ORDER BY created_at ASC
+		FOR UPDATE SKIP LOCKED`
+	for {
+		n, complete, err := o.claimAndPublish(ctx, publisher, query, pq.Array(ids), StatusPending)
+		if err != nil || n == 0 || !complete {
+			return err
+		}
+	}
+}
+
+func (o *Outbox) storeLSN(ctx context.Context, conn *sql.Conn, lsn uint64) error {
+	query := `INSERT INTO ` + o.cfg.CDC.StateTable + ` (slot_name, lsn, updated_at) VALUES ($1, $2, NOW())
+		ON CONFLICT (slot_name) DO UPDATE SET lsn = EXCLUDED.lsn, updated_at = NOW()`
+	_, err := conn.ExecContext(ctx, query, o.cfg.CDC.Slot, formatLSN(lsn))
+	return err
+}
+
+func (o *Outbox) advanceSlot(ctx context.Context, conn *sql.Conn, lsn uint64) error {
+	_, err := conn.ExecContext(ctx, `SELECT pg_replication_slot_advance($1, $2::pg_lsn)`, o.cfg.CDC.Slot, formatLSN(lsn))
+	if err != nil {
+		return fmt.Errorf("advance replication slot: %w", err)
+	}
+	return nil
+}
+
+// warnIdleSlot, polling modunda CDC'den kalmış bir slot varsa uyarır.
+// Okunmayan slot, Postgres'in WAL dosyalarını silmesini engeller ve diski doldurur.
+func (o *Outbox) warnIdleSlot(ctx context.Context) {
+	var exists bool
+	err := o.db.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM pg_replication_slots WHERE slot_name = $1)`,
+		o.cfg.CDC.Slot).Scan(&exists)
+	if err == nil && exists {
+		log.Printf("⚠ [Outbox] Replication slot %q exists but relay mode is %q; drop it with SELECT pg_drop_replication_slot('%s')",
+			o.cfg.CDC.Slot, o.cfg.Relay, o.cfg.CDC.Slot)
+	}
+}
@@ -0,0 +1,483 @@
+// Package outbox, servislerin olaylarını iş verisiyle aynı veritabanı transaction'ında
+// kaydetmesini ve ayrı bir relay ile Kafka'ya basmasını sağlar (Transactional Outbox).
+//
+// Neden? "Önce commit, sonra publish" yaklaşımında süreç iki adım arasında ölürse
+// veya Kafka o an erişilemezse olay sessizce kaybolur. Outbox ile olay ya iş verisiyle
+// birlikte kalıcı olur ya da hiç olmaz; relay onu Kafka'ya ulaşana kadar tekrar dener.
+package outbox
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"time"
+
+	"marketplace/pkg/messaging"
+	pb "marketplace/pkg/proto/events"
+	"marketplace/pkg/tracing"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Mesaj durumları. FAILED mesajlar relay tarafından bir daha denenmez; elle incelenmelidir.
+const (
+	StatusPending   = "PENDING"
+	StatusProcessed = "PROCESSED"
+	StatusFailed    = "FAILED"
+)
+
+// Relay modları. RelayPoll tabloyu PollInterval aralığıyla sorgular; RelayCDC yeni satırları
+// bir logical replication slot'unu SQL ile yoklayarak (CDCConfig.WaitInterval, varsayılan 200ms) bulur.
+const (
+	RelayPoll = "poll"
+	RelayCDC  = "cdc"
+)
+
+// DefaultTopic, relay'in yazacağı topic'in kayda not olarak düşülen adıdır.
+// Asıl hedef topic, relay'e verilen Publisher'ın yapılandırmasından gelir.
+const DefaultTopic = messaging.DefaultTopic
+
+// Publisher, relay'in mesajı basmak için kullandığı arayüzdür (Örn: *messaging.KafkaClient).
+type Publisher interface {
+	PublishMessage(ctx context.Context, msg *pb.Message) error
+}
+
+// Config, outbox tablosu ve relay davranışını belirler. Boş alanlar varsayılan değerleri alır.
+type Config struct {
+	Table           string        // Varsayılan: outbox_messages
+	Topic           string        // Kayıtlara yazılan topic adı; varsayılan: DefaultTopic
+	BatchSize       int           // Her turda kilitlenecek en fazla satır; varsayılan: 50
+	PollInterval    time.Duration // Bekleyen mesaj yokken kontrol aralığı; varsayılan: 1s
+	MaxAttempts     int           // Bu kadar başarısız denemeden sonra FAILED; varsayılan: 20
+	MaxBackoff      time.Duration // Başarısız denemeler arasındaki en uzun bekleme; varsayılan: 5m
+	ClaimTimeout    time.Duration // Alınan satırın yayınlanması için süre; dolarsa satır tekrar alınır; varsayılan: 1m
+	Retention       time.Duration // PROCESSED satırların saklanma süresi; varsayılan: 24h
+	CleanupInterval time.Duration // Temizlik aralığı; varsayılan: 10m
+	Relay           string        // RelayPoll veya RelayCDC; varsayılan: RelayPoll
+	CDC             CDCConfig     // Sadece RelayCDC modunda kullanılır
+}
+
+func (c Config) withDefaults() Config {
+	if c.Table == "" {
+		c.Table = "outbox_messages"
+	}
+	if c.Topic == "" {
+		c.Topic = DefaultTopic
+	}
+	if c.BatchSize <= 0 {
+		c.BatchSize = 50
+	}
+	if c.PollInterval <= 0 {
+		c.PollInterval = time.Second
+	}
+	if c.MaxAttempts <= 0 {
+		c.MaxAttempts = 20
+	}
+	if c.MaxBackoff <= 0 {
+		c.MaxBackoff = 5 * time.Minute
+	}
+	if c.ClaimTimeout <= 0 {
+		c.ClaimTimeout = time.Minute
+	}
+	if c.Retention <= 0 {
+		c.Retention = 24 * time.Hour
+	}
+	if c.CleanupInterval <= 0 {
+		c.CleanupInterval = 10 * time.Minute
+	}
+	if c.Relay == "" {
+		c.Relay = RelayPoll
+	}
+	c.CDC = c.CDC.withDefaults(c.Table)
+	return c
+}
+
+var tableNamePattern = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// Outbox, tek bir outbox tablosu üzerinde yazma, relay ve temizlik işlemlerini yapar.
+type Outbox struct {
+	db  *sql.DB
+	cfg Config
+}
+
+// New, tabloyu (yoksa) oluşturur ve eski şemadaki tabloya eksik kolonları ekler.
+func New(db *sql.DB, cfg Config) (*Outbox, error) {
+	cfg = cfg.withDefaults()
+	if !tableNamePattern.MatchString(cfg.Table) {
+		return nil, fmt.Errorf("invalid outbox table name %q", cfg.Table)
+	}
+	if cfg.Relay != RelayPoll && cfg.Relay != RelayCDC {
+		return nil, fmt.Errorf("unknown outbox relay mode %q", cfg.Relay)
+	}
+	for _, name := range []string{cfg.CDC.Slot, cfg.CDC.Publication, cfg.CDC.StateTable} {
+		if !tableNamePattern.MatchString(name) {
+			return nil, fmt.Errorf("invalid CDC identifier %q", name)
+		}
+	}
+
+	o := &Outbox{db: db, cfg: cfg}
+	if err := o.migrate(); err != nil {
+		return nil, fmt.Errorf("migrate outbox table: %w", err)
+	}
+	return o, nil
+}
+
+func (o *Outbox) migrate() error {
+	t := o.cfg.Table
+	statements := []string{
+		`CREATE TABLE IF NOT EXISTS ` + t + ` (
+			id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+			payload BYTEA NOT NULL,
+			topic TEXT NOT NULL,
+			status TEXT DEFAULT 'PENDING',
+			created_at TIMESTAMP DEFAULT NOW(),
+			updated_at TIMESTAMP DEFAULT NOW()
+		)`,
+		// user-service'in ilk outbox tablosunda bu kolonlar yoktu; mevcut tablolar yerinde güncellenir.
+		`ALTER TABLE ` + t + ` ADD COLUMN IF NOT EXISTS message_id TEXT`,
+		`ALTER TABLE ` + t + ` ADD COLUMN IF NOT EXISTS message_type TEXT`,
+		`ALTER TABLE ` + t + ` ADD COLUMN IF NOT EXISTS attempts INTEGER NOT NULL DEFAULT 0`,
+		`ALTER TABLE ` + t + ` ADD COLUMN IF NOT EXISTS last_error TEXT`,
+		`ALTER TABLE ` + t + ` ADD COLUMN IF NOT EXISTS processed_at TIMESTAMP`,
+		`ALTER TABLE ` + t + ` ADD COLUMN IF NOT EXISTS next_attempt_at TIMESTAMP`,
+		// aggregate_key ve seq, aynı aggregate'in olaylarını yazıldıkları sırayla yayınlamak içindir
+		// (bkz. headOfAggregate). Eski satırlarda aggregate_key NULL'dur ve sıralamaya katılmaz.
+		`ALTER TABLE ` + t + ` ADD COLUMN IF NOT EXISTS aggregate_key TEXT`,
+		`ALTER TABLE ` + t + ` ADD COLUMN IF NOT EXISTS seq BIGSERIAL`,
+		`CREATE INDEX IF NOT EXISTS idx_` + t + `_pending ON ` + t + ` (created_at) WHERE status = 'PENDING'`,
+		`CREATE INDEX IF NOT EXISTS idx_` + t + `_pending_aggregate ON ` + t + ` (aggregate_key, seq) WHERE status = 'PENDING'`,
+	}
+	for _, stmt := range statements {
+		if _, err := o.db.Exec(stmt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Add, mesajı çağıranın transaction'ı içinde outbox'a yazar. Mesaj, transaction commit
+// edildiğinde kalıcı olur; rollback olursa hiç yayınlanmaz.
+// ID ve oluşturulma zamanı burada damgalanır; böylece relay mesajı kaç kez basarsa bassın
+// tüketiciler aynı ID'yi görür ve tekilleştirme (IdempotencyStore) çalışır.
+func (o *Outbox) Add(ctx context.Context, tx *sql.Tx, msg *pb.Message) error {
+	if msg.Id == "" {
+		msg.Id = uuid.New().String()
+	}
+	if msg.Created == nil {
+		msg.Created = timestamppb.Now()
+	}
+	// Sürüm, mesajın oluşturulduğu kodun sürümüdür; relay daha yeni bir sürümle basarsa
+	// header zaten dolu olduğu için değişmez.
+	messaging.StampSchemaVersion(msg)
+	messaging.StampRequestID(ctx, msg)
+	// Trace context'i isteğin span'inden alınır; relay mesajı çok sonra ve istekten bağımsız bir
+	// context'le basar. Relay bu context'i okuyup producer span'ini onun altında açar (bkz. publishRow).
+	if msg.Headers == nil {
+		msg.Headers = make(map[string]string)
+	}
+	tracing.Inject(ctx, msg.Headers)
+
+	payload, err := proto.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("marshal outbox message: %w", err)
+	}
+
+	query := `INSERT INTO ` + o.cfg.Table + ` (payload, topic, status, message_id, message_type, aggregate_key, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, NOW(), NOW())`
+	if _, err := tx.ExecContext(ctx, query, payload, o.cfg.Topic, StatusPending, msg.Id, msg.Type.String(), aggregateKey(msg)); err != nil {
+		return fmt.Errorf("insert outbox message: %w", err)
+	}
+	return nil
+}
+
+// aggregateKey, mesajın Kafka'da da sıralandığı anahtardır: önce PartitionKeyHeader, yoksa
+// DefaultOrderingKey. Anahtar bulunamazsa mesaj ID'si döner; mesaj hiçbir satırı beklemez.
+func aggregateKey(msg *pb.Message) string {
+	if key := msg.Headers[messaging.PartitionKeyHeader]; key != "" {
+		return key
+	}
+	return messaging.DefaultOrderingKey(msg)
+}
+
+// PublishMessage, sadece outbox'a yazmak için kısa bir transaction açar.
+// Veritabanında başka bir değişiklik yapmayan akışlar (Örn: Stripe webhook) için kullanılır;
+// olay Kafka o an erişilemese bile kaybolmaz.
+func (o *Outbox) PublishMessage(ctx context.Context, msg *pb.Message) error {
+	tx, err := o.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin outbox transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := o.Add(ctx, tx, msg); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Close, servislerin Messaging arayüzüne uymak için vardır. Veritabanı bağlantısı
+// repository'ye ait olduğundan burada kapatılmaz.
+func (o *Outbox) Close() error {
+	return nil
+}
+
+// Run, yapılandırılan modda relay'i ve temizlik işini ctx iptal edilene kadar çalıştırır.
+// Birden fazla replika aynı tabloda güvenle çalışabilir: satırlar FOR UPDATE SKIP LOCKED
+// ile kilitlenir, bir replikanın aldığı satırı diğeri atlar.
+func (o *Outbox) Run(ctx context.Context, publisher Publisher) {
+	if o.cfg.Relay == RelayCDC {
+		o.runCDC(ctx, publisher)
+		return
+	}
+	o.warnIdleSlot(ctx)
+	o.runPolling(ctx, publisher)
+}
+
+// runPolling, tabloyu PollInterval aralığıyla sorgulayan relay'dir.
+func (o *Outbox) runPolling(ctx context.Context, publisher Publisher) {
+	log.Printf("📤 [Outbox] Relay started [table=%s]", o.cfg.Table)
+
+	poll := time.NewTicker(o.cfg.PollInterval)
+	defer poll.Stop()
+	cleanup := time.NewTicker(o.cfg.CleanupInterval)
+	defer cleanup.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Printf("📤 [Outbox] Relay stopping [table=%s]", o.cfg.Table)
+			return
+		case <-poll.C:
+			o.drain(ctx, publisher)
+		case <-cleanup.C:
+			o.cleanup(ctx)
+		}
+	}
+}
+
+// drain, bekleyen mesajları batch batch yayınlar. Batch'in tamamı yayınlandıysa arkasında
+// başka mesaj olabilir; ticker'ı beklemeden devam edilir.
+// Neden batch dolu mu diye bakılmıyor? Bir batch her aggregate'ten sadece sıradaki satırı alır;
+// tek bir siparişin 10 olayı 10 ayrı batch'te gelir. Yayın başarısız olduysa Kafka büyük
+// ihtimalle erişilemiyordur ve bir sonraki tur beklenir.
+func (o *Outbox) drain(ctx context.Context, publisher Publisher) {
+	for {
+		n, complete, err := o.processBatch(ctx, publisher)
+		if err != nil {
+			if ctx.Err() == nil {
+				log.Printf("✗ [Outbox] Relay batch failed: %v", err)
+			}
+			return
+		}
+		if n == 0 || !complete {
+			return
+		}
+	}
+}
+
+func (o *Outbox) cleanup(ctx context.Context) {
+	if n, err := o.Cleanup(ctx); err != nil {
+		log.Printf("✗ [Outbox] Cleanup failed: %v", err)
+	} else if n > 0 {
+		log.Printf("🧹 [Outbox] Removed %d processed messages", n)
+	}
+}
+
+type pendingRow struct {
+	id       uuid.UUID
+	payload  []byte
+	attempts int
+}
+
+// ProcessBatch, bekleyen mesajlardan bir batch'i kilitler, yayınlar ve sonucunu yazar.
+// İşlenen satır sayısını döner.
+func (o *Outbox) ProcessBatch(ctx context.Context, publisher Publisher) (int, error) {
+	n, _, err := o.processBatch(ctx, publisher)
+	return n, err
+}
+
+func (o *Outbox) processBatch(ctx context.Context, publisher Publisher) (int, bool, error) {
+	query := `SELECT id, payload, attempts FROM ` + o.cfg.Table + `
+		WHERE status = $1 AND (next_attempt_at IS NULL OR next_attempt_at <= NOW())
+			AND ` + o.headOfAggregate() + `
+		ORDER BY created_at ASC
+		LIMIT $2
+		FOR UPDATE SKIP LOCKED`
+	return o.claimAndPublish(ctx, publisher, query, StatusPending, o.cfg.BatchSize)
+}
+
+// headOfAggregate, sadece aggregate'inin bekleyen en eski satırı olan satırları seçen koşuldur.
+// Neden? Yayını başarısız olan satır backoff ile bekletilirken aynı siparişin sonraki olayları
+// alınırsa tüketiciler PAYMENT_SUCCESSFUL'u ORDER_CREATED'dan önce görür. Koşul, önündeki satır
+// başka bir replikada yayınlanmaktayken de geçerlidir; satır PROCESSED olana kadar arkası alınmaz.
+// FAILED'a düşen (vazgeçilen) satır artık PENDING olmadığından arkasındakileri bekletmez.
+// seq, aynı transaction'da yazılan ve created_at'i eşit olan satırları da sıralar.
+func (o *Outbox) headOfAggregate() string {
+	t := o.cfg.Table
+	return `NOT EXISTS (SELECT 1 FROM ` + t + ` earlier
+			WHERE earlier.aggregate_key = ` + t + `.aggregate_key
+				AND earlier.status = '` + StatusPending + `'
+				AND earlier.seq < ` + t + `.seq)`
+}
+
+// claimAndPublish, query'nin FOR UPDATE ile kilitlediği satırları kısa bir transaction'da
+// sahiplenir (next_attempt_at'i ClaimTimeout ileri atar) ve commit eder; ardından satırları
+// transaction dışında sırayla yayınlar. İşlenen satır sayısını ve hepsinin yayınlanıp
+// yayınlanmadığını döner.
+// Neden? Yayın transaction içinde yapılırsa 50 Kafka yazımı boyunca transaction ve satır
+// kilitleri açık kalır. Süreç yayın sırasında ölürse satırlar PENDING kalır ve ClaimTimeout
+// dolunca tekrar alınır; mesaj en az bir kez yayınlanır.
+func (o *Outbox) claimAndPublish(ctx context.Context, publisher Publisher, query string, args ...any) (int, bool, error) {
+	batch, err := o.claim(ctx, query, args...)
+	if err != nil || len(batch) == 0 {
+		return 0, true, err
+	}
+
+	processed := 0
+	for _, r := range batch {
+		published, err := o.publishRow(ctx, publisher, r)
+		if err != nil {
+			return processed, false, err
+		}
+		processed++
+		// Yayın başarısızsa Kafka büyük ihtimalle erişilemiyordur; batch'in kalanını
+		// boşuna denemek yerine sahipliği bırakıp sonraki turu bekliyoruz.
+		if !published {
+			o.release(ctx, batch[processed:])
+			return processed, false, nil
+		}
+	}
+	return processed, true, nil
+}
+
+func (o *Outbox) claim(ctx context.Context, query string, args ...any) ([]pendingRow, error) {
+	tx, err := o.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		batch []pendingRow
+		ids   []string
+	)
+	for rows.Next() {
+		var r pendingRow
+		if err := rows.Scan(&r.id, &r.payload, &r.attempts); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		batch = append(batch, r)
+		ids = append(ids, r.id.String())
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(batch) == 0 {
+		return nil, nil
+	}
+
+	claim := `UPDATE ` + o.cfg.Table + `
+		SET next_attempt_at = NOW() + $1 * INTERVAL '1 second', updated_at = NOW()
+		WHERE id = ANY($2::uuid[])`
+	if _, err := tx.ExecContext(ctx, claim, o.cfg.ClaimTimeout.Seconds(), pq.Array(ids)); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return batch, nil
+}
+
+// release, yayınlanmadan bırakılan satırların sahipliğini kaldırır; satırlar ClaimTimeout'u
+// beklemeden bir sonraki turda tekrar alınabilir.
+func (o *Outbox) release(ctx context.Context, batch []pendingRow) {
+	if len(batch) == 0 {
+		return
+	}
+	ids := make([]string, 0, len(batch))
+	for _, r := range batch {
+		ids = append(ids, r.id.String())
+	}
+	query := `UPDATE ` + o.cfg.Table + ` SET next_attempt_at = NULL WHERE id = ANY($1::uuid[]) AND status = $2`
+	if _, err := o.db.ExecContext(ctx, query, pq.Array(ids), StatusPending); err != nil {
+		log.Printf("⚠ [Outbox] Release failed, rows will be retried after claim timeout: %v", err)
+	}
+}
+
+// publishRow, tek bir satırı yayınlar ve durumunu günceller. Sadece veritabanı hataları error döner;
+// yayın hataları satıra yazılır, published=false döner ve mesaj backoff sonrası tekrar denenir.
+func (o *Outbox) publishRow(ctx context.Context, publisher Publisher, r pendingRow) (bool, error) {
+	msg := &pb.Message{}
+	if err := proto.Unmarshal(r.payload, msg); err != nil {
+		// Bozuk payload tekrar denemekle düzelmez; diğer satırları etkilemediği için batch devam eder.
+		return true, o.markFailed(ctx, r.id, r.attempts+1, fmt.Errorf("unmarshal payload: %w", err), true)
+	}
+
+	if err := publisher.PublishMessage(tracing.Extract(ctx, msg.Headers), msg); err != nil {
+		if errors.Is(err, context.Canceled) && ctx.Err() != nil {
+			return false, ctx.Err()
+		}
+		attempts := r.attempts + 1
+		log.Printf("✗ [Outbox] Publish failed [id=%s, attempt=%d]: %v", msg.Id, attempts, err)
+		return false, o.markFailed(ctx, r.id, attempts, err, attempts >= o.cfg.MaxAttempts)
+	}
+
+	// Yayın başarılıysa durum, relay kapanıyor olsa bile yazılır; aksi halde mesaj ClaimTimeout
+	// sonrası ikinci kez basılır.
+	query := `UPDATE ` + o.cfg.Table + `
+		SET status = $1, attempts = attempts + 1, last_error = NULL, processed_at = NOW(), updated_at = NOW()
+		WHERE id = $2`
+	_, err := o.db.ExecContext(context.WithoutCancel(ctx), query, StatusProcessed, r.id)
+	return true, err
+}
+
+func (o *Outbox) markFailed(ctx context.Context, id uuid.UUID, attempts int, cause error, final bool) error {
+	status := StatusPending
+	if final {
+		status = StatusFailed
+		log.Printf("⚠ [Outbox] Giving up on message [row=%s, attempts=%d]: %v", id, attempts, cause)
+	}
+
+	query := `UPDATE ` + o.cfg.Table + `
+		SET status = $1, attempts = $2, last_error = $3,
+			next_attempt_at = NOW() + $4 * INTERVAL '1 second', updated_at = NOW()
+		WHERE id = $5`
+	_, err := o.db.ExecContext(ctx, query, status, attempts, cause.Error(), o.backoff(attempts).Seconds(), id)
+	return err
+}
+
+// backoff, n. başarısız denemeden sonra beklenecek süreyi döner: 1s, 2s, 4s ... MaxBackoff.
+func (o *Outbox) backoff(attempts int) time.Duration {
+	if attempts > 20 {
+		return o.cfg.MaxBackoff
+	}
+	d := time.Second << (attempts - 1)
+	if d > o.cfg.MaxBackoff {
+		return o.cfg.MaxBackoff
+	}
+	return d
+}
+
+// Cleanup, saklama süresi dolmuş PROCESSED satırları siler ve silinen satır sayısını döner.
+// FAILED satırlara dokunulmaz; onlar incelenip elle tekrar PENDING yapılana kadar kalır.
+func (o *Outbox) Cleanup(ctx context.Context) (int64, error) {
+	// processed_at kolonu eklenmeden önce işlenmiş satırlarda updated_at kullanılır.
+	query := `DELETE FROM ` + o.cfg.Table + ` WHERE status = $1 AND COALESCE(processed_at, updated_at) < $2`
+	res, err := o.db.ExecContext(ctx, query, StatusProcessed, time.Now().Add(-o.cfg.Retention))
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
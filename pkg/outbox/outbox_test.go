@@ -0,0 +1,48 @@
+package outbox
+
+import (
+	"testing"
+
+	"marketplace/pkg/messaging"
+	pb "marketplace/pkg/proto/events"
+)
+
+// Outbox'taki sıralama anahtarı Kafka'daki partition anahtarıyla aynı olmalı; aksi halde
+// relay'in koruduğu sıra tüketicide tekrar bozulur.
+func TestAggregateKey(t *testing.T) {
+	orderCreated := &pb.Message{
+		Id:      "msg-1",
+		Type:    pb.MessageType_ORDER_CREATED,
+		Payload: &pb.Message_OrderCreatedData{OrderCreatedData: &pb.OrderCreatedData{OrderId: "order-1"}},
+	}
+	paymentSuccessful := &pb.Message{
+		Id:      "msg-2",
+		Type:    pb.MessageType_PAYMENT_SUCCESSFUL,
+		Payload: &pb.Message_PaymentSuccessfulData{PaymentSuccessfulData: &pb.PaymentSuccessfulData{OrderId: "order-1"}},
+	}
+	withHeader := &pb.Message{
+		Id:      "msg-3",
+		Type:    pb.MessageType_ORDER_CREATED,
+		Headers: map[string]string{messaging.PartitionKeyHeader: "tenant-7"},
+		Payload: &pb.Message_OrderCreatedData{OrderCreatedData: &pb.OrderCreatedData{OrderId: "order-1"}},
+	}
+
+	tests := []struct {
+		name string
+		msg  *pb.Message
+		want string
+	}{
+		{"order event", orderCreated, "order-1"},
+		{"payment event of the same order", paymentSuccessful, "order-1"},
+		{"partition key header wins", withHeader, "tenant-7"},
+		{"no aggregate falls back to the message id", &pb.Message{Id: "msg-4"}, "msg-4"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := aggregateKey(tt.msg); got != tt.want {
+				t.Fatalf("aggregateKey = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,193 @@
+package outbox
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// pgoutput mesaj tipleri (protokol sürümü 1). Sadece relay'in ihtiyaç duyduğu tipler çözülür;
+// diğerleri (Update, Delete, Truncate, Origin, Type, Message) atlanır.
+const (
+	pgoCommit   = 'C'
+	pgoRelation = 'R'
+	pgoInsert   = 'I'
+)
+
+// pgoRelationInfo, Relation mesajından öğrenilen tablo adı ve kolon sırasıdır.
+// pgoutput, Insert mesajlarında tabloyu sadece OID ile belirtir.
+type pgoRelationInfo struct {
+	name    string
+	columns []string
+}
+
+// pgoDecoder, pg_logical_slot_peek_binary_changes ile okunan pgoutput mesajlarını çözer.
+// Relation bilgisi oturum boyunca saklanır; pgoutput her relation'ı bir kez gönderir.
+type pgoDecoder struct {
+	relations map[uint32]pgoRelationInfo
+}
+
+func newPgoDecoder() *pgoDecoder {
+	return &pgoDecoder{relations: make(map[uint32]pgoRelationInfo)}
+}
+
+// pgoInsertRow, bir Insert mesajındaki text formatlı kolon değerleridir (NULL kolonlar yoktur).
+type pgoInsertRow struct {
+	table  string
+	values map[string]string
+}
+
+// decode tek bir pgoutput mesajını çözer. Insert ise satırı, Commit ise
+// transaction'ın bitiş LSN'ini döner; diğer mesajlarda ikisi de boştur.
+func (d *pgoDecoder) decode(data []byte) (*pgoInsertRow, uint64, error) {
+	if len(data) == 0 {
+		return nil, 0, errors.New("empty pgoutput message")
+	}
+	r := &pgoReader{buf: data[1:]}
+
+	switch data[0] {
+	case pgoRelation:
+		relID := r.uint32()
+		r.string() // şema; outbox tablosu search_path üzerinden adıyla eşleştirilir
+		name := r.string()
+		r.byte() // replica identity
+		n := int(r.uint16())
+		columns := make([]string, n)
+		for i := range columns {
+			r.byte() // flags
+			columns[i] = r.string()
+			r.uint32() // type oid
+			r.uint32() // type modifier
+		}
+		if r.err != nil {
+			return nil, 0, fmt.Errorf("decode relation: %w", r.err)
+		}
+		d.relations[relID] = pgoRelationInfo{name: name, columns: columns}
+		return nil, 0, nil
+
+	case pgoInsert:
+		relID := r.uint32()
+		rel, ok := d.relations[relID]
+		if !ok {
+			return nil, 0, fmt.Errorf("insert for unknown relation %d", relID)
+		}
+		if kind := r.byte(); kind != 'N' {
+			return nil, 0, fmt.Errorf("unexpected tuple kind %q in insert", kind)
+		}
+		row := &pgoInsertRow{table: rel.name, values: make(map[string]string)}
+		n := int(r.uint16())
+		for i := 0; i < n; i++ {
+			switch kind := r.byte(); kind {
+			case 'n', 'u': // NULL veya değişmemiş TOAST değeri
+			case 't':
+				value := r.bytes(int(r.uint32()))
+				if i < len(rel.columns) {
+					row.values[rel.columns[i]] = string(value)
+				}
+			default:
+				return nil, 0, fmt.Errorf("unsupported tuple column kind %q", kind)
+			}
+		}
+		if r.err != nil {
+			return nil, 0, fmt.Errorf("decode insert: %w", r.err)
+		}
+		return row, 0, nil
+
+	case pgoCommit:
+		r.byte()   // flags
+		r.uint64() // commit LSN
+		end := r.uint64()
+		if r.err != nil {
+			return nil, 0, fmt.Errorf("decode commit: %w", r.err)
+		}
+		return nil, end, nil
+	}
+
+	return nil, 0, nil
+}
+
+// pgoReader, big-endian pgoutput alanlarını okur. İlk hatadan sonra sıfır değer döner;
+// hata, mesajın sonunda tek seferde kontrol edilir.
+type pgoReader struct {
+	buf []byte
+	err error
+}
+
+func (r *pgoReader) bytes(n int) []byte {
+	if r.err != nil {
+		return nil
+	}
+	if n < 0 || n > len(r.buf) {
+		r.err = errors.New("pgoutput message truncated")
+		return nil
+	}
+	b := r.buf[:n]
+	r.buf = r.buf[n:]
+	return b
+}
+
+func (r *pgoReader) byte() byte {
+	if b := r.bytes(1); b != nil {
+		return b[0]
+	}
+	return 0
+}
+
+func (r *pgoReader) uint16() uint16 {
+	if b := r.bytes(2); b != nil {
+		return binary.BigEndian.Uint16(b)
+	}
+	return 0
+}
+
+func (r *pgoReader) uint32() uint32 {
+	if b := r.bytes(4); b != nil {
+		return binary.BigEndian.Uint32(b)
+	}
+	return 0
+}
+
+func (r *pgoReader) uint64() uint64 {
+	if b := r.bytes(8); b != nil {
+		return binary.BigEndian.Uint64(b)
+	}
+	return 0
+}
+
+func (r *pgoReader) string() string {
+	if r.err != nil {
+		return ""
+	}
+	for i, c := range r.buf {
+		if c == 0 {
+			s := string(r.buf[:i])
+			r.buf = r.buf[i+1:]
+			return s
+		}
+	}
+	r.err = errors.New("unterminated string in pgoutput message")
+	return ""
+}
+
+// parseLSN, Postgres'in "16/B374D848" biçimindeki LSN'ini sayıya çevirir.
+func parseLSN(s string) (uint64, error) {
+	hi, lo, ok := strings.Cut(s, "/")
+	if !ok {
+		return 0, fmt.Errorf("invalid LSN %q", s)
+	}
+	h, err := strconv.ParseUint(hi, 16, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid LSN %q: %w", s, err)
+	}
+	l, err := strconv.ParseUint(lo, 16, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid LSN %q: %w", s, err)
+	}
+	return h<<32 | l, nil
+}
+
+func formatLSN(lsn uint64) string {
+	return fmt.Sprintf("%X/%X", lsn>>32, uint32(lsn))
+}
@@ -0,0 +1,181 @@
+package outbox
+
+import (
+	"encoding/binary"
+	"reflect"
+	"testing"
+)
+
+// pgoBuilder, testler için big-endian pgoutput mesajı üretir.
+type pgoBuilder []byte
+
+func (b pgoBuilder) byte(v byte) pgoBuilder { return append(b, v) }
+
+func (b pgoBuilder) uint16(v uint16) pgoBuilder { return binary.BigEndian.AppendUint16(b, v) }
+
+func (b pgoBuilder) uint32(v uint32) pgoBuilder { return binary.BigEndian.AppendUint32(b, v) }
+
+func (b pgoBuilder) uint64(v uint64) pgoBuilder { return binary.BigEndian.AppendUint64(b, v) }
+
+func (b pgoBuilder) string(s string) pgoBuilder { return append(append(b, s...), 0) }
+
+func (b pgoBuilder) text(s string) pgoBuilder { return b.byte('t').uint32(uint32(len(s))).bytes(s) }
+
+func (b pgoBuilder) bytes(s string) pgoBuilder { return append(b, s...) }
+
+func relationMessage(relID uint32, table string, columns ...string) []byte {
+	b := pgoBuilder{pgoRelation}.uint32(relID).string("public").string(table).byte('d').uint16(uint16(len(columns)))
+	for _, c := range columns {
+		b = b.byte(0).string(c).uint32(25).uint32(0xFFFFFFFF)
+	}
+	return b
+}
+
+func TestPgoDecoder(t *testing.T) {
+	const relID = 16384
+	relation := relationMessage(relID, "outbox", "id", "payload", "headers")
+
+	tests := []struct {
+		name    string
+		data    []byte
+		wantRow *pgoInsertRow
+		wantLSN uint64
+		wantErr bool
+	}{
+		{
+			name: "insert with text columns",
+			data: pgoBuilder{pgoInsert}.uint32(relID).byte('N').uint16(3).text("a1").text("\x01\x02").text("{}"),
+			wantRow: &pgoInsertRow{table: "outbox", values: map[string]string{
+				"id": "a1", "payload": "\x01\x02", "headers": "{}",
+			}},
+		},
+		{
+			name: "null and unchanged toast columns are skipped",
+			data: pgoBuilder{pgoInsert}.uint32(relID).byte('N').uint16(3).text("a1").byte('n').byte('u'),
+			wantRow: &pgoInsertRow{table: "outbox", values: map[string]string{
+				"id": "a1",
+			}},
+		},
+		{
+			name:    "commit returns end lsn",
+			data:    pgoBuilder{pgoCommit}.byte(0).uint64(0x16_0000_0010).uint64(0x16_B374_D848),
+			wantLSN: 0x16_B374_D848,
+		},
+		{
+			name: "ignored message type",
+			data: pgoBuilder{'B'}.uint64(1).uint64(2).uint32(3),
+		},
+		{
+			name:    "empty message",
+			data:    nil,
+			wantErr: true,
+		},
+		{
+			name:    "insert for unknown relation",
+			data:    pgoBuilder{pgoInsert}.uint32(relID + 1).byte('N').uint16(0),
+			wantErr: true,
+		},
+		{
+			name:    "update tuple kind in insert",
+			data:    pgoBuilder{pgoInsert}.uint32(relID).byte('K').uint16(0),
+			wantErr: true,
+		},
+		{
+			name:    "unsupported column kind",
+			data:    pgoBuilder{pgoInsert}.uint32(relID).byte('N').uint16(1).byte('b'),
+			wantErr: true,
+		},
+		{
+			name:    "truncated column value",
+			data:    pgoBuilder{pgoInsert}.uint32(relID).byte('N').uint16(1).byte('t').uint32(10).bytes("short"),
+			wantErr: true,
+		},
+		{
+			name:    "truncated commit",
+			data:    pgoBuilder{pgoCommit}.byte(0).uint32(1),
+			wantErr: true,
+		},
+		{
+			name:    "unterminated relation name",
+			data:    pgoBuilder{pgoRelation}.uint32(relID + 2).string("public").bytes("outbox"),
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := newPgoDecoder()
+			if _, _, err := d.decode(relation); err != nil {
+				t.Fatalf("decode relation: %v", err)
+			}
+
+			row, lsn, err := d.decode(tt.data)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("decode error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(row, tt.wantRow) {
+				t.Fatalf("decode row = %+v, want %+v", row, tt.wantRow)
+			}
+			if lsn != tt.wantLSN {
+				t.Fatalf("decode lsn = %X, want %X", lsn, tt.wantLSN)
+			}
+		})
+	}
+}
+
+func TestPgoDecoderRelationUpdate(t *testing.T) {
+	d := newPgoDecoder()
+	for _, msg := range [][]byte{
+		relationMessage(1, "outbox", "id", "payload"),
+		// ALTER TABLE sonrası pgoutput relation'ı yeni kolon sırasıyla tekrar gönderir.
+		relationMessage(1, "outbox", "payload", "id"),
+	} {
+		if _, _, err := d.decode(msg); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	row, _, err := d.decode(pgoBuilder{pgoInsert}.uint32(1).byte('N').uint16(2).text("p").text("i"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if row.values["id"] != "i" || row.values["payload"] != "p" {
+		t.Fatalf("values = %v, want columns in the latest relation order", row.values)
+	}
+}
+
+func TestParseLSN(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    uint64
+		wantErr bool
+	}{
+		{in: "0/0", want: 0},
+		{in: "16/B374D848", want: 0x16_B374_D848},
+		{in: "0/16B3748", want: 0x16B3748},
+		{in: "FFFFFFFF/FFFFFFFF", want: 0xFFFF_FFFF_FFFF_FFFF},
+		{in: "b374d848", wantErr: true},
+		{in: "16/", wantErr: true},
+		{in: "G/0", wantErr: true},
+		{in: "100000000/0", wantErr: true},
+		{in: "0/100000000", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := parseLSN(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseLSN(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if got != tt.want {
+				t.Fatalf("parseLSN(%q) = %X, want %X", tt.in, got, tt.want)
+			}
+			if back := formatLSN(got); back != tt.in {
+				t.Fatalf("formatLSN(parseLSN(%q)) = %q", tt.in, back)
+			}
+		})
+	}
+}
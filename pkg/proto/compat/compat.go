@@ -0,0 +1,188 @@
+// Package compat, derlenmiş proto tanımlarını kayıtlı şema kilidiyle (pkg/proto/schema.lock.json)
+// karşılaştırır. cmd/proto-compat ve pkg/proto'daki test aynı kontrolü kullanır.
+package compat
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	authpb "marketplace/pkg/proto/auth"
+	basketpb "marketplace/pkg/proto/basket"
+	"marketplace/pkg/proto/common"
+	"marketplace/pkg/proto/events"
+	paymentpb "marketplace/pkg/proto/payment"
+	productpb "marketplace/pkg/proto/product"
+)
+
+// Schema, kilit dosyasının içeriğidir: mesajların alanları ve enum değerleri, tam adlarıyla.
+type Schema struct {
+	Messages map[string]map[string]Field `json:"messages"`
+	Enums    map[string]map[string]int32 `json:"enums"`
+}
+
+// Field, wire formatını belirleyen alan özellikleridir.
+type Field struct {
+	Number      int32  `json:"number"`
+	Type        string `json:"type"`
+	Cardinality string `json:"cardinality"`
+	Oneof       string `json:"oneof,omitempty"`
+}
+
+// Current, kilide giren tüm proto dosyalarının güncel şemasıdır.
+// Yeni bir .proto dosyası eklendiğinde buraya da eklenmelidir.
+func Current() *Schema {
+	return Snapshot(
+		events.File_events_proto,
+		common.File_common_proto,
+		authpb.File_auth_proto,
+		basketpb.File_basket_proto,
+		paymentpb.File_payment_proto,
+		productpb.File_product_proto,
+	)
+}
+
+// Snapshot, verilen dosyalardaki tüm mesajları (iç içe olanlar dahil) ve enum'ları çıkarır.
+func Snapshot(files ...protoreflect.FileDescriptor) *Schema {
+	s := &Schema{
+		Messages: make(map[string]map[string]Field),
+		Enums:    make(map[string]map[string]int32),
+	}
+	for _, fd := range files {
+		addEnums(s, fd.Enums())
+		addMessages(s, fd.Messages())
+	}
+	return s
+}
+
+func addMessages(s *Schema, messages protoreflect.MessageDescriptors) {
+	for i := 0; i < messages.Len(); i++ {
+		md := messages.Get(i)
+		fields := make(map[string]Field)
+		for j := 0; j < md.Fields().Len(); j++ {
+			fd := md.Fields().Get(j)
+			f := Field{
+				Number:      int32(fd.Number()),
+				Type:        fieldType(fd),
+				Cardinality: fd.Cardinality().String(),
+			}
+			if oneof := fd.ContainingOneof(); oneof != nil && !oneof.IsSynthetic() {
+				f.Oneof = string(oneof.Name())
+			}
+			fields[string(fd.Name())] = f
+		}
+		s.Messages[string(md.FullName())] = fields
+
+		addEnums(s, md.Enums())
+		addMessages(s, md.Messages())
+	}
+}
+
+func addEnums(s *Schema, enums protoreflect.EnumDescriptors) {
+	for i := 0; i < enums.Len(); i++ {
+		ed := enums.Get(i)
+		values := make(map[string]int32)
+		for j := 0; j < ed.Values().Len(); j++ {
+			v := ed.Values().Get(j)
+			values[string(v.Name())] = int32(v.Number())
+		}
+		s.Enums[string(ed.FullName())] = values
+	}
+}
+
+func fieldType(fd protoreflect.FieldDescriptor) string {
+	switch fd.Kind() {
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		return string(fd.Message().FullName())
+	case protoreflect.EnumKind:
+		return string(fd.Enum().FullName())
+	}
+	return fd.Kind().String()
+}
+
+// Compare, kilitteki şemaya göre geriye uyumsuz değişiklikleri listeler.
+// Yeni mesaj, alan veya enum değeri eklemek serbesttir.
+func Compare(locked, current *Schema) []string {
+	var violations []string
+
+	for _, name := range sortedKeys(locked.Messages) {
+		oldFields := locked.Messages[name]
+		newFields, ok := current.Messages[name]
+		if !ok {
+			violations = append(violations, fmt.Sprintf("message %s removed", name))
+			continue
+		}
+		for _, fieldName := range sortedKeys(oldFields) {
+			old := oldFields[fieldName]
+			cur, ok := newFields[fieldName]
+			switch {
+			case !ok:
+				violations = append(violations, fmt.Sprintf("%s.%s (= %d) removed", name, fieldName, old.Number))
+			case cur.Number != old.Number:
+				violations = append(violations, fmt.Sprintf("%s.%s renumbered %d -> %d", name, fieldName, old.Number, cur.Number))
+			case cur.Type != old.Type:
+				violations = append(violations, fmt.Sprintf("%s.%s type changed %s -> %s", name, fieldName, old.Type, cur.Type))
+			case cur.Cardinality != old.Cardinality:
+				violations = append(violations, fmt.Sprintf("%s.%s cardinality changed %s -> %s", name, fieldName, old.Cardinality, cur.Cardinality))
+			case cur.Oneof != old.Oneof:
+				violations = append(violations, fmt.Sprintf("%s.%s moved from oneof %q to %q", name, fieldName, old.Oneof, cur.Oneof))
+			}
+		}
+	}
+
+	for _, name := range sortedKeys(locked.Enums) {
+		oldValues := locked.Enums[name]
+		newValues, ok := current.Enums[name]
+		if !ok {
+			violations = append(violations, fmt.Sprintf("enum %s removed", name))
+			continue
+		}
+		for _, valueName := range sortedKeys(oldValues) {
+			old := oldValues[valueName]
+			cur, ok := newValues[valueName]
+			switch {
+			case !ok:
+				violations = append(violations, fmt.Sprintf("%s.%s (= %d) removed", name, valueName, old))
+			case cur != old:
+				violations = append(violations, fmt.Sprintf("%s.%s renumbered %d -> %d", name, valueName, old, cur))
+			}
+		}
+	}
+
+	return violations
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// ReadLock, kilit dosyasını okur.
+func ReadLock(path string) (*Schema, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var s Schema
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	return &s, nil
+}
+
+// WriteLock, şemayı kilit dosyasına yazar.
+func WriteLock(path string, s *Schema) error {
+	// encoding/json map anahtarlarını sıralar; kilit dosyasının diff'i kararlı kalır.
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
@@ -149,6 +149,86 @@ func (x *CreatePaymentResponse) GetSessionId() string {
 	return ""
 }
 
+type ExpirePaymentSessionRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	SessionId     string                 `protobuf:"bytes,1,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ExpirePaymentSessionRequest) Reset() {
+	*x = ExpirePaymentSessionRequest{}
+	mi := &file_payment_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ExpirePaymentSessionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExpirePaymentSessionRequest) ProtoMessage() {}
+
+func (x *ExpirePaymentSessionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_payment_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExpirePaymentSessionRequest.ProtoReflect.Descriptor instead.
+func (*ExpirePaymentSessionRequest) Descriptor() ([]byte, []int) {
+	return file_payment_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *ExpirePaymentSessionRequest) GetSessionId() string {
+	if x != nil {
+		return x.SessionId
+	}
+	return ""
+}
+
+type ExpirePaymentSessionResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ExpirePaymentSessionResponse) Reset() {
+	*x = ExpirePaymentSessionResponse{}
+	mi := &file_payment_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ExpirePaymentSessionResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExpirePaymentSessionResponse) ProtoMessage() {}
+
+func (x *ExpirePaymentSessionResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_payment_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExpirePaymentSessionResponse.ProtoReflect.Descriptor instead.
+func (*ExpirePaymentSessionResponse) Descriptor() ([]byte, []int) {
+	return file_payment_proto_rawDescGZIP(), []int{3}
+}
+
 var File_payment_proto protoreflect.FileDescriptor
 
 const file_payment_proto_rawDesc = "" +
@@ -165,9 +245,14 @@ const file_payment_proto_rawDesc = "" +
 	"\vpayment_url\x18\x01 \x01(\tR\n" +
 	"paymentUrl\x12\x1d\n" +
 	"\n" +
-	"session_id\x18\x02 \x01(\tR\tsessionId2g\n" +
+	"session_id\x18\x02 \x01(\tR\tsessionId\"<\n" +
+	"\x1bExpirePaymentSessionRequest\x12\x1d\n" +
+	"\n" +
+	"session_id\x18\x01 \x01(\tR\tsessionId\"\x1e\n" +
+	"\x1cExpirePaymentSessionResponse2\xcc\x01\n" +
 	"\x0ePaymentService\x12U\n" +
-	"\x14CreatePaymentSession\x12\x1d.payment.CreatePaymentRequest\x1a\x1e.payment.CreatePaymentResponseB\x1fZ\x1dmarketplace/pkg/proto/paymentb\x06proto3"
+	"\x14CreatePaymentSession\x12\x1d.payment.CreatePaymentRequest\x1a\x1e.payment.CreatePaymentResponse\x12c\n" +
+	"\x14ExpirePaymentSession\x12$.payment.ExpirePaymentSessionRequest\x1a%.payment.ExpirePaymentSessionResponseB\x1fZ\x1dmarketplace/pkg/proto/paymentb\x06proto3"
 
 var (
 	file_payment_proto_rawDescOnce sync.Once
@@ -181,16 +266,20 @@ func file_payment_proto_rawDescGZIP() []byte {
 	return file_payment_proto_rawDescData
 }
 
-var file_payment_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
+var file_payment_proto_msgTypes = make([]protoimpl.MessageInfo, 4)
 var file_payment_proto_goTypes = []any{
-	(*CreatePaymentRequest)(nil),  // 0: payment.CreatePaymentRequest
-	(*CreatePaymentResponse)(nil), // 1: payment.CreatePaymentResponse
+	(*CreatePaymentRequest)(nil),         // 0: payment.CreatePaymentRequest
+	(*CreatePaymentResponse)(nil),        // 1: payment.CreatePaymentResponse
+	(*ExpirePaymentSessionRequest)(nil),  // 2: payment.ExpirePaymentSessionRequest
+	(*ExpirePaymentSessionResponse)(nil), // 3: payment.ExpirePaymentSessionResponse
 }
 var file_payment_proto_depIdxs = []int32{
 	0, // 0: payment.PaymentService.CreatePaymentSession:input_type -> payment.CreatePaymentRequest
-	1, // 1: payment.PaymentService.CreatePaymentSession:output_type -> payment.CreatePaymentResponse
-	1, // [1:2] is the sub-list for method output_type
-	0, // [0:1] is the sub-list for method input_type
+	2, // 1: payment.PaymentService.ExpirePaymentSession:input_type -> payment.ExpirePaymentSessionRequest
+	1, // 2: payment.PaymentService.CreatePaymentSession:output_type -> payment.CreatePaymentResponse
+	3, // 3: payment.PaymentService.ExpirePaymentSession:output_type -> payment.ExpirePaymentSessionResponse
+	2, // [2:4] is the sub-list for method output_type
+	0, // [0:2] is the sub-list for method input_type
 	0, // [0:0] is the sub-list for extension type_name
 	0, // [0:0] is the sub-list for extension extendee
 	0, // [0:0] is the sub-list for field type_name
@@ -207,7 +296,7 @@ func file_payment_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_payment_proto_rawDesc), len(file_payment_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   2,
+			NumMessages:   4,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
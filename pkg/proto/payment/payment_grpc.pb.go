@@ -20,6 +20,7 @@ const _ = grpc.SupportPackageIsVersion9
 
 const (
 	PaymentService_CreatePaymentSession_FullMethodName = "/payment.PaymentService/CreatePaymentSession"
+	PaymentService_ExpirePaymentSession_FullMethodName = "/payment.PaymentService/ExpirePaymentSession"
 )
 
 // PaymentServiceClient is the client API for PaymentService service.
@@ -27,6 +28,7 @@ const (
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type PaymentServiceClient interface {
 	CreatePaymentSession(ctx context.Context, in *CreatePaymentRequest, opts ...grpc.CallOption) (*CreatePaymentResponse, error)
+	ExpirePaymentSession(ctx context.Context, in *ExpirePaymentSessionRequest, opts ...grpc.CallOption) (*ExpirePaymentSessionResponse, error)
 }
 
 type paymentServiceClient struct {
@@ -47,11 +49,22 @@ func (c *paymentServiceClient) CreatePaymentSession(ctx context.Context, in *Cre
 	return out, nil
 }
 
+func (c *paymentServiceClient) ExpirePaymentSession(ctx context.Context, in *ExpirePaymentSessionRequest, opts ...grpc.CallOption) (*ExpirePaymentSessionResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ExpirePaymentSessionResponse)
+	err := c.cc.Invoke(ctx, PaymentService_ExpirePaymentSession_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // PaymentServiceServer is the server API for PaymentService service.
 // All implementations must embed UnimplementedPaymentServiceServer
 // for forward compatibility.
 type PaymentServiceServer interface {
 	CreatePaymentSession(context.Context, *CreatePaymentRequest) (*CreatePaymentResponse, error)
+	ExpirePaymentSession(context.Context, *ExpirePaymentSessionRequest) (*ExpirePaymentSessionResponse, error)
 	mustEmbedUnimplementedPaymentServiceServer()
 }
 
@@ -65,6 +78,9 @@ type UnimplementedPaymentServiceServer struct{}
 func (UnimplementedPaymentServiceServer) CreatePaymentSession(context.Context, *CreatePaymentRequest) (*CreatePaymentResponse, error) {
 	return nil, status.Error(codes.Unimplemented, "method CreatePaymentSession not implemented")
 }
+func (UnimplementedPaymentServiceServer) ExpirePaymentSession(context.Context, *ExpirePaymentSessionRequest) (*ExpirePaymentSessionResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method ExpirePaymentSession not implemented")
+}
 func (UnimplementedPaymentServiceServer) mustEmbedUnimplementedPaymentServiceServer() {}
 func (UnimplementedPaymentServiceServer) testEmbeddedByValue()                        {}
 
@@ -104,6 +120,24 @@ func _PaymentService_CreatePaymentSession_Handler(srv interface{}, ctx context.C
 	return interceptor(ctx, in, info, handler)
 }
 
+func _PaymentService_ExpirePaymentSession_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ExpirePaymentSessionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PaymentServiceServer).ExpirePaymentSession(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PaymentService_ExpirePaymentSession_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PaymentServiceServer).ExpirePaymentSession(ctx, req.(*ExpirePaymentSessionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // PaymentService_ServiceDesc is the grpc.ServiceDesc for PaymentService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -115,6 +149,10 @@ var PaymentService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "CreatePaymentSession",
 			Handler:    _PaymentService_CreatePaymentSession_Handler,
 		},
+		{
+			MethodName: "ExpirePaymentSession",
+			Handler:    _PaymentService_ExpirePaymentSession_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "payment.proto",
@@ -0,0 +1,36 @@
+package proto_test
+
+import (
+	"testing"
+
+	"marketplace/pkg/proto/compat"
+)
+
+// go run ./cmd/proto-compat ile aynı kontrol; go test ./... çalıştıran CI, geriye uyumsuz bir
+// proto değişikliğinde burada kırılır.
+func TestSchemaIsBackwardCompatible(t *testing.T) {
+	locked, err := compat.ReadLock("schema.lock.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	violations := compat.Compare(locked, compat.Current())
+	for _, v := range violations {
+		t.Error(v)
+	}
+	if len(violations) > 0 {
+		t.Log("restore the fields or see pkg/messaging/upcasters.go")
+	}
+}
+
+// Kilitte olmayan bir alan bir sonraki değişiklikte korunmaz; eklemelerden sonra kilit
+// go run ./cmd/proto-compat -update ile güncellenmelidir.
+func TestSchemaLockIsUpToDate(t *testing.T) {
+	locked, err := compat.ReadLock("schema.lock.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Ters yöndeki karşılaştırma, kilide yazılmamış eklemeleri listeler.
+	for _, v := range compat.Compare(compat.Current(), locked) {
+		t.Errorf("%s in the lock; run go run ./cmd/proto-compat -update", v)
+	}
+}
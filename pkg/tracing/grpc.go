@@ -0,0 +1,18 @@
+package tracing
+
+import (
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"google.golang.org/grpc"
+)
+
+// ServerOption, gRPC sunucusunun gelen metadata'dan trace context'ini okuyup her RPC için
+// server span'i açmasını sağlar: grpc.NewServer(tracing.ServerOption()).
+func ServerOption() grpc.ServerOption {
+	return grpc.StatsHandler(otelgrpc.NewServerHandler())
+}
+
+// DialOption, gRPC istemcisinin her çağrıda client span'i açıp trace context'ini metadata'ya
+// yazmasını sağlar. Çağrıya verilen ctx'teki span (Örn: HTTP middleware'inin açtığı) parent olur.
+func DialOption() grpc.DialOption {
+	return grpc.WithStatsHandler(otelgrpc.NewClientHandler())
+}
@@ -0,0 +1,89 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/valyala/fasthttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// HeaderCarrier, fasthttp istek header'larını propagator'ın okuyup yazabileceği hale getirir.
+type HeaderCarrier struct {
+	Header *fasthttp.RequestHeader
+}
+
+func (c HeaderCarrier) Get(key string) string {
+	return string(c.Header.Peek(key))
+}
+
+func (c HeaderCarrier) Set(key, value string) {
+	c.Header.Set(key, value)
+}
+
+func (c HeaderCarrier) Keys() []string {
+	var keys []string
+	c.Header.VisitAll(func(key, _ []byte) {
+		keys = append(keys, string(key))
+	})
+	return keys
+}
+
+// InjectHTTP, ctx'teki trace context'ini giden isteğin header'larına yazar.
+// Gateway proxy'si, backend'e iletilen isteğe kendi span'inin context'ini koyar.
+func InjectHTTP(ctx context.Context, header *fasthttp.RequestHeader) {
+	otel.GetTextMapPropagator().Inject(ctx, HeaderCarrier{Header: header})
+}
+
+// Middleware, gelen isteğin traceparent header'ını okur, bir server span'i açar ve
+// span'li context'i c.UserContext() olarak handler'lara verir. Controller'lar zaten
+// UserContext ile çalıştığı için gRPC ve Kafka çağrıları bu span'in altına düşer.
+// /health ve /metrics gibi yoklama endpoint'leri trace üretmez.
+func Middleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		switch c.Path() {
+		case "/health", "/metrics":
+			return c.Next()
+		}
+
+		ctx := otel.GetTextMapPropagator().Extract(c.UserContext(), HeaderCarrier{Header: &c.Request().Header})
+		ctx, span := Tracer().Start(ctx, c.Method()+" "+c.Path(),
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				attribute.String("http.request.method", c.Method()),
+				attribute.String("url.path", c.Path()),
+			),
+		)
+		defer span.End()
+
+		c.SetUserContext(ctx)
+		err := c.Next()
+
+		// Route ancak eşleştikten sonra bilinir; span adı yol parametreleri olmadan tutulur.
+		if route := c.Route(); route != nil && route.Path != "" {
+			span.SetName(c.Method() + " " + route.Path)
+			span.SetAttributes(attribute.String("http.route", route.Path))
+		}
+
+		status := c.Response().StatusCode()
+		if err != nil {
+			// Hata cevabı fiber'ın ErrorHandler'ında yazılır; status henüz set edilmemiştir.
+			status = fiber.StatusInternalServerError
+			var fiberErr *fiber.Error
+			if errors.As(err, &fiberErr) {
+				status = fiberErr.Code
+			}
+			span.RecordError(err)
+		}
+		span.SetAttributes(attribute.Int("http.response.status_code", status))
+		if status >= fiber.StatusInternalServerError {
+			span.SetStatus(codes.Error, fmt.Sprintf("HTTP %d", status))
+		}
+		return err
+	}
+}
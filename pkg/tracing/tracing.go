@@ -0,0 +1,131 @@
+// Package tracing, servislerin OpenTelemetry trace'lerini kurar ve trace context'ini
+// HTTP, gRPC ve Kafka mesajları arasında taşır.
+// Neden? Bir sipariş isteği gateway -> order-service -> (gRPC) product/payment -> (Kafka)
+// notification-service zincirinden geçer; context taşınmazsa her servis ayrı, bağlantısız
+// bir trace üretir ve yavaşlığın hangi halkada olduğu görülemez.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// EnvExporter, span'lerin nereye gönderileceğini seçer (OpenTelemetry'nin standart değişkeni):
+//   - "otlp": OTEL_EXPORTER_OTLP_ENDPOINT'teki collector'a gRPC ile (Örn: Jaeger, Tempo)
+//   - "stdout" / "console": span'ler log'a yazılır, lokal geliştirme içindir
+//   - "none": span üretilir ama hiçbir yere gönderilmez; context yine de taşınır
+//
+// Boşsa, OTEL_EXPORTER_OTLP_ENDPOINT tanımlıysa otlp, değilse none kullanılır.
+// Endpoint, TLS (OTEL_EXPORTER_OTLP_INSECURE) ve örnekleme (OTEL_TRACES_SAMPLER) gibi
+// ayarlar SDK tarafından doğrudan ortamdan okunur.
+const EnvExporter = "OTEL_TRACES_EXPORTER"
+
+const envOTLPEndpoint = "OTEL_EXPORTER_OTLP_ENDPOINT"
+
+// instrumentationName, paylaşılan paketlerin (messaging, gateway proxy) tracer adıdır.
+const instrumentationName = "marketplace/pkg/tracing"
+
+// ShutdownFunc, bekleyen span'leri gönderir ve exporter'ı kapatır.
+type ShutdownFunc func(context.Context) error
+
+// Flush, ShutdownFunc'ı en fazla 5 saniye bekleyerek çağırır. Main, servis durduktan sonra
+// (hata ile dursa bile, log.Fatalf'tan önce) çağırır. Collector'a ulaşılamaması çıkışı engellemez.
+func (f ShutdownFunc) Flush() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := f(ctx); err != nil {
+		log.Printf("✗ [Tracing] Shutdown failed: %v", err)
+	}
+}
+
+// Init, global TracerProvider'ı ve W3C trace context + baggage propagator'ını kurar.
+// Servislerin main'i bir kez çağırır ve kapanışta dönen fonksiyonu çağırır; aksi halde
+// son batch'teki span'ler kaybolur.
+// Exporter "none" olsa bile propagator kurulur: gelen traceparent sonraki servise aktarılır,
+// böylece tracing'i açık olan servisler arasındaki zincir kopmaz.
+func Init(ctx context.Context, serviceName string) (ShutdownFunc, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	exporter, name, err := newExporter(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(semconv.ServiceName(serviceName)))
+	if err != nil {
+		return nil, fmt.Errorf("build trace resource: %w", err)
+	}
+
+	opts := []sdktrace.TracerProviderOption{sdktrace.WithResource(res)}
+	if exporter != nil {
+		opts = append(opts, sdktrace.WithBatcher(exporter))
+	}
+	provider := sdktrace.NewTracerProvider(opts...)
+	otel.SetTracerProvider(provider)
+
+	log.Printf("✓ [Tracing] Initialized [service=%s, exporter=%s]", serviceName, name)
+	return provider.Shutdown, nil
+}
+
+func newExporter(ctx context.Context) (sdktrace.SpanExporter, string, error) {
+	name := strings.ToLower(strings.TrimSpace(os.Getenv(EnvExporter)))
+	if name == "" {
+		name = "none"
+		if os.Getenv(envOTLPEndpoint) != "" {
+			name = "otlp"
+		}
+	}
+
+	switch name {
+	case "otlp":
+		exporter, err := otlptracegrpc.New(ctx)
+		if err != nil {
+			return nil, name, fmt.Errorf("create otlp exporter: %w", err)
+		}
+		return exporter, name, nil
+	case "stdout", "console":
+		exporter, err := stdouttrace.New(stdouttrace.WithPrettyPrint())
+		if err != nil {
+			return nil, name, fmt.Errorf("create stdout exporter: %w", err)
+		}
+		return exporter, name, nil
+	case "none":
+		return nil, name, nil
+	default:
+		return nil, name, fmt.Errorf("unknown %s %q (want otlp, stdout or none)", EnvExporter, name)
+	}
+}
+
+// Tracer, paylaşılan paketlerin span açtığı tracer'dır. Init çağrılmadıysa global no-op
+// provider'a düşer; testler ve araçlar (dlq-admin, events-tail) ek kurulum gerektirmez.
+func Tracer() trace.Tracer {
+	return otel.Tracer(instrumentationName)
+}
+
+// Inject, ctx'teki trace context'ini header map'ine yazar (traceparent, tracestate, baggage).
+// Kafka mesajlarının Headers alanı için kullanılır.
+func Inject(ctx context.Context, headers map[string]string) {
+	otel.GetTextMapPropagator().Inject(ctx, propagation.MapCarrier(headers))
+}
+
+// Extract, header map'indeki trace context'ini ctx'e ekler. Header yoksa ctx aynen döner.
+func Extract(ctx context.Context, headers map[string]string) context.Context {
+	return otel.GetTextMapPropagator().Extract(ctx, propagation.MapCarrier(headers))
+}